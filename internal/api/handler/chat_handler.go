@@ -2,16 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zgsm-ai/chat-rag/internal/api/helper"
 	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/logic"
 	"github.com/zgsm-ai/chat-rag/internal/model"
 	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
 	"go.uber.org/zap"
 )
 
@@ -24,6 +27,10 @@ func ChatCompletionHandler(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
 			helper.SendErrorResponse(c, http.StatusBadRequest, err)
 			return
 		}
+		if err := utils.CheckMessagesContentDepth(req.Messages, svcCtx.Config.ContentParts.MaxDepth); err != nil {
+			helper.SendErrorResponse(c, http.StatusBadRequest, err)
+			return
+		}
 
 		// 2. Get identity from context (set by middleware)
 		identity, exists := model.GetIdentityFromContext(c.Request.Context())
@@ -104,7 +111,8 @@ func sendStreamError(c *gin.Context, err error, flusher http.Flusher) {
 	}
 
 	// Check if the error is an APIError with a specific status code
-	if apiErr, ok := err.(*types.APIError); ok && apiErr.StatusCode != 0 {
+	var apiErr *types.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
 		errorMsg.Error.Code = apiErr.StatusCode
 	}
 
@@ -134,6 +142,14 @@ func ChatStatusHandler(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
 		// Get tool status from Redis
 		toolStatusKey := types.ToolStatusRedisKeyPrefix + requestId
 		toolStatusData, err := svcCtx.RedisClient.GetHash(c.Request.Context(), toolStatusKey)
+		if errors.Is(err, client.ErrRedisUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, types.ToolStatusResponse{
+				Code:    http.StatusServiceUnavailable,
+				Data:    types.ToolStatusData{},
+				Message: "tool status temporarily unavailable",
+			})
+			return
+		}
 		if err != nil {
 			logger.Warn("Error fetching tool status from Redis", zap.Error(err))
 			// Return 404 if requestID not found in Redis
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+)
+
+// DebugConfigHandler reports how often each Nacos config was reloaded and when it last was,
+// to callers sending the trusted debug header
+func DebugConfigHandler(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		debugCfg := svcCtx.Config.Debug
+		token := c.GetHeader(debugCfg.TrustedHeader)
+		if !debugCfg.Enabled || debugCfg.TrustedToken == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(debugCfg.TrustedToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"message": "debug access denied"})
+			return
+		}
+
+		reloads := []bootstrap.ConfigReload{}
+		if svcCtx.NacosConfigManager != nil {
+			reloads = svcCtx.NacosConfigManager.Reloads()
+		}
+		c.JSON(http.StatusOK, gin.H{"reloads": reloads})
+	}
+}
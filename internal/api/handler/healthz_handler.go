@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"go.uber.org/zap"
+)
+
+// healthzClientID identifies the synthetic caller of tool readiness checks
+const healthzClientID = "chat-rag-healthz"
+
+// Overall /healthz statuses
+const (
+	healthzOK       = "ok"
+	healthzDegraded = "degraded"
+	healthzDown     = "down"
+)
+
+// DependencyStatus is the result of checking one downstream dependency
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"` // "up" or "down"
+	Optional  bool   `json:"optional"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthzResponse is the body of the /healthz endpoint
+type HealthzResponse struct {
+	Status       string             `json:"status"`
+	Timestamp    int64              `json:"timestamp"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// dependencyCheck probes one dependency, a nil error means it is reachable
+type dependencyCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// HealthzHandler checks Redis and every tool backend concurrently. It responds 200 when all
+// mandatory dependencies are up, "degraded" when only optional ones are down, and 503 otherwise.
+func HealthzHandler(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := svcCtx.GetConfig().Healthz
+		statuses := runDependencyChecks(c.Request.Context(), healthzChecks(svcCtx),
+			time.Duration(cfg.TimeoutMs)*time.Millisecond)
+
+		response := HealthzResponse{Status: healthzOK, Timestamp: time.Now().Unix(), Dependencies: statuses}
+		for i := range response.Dependencies {
+			dep := &response.Dependencies[i]
+			dep.Optional = slices.Contains(cfg.Optional, dep.Name)
+			if dep.Status == "up" {
+				continue
+			}
+			if !dep.Optional {
+				response.Status = healthzDown
+			} else if response.Status == healthzOK {
+				response.Status = healthzDegraded
+			}
+		}
+
+		code := http.StatusOK
+		if response.Status == healthzDown {
+			code = http.StatusServiceUnavailable
+			logger.Warn("healthz check failed", zap.Any("dependencies", response.Dependencies))
+		}
+		c.JSON(code, response)
+	}
+}
+
+// healthzChecks lists the dependency checks of the service: Redis PING and the readiness
+// endpoint of each tool, called with a synthetic identity
+func healthzChecks(svcCtx *bootstrap.ServiceContext) []dependencyCheck {
+	var checks []dependencyCheck
+	if svcCtx.RedisClient != nil {
+		checks = append(checks, dependencyCheck{name: "redis", check: func(ctx context.Context) error {
+			pinger, ok := svcCtx.RedisClient.(client.RedisPinger)
+			if !ok {
+				return nil
+			}
+			return pinger.Ping(ctx)
+		}})
+	}
+
+	executor := svcCtx.ToolExecutor
+	if executor == nil {
+		return checks
+	}
+	for _, tool := range executor.GetAllTools() {
+		checks = append(checks, dependencyCheck{name: tool, check: func(ctx context.Context) error {
+			ctx = context.WithValue(ctx, model.IdentityContextKey, &model.Identity{ClientID: healthzClientID})
+			// A backend answering "not ready" for the synthetic project is still reachable
+			_, err := executor.CheckToolReady(ctx, tool)
+			return err
+		}})
+	}
+	return checks
+}
+
+// runDependencyChecks runs the checks concurrently, each bounded by timeout when positive
+func runDependencyChecks(ctx context.Context, checks []dependencyCheck, timeout time.Duration) []DependencyStatus {
+	statuses := make([]DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, dep := range checks {
+		wg.Add(1)
+		go func(i int, dep dependencyCheck) {
+			defer wg.Done()
+			checkCtx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				checkCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			err := dep.check(checkCtx)
+			statuses[i] = DependencyStatus{Name: dep.name, Status: "up", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				statuses[i].Status = "down"
+				statuses[i].Error = err.Error()
+			}
+		}(i, dep)
+	}
+	wg.Wait()
+	return statuses
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+type fakeRedis struct {
+	client.RedisInterface
+	err error
+}
+
+func (r *fakeRedis) Ping(context.Context) error { return r.err }
+
+// fakeReadyExecutor fails the readiness check of the tools in down
+type fakeReadyExecutor struct {
+	functions.ToolExecutor
+	tools []string
+	down  map[string]bool
+}
+
+func (e *fakeReadyExecutor) GetAllTools() []string { return e.tools }
+
+func (e *fakeReadyExecutor) CheckToolReady(ctx context.Context, tool string) (bool, error) {
+	if _, ok := model.GetIdentityFromContext(ctx); !ok {
+		return false, errors.New("no identity")
+	}
+	if e.down[tool] {
+		return false, errors.New("connection refused")
+	}
+	return false, nil
+}
+
+func TestHealthzHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(svcCtx *bootstrap.ServiceContext) (int, HealthzResponse) {
+		router := gin.New()
+		router.GET("/healthz", HealthzHandler(svcCtx))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		var body HealthzResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+	newSvcCtx := func(redisErr error, down map[string]bool) *bootstrap.ServiceContext {
+		return &bootstrap.ServiceContext{
+			Config: config.Config{Healthz: config.HealthzConfig{
+				TimeoutMs: 1000,
+				Optional:  []string{"knowledge_base_search"},
+			}},
+			RedisClient: &fakeRedis{err: redisErr},
+			ToolExecutor: &fakeReadyExecutor{
+				tools: []string{"codebase_search", "knowledge_base_search"},
+				down:  down,
+			},
+		}
+	}
+
+	code, body := serve(newSvcCtx(nil, nil))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, healthzOK, body.Status)
+	require.Len(t, body.Dependencies, 3)
+	assert.Equal(t, "redis", body.Dependencies[0].Name)
+	for _, dep := range body.Dependencies {
+		assert.Equal(t, "up", dep.Status, dep.Name)
+	}
+
+	code, body = serve(newSvcCtx(nil, map[string]bool{"knowledge_base_search": true}))
+	assert.Equal(t, http.StatusOK, code, "optional dependencies do not fail the probe")
+	assert.Equal(t, healthzDegraded, body.Status)
+	assert.Equal(t, "connection refused", body.Dependencies[2].Error)
+	assert.True(t, body.Dependencies[2].Optional)
+
+	code, body = serve(newSvcCtx(errors.New("redis down"), nil))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, healthzDown, body.Status)
+	assert.Equal(t, "down", body.Dependencies[0].Status)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/logic"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"go.uber.org/zap"
+)
+
+// RetrievalFeedbackHandler accepts the client's verdict on the context retrieved for a request
+func RetrievalFeedbackHandler(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respond := func(code int, message string) {
+			c.JSON(code, types.RetrievalFeedbackResponse{Code: code, Message: message})
+		}
+
+		requestId := c.Param("requestId")
+		if requestId == "" {
+			respond(http.StatusBadRequest, "requestId is required")
+			return
+		}
+		var req types.RetrievalFeedbackRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respond(http.StatusBadRequest, "useful is required")
+			return
+		}
+
+		err := logic.SubmitRetrievalFeedback(c.Request.Context(), svcCtx, requestId, req)
+		switch {
+		case err == nil:
+			respond(http.StatusOK, "success")
+		case errors.Is(err, logic.ErrRetrievalNotFound):
+			respond(http.StatusNotFound, "request-id not found or expired")
+		case errors.Is(err, logic.ErrFeedbackExists):
+			respond(http.StatusConflict, "feedback already submitted")
+		case errors.Is(err, client.ErrRedisUnavailable):
+			respond(http.StatusServiceUnavailable, "feedback temporarily unavailable")
+		default:
+			logger.Warn("failed to record retrieval feedback", zap.String("requestId", requestId), zap.Error(err))
+			respond(http.StatusInternalServerError, "failed to record feedback")
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/logic"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"go.uber.org/zap"
+)
+
+// ToolCallsHandler returns the tool calls stored for a request, for debugging its answer
+func ToolCallsHandler(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respond := func(code int, message string) {
+			c.JSON(code, types.ToolCallsResponse{Code: code, Data: []json.RawMessage{}, Message: message})
+		}
+
+		requestId := c.Param("requestId")
+		if requestId == "" {
+			respond(http.StatusBadRequest, "requestId is required")
+			return
+		}
+		if svcCtx.RedisClient == nil {
+			respond(http.StatusServiceUnavailable, "tool calls temporarily unavailable")
+			return
+		}
+
+		toolCalls, err := logic.GetToolCallReplay(c.Request.Context(), svcCtx.RedisClient, requestId)
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, types.ToolCallsResponse{Code: http.StatusOK, Data: toolCalls, Message: "success"})
+		case errors.Is(err, logic.ErrToolCallsNotFound):
+			respond(http.StatusNotFound, "request-id not found or expired")
+		case errors.Is(err, client.ErrRedisUnavailable):
+			respond(http.StatusServiceUnavailable, "tool calls temporarily unavailable")
+		default:
+			logger.Warn("failed to read tool calls", zap.String("requestId", requestId), zap.Error(err))
+			respond(http.StatusInternalServerError, "failed to read tool calls")
+		}
+	}
+}
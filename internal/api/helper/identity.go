@@ -4,6 +4,7 @@ import (
 	"net/url"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zgsm-ai/chat-rag/internal/config"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
 	"github.com/zgsm-ai/chat-rag/internal/types"
@@ -45,6 +46,41 @@ func GetIdentityFromHeaders(c *gin.Context) *model.Identity {
 		Language:      c.GetHeader(types.HeaderLanguage),
 		Sender:        sender,
 		UserInfo:      userInfo,
+		OriginalModel: c.GetHeader(types.HeaderOriginalModel),
+	}
+}
+
+// ApplyDefaultProjectPath fills an empty project path from the configured alternative header
+// or defaults, so tools get a workspace even when the client did not send one
+func ApplyDefaultProjectPath(c *gin.Context, identity *model.Identity, cfg config.DefaultProjectPathConfig) {
+	if identity == nil || identity.ProjectPath != "" {
+		return
+	}
+
+	source := ""
+	if cfg.Header != "" {
+		if path := c.GetHeader(cfg.Header); path != "" {
+			if decodedPath, err := url.PathUnescape(path); err == nil {
+				path = decodedPath
+			}
+			identity.ProjectPath, source = path, "header"
+		}
+	}
+	if identity.ProjectPath == "" && identity.ClientID != "" {
+		if path := cfg.Clients[identity.ClientID]; path != "" {
+			identity.ProjectPath, source = path, "client"
+		}
+	}
+	if identity.ProjectPath == "" && cfg.Path != "" {
+		identity.ProjectPath, source = cfg.Path, "default"
+	}
+
+	if source != "" {
+		logger.Info("default project path applied",
+			zap.String("requestID", identity.RequestID),
+			zap.String("clientID", identity.ClientID),
+			zap.String("source", source),
+			zap.String("projectPath", identity.ProjectPath))
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -35,7 +36,8 @@ func SendErrorResponse(c *gin.Context, statusCode int, err error) {
 	errType := "server_error"
 
 	// Check if the error is an APIError with a specific status code
-	if apiErr, ok := err.(*types.APIError); ok {
+	var apiErr *types.APIError
+	if errors.As(err, &apiErr) {
 		statusCode = apiErr.StatusCode
 		message = apiErr.Message
 		errType = apiErr.Type
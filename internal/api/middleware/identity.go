@@ -16,6 +16,7 @@ func IdentityMiddleware(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract identity information from request headers
 		identity := helper.GetIdentityFromHeaders(c)
+		helper.ApplyDefaultProjectPath(c, identity, svcCtx.Config.DefaultProjectPath)
 
 		// Store identity information in context
 		ctxWithIdentity := context.WithValue(c.Request.Context(), model.IdentityContextKey, identity)
@@ -3,6 +3,7 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/zgsm-ai/chat-rag/internal/api/helper"
 	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
 	"github.com/zgsm-ai/chat-rag/internal/config"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
@@ -125,6 +127,12 @@ func VoucherActivityMiddleware(svcCtx *bootstrap.ServiceContext) gin.HandlerFunc
 		// 8. Check if user has already redeemed
 		usersKey := fmt.Sprintf("voucher:activity:%s:users", matchedActivity.Keyword)
 		redeemedRecord, err := svcCtx.RedisClient.GetHashField(ctx, usersKey, userID)
+		if errors.Is(err, client.ErrRedisUnavailable) {
+			// Redemptions cannot be deduplicated without Redis, handle the message as a normal chat
+			logger.InfoC(ctx, "Redis unavailable, skipping voucher activity")
+			c.Next()
+			return
+		}
 		if err != nil {
 			logger.WarnC(ctx, "Failed to get user redemption status from Redis", zap.Error(err))
 		}
@@ -8,6 +8,7 @@ import (
 	"github.com/zgsm-ai/chat-rag/internal/api/handler"
 	"github.com/zgsm-ai/chat-rag/internal/api/middleware"
 	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
 )
 
 func RegisterHandlers(router *gin.Engine, serverCtx *bootstrap.ServiceContext) {
@@ -21,6 +22,16 @@ func RegisterHandlers(router *gin.Engine, serverCtx *bootstrap.ServiceContext) {
 			handler.ChatCompletionHandler(serverCtx),
 		)
 		apiGroup.GET("/v1/chat/requests/:requestId/status", handler.ChatStatusHandler(serverCtx))
+		if serverCtx.Config.RetrievalFeedback.Enabled {
+			apiGroup.POST(
+				"/v1/chat/requests/:requestId/feedback",
+				middleware.IdentityMiddleware(serverCtx),
+				handler.RetrievalFeedbackHandler(serverCtx),
+			)
+		}
+		if serverCtx.Config.ToolCallReplay.Enabled {
+			apiGroup.GET("/v1/tool-calls/:requestId", handler.ToolCallsHandler(serverCtx))
+		}
 		apiGroup.GET("/v1/voucher/activity/query", handler.VoucherActivityQueryHandler(serverCtx))
 
 		// 添加转发接口 - 支持所有HTTP方法（仅在启用时注册）
@@ -35,6 +46,14 @@ func RegisterHandlers(router *gin.Engine, serverCtx *bootstrap.ServiceContext) {
 	// 添加就绪检查端点 - 用于K8s readiness probe
 	router.GET("/ready", ReadyHandler(serverCtx))
 
+	// Readiness probe checking Redis and the tool backends
+	router.GET("/healthz", handler.HealthzHandler(serverCtx))
+
+	// Reload history of the Nacos configs for trusted debug callers
+	if serverCtx.Config.Debug.ConfigReloads {
+		router.GET("/debug/config", handler.DebugConfigHandler(serverCtx))
+	}
+
 	// 指标端点
 	router.GET("/metrics", handler.MetricsHandler(serverCtx))
 }
@@ -46,10 +65,26 @@ func HealthHandler(ctx *bootstrap.ServiceContext) gin.HandlerFunc {
 			"status":    "healthy",
 			"timestamp": time.Now().Unix(),
 			"service":   "chat-rag",
+			// Non-critical dependencies, reported without affecting the status
+			"dependencies": gin.H{
+				"redis": redisHealth(ctx),
+			},
 		})
 	}
 }
 
+// redisHealth reports "up" or "down" from the Redis circuit breaker, "unknown" when it is disabled
+func redisHealth(ctx *bootstrap.ServiceContext) string {
+	checker, ok := ctx.RedisClient.(client.RedisHealthChecker)
+	if !ok {
+		return "unknown"
+	}
+	if checker.Available() {
+		return "up"
+	}
+	return "down"
+}
+
 // ReadyHandler 处理就绪检查请求
 func ReadyHandler(ctx *bootstrap.ServiceContext) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -3,10 +3,11 @@ package bootstrap
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/zgsm-ai/chat-rag/internal/config"
-	"github.com/zgsm-ai/chat-rag/internal/functions"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"go.uber.org/zap"
 )
@@ -18,6 +19,7 @@ type NacosConfigResult struct {
 	PreciseContextConfig  *config.PreciseContextConfig
 	RouterConfig          *config.RouterConfig
 	VoucherActivityConfig *config.VoucherActivityConfig
+	ResponseFilters       *config.ResponseFiltersConfig
 }
 
 // NacosConfigMetadata holds metadata for Nacos configuration registration
@@ -25,6 +27,8 @@ type NacosConfigMetadata struct {
 	DataId     string
 	ConfigType interface{}
 	UpdateFunc func(svc *ServiceContext, config interface{})
+	// Optional configurations fall back to their zero value when missing in Nacos
+	Optional bool
 }
 
 // NacosConfigManager handles all Nacos configuration management operations
@@ -34,6 +38,16 @@ type NacosConfigManager struct {
 	config      config.Config
 	stopChan    chan struct{}
 	stopOnce    sync.Once
+
+	reloadsMu sync.Mutex
+	reloads   map[string]*ConfigReload
+}
+
+// ConfigReload counts the updates applied to one Nacos config since startup
+type ConfigReload struct {
+	DataId     string     `json:"data_id"`
+	Count      int        `json:"count"`
+	LastReload *time.Time `json:"last_reload,omitempty"`
 }
 
 // NewNacosConfigManager creates a new Nacos configuration manager
@@ -131,7 +145,12 @@ func (m *NacosConfigManager) loadAllConfigurations(metadataList []NacosConfigMet
 		// Create a new instance of the config type
 		configInstance := metadata.ConfigType
 		if err := m.nacosLoader.LoadConfig(metadata.DataId, configInstance); err != nil {
-			return nil, fmt.Errorf("failed to load %s from Nacos: %w", metadata.DataId, err)
+			if !metadata.Optional {
+				return nil, fmt.Errorf("failed to load %s from Nacos: %w", metadata.DataId, err)
+			}
+			logger.Warn("Optional configuration not loaded from Nacos, using defaults",
+				zap.String("dataId", metadata.DataId),
+				zap.Error(err))
 		}
 
 		// Use reflection to automatically assign to result fields based on type
@@ -159,12 +178,52 @@ func (m *NacosConfigManager) registerConfig(metadata NacosConfigMetadata, svc *S
 		metadata.ConfigType,
 		func(data interface{}) {
 			metadata.UpdateFunc(svc, data)
+			m.recordReload(metadata.DataId, svc)
 			logger.Info(fmt.Sprintf("Configuration %s updated successfully", metadata.DataId),
 				zap.String("dataId", metadata.DataId))
 		},
 	)
 }
 
+// recordReload counts an applied update of a config for /debug/config and the metrics
+func (m *NacosConfigManager) recordReload(dataId string, svc *ServiceContext) {
+	m.reloadsMu.Lock()
+	if m.reloads == nil {
+		m.reloads = make(map[string]*ConfigReload)
+	}
+	reload, ok := m.reloads[dataId]
+	if !ok {
+		reload = &ConfigReload{DataId: dataId}
+		m.reloads[dataId] = reload
+	}
+	now := time.Now()
+	reload.Count++
+	reload.LastReload = &now
+	m.reloadsMu.Unlock()
+
+	if svc != nil && svc.MetricsService != nil {
+		svc.MetricsService.RecordConfigReload(dataId)
+	}
+}
+
+// Reloads returns the updates applied to each config since startup, ordered by data id.
+// Configs never updated after their initial load are listed with a zero count.
+func (m *NacosConfigManager) Reloads() []ConfigReload {
+	m.reloadsMu.Lock()
+	defer m.reloadsMu.Unlock()
+
+	reloads := make([]ConfigReload, 0, len(m.reloads))
+	for _, metadata := range getNacosConfigMetadata() {
+		if reload, ok := m.reloads[metadata.DataId]; ok {
+			reloads = append(reloads, *reload)
+		} else {
+			reloads = append(reloads, ConfigReload{DataId: metadata.DataId})
+		}
+	}
+	sort.Slice(reloads, func(i, j int) bool { return reloads[i].DataId < reloads[j].DataId })
+	return reloads
+}
+
 // getNacosConfigMetadata returns the centralized configuration metadata
 // This is the only place that needs to be modified when adding new configurations
 // All DataId strings are hardcoded here - when adding new configurations, only modify this function
@@ -187,7 +246,7 @@ func getNacosConfigMetadata() []NacosConfigMetadata {
 			UpdateFunc: func(svc *ServiceContext, data interface{}) {
 				if toolsConfig, ok := data.(*config.ToolConfig); ok {
 					logger.Info("Recreating tool executor with new tools configuration")
-					newToolExecutor := functions.NewGenericToolExecutor(toolsConfig)
+					newToolExecutor := svc.newToolExecutor(toolsConfig)
 					svc.updateToolExecutor(newToolExecutor)
 					logger.Info("Tool executor successfully recreated with new configuration")
 				}
@@ -229,6 +288,18 @@ func getNacosConfigMetadata() []NacosConfigMetadata {
 				}
 			},
 		},
+		{
+			DataId:     "response_filters",
+			ConfigType: &config.ResponseFiltersConfig{},
+			UpdateFunc: func(svc *ServiceContext, data interface{}) {
+				if responseFilters, ok := data.(*config.ResponseFiltersConfig); ok {
+					svc.updateResponseFiltersConfig(responseFilters)
+					logger.Info("Response filters configuration updated",
+						zap.Int("filtersCount", len(responseFilters.Filters)))
+				}
+			},
+			Optional: true,
+		},
 	}
 }
 
@@ -0,0 +1,38 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/service/mocks"
+)
+
+func TestNacosConfigManager_Reloads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	metrics := mocks.NewMockMetricsInterface(ctrl)
+	metrics.EXPECT().RecordConfigReload("model_router").Times(2)
+	metrics.EXPECT().RecordConfigReload("agent_rules")
+
+	m := &NacosConfigManager{}
+	svc := &ServiceContext{MetricsService: metrics}
+	m.recordReload("model_router", svc)
+	m.recordReload("agent_rules", svc)
+	m.recordReload("model_router", svc)
+
+	reloads := m.Reloads()
+	require.Len(t, reloads, len(getNacosConfigMetadata()))
+	counts := map[string]int{}
+	for i, reload := range reloads {
+		if i > 0 {
+			assert.Less(t, reloads[i-1].DataId, reload.DataId, "ordered by data id")
+		}
+		counts[reload.DataId] = reload.Count
+		assert.Equal(t, reload.Count > 0, reload.LastReload != nil)
+	}
+	assert.Equal(t, 2, counts["model_router"])
+	assert.Equal(t, 1, counts["agent_rules"])
+	assert.Equal(t, 0, counts["tools_prompt"])
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/zgsm-ai/chat-rag/internal/config"
 	"github.com/zgsm-ai/chat-rag/internal/functions"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/processor"
 	"github.com/zgsm-ai/chat-rag/internal/service"
 	"github.com/zgsm-ai/chat-rag/internal/storage"
 	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
@@ -32,11 +33,20 @@ type ServiceContext struct {
 	MetricsService service.MetricsInterface
 	VoucherService *service.VoucherService
 
+	// Tool execution audit log, nil when disabled
+	ToolAuditLogger service.ToolAuditInterface
+
+	// Sampled request/response records for QA, nil when disabled
+	QASampler service.QASamplerInterface
+
 	// Utilities
 	TokenCounter *tokenizer.TokenCounter
 
 	ToolExecutor functions.ToolExecutor
 
+	// Bounds the summary model calls of prompt compression, nil when unlimited
+	CompressPool *processor.CompressPool
+
 	// Router strategy instance (maintained as singleton for state consistency)
 	// This ensures round-robin and other stateful strategies maintain their state across requests
 	// Stored as interface{} to avoid circular dependency with router package
@@ -88,6 +98,7 @@ func NewServiceContext(c config.Config, opts ...ServiceContextOption) *ServiceCo
 
 // initialize orchestrates the initialization of all components
 func (svc *ServiceContext) initialize() error {
+	logger.SetEnvironment(svc.Config.Environment)
 	logger.Info("Starting service context initialization")
 
 	// Initialize components in dependency order
@@ -100,6 +111,9 @@ func (svc *ServiceContext) initialize() error {
 		svc.initializeNacosConfig,
 		svc.initializeVoucherService,
 		svc.initializeToolExecutor,
+		svc.initializeCompressPool,
+		svc.initializeToolAuditLogger,
+		svc.initializeQASampler,
 		svc.initializeRouterStrategy,
 		svc.startNacosConfigWatching,
 	}
@@ -130,6 +144,12 @@ func (svc *ServiceContext) initializeTokenCounter() error {
 		return fmt.Errorf("failed to initialize token counter: %w", err)
 	}
 
+	if svc.Config.TokenizerWarmUp.Enabled {
+		if err := counter.WarmUp(); err != nil {
+			return fmt.Errorf("tokenizer warm-up failed: %w", err)
+		}
+	}
+
 	svc.TokenCounter = counter
 	logger.Info("Token counter initialized successfully")
 	return nil
@@ -137,7 +157,7 @@ func (svc *ServiceContext) initializeTokenCounter() error {
 
 // initializeMetricsService initializes the metrics service
 func (svc *ServiceContext) initializeMetricsService() error {
-	svc.MetricsService = service.NewMetricsService()
+	svc.MetricsService = service.NewMetricsService(svc.Config.Metrics, svc.Config.Environment)
 	logger.Info("Metrics service initialized successfully")
 	return nil
 }
@@ -191,6 +211,41 @@ func (svc *ServiceContext) initializeLoggerService() error {
 	return nil
 }
 
+// initializeToolAuditLogger opens the tool audit log when enabled
+func (svc *ServiceContext) initializeToolAuditLogger() error {
+	if !svc.Config.ToolAudit.Enabled || svc.ToolAuditLogger != nil {
+		return nil
+	}
+
+	auditLogger, err := service.NewToolAuditLogger(svc.Config.ToolAudit)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tool audit logger: %w", err)
+	}
+	svc.ToolAuditLogger = auditLogger
+
+	logger.Info("Tool audit logger initialized",
+		zap.String("destination", svc.Config.ToolAudit.Destination))
+	return nil
+}
+
+// initializeQASampler prepares the QA sample directory when sampling is enabled
+func (svc *ServiceContext) initializeQASampler() error {
+	if !svc.Config.QASampling.Enabled || svc.QASampler != nil {
+		return nil
+	}
+
+	sampler, err := service.NewQASampler(svc.Config.QASampling)
+	if err != nil {
+		return fmt.Errorf("failed to initialize qa sampler: %w", err)
+	}
+	svc.QASampler = sampler
+
+	logger.Info("QA sampler initialized",
+		zap.Float64("rate", svc.Config.QASampling.Rate),
+		zap.String("directory", svc.Config.QASampling.Directory))
+	return nil
+}
+
 // initializeRedisClient initializes the Redis client
 func (svc *ServiceContext) initializeRedisClient() error {
 	if svc.RedisClient != nil {
@@ -198,8 +253,16 @@ func (svc *ServiceContext) initializeRedisClient() error {
 	}
 
 	svc.RedisClient = client.NewRedisClient(svc.Config.Redis)
+	if svc.Config.Redis.Breaker.Enabled {
+		breaker := client.NewBreakerRedisClient(svc.RedisClient, svc.Config.Redis.Breaker)
+		if svc.MetricsService != nil {
+			breaker.SetAvailabilityObserver(svc.MetricsService.SetRedisAvailable)
+		}
+		svc.RedisClient = breaker
+	}
 	logger.Info("Redis client initialized successfully",
-		zap.String("addr", svc.Config.Redis.Addr))
+		zap.String("addr", svc.Config.Redis.Addr),
+		zap.Bool("breaker", svc.Config.Redis.Breaker.Enabled))
 	return nil
 }
 
@@ -245,6 +308,7 @@ func (svc *ServiceContext) initializeNacosConfig() error {
 	svc.Config.PreciseContextConfig = nacosResult.PreciseContextConfig
 	svc.Config.Router = nacosResult.RouterConfig
 	svc.Config.VoucherActivityConfig = nacosResult.VoucherActivityConfig
+	svc.Config.ResponseFilters = nacosResult.ResponseFilters
 
 	// Apply router defaults after loading from Nacos
 	config.ApplyRouterDefaults(&svc.Config)
@@ -259,11 +323,37 @@ func (svc *ServiceContext) initializeNacosConfig() error {
 
 // initializeToolExecutor initializes the tool executor
 func (svc *ServiceContext) initializeToolExecutor() error {
-	svc.ToolExecutor = functions.NewGenericToolExecutor(svc.Config.Tools)
+	svc.ToolExecutor = svc.newToolExecutor(svc.Config.Tools)
 	logger.Info("Tool executor initialized successfully")
 	return nil
 }
 
+// initializeCompressPool bounds the summary model calls of prompt compression when configured
+func (svc *ServiceContext) initializeCompressPool() error {
+	cfg := svc.Config.ContextCompressConfig
+	if cfg.CompressWorkers <= 0 {
+		return nil
+	}
+
+	svc.CompressPool = processor.NewCompressPool(cfg.CompressWorkers, cfg.CompressQueueSize)
+	if svc.MetricsService != nil {
+		svc.CompressPool.SetQueueDepthObserver(svc.MetricsService.SetCompressQueueDepth)
+	}
+	logger.Info("Compression pool initialized",
+		zap.Int("workers", cfg.CompressWorkers),
+		zap.Int("queueSize", cfg.CompressQueueSize))
+	return nil
+}
+
+// newToolExecutor creates a tool executor reporting its tool queue depths as metrics
+func (svc *ServiceContext) newToolExecutor(toolsConfig *config.ToolConfig) *functions.GenericToolExecutor {
+	executor := functions.NewGenericToolExecutor(toolsConfig)
+	if svc.MetricsService != nil {
+		executor.SetQueueDepthObserver(svc.MetricsService.SetToolQueueDepth)
+	}
+	return executor
+}
+
 // initializeRouterStrategy initializes the router strategy placeholder
 // The actual strategy will be set by the router package on first use
 func (svc *ServiceContext) initializeRouterStrategy() error {
@@ -369,6 +459,8 @@ func (svc *ServiceContext) Stop() error {
 			fn   func(context.Context) error
 		}{
 			{"logger service", svc.shutdownLoggerService},
+			{"tool audit logger", svc.shutdownToolAuditLogger},
+			{"qa sampler", svc.shutdownQASampler},
 			{"storage backend", svc.shutdownStorageBackend},
 			{"Nacos connection", svc.shutdownNacosConnection},
 			{"Redis connection", svc.shutdownRedisConnection},
@@ -411,6 +503,38 @@ func (svc *ServiceContext) shutdownLoggerService(ctx context.Context) error {
 	return nil
 }
 
+// shutdownToolAuditLogger closes the tool audit log
+func (svc *ServiceContext) shutdownToolAuditLogger(ctx context.Context) error {
+	if svc.ToolAuditLogger == nil {
+		return nil
+	}
+
+	if err := svc.ToolAuditLogger.Close(); err != nil {
+		logger.Error("Failed to close tool audit logger",
+			zap.Error(err))
+		return err
+	}
+
+	logger.Info("Tool audit logger closed")
+	return nil
+}
+
+// shutdownQASampler closes the QA sample file
+func (svc *ServiceContext) shutdownQASampler(ctx context.Context) error {
+	if svc.QASampler == nil {
+		return nil
+	}
+
+	if err := svc.QASampler.Close(); err != nil {
+		logger.Error("Failed to close qa sampler",
+			zap.Error(err))
+		return err
+	}
+
+	logger.Info("QA sampler closed")
+	return nil
+}
+
 // shutdownStorageBackend closes the storage backend
 func (svc *ServiceContext) shutdownStorageBackend(ctx context.Context) error {
 	if svc.StorageBackend == nil {
@@ -504,6 +628,12 @@ func (svc *ServiceContext) updateRouterConfig(routerConfig *config.RouterConfig)
 	logger.Info("Router configuration updated, strategy cache cleared")
 }
 
+func (svc *ServiceContext) updateResponseFiltersConfig(config *config.ResponseFiltersConfig) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.Config.ResponseFilters = config
+}
+
 func (svc *ServiceContext) updateVoucherActivityConfig(newConfig *config.VoucherActivityConfig) {
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
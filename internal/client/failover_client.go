@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// FailoverBackend is one backend of a failover client
+type FailoverBackend struct {
+	Endpoint string // Search endpoint, recorded as the endpoint that served a call
+	Client   GenericClientInterface
+}
+
+// errStreamUnsupported stops the failover loop when the primary backend has no stream endpoint
+var errStreamUnsupported = errors.New("stream not supported")
+
+// FailoverToolClient tries its backends in order until one returns non-empty results.
+// Progress and streaming come from the primary backend; once a call fails over,
+// the remaining backends are called with Execute.
+type FailoverToolClient struct {
+	toolName string
+	backends []FailoverBackend
+}
+
+// NewFailoverToolClient Create a client trying the backends in the given order
+func NewFailoverToolClient(toolName string, backends []FailoverBackend) *FailoverToolClient {
+	return &FailoverToolClient{toolName: toolName, backends: backends}
+}
+
+// Execute Execute the request on the first backend returning non-empty results. When every
+// backend fails or comes back empty, the first empty result is returned, otherwise the last error
+func (c *FailoverToolClient) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	return c.execute(ctx, params, nil)
+}
+
+// ExecuteStream Stream the results of the primary backend, failing over to Execute on the
+// other backends when the stream fails or comes back empty. ok is false when the primary
+// backend cannot stream.
+func (c *FailoverToolClient) ExecuteStream(ctx context.Context, params map[string]interface{},
+	onItem func(item json.RawMessage)) (string, bool, error) {
+	primary, ok := c.backends[0].Client.(StreamingClient)
+	if !ok {
+		return "", false, nil
+	}
+
+	result, err := c.execute(ctx, params, func(ctx context.Context, params map[string]interface{}) (string, error) {
+		result, ok, err := primary.ExecuteStream(ctx, params, onItem)
+		if !ok {
+			return "", errStreamUnsupported
+		}
+		return result, err
+	})
+	if errors.Is(err, errStreamUnsupported) {
+		return "", false, nil
+	}
+	return result, true, err
+}
+
+// Progress Report the progress of the primary backend
+func (c *FailoverToolClient) Progress(ctx context.Context, params map[string]interface{}) (int, bool, error) {
+	reporter, ok := c.backends[0].Client.(ProgressReporter)
+	if !ok {
+		return 0, false, nil
+	}
+	return reporter.Progress(ctx, params)
+}
+
+// execute runs the failover loop, calling the primary backend with primaryCall when it is set.
+// A primary call that cannot stream returns immediately so the caller falls back to Execute.
+func (c *FailoverToolClient) execute(ctx context.Context, params map[string]interface{},
+	primaryCall func(ctx context.Context, params map[string]interface{}) (string, error)) (string, error) {
+	var lastErr error
+	emptyResult, emptyEndpoint, hasEmpty := "", "", false
+	for i, backend := range c.backends {
+		call := backend.Client.Execute
+		if i == 0 && primaryCall != nil {
+			call = primaryCall
+		}
+		result, err := call(ctx, params)
+		if errors.Is(err, errStreamUnsupported) {
+			return "", err
+		}
+		if err != nil {
+			lastErr = err
+			logger.WarnC(ctx, "tool backend failed, trying next",
+				zap.String("tool", c.toolName), zap.String("endpoint", backend.Endpoint), zap.Error(err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if IsEmptyResult(result) {
+			if !hasEmpty {
+				emptyResult, emptyEndpoint, hasEmpty = result, backend.Endpoint, true
+			}
+			logger.InfoC(ctx, "tool backend returned no results, trying next",
+				zap.String("tool", c.toolName), zap.String("endpoint", backend.Endpoint))
+			continue
+		}
+
+		if i > 0 {
+			logger.InfoC(ctx, "tool call served by failover backend",
+				zap.String("tool", c.toolName), zap.String("endpoint", backend.Endpoint))
+		}
+		recordServedEndpoint(ctx, backend.Endpoint)
+		return result, nil
+	}
+
+	if hasEmpty {
+		recordServedEndpoint(ctx, emptyEndpoint)
+		return emptyResult, nil
+	}
+	return "", fmt.Errorf("all %d backends failed: %w", len(c.backends), lastErr)
+}
+
+// CheckReady Report ready when any backend is ready
+func (c *FailoverToolClient) CheckReady(ctx context.Context, params map[string]interface{}) (bool, error) {
+	var lastErr error
+	for _, backend := range c.backends {
+		ready, err := backend.Client.CheckReady(ctx, params)
+		if ready {
+			return true, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return false, lastErr
+}
+
+// ServedEndpoint holds the endpoint that served a tool call
+type ServedEndpoint struct {
+	mu       sync.Mutex
+	endpoint string
+}
+
+// Endpoint returns the recorded endpoint, empty when the tool has no failover backends
+func (s *ServedEndpoint) Endpoint() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endpoint
+}
+
+type servedEndpointKey struct{}
+
+// WithServedEndpoint returns a context in which failover clients record the endpoint serving the call
+func WithServedEndpoint(ctx context.Context) (context.Context, *ServedEndpoint) {
+	served := &ServedEndpoint{}
+	return context.WithValue(ctx, servedEndpointKey{}, served), served
+}
+
+// recordServedEndpoint records the endpoint in the context, the last call of a tool execution wins
+func recordServedEndpoint(ctx context.Context, endpoint string) {
+	if served, ok := ctx.Value(servedEndpointKey{}).(*ServedEndpoint); ok {
+		served.mu.Lock()
+		served.endpoint = endpoint
+		served.mu.Unlock()
+	}
+}
+
+// IsEmptyResult reports whether a tool response carries no results
+func IsEmptyResult(result string) bool {
+	trimmed := strings.TrimSpace(result)
+	if trimmed == "" {
+		return true
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		// Not JSON, treat any text as a result
+		return false
+	}
+	return isEmptyValue(data)
+}
+
+// isEmptyValue checks decoded JSON for empty results, looking into the
+// usual envelope fields when the value is an object
+func isEmptyValue(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(val) == ""
+	case []interface{}:
+		return len(val) == 0
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return true
+		}
+		for _, key := range []string{"data", "list", "results", "items"} {
+			if inner, ok := val[key]; ok {
+				return isEmptyValue(inner)
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// stubToolClient returns fixed responses
+type stubToolClient struct {
+	result string
+	err    error
+	ready  bool
+	calls  int
+}
+
+func (s *stubToolClient) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func (s *stubToolClient) CheckReady(ctx context.Context, params map[string]interface{}) (bool, error) {
+	if s.ready {
+		return true, nil
+	}
+	return false, errors.New("not ready")
+}
+
+func TestFailoverToolClient_Execute(t *testing.T) {
+	tests := []struct {
+		name           string
+		backends       []*stubToolClient
+		expectResult   string
+		expectErr      bool
+		expectEndpoint string
+		expectCalls    []int
+	}{
+		{
+			name:           "primary serves",
+			backends:       []*stubToolClient{{result: `{"data": [1]}`}, {result: `{"data": [2]}`}},
+			expectResult:   `{"data": [1]}`,
+			expectEndpoint: "primary",
+			expectCalls:    []int{1, 0},
+		},
+		{
+			name:           "primary error",
+			backends:       []*stubToolClient{{err: errors.New("down")}, {result: `{"data": [2]}`}},
+			expectResult:   `{"data": [2]}`,
+			expectEndpoint: "secondary",
+			expectCalls:    []int{1, 1},
+		},
+		{
+			name:           "primary empty",
+			backends:       []*stubToolClient{{result: `{"data": []}`}, {result: `{"data": [2]}`}},
+			expectResult:   `{"data": [2]}`,
+			expectEndpoint: "secondary",
+			expectCalls:    []int{1, 1},
+		},
+		{
+			name:           "empty everywhere",
+			backends:       []*stubToolClient{{result: `{"data": []}`}, {err: errors.New("down")}},
+			expectResult:   `{"data": []}`,
+			expectEndpoint: "primary",
+			expectCalls:    []int{1, 1},
+		},
+		{
+			name:        "all failed",
+			backends:    []*stubToolClient{{err: errors.New("down")}, {err: errors.New("down")}},
+			expectErr:   true,
+			expectCalls: []int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failover := NewFailoverToolClient("codebase_search", []FailoverBackend{
+				{Endpoint: "primary", Client: tt.backends[0]},
+				{Endpoint: "secondary", Client: tt.backends[1]},
+			})
+			ctx, served := WithServedEndpoint(context.Background())
+
+			result, err := failover.Execute(ctx, map[string]interface{}{})
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectResult, result)
+			assert.Equal(t, tt.expectEndpoint, served.Endpoint())
+			assert.Equal(t, tt.expectCalls, []int{tt.backends[0].calls, tt.backends[1].calls})
+		})
+	}
+}
+
+func TestFailoverToolClient_CheckReady(t *testing.T) {
+	ready, err := NewFailoverToolClient("codebase_search", []FailoverBackend{
+		{Endpoint: "primary", Client: &stubToolClient{}},
+		{Endpoint: "secondary", Client: &stubToolClient{ready: true}},
+	}).CheckReady(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.True(t, ready, "ready when any backend is")
+
+	ready, err = NewFailoverToolClient("codebase_search", []FailoverBackend{
+		{Endpoint: "primary", Client: &stubToolClient{}},
+	}).CheckReady(context.Background(), nil)
+	assert.Error(t, err)
+	assert.False(t, ready)
+}
+
+func TestGenericClientFactory_FailoverEndpoints(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"filePath": "a.go"}]}`)
+	}))
+	defer secondary.Close()
+
+	toolClient, err := NewGenericClientFactory().CreateClient(config.GenericToolConfig{
+		Name:              "codebase_search",
+		Method:            http.MethodPost,
+		Endpoints:         config.GenericToolEndpoints{Search: primary.URL, Ready: primary.URL},
+		FailoverEndpoints: []config.GenericToolEndpoints{{Search: secondary.URL, Ready: secondary.URL}},
+	})
+	assert.NoError(t, err)
+
+	ctx, served := WithServedEndpoint(context.Background())
+	result, err := toolClient.Execute(ctx, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Contains(t, result, "a.go")
+	assert.Equal(t, secondary.URL, served.Endpoint())
+}
+
+// streamingStubClient streams its items, or reports no stream endpoint when noStream is set
+type streamingStubClient struct {
+	stubToolClient
+	items    []string
+	noStream bool
+	percent  int
+}
+
+func (s *streamingStubClient) ExecuteStream(ctx context.Context, params map[string]interface{},
+	onItem func(item json.RawMessage)) (string, bool, error) {
+	if s.noStream {
+		return "", false, nil
+	}
+	for _, item := range s.items {
+		onItem(json.RawMessage(item))
+	}
+	return `{"data": [` + strings.Join(s.items, ",") + `]}`, true, nil
+}
+
+func (s *streamingStubClient) Progress(ctx context.Context, params map[string]interface{}) (int, bool, error) {
+	return s.percent, true, nil
+}
+
+func TestFailoverToolClient_ExecuteStream(t *testing.T) {
+	var streamed []string
+	onItem := func(item json.RawMessage) { streamed = append(streamed, string(item)) }
+
+	primary := &streamingStubClient{items: []string{"1", "2"}}
+	secondary := &stubToolClient{result: `{"data": [3]}`}
+	failover := NewFailoverToolClient("codebase_search", []FailoverBackend{
+		{Endpoint: "primary", Client: primary},
+		{Endpoint: "secondary", Client: secondary},
+	})
+	ctx, served := WithServedEndpoint(context.Background())
+	result, ok, err := failover.ExecuteStream(ctx, nil, onItem)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"data": [1,2]}`, result)
+	assert.Equal(t, []string{"1", "2"}, streamed)
+	assert.Equal(t, "primary", served.Endpoint())
+	assert.Equal(t, 0, secondary.calls)
+
+	// An empty stream fails over to Execute on the next backend
+	primary.items = nil
+	ctx, served = WithServedEndpoint(context.Background())
+	result, ok, err = failover.ExecuteStream(ctx, nil, onItem)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"data": [3]}`, result)
+	assert.Equal(t, "secondary", served.Endpoint())
+
+	// Without a stream endpoint on the primary nothing is called
+	primary.noStream = true
+	secondary.calls = 0
+	_, ok, err = failover.ExecuteStream(context.Background(), nil, onItem)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0, secondary.calls)
+
+	_, ok, _ = NewFailoverToolClient("codebase_search", []FailoverBackend{
+		{Endpoint: "primary", Client: &stubToolClient{}},
+	}).ExecuteStream(context.Background(), nil, onItem)
+	assert.False(t, ok, "primary without streaming support")
+}
+
+func TestFailoverToolClient_Progress(t *testing.T) {
+	percent, ok, err := NewFailoverToolClient("codebase_search", []FailoverBackend{
+		{Endpoint: "primary", Client: &streamingStubClient{percent: 40}},
+		{Endpoint: "secondary", Client: &stubToolClient{}},
+	}).Progress(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 40, percent)
+
+	_, ok, err = NewFailoverToolClient("codebase_search", []FailoverBackend{
+		{Endpoint: "primary", Client: &stubToolClient{}},
+	}).Progress(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
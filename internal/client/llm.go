@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"strings"
@@ -201,6 +202,14 @@ func (c *LLMClient) ChatLLMWithMessagesStreamRaw(ctx context.Context, params typ
 	if params.Extra == nil {
 		params.Extra = make(map[string]any)
 	}
+	// Offer the configured tools unless the caller sent its own. The map is copied because
+	// it is shared with the request, where tools mean the caller handles the calls itself
+	if _, ok := params.Extra["tools"]; !ok && len(c.tools) > 0 {
+		extra := make(map[string]any, len(params.Extra)+1)
+		maps.Copy(extra, params.Extra)
+		extra["tools"] = c.tools
+		params.Extra = extra
+	}
 	params.Extra["model"] = c.modelName
 
 	// Prepare request data structure
@@ -259,7 +268,7 @@ func (c *LLMClient) ChatLLMWithMessagesStreamRaw(ctx context.Context, params typ
 		}
 
 		logger.ErrorC(ctx, "Failed to connect to LLM service", zap.Error(err))
-		return types.NewModelServiceUnavailableError()
+		return types.NewUpstreamDropError(types.NewModelServiceUnavailableError())
 	}
 	defer resp.Body.Close()
 
@@ -318,6 +327,8 @@ func (c *LLMClient) ChatLLMWithMessagesStreamRaw(ctx context.Context, params typ
 	scanner := bufio.NewScanner(resp.Body)
 	// Increase buffer size to handle long response lines
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	// Upstreams may batch several payloads per line or split one across lines
+	var splitter sseLineSplitter
 	chunkStartTime := time.Now()
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -337,17 +348,28 @@ func (c *LLMClient) ChatLLMWithMessagesStreamRaw(ctx context.Context, params typ
 			idleTimer.Reset()
 		}
 
-		// Arrange non-empty lines, including empty data lines
+		// Arrange non-empty lines, including empty data lines, one payload per callback
 		if line != "" || strings.HasPrefix(line, "data:") {
-			llmResp.ResonseLine = line
-			if err := callback(llmResp); err != nil {
-				return fmt.Errorf("callback error: %w", err)
+			for _, dataLine := range splitter.Split(line) {
+				llmResp.ResonseLine = dataLine
+				if err := callback(llmResp); err != nil {
+					return fmt.Errorf("callback error: %w", err)
+				}
 			}
 		}
 		if chunkTimeChan != nil {
 			chunkStartTime = time.Now()
 		}
 	}
+	if scanner.Err() == nil {
+		for _, dataLine := range splitter.Flush() {
+			logger.WarnC(ctx, "stream ended with incomplete data payload", zap.Int("length", len(dataLine)))
+			llmResp.ResonseLine = dataLine
+			if err := callback(llmResp); err != nil {
+				return fmt.Errorf("callback error: %w", err)
+			}
+		}
+	}
 	// steam is End
 	if streamEnd != nil {
 		streamEnd <- true
@@ -368,7 +390,7 @@ func (c *LLMClient) ChatLLMWithMessagesStreamRaw(ctx context.Context, params typ
 		}
 
 		logger.ErrorC(ctx, "Error reading response", zap.Error(err))
-		return types.NewNetWorkError()
+		return types.NewUpstreamDropError(types.NewNetWorkError())
 	}
 	// Wait for chunk time calculation (max 3 seconds)
 	if chunkTimeCaculated != nil {
@@ -441,7 +463,7 @@ func (c *LLMClient) ChatLLMWithMessagesRaw(ctx context.Context, params types.LLM
 		}
 
 		logger.ErrorC(ctx, "Failed to connect to LLM service", zap.Error(err))
-		return nil_resp, types.NewModelServiceUnavailableError()
+		return nil_resp, types.NewUpstreamDropError(types.NewModelServiceUnavailableError())
 	}
 	defer resp.Body.Close()
 
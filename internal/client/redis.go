@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,18 @@ import (
 	"github.com/zgsm-ai/chat-rag/internal/config"
 )
 
+// ErrRedisNotFound matches errors reporting a missing key or field, which say nothing about Redis health
+var ErrRedisNotFound = errors.New("redis key not found")
+
+// notFoundError keeps the detailed message while matching ErrRedisNotFound
+type notFoundError struct {
+	msg string
+}
+
+func (e *notFoundError) Error() string { return e.msg }
+
+func (e *notFoundError) Unwrap() error { return ErrRedisNotFound }
+
 // RedisInterface defines the interface for Redis client
 type RedisInterface interface {
 	// Connect establishes a connection to Redis
@@ -17,6 +30,17 @@ type RedisInterface interface {
 	// SetHashField sets a field-value pair in a Redis hash
 	SetHashField(ctx context.Context, key string, field string, value interface{}, expiration time.Duration) error
 
+	// SetHashFields sets several field-value pairs in a Redis hash in a single round trip
+	SetHashFields(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error
+
+	// DeleteHashFields removes fields from a Redis hash
+	DeleteHashFields(ctx context.Context, key string, fields ...string) error
+
+	// RaiseHashField atomically sets a numeric hash field to value, along with the extra fields,
+	// unless it already holds a greater number. It returns the number held before, 0 when unset
+	RaiseHashField(ctx context.Context, key string, field string, value int64,
+		extra map[string]interface{}, expiration time.Duration) (int64, error)
+
 	// GetHashField retrieves a field value from a Redis hash
 	GetHashField(ctx context.Context, key string, field string) (string, error)
 
@@ -29,6 +53,12 @@ type RedisInterface interface {
 	// GetString retrieves a string value by key
 	GetString(ctx context.Context, key string) (string, error)
 
+	// PushList prepends a value to a Redis list, keeping at most maxLen elements
+	PushList(ctx context.Context, key string, value interface{}, maxLen int64, expiration time.Duration) error
+
+	// GetListRange retrieves list elements between start and stop (inclusive)
+	GetListRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+
 	// Close gracefully closes the Redis connection
 	Close() error
 }
@@ -70,6 +100,14 @@ func (c *RedisClient) Connect(ctx context.Context) error {
 	return nil
 }
 
+// Ping checks that Redis answers, connecting first when there is no connection yet
+func (c *RedisClient) Ping(ctx context.Context) error {
+	if c.client == nil {
+		return c.Connect(ctx)
+	}
+	return c.client.Ping(ctx).Err()
+}
+
 // SetHashField sets a field-value pair in a Redis hash
 func (c *RedisClient) SetHashField(ctx context.Context, key string, field string, value interface{}, expiration time.Duration) error {
 	if c.client == nil {
@@ -93,6 +131,89 @@ func (c *RedisClient) SetHashField(ctx context.Context, key string, field string
 	return nil
 }
 
+// SetHashFields sets several field-value pairs in a Redis hash, pipelining the
+// update and the expiration into a single round trip
+func (c *RedisClient) SetHashFields(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if c.client == nil {
+		if err := c.Connect(ctx); err != nil {
+			return fmt.Errorf("redis client not connected and failed to reconnect: %w", err)
+		}
+	}
+
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fields)
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set hash fields in Redis: %w", err)
+	}
+
+	return nil
+}
+
+// raiseHashFieldScript raises ARGV[1] to ARGV[2] unless it holds more, then sets the extra
+// field-value pairs from ARGV[4] on and the expiration in milliseconds from ARGV[3]
+var raiseHashFieldScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], ARGV[1])) or 0
+if current > tonumber(ARGV[2]) then
+	return current
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+for i = 4, #ARGV, 2 do
+	redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return current
+`)
+
+// RaiseHashField atomically sets a numeric hash field to value, along with the extra fields,
+// unless it already holds a greater number. It returns the number held before, 0 when unset
+func (c *RedisClient) RaiseHashField(ctx context.Context, key string, field string, value int64,
+	extra map[string]interface{}, expiration time.Duration) (int64, error) {
+	if c.client == nil {
+		if err := c.Connect(ctx); err != nil {
+			return 0, fmt.Errorf("redis client not connected and failed to reconnect: %w", err)
+		}
+	}
+
+	args := []interface{}{field, value, expiration.Milliseconds()}
+	for k, v := range extra {
+		args = append(args, k, v)
+	}
+	previous, err := raiseHashFieldScript.Run(ctx, c.client, []string{key}, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to raise hash field in Redis: %w", err)
+	}
+
+	return previous, nil
+}
+
+// DeleteHashFields removes fields from a Redis hash
+func (c *RedisClient) DeleteHashFields(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if c.client == nil {
+		if err := c.Connect(ctx); err != nil {
+			return fmt.Errorf("redis client not connected and failed to reconnect: %w", err)
+		}
+	}
+
+	if err := c.client.HDel(ctx, key, fields...).Err(); err != nil {
+		return fmt.Errorf("failed to delete hash fields in Redis: %w", err)
+	}
+
+	return nil
+}
+
 // GetHashField retrieves a field value from a Redis hash
 func (c *RedisClient) GetHashField(ctx context.Context, key string, field string) (string, error) {
 	if c.client == nil {
@@ -104,7 +225,7 @@ func (c *RedisClient) GetHashField(ctx context.Context, key string, field string
 	value, err := c.client.HGet(ctx, key, field).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return "", fmt.Errorf("hash field does not exist: %s:%s", key, field)
+			return "", &notFoundError{msg: fmt.Sprintf("hash field does not exist: %s:%s", key, field)}
 		}
 		return "", fmt.Errorf("failed to get hash field from Redis: %w", err)
 	}
@@ -134,7 +255,7 @@ func (c *RedisClient) GetHash(ctx context.Context, key string) (map[string]strin
 	}
 
 	if len(values) == 0 {
-		return nil, fmt.Errorf("hash does not exist: %s", key)
+		return nil, &notFoundError{msg: fmt.Sprintf("hash does not exist: %s", key)}
 	}
 
 	return values, nil
@@ -167,10 +288,49 @@ func (c *RedisClient) GetString(ctx context.Context, key string) (string, error)
 	value, err := c.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return "", fmt.Errorf("key does not exist: %s", key)
+			return "", &notFoundError{msg: fmt.Sprintf("key does not exist: %s", key)}
 		}
 		return "", fmt.Errorf("failed to get key from Redis: %w", err)
 	}
 
 	return value, nil
 }
+
+// PushList prepends a value to a Redis list, keeping at most maxLen elements
+func (c *RedisClient) PushList(ctx context.Context, key string, value interface{}, maxLen int64, expiration time.Duration) error {
+	if c.client == nil {
+		if err := c.Connect(ctx); err != nil {
+			return fmt.Errorf("redis client not connected and failed to reconnect: %w", err)
+		}
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.LPush(ctx, key, value)
+	if maxLen > 0 {
+		pipe.LTrim(ctx, key, 0, maxLen-1)
+	}
+	if expiration > 0 {
+		pipe.Expire(ctx, key, expiration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to push list value to Redis: %w", err)
+	}
+
+	return nil
+}
+
+// GetListRange retrieves list elements between start and stop (inclusive)
+func (c *RedisClient) GetListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if c.client == nil {
+		if err := c.Connect(ctx); err != nil {
+			return nil, fmt.Errorf("redis client not connected and failed to reconnect: %w", err)
+		}
+	}
+
+	values, err := c.client.LRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list range from Redis: %w", err)
+	}
+
+	return values, nil
+}
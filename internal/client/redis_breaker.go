@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// ErrRedisUnavailable is returned without calling Redis while the circuit breaker is open.
+// Callers treat it as "feature degraded" rather than as an error worth logging.
+var ErrRedisUnavailable = errors.New("redis unavailable")
+
+// RedisHealthChecker reports whether Redis is currently considered available
+type RedisHealthChecker interface {
+	Available() bool
+}
+
+// RedisPinger is optionally implemented by Redis clients able to check the connection directly
+type RedisPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// BreakerRedisClient wraps a Redis client with a circuit breaker: after consecutive
+// failures calls fail fast with ErrRedisUnavailable until the cooldown allows a probe
+type BreakerRedisClient struct {
+	inner    RedisInterface
+	cfg      config.RedisBreakerConfig
+	now      func() time.Time
+	observer func(available bool)
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	open      bool
+	probing   bool
+}
+
+// NewBreakerRedisClient wraps inner with a circuit breaker
+func NewBreakerRedisClient(inner RedisInterface, cfg config.RedisBreakerConfig) *BreakerRedisClient {
+	return &BreakerRedisClient{inner: inner, cfg: cfg, now: time.Now}
+}
+
+// SetAvailabilityObserver registers a callback invoked with the initial state and on every state change
+func (b *BreakerRedisClient) SetAvailabilityObserver(observer func(available bool)) {
+	b.mu.Lock()
+	b.observer = observer
+	available := !b.open
+	b.mu.Unlock()
+
+	if observer != nil {
+		observer(available)
+	}
+}
+
+// Available reports whether the breaker is closed
+func (b *BreakerRedisClient) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.open
+}
+
+// allow reports whether a call may go to Redis. Once the cooldown is over a single
+// probe call is let through; its outcome closes or reopens the breaker.
+func (b *BreakerRedisClient) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of a Redis call
+func (b *BreakerRedisClient) record(err error) {
+	failed := err != nil && !errors.Is(err, ErrRedisNotFound) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+
+	b.mu.Lock()
+	b.probing = false
+	var changed bool
+	if !failed {
+		b.failures = 0
+		changed = b.open
+		b.open = false
+	} else {
+		b.failures++
+		if b.open || b.failures >= b.cfg.FailureThreshold {
+			changed = !b.open
+			b.open = true
+			b.openUntil = b.now().Add(time.Duration(b.cfg.CooldownMs) * time.Millisecond)
+		}
+	}
+	available := !b.open
+	observer := b.observer
+	b.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if available {
+		logger.Info("Redis available again, resuming Redis-backed features")
+	} else {
+		logger.Warn("Redis unavailable, degrading Redis-backed features",
+			zap.Int("cooldownMs", b.cfg.CooldownMs), zap.Error(err))
+	}
+	if observer != nil {
+		observer(available)
+	}
+}
+
+// Connect establishes a connection to Redis
+func (b *BreakerRedisClient) Connect(ctx context.Context) error {
+	err := b.inner.Connect(ctx)
+	b.record(err)
+	return err
+}
+
+// Ping checks Redis even while the breaker is open, a successful ping closes the breaker
+func (b *BreakerRedisClient) Ping(ctx context.Context) error {
+	pinger, ok := b.inner.(RedisPinger)
+	if !ok {
+		return errors.New("redis client does not support ping")
+	}
+	err := pinger.Ping(ctx)
+	b.record(err)
+	return err
+}
+
+// SetHashField sets a field-value pair in a Redis hash
+func (b *BreakerRedisClient) SetHashField(ctx context.Context, key string, field string, value interface{}, expiration time.Duration) error {
+	if !b.allow() {
+		return ErrRedisUnavailable
+	}
+	err := b.inner.SetHashField(ctx, key, field, value, expiration)
+	b.record(err)
+	return err
+}
+
+// SetHashFields sets several field-value pairs in a Redis hash in a single round trip
+func (b *BreakerRedisClient) SetHashFields(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error {
+	if !b.allow() {
+		return ErrRedisUnavailable
+	}
+	err := b.inner.SetHashFields(ctx, key, fields, expiration)
+	b.record(err)
+	return err
+}
+
+// DeleteHashFields removes fields from a Redis hash
+func (b *BreakerRedisClient) DeleteHashFields(ctx context.Context, key string, fields ...string) error {
+	if !b.allow() {
+		return ErrRedisUnavailable
+	}
+	err := b.inner.DeleteHashFields(ctx, key, fields...)
+	b.record(err)
+	return err
+}
+
+// RaiseHashField atomically raises a numeric hash field, see RedisInterface
+func (b *BreakerRedisClient) RaiseHashField(ctx context.Context, key string, field string, value int64,
+	extra map[string]interface{}, expiration time.Duration) (int64, error) {
+	if !b.allow() {
+		return 0, ErrRedisUnavailable
+	}
+	previous, err := b.inner.RaiseHashField(ctx, key, field, value, extra, expiration)
+	b.record(err)
+	return previous, err
+}
+
+// GetHashField retrieves a field value from a Redis hash
+func (b *BreakerRedisClient) GetHashField(ctx context.Context, key string, field string) (string, error) {
+	if !b.allow() {
+		return "", ErrRedisUnavailable
+	}
+	value, err := b.inner.GetHashField(ctx, key, field)
+	b.record(err)
+	return value, err
+}
+
+// GetHash retrieves all field-value pairs from a Redis hash
+func (b *BreakerRedisClient) GetHash(ctx context.Context, key string) (map[string]string, error) {
+	if !b.allow() {
+		return nil, ErrRedisUnavailable
+	}
+	values, err := b.inner.GetHash(ctx, key)
+	b.record(err)
+	return values, err
+}
+
+// HashLen returns the number of fields in a hash
+func (b *BreakerRedisClient) HashLen(ctx context.Context, key string) (int64, error) {
+	if !b.allow() {
+		return 0, ErrRedisUnavailable
+	}
+	length, err := b.inner.HashLen(ctx, key)
+	b.record(err)
+	return length, err
+}
+
+// GetString retrieves a string value by key
+func (b *BreakerRedisClient) GetString(ctx context.Context, key string) (string, error) {
+	if !b.allow() {
+		return "", ErrRedisUnavailable
+	}
+	value, err := b.inner.GetString(ctx, key)
+	b.record(err)
+	return value, err
+}
+
+// PushList prepends a value to a Redis list, keeping at most maxLen elements
+func (b *BreakerRedisClient) PushList(ctx context.Context, key string, value interface{}, maxLen int64, expiration time.Duration) error {
+	if !b.allow() {
+		return ErrRedisUnavailable
+	}
+	err := b.inner.PushList(ctx, key, value, maxLen, expiration)
+	b.record(err)
+	return err
+}
+
+// GetListRange retrieves list elements between start and stop (inclusive)
+func (b *BreakerRedisClient) GetListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if !b.allow() {
+		return nil, ErrRedisUnavailable
+	}
+	values, err := b.inner.GetListRange(ctx, key, start, stop)
+	b.record(err)
+	return values, err
+}
+
+// Close gracefully closes the Redis connection
+func (b *BreakerRedisClient) Close() error {
+	return b.inner.Close()
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// failingRedis counts calls and fails them with err
+type failingRedis struct {
+	RedisInterface
+	err   error
+	calls int
+}
+
+func (f *failingRedis) GetString(ctx context.Context, key string) (string, error) {
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+	return "value", nil
+}
+
+func (f *failingRedis) Ping(ctx context.Context) error {
+	f.calls++
+	return f.err
+}
+
+func TestBreakerRedisClient_OpensAndRecovers(t *testing.T) {
+	inner := &failingRedis{err: errors.New("connection refused")}
+	breaker := NewBreakerRedisClient(inner, config.RedisBreakerConfig{Enabled: true, FailureThreshold: 2, CooldownMs: 1000})
+	now := time.Unix(0, 0)
+	breaker.now = func() time.Time { return now }
+
+	var states []bool
+	breaker.SetAvailabilityObserver(func(available bool) { states = append(states, available) })
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := breaker.GetString(ctx, "k")
+		assert.EqualError(t, err, "connection refused")
+	}
+	assert.False(t, breaker.Available())
+
+	// Open breaker fails fast without calling Redis
+	_, err := breaker.GetString(ctx, "k")
+	assert.ErrorIs(t, err, ErrRedisUnavailable)
+	assert.Equal(t, 2, inner.calls)
+
+	// After the cooldown a successful probe closes the breaker
+	now = now.Add(time.Second)
+	inner.err = nil
+	value, err := breaker.GetString(ctx, "k")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.True(t, breaker.Available())
+	assert.Equal(t, []bool{true, false, true}, states)
+}
+
+func TestBreakerRedisClient_NotFoundIsNotAFailure(t *testing.T) {
+	inner := &failingRedis{err: &notFoundError{msg: "key does not exist: k"}}
+	breaker := NewBreakerRedisClient(inner, config.RedisBreakerConfig{Enabled: true, FailureThreshold: 1, CooldownMs: 1000})
+
+	_, err := breaker.GetString(context.Background(), "k")
+	assert.ErrorIs(t, err, ErrRedisNotFound)
+	assert.EqualError(t, err, "key does not exist: k")
+	assert.True(t, breaker.Available())
+}
+
+func TestBreakerRedisClient_PingWhileOpen(t *testing.T) {
+	inner := &failingRedis{err: errors.New("connection refused")}
+	breaker := NewBreakerRedisClient(inner, config.RedisBreakerConfig{Enabled: true, FailureThreshold: 1, CooldownMs: 60000})
+
+	ctx := context.Background()
+	assert.Error(t, breaker.Ping(ctx))
+	assert.False(t, breaker.Available())
+
+	// Pings reach Redis during the cooldown, a successful one closes the breaker
+	inner.err = nil
+	assert.NoError(t, breaker.Ping(ctx))
+	assert.Equal(t, 2, inner.calls)
+	assert.True(t, breaker.Available())
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxPendingSSEBytes bounds the incomplete JSON buffered across lines, matching the scanner limit
+const maxPendingSSEBytes = 1024 * 1024
+
+// sseLineSplitter normalizes upstream SSE lines into one "data: " line per JSON payload.
+// Some upstreams batch several payloads in one line ("data: {...}data: {...}" or "{...}{...}")
+// or break a payload across lines; the splitter emits each payload separately and buffers
+// incomplete JSON until the rest arrives. Only data lines and bare continuation lines are
+// joined to the buffered JSON.
+type sseLineSplitter struct {
+	pending string
+}
+
+// sseFieldPrefixes are the SSE fields other than data, they never continue a payload
+var sseFieldPrefixes = []string{"event:", "id:", "retry:"}
+
+// Split returns the lines to process for one scanned line, possibly none while JSON is incomplete
+func (s *sseLineSplitter) Split(line string) []string {
+	isData := strings.HasPrefix(line, "data:")
+	if s.pending != "" && !isData {
+		// Comments such as keepalives are passed on, the payload stays buffered for its rest
+		if strings.HasPrefix(line, ":") {
+			return []string{line}
+		}
+		// Another field ends the event, the incomplete payload is passed on as it is
+		for _, prefix := range sseFieldPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				return append(s.Flush(), line)
+			}
+		}
+	}
+	if s.pending == "" {
+		if !isData {
+			return []string{line}
+		}
+		if strings.TrimSpace(strings.TrimPrefix(line, "data:")) == "" {
+			return []string{line}
+		}
+	}
+
+	rest := line
+	if isData {
+		rest = trimSSEDataPrefix(rest)
+	}
+	rest = s.pending + rest
+	s.pending = ""
+
+	var lines []string
+	for {
+		rest = trimSSEDataPrefix(rest)
+		if rest == "" {
+			return lines
+		}
+
+		if strings.HasPrefix(rest, "[DONE]") {
+			lines = append(lines, "data: [DONE]")
+			rest = rest[len("[DONE]"):]
+			continue
+		}
+
+		// Only JSON objects and arrays are split, anything else is passed on as it is
+		if rest[0] != '{' && rest[0] != '[' {
+			return append(lines, "data: "+rest)
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(rest))
+		var payload json.RawMessage
+		if err := decoder.Decode(&payload); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) && len(rest) < maxPendingSSEBytes {
+				s.pending = rest
+				return lines
+			}
+			return append(lines, "data: "+rest)
+		}
+		lines = append(lines, "data: "+string(payload))
+		rest = rest[decoder.InputOffset():]
+	}
+}
+
+// Flush returns the buffered incomplete payload, if any, at the end of the stream
+func (s *sseLineSplitter) Flush() []string {
+	if s.pending == "" {
+		return nil
+	}
+	line := "data: " + s.pending
+	s.pending = ""
+	return []string{line}
+}
+
+// trimSSEDataPrefix strips surrounding whitespace and a leading "data:" field name
+func trimSSEDataPrefix(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "data:") {
+		s = strings.TrimSpace(strings.TrimPrefix(s, "data:"))
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestSSELineSplitter_Split(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{
+			name:     "single payload is unchanged",
+			lines:    []string{`data: {"id":"1"}`},
+			expected: []string{`data: {"id":"1"}`},
+		},
+		{
+			name:     "non-data lines pass through",
+			lines:    []string{": keep-alive", "event: message"},
+			expected: []string{": keep-alive", "event: message"},
+		},
+		{
+			name:     "empty data line passes through",
+			lines:    []string{"data: "},
+			expected: []string{"data: "},
+		},
+		{
+			name:     "multiple data events on one line",
+			lines:    []string{`data: {"id":"1"}data: {"id":"2"} data: [DONE]`},
+			expected: []string{`data: {"id":"1"}`, `data: {"id":"2"}`, "data: [DONE]"},
+		},
+		{
+			name:     "concatenated JSON without separators",
+			lines:    []string{`data: {"id":"1"}{"id":"2"}`},
+			expected: []string{`data: {"id":"1"}`, `data: {"id":"2"}`},
+		},
+		{
+			name:     "payload containing data: in a string",
+			lines:    []string{`data: {"content":"data: {x}"}`},
+			expected: []string{`data: {"content":"data: {x}"}`},
+		},
+		{
+			name:     "JSON split across lines",
+			lines:    []string{`data: {"id":"1","choices":[{"delta":`, `{"content":"hi"}}]}`, "data: [DONE]"},
+			expected: []string{`data: {"id":"1","choices":[{"delta":{"content":"hi"}}]}`, "data: [DONE]"},
+		},
+		{
+			name: "keepalive between the parts of a payload",
+			lines: []string{`data: {"id":"1","choices":[{"delta":`, ": keepalive",
+				`data: {"content":"hi"}}]}`},
+			expected: []string{": keepalive", `data: {"id":"1","choices":[{"delta":{"content":"hi"}}]}`},
+		},
+		{
+			name:     "event field ends an incomplete payload",
+			lines:    []string{`data: {"id":"1","choices":[`, "event: error", `data: {"error":"overloaded"}`},
+			expected: []string{`data: {"id":"1","choices":[`, "event: error", `data: {"error":"overloaded"}`},
+		},
+		{
+			name:     "missing space after data prefix",
+			lines:    []string{`data:{"id":"1"}`},
+			expected: []string{`data: {"id":"1"}`},
+		},
+		{
+			name:     "malformed payload is passed on",
+			lines:    []string{`data: {"id":}`},
+			expected: []string{`data: {"id":}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var splitter sseLineSplitter
+			var got []string
+			for _, line := range tt.lines {
+				got = append(got, splitter.Split(line)...)
+			}
+			got = append(got, splitter.Flush()...)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+// sseTransport returns a fixed SSE body for every request
+type sseTransport struct {
+	body string
+}
+
+func (m *sseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(m.body)),
+	}, nil
+}
+
+func TestLLMClient_ChatLLMWithMessagesStreamRaw_BatchedEvents(t *testing.T) {
+	body := `data: {"choices":[{"delta":{"content":"Hel"}}]}data: {"choices":[{"delta":{"content":"lo"}}]}` + "\n\n" +
+		`data: {"choices":[{"delta":` + "\n" + `{"content":"!"}}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+	headers := make(http.Header)
+	client := &LLMClient{
+		modelName:  "test-model",
+		endpoint:   "http://mock-endpoint/v1/chat/completions",
+		httpClient: &http.Client{Transport: &sseTransport{body: body}},
+		headers:    &headers,
+	}
+
+	var lines []string
+	err := client.ChatLLMWithMessagesStreamRaw(context.Background(), types.LLMRequestParams{}, nil,
+		func(resp LLMResponse) error {
+			lines = append(lines, resp.ResonseLine)
+			return nil
+		})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`data: {"choices":[{"delta":{"content":"Hel"}}]}`,
+		`data: {"choices":[{"delta":{"content":"lo"}}]}`,
+		`data: {"choices":[{"delta":{"content":"!"}}]}`,
+		"data: [DONE]",
+	}, lines)
+}
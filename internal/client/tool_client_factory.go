@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,6 +21,22 @@ type GenericClientInterface interface {
 	CheckReady(ctx context.Context, params map[string]interface{}) (bool, error)
 }
 
+// ProgressReporter is optionally implemented by clients whose backend reports execution progress
+type ProgressReporter interface {
+	// Progress returns the completion percentage (0-100) of the running request,
+	// ok is false when the backend does not report progress
+	Progress(ctx context.Context, params map[string]interface{}) (percent int, ok bool, err error)
+}
+
+// StreamingClient is optionally implemented by clients whose backend can stream results
+type StreamingClient interface {
+	// ExecuteStream executes the tool request, calling onItem with each result as it arrives, and
+	// returns all results in one {"data": [...]} document. ok is false when the backend has no
+	// stream endpoint, in which case nothing was sent and Execute should be used instead.
+	ExecuteStream(ctx context.Context, params map[string]interface{},
+		onItem func(item json.RawMessage)) (result string, ok bool, err error)
+}
+
 // CommonParameterNames Define common parameter name constants
 const (
 	CommonParamClientID      = "clientId"
@@ -42,6 +59,8 @@ type GenericToolClient struct {
 	toolConfig      config.GenericToolConfig
 	searchClient    *HTTPClient
 	readyClient     *HTTPClient
+	progressClient  *HTTPClient
+	streamClient    *HTTPClient
 	requestBuilder  *GenericRequestBuilder
 	responseHandler *GenericResponseHandler
 }
@@ -70,7 +89,13 @@ func (f *GenericClientFactory) CreateClient(toolConfig config.GenericToolConfig)
 	}
 
 	// Create new generic client
-	client, err := f.createGenericClient(toolConfig)
+	var client GenericClientInterface
+	var err error
+	if len(toolConfig.FailoverEndpoints) > 0 {
+		client, err = f.createFailoverClient(toolConfig)
+	} else {
+		client, err = f.createGenericClient(toolConfig)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create universal client for tool %s: %w", toolConfig.Name, err)
 	}
@@ -94,15 +119,46 @@ func (f *GenericClientFactory) createGenericClient(toolConfig config.GenericTool
 	searchClient := NewHTTPClient(toolConfig.Endpoints.Search, searchConfig)
 	readyClient := NewHTTPClient(toolConfig.Endpoints.Ready, readyConfig)
 
+	// Progress endpoint is optional
+	var progressClient *HTTPClient
+	if toolConfig.Endpoints.Progress != "" {
+		progressClient = NewHTTPClient(toolConfig.Endpoints.Progress, readyConfig)
+	}
+
+	// Stream endpoint is optional, it stays open until the last result is sent
+	var streamClient *HTTPClient
+	if toolConfig.Endpoints.Stream != "" {
+		streamClient = NewHTTPClient(toolConfig.Endpoints.Stream, HTTPClientConfig{Timeout: streamTimeout})
+	}
+
 	return &GenericToolClient{
 		toolConfig:      toolConfig,
 		searchClient:    searchClient,
 		readyClient:     readyClient,
+		progressClient:  progressClient,
+		streamClient:    streamClient,
 		requestBuilder:  &GenericRequestBuilder{toolConfig: toolConfig},
 		responseHandler: &GenericResponseHandler{},
 	}, nil
 }
 
+// createFailoverClient Create a client trying the primary endpoints, then each failover endpoint
+func (f *GenericClientFactory) createFailoverClient(toolConfig config.GenericToolConfig) (*FailoverToolClient, error) {
+	endpoints := append([]config.GenericToolEndpoints{toolConfig.Endpoints}, toolConfig.FailoverEndpoints...)
+	backends := make([]FailoverBackend, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		backendConfig := toolConfig
+		backendConfig.Endpoints = endpoint
+		backendConfig.FailoverEndpoints = nil
+		backend, err := f.createGenericClient(backendConfig)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, FailoverBackend{Endpoint: endpoint.Search, Client: backend})
+	}
+	return NewFailoverToolClient(toolConfig.Name, backends), nil
+}
+
 // ClearCache Clear client cache
 func (f *GenericClientFactory) ClearCache() {
 	f.mutex.Lock()
@@ -137,6 +193,24 @@ func (c *GenericToolClient) CheckReady(ctx context.Context, params map[string]in
 	return c.responseHandler.HandleReadyResponse(resp)
 }
 
+// Progress Poll the progress endpoint of the running request
+func (c *GenericToolClient) Progress(ctx context.Context, params map[string]interface{}) (int, bool, error) {
+	if c.progressClient == nil {
+		return 0, false, nil
+	}
+
+	// Progress is queried with the same identifying parameters as the readiness check
+	httpReq := c.requestBuilder.BuildReadyRequest(params)
+
+	resp, err := c.progressClient.DoRequest(ctx, httpReq)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get progress: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return c.responseHandler.HandleProgressResponse(resp)
+}
+
 // GenericRequestBuilder Generic request builder
 type GenericRequestBuilder struct {
 	toolConfig config.GenericToolConfig
@@ -277,6 +351,43 @@ func (h *GenericResponseHandler) HandleReadyResponse(resp *http.Response) (bool,
 	return false, fmt.Errorf("code: %d, body: %s", resp.StatusCode, body)
 }
 
+// HandleProgressResponse Parse a progress response such as {"progress": 40} or {"data": {"progress": 40}}
+func (h *GenericResponseHandler) HandleProgressResponse(resp *http.Response) (int, bool, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var progressResp struct {
+		Progress *float64 `json:"progress"`
+		Data     struct {
+			Progress *float64 `json:"progress"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &progressResp); err != nil {
+		return 0, false, fmt.Errorf("failed to parse progress response: %w", err)
+	}
+
+	progress := progressResp.Progress
+	if progress == nil {
+		progress = progressResp.Data.Progress
+	}
+	if progress == nil {
+		return 0, false, nil
+	}
+
+	percent := int(*progress)
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	return percent, true, nil
+}
+
 // getStringParam Get string parameter
 func getStringParam(params map[string]interface{}, key string) string {
 	if value, exists := params[key]; exists {
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// streamTimeout bounds a whole streamed search, which takes longer than the first result
+const streamTimeout = 30 * time.Second
+
+// maxStreamLineBytes bounds a single streamed result
+const maxStreamLineBytes = 1024 * 1024
+
+// ExecuteStream Execute tool request against the stream endpoint
+func (c *GenericToolClient) ExecuteStream(ctx context.Context, params map[string]interface{},
+	onItem func(item json.RawMessage)) (string, bool, error) {
+	if c.streamClient == nil {
+		return "", false, nil
+	}
+
+	httpReq := c.requestBuilder.BuildRequest(params)
+
+	resp, err := c.streamClient.DoRequest(ctx, httpReq)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to execute stream request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", true, fmt.Errorf(
+			"stream request failed! status: %d, response:%s, url: %s",
+			resp.StatusCode, string(body), resp.Request.URL.String(),
+		)
+	}
+
+	items, err := readStreamItems(resp.Body, onItem)
+	if err != nil {
+		return "", true, err
+	}
+
+	result, err := json.Marshal(map[string]interface{}{"data": items})
+	if err != nil {
+		return "", true, fmt.Errorf("failed to assemble stream results: %w", err)
+	}
+	return string(result), true, nil
+}
+
+// readStreamItems reads one JSON result per line, accepting plain NDJSON as well as SSE
+// "data: " lines, and stops at the end of the body or a "[DONE]" marker
+func readStreamItems(body io.Reader, onItem func(item json.RawMessage)) ([]json.RawMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineBytes)
+
+	items := make([]json.RawMessage, 0)
+	for scanner.Scan() {
+		line := trimSSEDataPrefix(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "[DONE]" {
+			break
+		}
+		if !json.Valid([]byte(line)) {
+			return nil, fmt.Errorf("invalid stream item after %d results: %.100s", len(items), line)
+		}
+
+		item := json.RawMessage(line)
+		items = append(items, item)
+		if onItem != nil {
+			onItem(item)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream after %d results: %w", len(items), err)
+	}
+	return items, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestGenericToolClient_ExecuteStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"filePath": "a.go"}`)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, `data: {"filePath": "b.go"}`)
+		fmt.Fprintln(w, `data: [DONE]`)
+		fmt.Fprintln(w, `{"filePath": "ignored.go"}`)
+	}))
+	defer server.Close()
+
+	toolClient, err := NewGenericClientFactory().createGenericClient(config.GenericToolConfig{
+		Name:      "codebase_search",
+		Method:    http.MethodPost,
+		Endpoints: config.GenericToolEndpoints{Search: server.URL, Ready: server.URL, Stream: server.URL},
+	})
+	assert.NoError(t, err)
+
+	var items []string
+	result, ok, err := toolClient.ExecuteStream(context.Background(), map[string]interface{}{},
+		func(item json.RawMessage) { items = append(items, string(item)) })
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{`{"filePath": "a.go"}`, `{"filePath": "b.go"}`}, items)
+	assert.JSONEq(t, `{"data": [{"filePath": "a.go"}, {"filePath": "b.go"}]}`, result)
+}
+
+func TestGenericToolClient_ExecuteStream_Unsupported(t *testing.T) {
+	toolClient, err := NewGenericClientFactory().createGenericClient(config.GenericToolConfig{
+		Name:      "codebase_search",
+		Endpoints: config.GenericToolEndpoints{Search: "http://localhost", Ready: "http://localhost"},
+	})
+	assert.NoError(t, err)
+
+	_, ok, err := toolClient.ExecuteStream(context.Background(), map[string]interface{}{}, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestReadStreamItems_InvalidItem(t *testing.T) {
+	body := `{"filePath": "a.go"}` + "\n" + `<html>502 Bad Gateway</html>` + "\n"
+
+	_, err := readStreamItems(bytes.NewReader([]byte(body)), nil)
+	assert.ErrorContains(t, err, "invalid stream item after 1 results")
+}
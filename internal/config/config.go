@@ -38,6 +38,10 @@ type LLMTimeoutConfig struct {
 	// Retry configuration for regular mode
 	MaxRetryCount   int `mapstructure:"maxRetryCount" yaml:"maxRetryCount"`
 	RetryIntervalMs int `mapstructure:"retryIntervalMs" yaml:"retryIntervalMs"`
+
+	// Immediate whole-request retries per request when the upstream drops before the first token,
+	// on top of MaxRetryCount. 0 disables
+	StreamDropRetryCount int `mapstructure:"streamDropRetryCount" yaml:"streamDropRetryCount"`
 }
 
 // RedisConfig holds Redis configuration
@@ -45,6 +49,19 @@ type RedisConfig struct {
 	Addr     string
 	Password string
 	DB       int
+
+	// Circuit breaker turning Redis into an optional dependency
+	Breaker RedisBreakerConfig
+}
+
+// RedisBreakerConfig stops calling Redis after consecutive failures, so that
+// Redis-backed features degrade quietly while Redis is down
+type RedisBreakerConfig struct {
+	Enabled bool
+	// Consecutive connection failures opening the breaker, default 3
+	FailureThreshold int
+	// Time before a single probe call is let through an open breaker, default 10000
+	CooldownMs int
 }
 
 type ToolConfig struct {
@@ -52,9 +69,111 @@ type ToolConfig struct {
 	DisableTools bool
 	// Control which agents in which modes cannot use tools
 	DisabledAgents map[string][]string
+	// Control which tools each agent can use, agents not listed can use all tools
+	EnabledAgentTools map[string][]string
 
 	// Generic tool configuration
 	GenericTools []GenericToolConfig
+
+	// Remove repeated rule/guideline blocks from the system prompt
+	DedupeRules DedupeRulesConfig
+
+	// Strip tool sections from the system prompt when no tools are available
+	NoToolsPrompt NoToolsPromptConfig
+
+	// Cache tool readiness results for a short time
+	ReadyCache ReadyCacheConfig
+
+	// Maximum number of different tools one request can call, 0 means unlimited.
+	// Once reached, tags of other tools are passed through as plain text
+	MaxDistinctTools int
+
+	// Replace a tool result identical to the previously injected one with a short reference
+	DedupeResults bool
+
+	// Variants of the instruction appended after each tool result, the first variant matching
+	// the model or agent wins; without a match the built-in instruction is used
+	FollowUpInstructions []ToolFollowUpInstruction
+
+	// Escape tool tags found inside tool results before injecting them
+	SanitizeResults ToolResultSanitizeConfig
+
+	// Semantic search tools left out for requests sending extra_body.disable_semantic,
+	// default is ["codebase_search"]
+	SemanticTools []string
+
+	// Scale the timeout of each tool call with its depth in the tool call chain
+	DepthTimeout ToolDepthTimeoutConfig
+
+	// Maximum tool call depth per prompt mode, modes not listed use the built-in depth of 6
+	MaxToolCallDepthByMode map[string]int
+}
+
+// Tool timeout scaling modes
+const (
+	// ToolTimeoutConstant uses the base timeout at every depth
+	ToolTimeoutConstant = "constant"
+	// ToolTimeoutIncreasing adds the step for each level, allowing more time for later calls
+	ToolTimeoutIncreasing = "increasing"
+	// ToolTimeoutDecreasing subtracts the step for each level, bounding the latency of deep chains
+	ToolTimeoutDecreasing = "decreasing"
+)
+
+// ToolDepthTimeoutConfig Timeout of a tool call derived from its depth, the first call having depth 0.
+// The timeout of the tool's HTTP client still applies on top of it.
+type ToolDepthTimeoutConfig struct {
+	Mode   string `yaml:"mode"`   // "constant" (default), "increasing" or "decreasing"
+	BaseMs int    `yaml:"baseMs"` // Timeout of the first call, 0 applies no timeout
+	StepMs int    `yaml:"stepMs"` // Change of the timeout per level of depth
+	MinMs  int    `yaml:"minMs"`  // Lower bound of the scaled timeout, default is 1000
+	MaxMs  int    `yaml:"maxMs"`  // Upper bound of the scaled timeout, 0 means unbounded
+}
+
+// ToolResultSanitizeConfig Configuration for escaping tool tags in tool results, so that
+// tag-like text returned by a tool is never detected as a tool call in the next turn
+type ToolResultSanitizeConfig struct {
+	Enabled bool `yaml:"enabled"` // Enable escaping, default is false
+	// Tags escaped in addition to the configured tools, e.g. attempt_completion
+	Tags []string `yaml:"tags"`
+}
+
+// ToolFollowUpInstruction A variant of the instruction appended after tool results
+type ToolFollowUpInstruction struct {
+	Name   string   `yaml:"name"`   // Variant name recorded in the tool call log
+	Models []string `yaml:"models"` // Models using this variant, matched case-insensitively
+	Agents []string `yaml:"agents"` // Agents using this variant, matched case-insensitively
+	// Go template of the instruction with {{.Tool}} and {{.Tools}}; empty appends no instruction
+	Template string `yaml:"template"`
+	// Where the instruction goes relative to the tool result: "append" (default) places it
+	// after the result, "prepend" before it so the retrieved context comes last
+	Placement string `yaml:"placement"`
+}
+
+// Placements of the tool follow-up instruction
+const (
+	FollowUpAppend  = "append"
+	FollowUpPrepend = "prepend"
+)
+
+// NoToolsPromptConfig Configuration for the system prompt variant used when no tools are available,
+// because tools are disabled, the request set tool_choice to "none" or the agent has no tools
+type NoToolsPromptConfig struct {
+	Enabled bool `yaml:"enabled"` // Leave the server tool descriptions and rules out, default is false
+}
+
+// ReadyCacheConfig Configuration for caching tool readiness check results
+type ReadyCacheConfig struct {
+	Enabled bool `yaml:"enabled"` // Enable caching, default is false
+	// How long a readiness result is reused for the same client, project and tool, default is 5
+	TTLSec int `yaml:"ttlSec"`
+}
+
+// DedupeRulesConfig Configuration for removing duplicated blocks from the system prompt
+type DedupeRulesConfig struct {
+	Enabled bool `yaml:"enabled"` // Enable de-duplication, default is false
+	// Regex patterns matching the first line of a block, e.g. "^#+ Tool Use Guidelines";
+	// defaults to markdown headings about tool use, rules and guidelines
+	Markers []string `yaml:"markers"`
 }
 
 // GenericToolConfig Generic tool configuration structure
@@ -66,12 +185,275 @@ type GenericToolConfig struct {
 	Method      string                 `yaml:"method"`      // HTTP request method
 	Parameters  []GenericToolParameter `yaml:"parameters"`  // Parameter definitions
 	Rule        string                 `yaml:"rule"`        // Tool usage rules
+	// Backends tried in order after endpoints when a call fails or returns no results.
+	// Progress and streaming use the primary endpoints only; failover calls wait for full results
+	FailoverEndpoints []GenericToolEndpoints `yaml:"failoverEndpoints"`
+	// Longest time one call may take, a call running out of time returns no results;
+	// 0 leaves the call bound by the request context only
+	TimeoutMs int `yaml:"timeoutMs"`
+	// Broadened retry when the tool returns no results
+	Broaden GenericToolBroadenConfig `yaml:"broaden"`
+	// Record result counts of each call
+	ResultStats GenericToolResultStatsConfig `yaml:"resultStats"`
+	// Blend the model's latest thinking summary into the query
+	SummaryAugment GenericToolSummaryAugmentConfig `yaml:"summaryAugment"`
+	// Search several paths concurrently when the model passes repeated path tags
+	MultiPath GenericToolMultiPathConfig `yaml:"multiPath"`
+	// Warn the user when the backend reports a stale index
+	IndexAge GenericToolIndexAgeConfig `yaml:"indexAge"`
+	// Prune whole nodes of oversized JSON results instead of cutting them mid-structure
+	TreeLimit GenericToolTreeLimitConfig `yaml:"treeLimit"`
+	// Let the model ask for declarations only instead of full definitions
+	SignatureMode GenericToolSignatureModeConfig `yaml:"signatureMode"`
+	// Limit concurrent calls so one expensive backend cannot be flooded
+	Concurrency GenericToolConcurrencyConfig `yaml:"concurrency"`
+	// Let callers ask for more or fewer results per request via extra_body
+	TopKOverride GenericToolTopKOverrideConfig `yaml:"topKOverride"`
+	// Summarize oversized results with the summary model instead of truncating them
+	ResultSummary GenericToolResultSummaryConfig `yaml:"resultSummary"`
+	// List the files of the results before the results themselves
+	FileIndex GenericToolFileIndexConfig `yaml:"fileIndex"`
+	// Cap the share of the context window taken by the results of this tool
+	ContextShare GenericToolContextShareConfig `yaml:"contextShare"`
+	// Reduce qualified symbol names to their plain form before searching
+	SymbolNormalize GenericToolSymbolNormalizeConfig `yaml:"symbolNormalize"`
+	// Leave the tool out of the prompt while its backend keeps failing
+	HealthGate GenericToolHealthGateConfig `yaml:"healthGate"`
+
+	// Replace malformed results with a neutral no-results message before injecting them
+	ResultValidate GenericToolResultValidateConfig `yaml:"resultValidate"`
+	// Read results from the stream endpoint as they arrive and show early findings to the user
+	Streaming GenericToolStreamingConfig `yaml:"streaming"`
+	// Drop overlapping chunks of the same file and cap the characters of the returned chunks
+	ChunkDedupe GenericToolChunkDedupeConfig `yaml:"chunkDedupe"`
+	// Check the regex of literal search tools such as search_files and list matches line by line
+	LineMatches GenericToolLineMatchesConfig `yaml:"lineMatches"`
+	// Result format: ToolOutputText (default) returns the backend result as is, ToolOutputJSON
+	// returns a JSON array of {filePath, score, content, startLine, endLine} for custom rendering
+	OutputFormat string `yaml:"outputFormat"`
+	// Result item fields read when OutputFormat is ToolOutputJSON
+	JSONOutput GenericToolJSONOutputConfig `yaml:"jsonOutput"`
+	// Drop results from generated, vendored or fixture files before they reach the model
+	PathDenylist GenericToolPathDenylistConfig `yaml:"pathDenylist"`
+	// Part of an oversized result kept when it is truncated: ToolTruncateHead (default),
+	// ToolTruncateTail or ToolTruncateHeadTail, which drops the middle
+	Truncation string `yaml:"truncation"`
+	// Share results of identical calls for the same client and project across requests
+	ResultCache GenericToolResultCacheConfig `yaml:"resultCache"`
+}
+
+// GenericToolResultCacheConfig Cache results in Redis keyed by client, project and normalized
+// parameters. The index time reported in results versions the entries, so results of a newer
+// index make older entries unreachable
+type GenericToolResultCacheConfig struct {
+	Enabled bool `yaml:"enabled"` // Enable the cache for this tool, default is false
+	TTLSec  int  `yaml:"ttlSec"`  // How long a result is reused, default is 600
+	// Result field holding the index time, defaults to indexAge.timestampField or "indexedAt"
+	IndexVersionField string `yaml:"indexVersionField"`
+	// How long the index version learned from a backend result is trusted. Once it is older,
+	// the next call goes to the backend, so a reindex is noticed within this time; default is 60
+	VersionCheckSec int `yaml:"versionCheckSec"`
+}
+
+// Tool result truncation strategies
+const (
+	ToolTruncateHead     = "head"
+	ToolTruncateTail     = "tail"
+	ToolTruncateHeadTail = "head_tail"
+)
+
+// GenericToolPathDenylistConfig Glob patterns of result files left out of the result, e.g. "vendor/**" or
+// "**/*_test.go". "**" spans directories, "*" and "?" stay within one; a pattern may match from any
+// directory of the path. Matching ignores case and treats backslashes as "/" so Windows paths match as well
+type GenericToolPathDenylistConfig struct {
+	Patterns  []string `yaml:"patterns"`  // Patterns of denied paths, empty disables filtering
+	PathField string   `yaml:"pathField"` // Result item field holding the file path, default is "filePath"
+}
+
+// Tool result output formats
+const (
+	ToolOutputText = "text"
+	ToolOutputJSON = "json"
+)
+
+// GenericToolJSONOutputConfig Result item fields mapped to the JSON output, empty fields use the defaults
+type GenericToolJSONOutputConfig struct {
+	PathField      string `yaml:"pathField"`      // Default is "filePath"
+	ScoreField     string `yaml:"scoreField"`     // Default is "score"
+	ContentField   string `yaml:"contentField"`   // Default is "content"
+	StartLineField string `yaml:"startLineField"` // Default is "startLine"
+	EndLineField   string `yaml:"endLineField"`   // Default is "endLine"
+}
+
+// GenericToolLineMatchesConfig Pattern search over files: the regex parameter is compiled before the
+// backend is called, and the match list is rendered as "path:line: text" lines up to MaxMatches
+type GenericToolLineMatchesConfig struct {
+	Enabled      bool   `yaml:"enabled"`      // Enable line match handling, default is false
+	PatternParam string `yaml:"patternParam"` // Parameter holding the regex, default is "regex"
+	// Parameter receiving MaxMatches so the backend can stop early, empty sends nothing
+	MaxMatchesParam string `yaml:"maxMatchesParam"`
+	MaxMatches      int    `yaml:"maxMatches"` // Matches listed in the result, default is 50
+	PathField       string `yaml:"pathField"`  // Match field holding the file path, default is "filePath"
+	LineField       string `yaml:"lineField"`  // Match field holding the line number, default is "lineNumber"
+	TextField       string `yaml:"textField"`  // Match field holding the matched line, default is "content"
+}
+
+// GenericToolChunkDedupeConfig Of result chunks from the same file with overlapping line ranges only the
+// highest scored is kept; the remaining chunks are then cut at a chunk boundary once MaxChars is reached
+type GenericToolChunkDedupeConfig struct {
+	Enabled        bool   `yaml:"enabled"`        // Enable deduplication, default is false
+	PathField      string `yaml:"pathField"`      // Result item field holding the file path, default is "filePath"
+	StartLineField string `yaml:"startLineField"` // Result item field holding the first line, default is "startLine"
+	EndLineField   string `yaml:"endLineField"`   // Result item field holding the last line, default is "endLine"
+	ScoreField     string `yaml:"scoreField"`     // Score field of each result item, default is "score"
+	MaxChars       int    `yaml:"maxChars"`       // Character budget of the kept chunks, 0 disables the cap
+}
+
+// GenericToolStreamingConfig Use the stream endpoint instead of the search endpoint; each streamed item is
+// previewed in the response while the search runs, the assembled list is injected once it completes
+type GenericToolStreamingConfig struct {
+	Enabled      bool   `yaml:"enabled"`      // Enable streaming, default is false
+	PreviewField string `yaml:"previewField"` // Item field shown as an early finding, default is "filePath"
+	MaxPreviews  int    `yaml:"maxPreviews"`  // Maximum distinct findings shown per call, default is 5
+}
+
+// GenericToolResultValidateConfig Check that results have the shape expected from the tool, so a truncated
+// body or a backend error page is never handed to the model
+type GenericToolResultValidateConfig struct {
+	Enabled bool   `yaml:"enabled"` // Enable validation, default is false
+	Format  string `yaml:"format"`  // Validator of the result: "json" (default) or "text"
+	// Top-level fields a JSON result must contain, e.g. ["data"]
+	RequiredFields []string `yaml:"requiredFields"`
+}
+
+// GenericToolHealthGateConfig Mark a tool unhealthy after consecutive backend failures; unhealthy tools
+// are not injected into prompts until the cooldown passes and a call succeeds again
+type GenericToolHealthGateConfig struct {
+	Enabled          bool `yaml:"enabled"`          // Enable the health gate, default is false
+	FailureThreshold int  `yaml:"failureThreshold"` // Consecutive failures marking the tool unhealthy, default is 3
+	CooldownSec      int  `yaml:"cooldownSec"`      // Time before the tool is offered again to probe the backend, default is 30
+}
+
+// GenericToolSymbolNormalizeConfig Strip package/namespace prefixes (everything before the last "." or "::")
+// from symbol parameters, e.g. "types.QueryCallGraphOptions" becomes "QueryCallGraphOptions"
+type GenericToolSymbolNormalizeConfig struct {
+	Enabled   bool     `yaml:"enabled"`   // Enable normalization, default is false
+	Params    []string `yaml:"params"`    // Symbol parameters, comma separated values are normalized one by one, default is ["symbolName"]
+	Lowercase bool     `yaml:"lowercase"` // Also lowercase the names, for backends matching case-insensitively
+}
+
+// GenericToolContextShareConfig Keep injected results within a fraction of the context window
+// (contextCompress tokenThreshold), shared by all calls of a request, by dropping the lowest scored results
+type GenericToolContextShareConfig struct {
+	Enabled    bool    `yaml:"enabled"`    // Enable the cap, default is false
+	MaxShare   float64 `yaml:"maxShare"`   // Fraction of the context window the results may take, between 0 and 1
+	ScoreField string  `yaml:"scoreField"` // Score field of each result item, default is "score"
+}
+
+// GenericToolFileIndexConfig Compact index of the files in a result list, injected above the
+// unchanged result so the model can see which files are involved before reading the chunks
+type GenericToolFileIndexConfig struct {
+	Enabled   bool   `yaml:"enabled"`   // Enable the index, default is false
+	PathField string `yaml:"pathField"` // Result item field holding the file path, default is "filePath"
+	MaxFiles  int    `yaml:"maxFiles"`  // Files listed before the rest is summarized as a count, default is 50
+	MinFiles  int    `yaml:"minFiles"`  // Results spanning fewer files get no index, default is 2
+}
+
+// GenericToolResultSummaryConfig Condense results above a token budget with the summary model.
+// It adds a model call to the tool round trip; on failure or timeout the result is truncated
+type GenericToolResultSummaryConfig struct {
+	Enabled   bool `yaml:"enabled"`   // Enable summarizing, default is false
+	MaxTokens int  `yaml:"maxTokens"` // Token budget of the injected result, default is 8000
+	TimeoutMs int  `yaml:"timeoutMs"` // Longest wait for the summary, default is 20000
+	// Model writing the summary, defaults to the context compression summary model
+	Model string `yaml:"model"`
+}
+
+// GenericToolTopKOverrideConfig Per-request result count taken from extra_body.semantic_top_k
+type GenericToolTopKOverrideConfig struct {
+	Enabled bool   `yaml:"enabled"` // Accept the override for this tool, default is false
+	Param   string `yaml:"param"`   // Request parameter receiving the result count, default is "topK"
+	Max     int    `yaml:"max"`     // Largest accepted result count, larger values are capped, default is 50
+}
+
+// GenericToolConcurrencyConfig Per-tool limit of concurrent calls across all requests
+type GenericToolConcurrencyConfig struct {
+	MaxConcurrent int `yaml:"maxConcurrent"` // Maximum concurrent calls, 0 means unlimited
+	// Longest time an over-limit call waits for a slot before the tool reports it is busy,
+	// 0 rejects over-limit calls immediately
+	MaxWaitMs int `yaml:"maxWaitMs"`
+}
+
+// GenericToolSignatureModeConfig Optional compact mode returning only the signatures of definitions
+type GenericToolSignatureModeConfig struct {
+	Enabled   bool   `yaml:"enabled"`   // Enable the mode parameter and document it in the tool description, default is false
+	ModeParam string `yaml:"modeParam"` // Mode parameter name, default is "mode"
+	// The backend understands the mode parameter itself; otherwise it is not sent and
+	// the full definitions are reduced to their signatures after the call
+	BackendSupported bool   `yaml:"backendSupported"`
+	ContentField     string `yaml:"contentField"` // Result item field holding the code, default is "content"
+}
+
+// GenericToolTreeLimitConfig Fit tree-shaped JSON results (e.g. reference relations) into a size budget
+// by removing whole nodes, deepest and least relevant first, so the result stays valid JSON
+type GenericToolTreeLimitConfig struct {
+	Enabled    bool   `yaml:"enabled"`    // Enable tree pruning, default is false
+	MaxBytes   int    `yaml:"maxBytes"`   // Size budget of the result, default is 80000
+	ScoreField string `yaml:"scoreField"` // Relevance field of nodes, lower scores are pruned first; empty prunes by position
+}
+
+// GenericToolIndexAgeConfig Compare the index timestamp reported in tool results against the request time
+type GenericToolIndexAgeConfig struct {
+	Enabled bool `yaml:"enabled"` // Enable the stale index advisory, default is false
+	// Result field holding the index time (RFC3339 or unix seconds/milliseconds), looked up
+	// at the top level and under "data", default is "indexedAt"
+	TimestampField string `yaml:"timestampField"`
+	MaxAgeSec      int    `yaml:"maxAgeSec"` // Index age above which the advisory is shown, default is 86400
+}
+
+// GenericToolMultiPathConfig Fan out one request per path when the path parameter is repeated,
+// merging the result lists by score
+type GenericToolMultiPathConfig struct {
+	Enabled    bool   `yaml:"enabled"`    // Enable multi-path search, default is false
+	PathParam  string `yaml:"pathParam"`  // Path parameter name, default is "path"
+	ScoreField string `yaml:"scoreField"` // Score field used to rank merged results, default is "score"
+	MaxPaths   int    `yaml:"maxPaths"`   // Maximum number of paths searched, extra paths are ignored, default is 5
+	MaxResults int    `yaml:"maxResults"` // Maximum number of merged results, 0 keeps all
+}
+
+// GenericToolSummaryAugmentConfig Add keywords from the model's latest <thinking> summary to the query,
+// biasing follow-up searches toward areas not covered by previous results
+type GenericToolSummaryAugmentConfig struct {
+	Enabled    bool   `yaml:"enabled"`    // Enable query augmentation, default is false
+	QueryParam string `yaml:"queryParam"` // Query parameter name to augment
+	// Summary keywords added relative to the query's keyword count, default is 0.5
+	Weight float64 `yaml:"weight"`
+}
+
+// GenericToolResultStatsConfig Count returned results and results above the score threshold
+type GenericToolResultStatsConfig struct {
+	Enabled        bool    `yaml:"enabled"`        // Enable result counting, default is false
+	ScoreField     string  `yaml:"scoreField"`     // Score field of each result item, default is "score"
+	ScoreThreshold float64 `yaml:"scoreThreshold"` // Minimum score counted as above threshold, 0 counts all results
+}
+
+// GenericToolBroadenConfig Retry once with a broadened query on empty results
+type GenericToolBroadenConfig struct {
+	Enabled    bool    `yaml:"enabled"`    // Enable broadened retry, default is false
+	ScoreParam string  `yaml:"scoreParam"` // Score threshold parameter name to lower
+	ScoreDelta float64 `yaml:"scoreDelta"` // Amount subtracted from the score threshold
+	QueryParam string  `yaml:"queryParam"` // Query parameter name to simplify (strip code/paths, keep keywords)
+	// Path filter parameter removed by the retry, so a search scoped to the wrong directory
+	// is repeated across the whole codebase; empty keeps the path
+	DropPathParam string `yaml:"dropPathParam"`
 }
 
 // GenericToolEndpoints Tool endpoint configuration
 type GenericToolEndpoints struct {
-	Search string `yaml:"search"` // Search endpoint
-	Ready  string `yaml:"ready"`  // Readiness check endpoint
+	Search   string `yaml:"search"`   // Search endpoint
+	Ready    string `yaml:"ready"`    // Readiness check endpoint
+	Progress string `yaml:"progress"` // Optional progress polling endpoint
+	Stream   string `yaml:"stream"`   // Optional endpoint returning results as newline-delimited JSON
 }
 
 // GenericToolParameter Tool parameter definition
@@ -83,6 +465,10 @@ type GenericToolParameter struct {
 	Default     interface{} `yaml:"default,omitempty"` // Default value (optional)
 	// Parameter source
 	Source ParameterSource `yaml:"source"`
+	// Bounds of an integer or float parameter from the model, values outside are clamped.
+	// With bounds set, an optional value that is not a number falls back to the default
+	Min *float64 `yaml:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty"`
 }
 
 // LogS3Config holds S3/MinIO storage configuration for log archival
@@ -102,15 +488,38 @@ type LogConfig struct {
 	// StorageType controls where logs are persisted: "disk" (default) or "s3"
 	StorageType string     `mapstructure:"storageType" yaml:"storageType"`
 	S3          LogS3Config `mapstructure:"s3" yaml:"s3"`
+	// Maximum bytes of response content kept in chat logs, 0 keeps it all
+	MaxContentBytes int `mapstructure:"maxContentBytes" yaml:"maxContentBytes"`
+	// How prompts are stored: "full" (default) keeps the processed prompt, "diff" adds the
+	// original prompt and stores the processed prompt as a diff against it
+	PromptStorage string `mapstructure:"promptStorage" yaml:"promptStorage"`
+	// Logs enriched ahead of storage: the department lookup of the next logs overlaps
+	// the write of the current one, 0 runs both steps in turn for each log
+	PipelineDepth int `mapstructure:"pipelineDepth" yaml:"pipelineDepth"`
+	// Goroutines draining the log queue, each looking up the department of a log and then
+	// storing it. Above 1 logs are no longer stored in arrival order; default is 1
+	LogProcessorConcurrency int `mapstructure:"logProcessorConcurrency" yaml:"logProcessorConcurrency"`
+	// Write a one-line JSON summary of each completed request to stdout for container log
+	// collectors, independent of the log storage
+	StdoutSummary bool `mapstructure:"stdoutSummary" yaml:"stdoutSummary"`
 	// LogScanIntervalSec   int
 	// ClassifyModel        string
 	// EnableClassification bool
 }
 
+// Prompt storage modes of chat logs
+const (
+	PromptStorageFull = "full"
+	PromptStorageDiff = "diff"
+)
+
 // Deprecated
 type ContextCompressConfig struct {
 	// Context compression enable flag
 	EnableCompress bool
+	// Run the user message summary compressor on the prompt chain, default false. It stays
+	// out of the chain unless both this and EnableCompress are set
+	EnableUserCompressor bool
 	// Context compression token threshold
 	TokenThreshold int
 	// Summary Model configuration
@@ -118,6 +527,58 @@ type ContextCompressConfig struct {
 	SummaryModelTokenThreshold int
 	// used recent user prompt messages nums
 	RecentUserMsgUsedNums int
+	// Minimum number of user-side messages before compression can trigger,
+	// 0 allows compression at any conversation length
+	MinMessagesForCompression int
+	// Compression used when the summary model fails: "none" (default) keeps the
+	// uncompressed messages, "head_tail" keeps the first and most recent messages
+	// within TokenThreshold
+	SummaryFallback string
+	// Cheaper summary models for expensive main models, the first tier listing
+	// the request's main model wins and SummaryModel is used when none matches
+	SummaryModelTiers []SummaryModelTier
+	// Restate the latest user question at the end of the prompt after summary compression,
+	// truncated to ReinjectQuestionMaxBytes (default 2000)
+	ReinjectQuestion         bool
+	ReinjectQuestionMaxBytes int
+	// Split a user message larger than this many tokens into chunks at file boundaries, code
+	// fences or the size itself before it is summarized, 0 disables splitting. Only the copy
+	// sent to the summary model is split, the prompt sent to the main model is not changed
+	SplitUserMessageTokens int
+	// Compression strategy per size bucket, picked by how far the conversation exceeds
+	// TokenThreshold; without buckets every conversation gets the full summary. E.g.
+	// [{1.3, trim_only}, {2, summarize_middle}, {0, full_summary}] only trims conversations
+	// up to 30% over the threshold
+	StrategyBuckets []CompressStrategyBucket
+	// Summary model calls of user prompt compression running at once, further compressions
+	// wait in a queue of CompressQueueSize and proceed uncompressed when it is full, without
+	// a queue any compression arriving while no worker is free. 0 workers does not limit them
+	CompressWorkers   int
+	CompressQueueSize int
+}
+
+// Compression strategies selectable per size bucket
+const (
+	// CompressStrategyTrimOnly keeps the first and most recent messages, no summary is made
+	CompressStrategyTrimOnly = "trim_only"
+	// CompressStrategySummarizeMiddle keeps the first message and summarizes up to the recent ones
+	CompressStrategySummarizeMiddle = "summarize_middle"
+	// CompressStrategyFullSummary summarizes every message before the recent ones
+	CompressStrategyFullSummary = "full_summary"
+)
+
+// CompressStrategyBucket applies Strategy to conversations of at most MaxOverRatio times
+// TokenThreshold; buckets are matched in order, a MaxOverRatio of 0 matches any size
+type CompressStrategyBucket struct {
+	MaxOverRatio float64
+	Strategy     string
+}
+
+// SummaryModelTier maps a group of main models to the model summarizing their context
+type SummaryModelTier struct {
+	Name         string
+	MainModels   []string
+	SummaryModel string
 }
 
 type PreciseContextConfig struct {
@@ -153,6 +614,7 @@ type FromNacos struct {
 	Router                *RouterConfig
 	PreciseContextConfig  *PreciseContextConfig
 	VoucherActivityConfig *VoucherActivityConfig
+	ResponseFilters       *ResponseFiltersConfig
 }
 
 // Config holds all service configuration
@@ -192,6 +654,293 @@ type Config struct {
 
 	// Request verification configuration
 	RequestVerify RequestVerifyConfig `mapstructure:"requestVerify" yaml:"requestVerify"`
+
+	// Debug output configuration, disabled by default
+	Debug DebugConfig `mapstructure:"debug" yaml:"debug"`
+
+	// Response header handling configuration
+	ResponseHeaders ResponseHeadersConfig `mapstructure:"responseHeaders" yaml:"responseHeaders"`
+
+	// Output token limits applied to max_tokens/max_completion_tokens
+	OutputTokens OutputTokensConfig `mapstructure:"outputTokens" yaml:"outputTokens"`
+
+	// Response language configuration
+	Language LanguageConfig `mapstructure:"language" yaml:"language"`
+
+	// Current date and time injected into the system prompt, disabled by default
+	CurrentTime CurrentTimeConfig `mapstructure:"currentTime" yaml:"currentTime"`
+
+	// Per-model sampling temperature defaults and allowed ranges
+	Temperature TemperatureConfig `mapstructure:"temperature" yaml:"temperature"`
+
+	// Requests whose original prompt exceeds this many tokens are rejected, 0 disables the limit
+	MaxPromptTokens int `mapstructure:"maxPromptTokens" yaml:"maxPromptTokens"`
+
+	// Tool-call history persisted per session, disabled by default
+	ToolHistory ToolHistoryConfig `mapstructure:"toolHistory" yaml:"toolHistory"`
+
+	// Structured summary event sent at the end of streamed responses, disabled by default
+	StreamSummary StreamSummaryConfig `mapstructure:"streamSummary" yaml:"streamSummary"`
+
+	// Prometheus metrics options
+	Metrics MetricsConfig `mapstructure:"metrics" yaml:"metrics"`
+
+	// Reserved model answered without any processing, disabled by default
+	EchoModel EchoModelConfig `mapstructure:"echoModel" yaml:"echoModel"`
+
+	// Structured audit log of tool executions, disabled by default
+	ToolAudit ToolAuditConfig `mapstructure:"toolAudit" yaml:"toolAudit"`
+
+	// Tool status updates in Redis read by the status endpoint
+	ToolStatus ToolStatusConfig `mapstructure:"toolStatus" yaml:"toolStatus"`
+
+	// Encode a sample at startup so a broken tokenizer fails fast, enabled by default
+	TokenizerWarmUp TokenizerWarmUpConfig `mapstructure:"tokenizerWarmUp" yaml:"tokenizerWarmUp"`
+
+	// Sampled request/response pairs for offline evaluation, disabled by default
+	QASampling QASamplingConfig `mapstructure:"qaSampling" yaml:"qaSampling"`
+
+	// Limit of content parts per message sent upstream, 0 means unlimited
+	ContentParts ContentPartsConfig `mapstructure:"contentParts" yaml:"contentParts"`
+
+	// Client feedback on whether the retrieved context was useful, disabled by default
+	RetrievalFeedback RetrievalFeedbackConfig `mapstructure:"retrievalFeedback" yaml:"retrievalFeedback"`
+
+	// Project path used when the client sends none, for single-workspace deployments
+	DefaultProjectPath DefaultProjectPathConfig `mapstructure:"defaultProjectPath" yaml:"defaultProjectPath"`
+
+	// What to do when a client reads the stream slower than the model produces it
+	StreamBackpressure StreamBackpressureConfig `mapstructure:"streamBackpressure" yaml:"streamBackpressure"`
+
+	// Dependency checks of the /healthz readiness probe
+	Healthz HealthzConfig `mapstructure:"healthz" yaml:"healthz"`
+
+	// Deployment environment (e.g. dev, staging, prod) attached to service logs, stored chat
+	// logs and, when metrics.environmentLabel is set, metrics. Empty leaves them untagged
+	Environment string `mapstructure:"environment" yaml:"environment"`
+
+	// Keep each tool call of a request in Redis for debugging, disabled by default
+	ToolCallReplay ToolCallReplayConfig `mapstructure:"toolCallReplay" yaml:"toolCallReplay"`
+
+	// Drop upstream stream chunks carrying nothing for the client (empty content deltas,
+	// keepalives); the first role chunk, finish reasons and usage are still forwarded
+	SuppressEmptyDeltas bool `mapstructure:"suppressEmptyDeltas" yaml:"suppressEmptyDeltas"`
+
+	// File path and score of retrieved chunks in the chat log, disabled by default
+	RetrievalProvenance RetrievalProvenanceConfig `mapstructure:"retrievalProvenance" yaml:"retrievalProvenance"`
+
+	// Reuse results of repeated tool calls within a request, enabled by default
+	RequestToolCache RequestToolCacheConfig `mapstructure:"requestToolCache" yaml:"requestToolCache"`
+}
+
+// Stream backpressure policies
+const (
+	// StreamBackpressureBlock waits for the client, pausing the upstream read meanwhile
+	StreamBackpressureBlock = "backpressure"
+	// StreamBackpressureAbort ends the stream when a write does not complete in time
+	StreamBackpressureAbort = "abort"
+)
+
+// StreamBackpressureConfig bounds how long one stream write may wait for a slow client
+type StreamBackpressureConfig struct {
+	// "backpressure" (default) or "abort"
+	Policy string `mapstructure:"policy" yaml:"policy"`
+	// Write deadline of each stream chunk under the abort policy, default 30000
+	WriteTimeoutMs int `mapstructure:"writeTimeoutMs" yaml:"writeTimeoutMs"`
+}
+
+// HealthzConfig controls the dependency checks of the /healthz endpoint
+type HealthzConfig struct {
+	// Longest time each dependency check may take, default is 2000
+	TimeoutMs int `mapstructure:"timeoutMs" yaml:"timeoutMs"`
+	// Dependencies ("redis" or a tool name) reported as degraded when down without failing the probe
+	Optional []string `mapstructure:"optional" yaml:"optional"`
+}
+
+// DefaultProjectPathConfig fills an empty project path before tools run. The first match wins:
+// the Header value, the path configured for the client ID, then Path
+type DefaultProjectPathConfig struct {
+	// Alternative request header carrying the workspace path, e.g. set by a gateway
+	Header string `mapstructure:"header" yaml:"header"`
+	// Default path per client ID
+	Clients map[string]string `mapstructure:"clients" yaml:"clients"`
+	// Default path for all other clients, empty leaves the project path unset
+	Path string `mapstructure:"path" yaml:"path"`
+}
+
+// RetrievalFeedbackConfig controls the feedback endpoint measuring retrieval quality.
+// Requests that called tools are kept in Redis for TTLSec so feedback can be matched to them
+type RetrievalFeedbackConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// How long feedback is accepted after the request, default is 86400
+	TTLSec int `mapstructure:"ttlSec" yaml:"ttlSec"`
+}
+
+// RetrievalProvenanceConfig controls recording the chunks injected into the prompt in the chat log,
+// to trace which code a response was based on
+type RetrievalProvenanceConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Most chunks recorded per request, later chunks are only counted, default is 50
+	MaxChunks int `mapstructure:"maxChunks" yaml:"maxChunks"`
+}
+
+// RequestToolCacheConfig controls the in-memory cache answering identical tool calls made
+// again within the tool chain of one request. It lives and dies with the request
+type RequestToolCacheConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+}
+
+// ToolCallReplayConfig controls storing the tool calls of a request, with their input and
+// output, so they can be read back with GET /v1/tool-calls/{requestId}
+type ToolCallReplayConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// How long the tool calls of a request are kept, default is 86400
+	TTLSec int `mapstructure:"ttlSec" yaml:"ttlSec"`
+}
+
+// ContentPartsConfig caps content arrays for providers limiting the parts of a message.
+// Text parts are merged to fit, image and other non-text parts are always kept
+type ContentPartsConfig struct {
+	MaxPerMessage int `mapstructure:"maxPerMessage" yaml:"maxPerMessage"`
+	// Deepest nesting of arrays and objects accepted in message content, requests beyond it
+	// are rejected. Default is 32, a negative value disables the check
+	MaxDepth int `mapstructure:"maxDepth" yaml:"maxDepth"`
+}
+
+// QASamplingConfig controls the sampled request/response records written for QA pipelines.
+// Records are kept apart from chat logs and carry no credentials or identity
+type QASamplingConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Fraction of completed requests sampled, between 0 and 1
+	Rate float64 `mapstructure:"rate" yaml:"rate"`
+	// Directory of the daily qa-YYYY-MM-DD.jsonl files, default is "logs/qa"
+	Directory string `mapstructure:"directory" yaml:"directory"`
+}
+
+// TokenizerWarmUpConfig controls the tokenizer warm-up during bootstrap
+type TokenizerWarmUpConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+}
+
+// ToolStatusConfig controls how tool status changes are written to Redis
+type ToolStatusConfig struct {
+	// Write each update in one pipelined round trip and hold back final statuses so they
+	// are written together with the next tool's "running" status, default is false
+	BatchUpdates bool `mapstructure:"batchUpdates" yaml:"batchUpdates"`
+	// Longest time a held back status waits before it is written on its own, default is 1000
+	MaxDelayMs int `mapstructure:"maxDelayMs" yaml:"maxDelayMs"`
+	// Most tools tracked in the status hash of a request, the oldest are evicted beyond it,
+	// default is 64
+	MaxTools int `mapstructure:"maxTools" yaml:"maxTools"`
+}
+
+// ToolAuditConfig controls the audit log of tool executions. It is written independently
+// of the chat log pipeline and never contains tool results
+type ToolAuditConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Where records are written: "file" or "stdout", default is "file"
+	Destination string `mapstructure:"destination" yaml:"destination"`
+	// File the records are appended to when Destination is "file", default is "logs/tool-audit.log"
+	FilePath string `mapstructure:"filePath" yaml:"filePath"`
+}
+
+// EchoModelConfig controls the reserved model used for health checks and latency baselines.
+// Requests for it skip prompt processing, tools and the upstream call and get a canned response
+type EchoModelConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Reserved model name, must start and end with "__" so no real model can match it, default is "__echo__"
+	Name string `mapstructure:"name" yaml:"name"`
+	// Canned response content, default is "pong"
+	Response string `mapstructure:"response" yaml:"response"`
+}
+
+// MetricsConfig holds optional Prometheus label settings
+type MetricsConfig struct {
+	// Add a prompt_checksum label (first 8 hex chars of the processed system prompt checksum)
+	// to every metric. Only enable where agents use a small, fixed set of system prompts
+	SystemPromptChecksumLabel bool `mapstructure:"systemPromptChecksumLabel" yaml:"systemPromptChecksumLabel"`
+	// Add the task category label to the token ratio metric, to compare compression per category.
+	// Categories are validated against a fixed list, so cardinality stays bounded
+	CompressionRatioCategoryLabel bool `mapstructure:"compressionRatioCategoryLabel" yaml:"compressionRatioCategoryLabel"`
+	// Use the x-original-model header, when present, as the model label, attributing
+	// metrics to the model the user asked for rather than the one a gateway remapped it to
+	AttributeOriginalModel bool `mapstructure:"attributeOriginalModel" yaml:"attributeOriginalModel"`
+	// Add a constant environment label with the configured environment to every metric
+	EnvironmentLabel bool `mapstructure:"environmentLabel" yaml:"environmentLabel"`
+}
+
+// StreamSummaryConfig controls the final summary event sent before [DONE]
+type StreamSummaryConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+}
+
+// ToolHistoryConfig controls persisting tool calls to Redis for follow-up requests
+type ToolHistoryConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Expiration of a session's history, refreshed on every call
+	TTLSec int `mapstructure:"ttlSec" yaml:"ttlSec"`
+	// Maximum number of records kept per session, oldest are dropped first
+	MaxEntries int `mapstructure:"maxEntries" yaml:"maxEntries"`
+	// Tool results are truncated to this many bytes in the record
+	MaxResultBytes int `mapstructure:"maxResultBytes" yaml:"maxResultBytes"`
+}
+
+// TemperatureConfig holds per-model temperature settings
+type TemperatureConfig struct {
+	Models []ModelTemperatureConfig `mapstructure:"models" yaml:"models"`
+}
+
+// ModelTemperatureConfig holds the temperature default and allowed range of a model
+type ModelTemperatureConfig struct {
+	Model string `mapstructure:"model" yaml:"model"`
+	// Applied when the request omits temperature
+	Default *float64 `mapstructure:"default" yaml:"default"`
+	// Requested values outside [Min, Max] are clamped, nil leaves the bound open
+	Min *float64 `mapstructure:"min" yaml:"min"`
+	Max *float64 `mapstructure:"max" yaml:"max"`
+}
+
+// LanguageConfig controls how the Accept-Language preference is used
+type LanguageConfig struct {
+	// Inject a "respond in <language>" instruction into the system prompt, default is true
+	InjectInstruction bool `mapstructure:"injectInstruction" yaml:"injectInstruction"`
+}
+
+// CurrentTimeConfig controls the current date and time instruction in the system prompt
+type CurrentTimeConfig struct {
+	// Inject the current date and time, default is false
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Request header carrying the user's IANA time zone, e.g. "Asia/Shanghai"
+	Header string `mapstructure:"header" yaml:"header"`
+	// Time zone used when the header is missing or invalid, empty uses the server's local time zone
+	Timezone string `mapstructure:"timezone" yaml:"timezone"`
+	// Go time layout of the injected value
+	Format string `mapstructure:"format" yaml:"format"`
+}
+
+// OutputTokenLimit holds default and maximum output tokens, 0 disables either limit
+type OutputTokenLimit struct {
+	// Applied when the request does not set max_tokens
+	DefaultMaxTokens int `mapstructure:"defaultMaxTokens" yaml:"defaultMaxTokens"`
+	// Upper bound for the requested max_tokens
+	MaxTokens int `mapstructure:"maxTokens" yaml:"maxTokens"`
+}
+
+// OutputTokensConfig holds global output token limits with per-agent overrides
+type OutputTokensConfig struct {
+	OutputTokenLimit `mapstructure:",squash" yaml:",inline"`
+	// Per-agent limits keyed by agent name, override the global limits
+	Agents map[string]OutputTokenLimit `mapstructure:"agents" yaml:"agents"`
+}
+
+// ResponseHeadersConfig controls which headers may reach the client
+type ResponseHeadersConfig struct {
+	// Headers stripped before the response is written to the client, in every response path
+	Suppress []string `mapstructure:"suppress" yaml:"suppress"`
+	// Set X-Chat-Rag-Agent to the agent matched for the request, default is false
+	IncludeAgent bool `mapstructure:"includeAgent" yaml:"includeAgent"`
+	// Set X-Chat-Rag-Messages-Dropped to the number of messages summarized or trimmed away, default is false
+	IncludeMessagesDropped bool `mapstructure:"includeMessagesDropped" yaml:"includeMessagesDropped"`
 }
 
 // VoucherActivity holds individual voucher activity configuration
@@ -208,6 +957,22 @@ type VoucherActivity struct {
 	QuotaExhaustedMessage  string    `mapstructure:"quotaExhaustedMessage" yaml:"quotaExhaustedMessage"`   // Quota exhausted message (Go template format)
 }
 
+// ResponseFiltersConfig holds the rewrite rules applied to model output before it reaches the client
+type ResponseFiltersConfig struct {
+	// Filters are applied in order, each to the output of the previous one
+	Filters []ResponseFilterRule `mapstructure:"filters" yaml:"filters"`
+	// Longest text a single match may span, streamed content this close to the end
+	// is held back until more arrives so matches split across chunks are still caught
+	MaxMatchBytes int `mapstructure:"maxMatchBytes" yaml:"maxMatchBytes"`
+}
+
+// ResponseFilterRule is a single regex replace rule
+type ResponseFilterRule struct {
+	Name        string `mapstructure:"name" yaml:"name"`
+	Pattern     string `mapstructure:"pattern" yaml:"pattern"`         // Go regexp syntax
+	Replacement string `mapstructure:"replacement" yaml:"replacement"` // Supports $1 style group references
+}
+
 // VoucherActivityConfig holds voucher activity configuration
 type VoucherActivityConfig struct {
 	Enabled    bool              `mapstructure:"enabled" yaml:"enabled"`       // Activity enable flag
@@ -258,6 +1023,18 @@ type AnalyzerConfig struct {
 	PromptTemplate string               `mapstructure:"promptTemplate" yaml:"promptTemplate"`
 	AnalysisLabels []string             `mapstructure:"analysisLabels" yaml:"analysisLabels"`
 	DynamicMetrics DynamicMetricsConfig `mapstructure:"dynamicMetrics" yaml:"dynamicMetrics"`
+	// Skip the analyzer while it keeps failing, routing straight to the fallback model
+	Breaker AnalyzerBreakerConfig `mapstructure:"breaker" yaml:"breaker"`
+}
+
+// AnalyzerBreakerConfig opens after consecutive failed analyzer calls; while open requests are
+// routed to the fallback model without waiting for the analyzer timeouts
+type AnalyzerBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Consecutive failed analyzer calls opening the breaker, default 3
+	FailureThreshold int `mapstructure:"failureThreshold" yaml:"failureThreshold"`
+	// Time before a single request probes the analyzer again, default 30000
+	CooldownMs int `mapstructure:"cooldownMs" yaml:"cooldownMs"`
 }
 
 // InputExtractionConfig controls how to extract input and history
@@ -271,8 +1048,22 @@ type InputExtractionConfig struct {
 	// MaxHistoryMessages limits how many history entries (after processing) can be included.
 	// When >0, only the most recent N history items are kept.
 	MaxHistoryMessages int `mapstructure:"maxHistoryMessages" yaml:"maxHistoryMessages"`
+	// MaxContentBytes caps the text read from each message before extraction, so huge
+	// content arrays never become huge intermediate strings. Default is 65536.
+	MaxContentBytes int `mapstructure:"maxContentBytes" yaml:"maxContentBytes"`
+	// Scope is the part of the conversation sent to the analyzer: InputScopeLastMessage
+	// (default) or InputScopeFull, which adds up to MaxHistoryMessages earlier user messages
+	Scope string `mapstructure:"scope" yaml:"scope"`
 }
 
+// Conversation scopes of the semantic analyzer input
+const (
+	// Only the current message, plus the previous assistant reply when it is too short to classify alone
+	InputScopeLastMessage = "last_message"
+	// The current message and the earlier user messages as history
+	InputScopeFull = "full"
+)
+
 // RoutingConfig holds candidate model routing configuration
 type RoutingConfig struct {
 	Candidates        []RoutingCandidate `mapstructure:"candidates" yaml:"candidates"`
@@ -365,6 +1156,11 @@ type NacosConfig struct {
 	LogDir string `mapstructure:"logDir" yaml:"logDir"`
 	// Cache directory for Nacos client
 	CacheDir string `mapstructure:"cacheDir" yaml:"cacheDir"`
+	// Configs larger than this many bytes are rejected, 0 disables the check
+	MaxConfigBytes int `mapstructure:"maxConfigBytes" yaml:"maxConfigBytes"`
+	// Changes to one dataId are applied at most once per interval, the latest
+	// content arriving within the interval wins. 0 applies every change immediately
+	MinUpdateIntervalMs int `mapstructure:"minUpdateIntervalMs" yaml:"minUpdateIntervalMs"`
 }
 
 type ChatMetrics struct {
@@ -382,3 +1178,36 @@ type RequestVerifyConfig struct {
 	Enabled           bool `yaml:"enabled"`           // Enable request verification
 	EnabledTimeVerify bool `yaml:"enabledTimeVerify"` // Enable timestamp verification
 }
+
+// DebugConfig controls opt-in debug output returned to trusted callers
+type DebugConfig struct {
+	// Enable debug output, default is false
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Request header a caller must send to receive debug output
+	TrustedHeader string `mapstructure:"trustedHeader" yaml:"trustedHeader"`
+	// Expected value of TrustedHeader; requests with any other value are ignored
+	TrustedToken string `mapstructure:"trustedToken" yaml:"trustedToken"`
+	// Also return the full (base64 encoded) system prompt text, not just its cache key and length
+	IncludeSystemPrompt bool `mapstructure:"includeSystemPrompt" yaml:"includeSystemPrompt"`
+	// Maximum bytes of system prompt text returned in the header
+	MaxSystemPromptBytes int `mapstructure:"maxSystemPromptBytes" yaml:"maxSystemPromptBytes"`
+	// Marker where the compressed (cached) region of the system prompt starts, used for the cache key
+	SystemPromptSplitStr string `mapstructure:"systemPromptSplitStr" yaml:"systemPromptSplitStr"`
+	// Also return the settings resolved for the request (mode, model, compression and tool limits)
+	EffectiveConfig bool `mapstructure:"effectiveConfig" yaml:"effectiveConfig"`
+	// Serve the reload count and last reload time of each Nacos config at /debug/config
+	ConfigReloads bool `mapstructure:"configReloads" yaml:"configReloads"`
+
+	// Tool call traces in the stream summary event, requested per call through extra_body
+	ToolTrace DebugToolTraceConfig `mapstructure:"toolTrace" yaml:"toolTrace"`
+}
+
+// DebugToolTraceConfig controls which callers may request tool call traces
+type DebugToolTraceConfig struct {
+	// Enable tool traces, default is false
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// User names allowed to request traces, other callers never receive them
+	AllowedUsers []string `mapstructure:"allowedUsers" yaml:"allowedUsers"`
+	// Maximum bytes of each tool result when results are requested, 0 keeps them whole
+	MaxResultBytes int `mapstructure:"maxResultBytes" yaml:"maxResultBytes"`
+}
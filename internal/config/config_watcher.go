@@ -212,6 +212,17 @@ type ConfigWatcher struct {
 	handlers    map[string]ConfigChangeHandler
 	mutex       sync.RWMutex
 	isConnected bool
+
+	// updates throttles change handling per dataId
+	updates   map[string]*pendingUpdate
+	updatesMu sync.Mutex
+}
+
+// pendingUpdate tracks when a dataId was last applied and the latest content held back
+type pendingUpdate struct {
+	lastApplied time.Time
+	data        string
+	scheduled   bool
 }
 
 // NewConfigWatcher 创建配置监听器
@@ -221,6 +232,7 @@ func NewConfigWatcher(config NacosConfig, client config_client.IConfigClient) *C
 		config:      config,
 		handlers:    make(map[string]ConfigChangeHandler),
 		isConnected: client != nil,
+		updates:     make(map[string]*pendingUpdate),
 	}
 }
 
@@ -285,11 +297,7 @@ func (w *ConfigWatcher) startWatchingConfig(dataId string, handler ConfigChangeH
 				zap.String("dataId", dataId),
 				zap.Int("dataLength", len(data)))
 
-			if err := handler.OnChange(data); err != nil {
-				logger.Error("Failed to handle configuration change",
-					zap.Error(err),
-					zap.String("dataId", dataId))
-			}
+			w.dispatchChange(dataId, handler, data)
 		},
 	})
 	if err != nil {
@@ -303,6 +311,70 @@ func (w *ConfigWatcher) startWatchingConfig(dataId string, handler ConfigChangeH
 	return nil
 }
 
+// dispatchChange applies a config change, rejecting oversized content and applying
+// changes that arrive within MinUpdateIntervalMs of the last one after the interval ends
+func (w *ConfigWatcher) dispatchChange(dataId string, handler ConfigChangeHandler, data string) {
+	if w.config.MaxConfigBytes > 0 && len(data) > w.config.MaxConfigBytes {
+		logger.Error("Configuration change rejected, config too large",
+			zap.String("dataId", dataId),
+			zap.Int("dataLength", len(data)),
+			zap.Int("maxConfigBytes", w.config.MaxConfigBytes))
+		return
+	}
+
+	interval := time.Duration(w.config.MinUpdateIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		w.applyChange(dataId, handler, data)
+		return
+	}
+
+	w.updatesMu.Lock()
+	update, exists := w.updates[dataId]
+	if !exists {
+		update = &pendingUpdate{}
+		w.updates[dataId] = update
+	}
+	wait := interval - time.Since(update.lastApplied)
+	if wait <= 0 && !update.scheduled {
+		update.lastApplied = time.Now()
+		w.updatesMu.Unlock()
+		w.applyChange(dataId, handler, data)
+		return
+	}
+
+	update.data = data
+	if update.scheduled {
+		w.updatesMu.Unlock()
+		logger.Info("Configuration change debounced, replacing the pending change",
+			zap.String("dataId", dataId))
+		return
+	}
+	update.scheduled = true
+	w.updatesMu.Unlock()
+
+	logger.Info("Configuration change debounced",
+		zap.String("dataId", dataId),
+		zap.Duration("applyIn", wait))
+	time.AfterFunc(wait, func() {
+		w.updatesMu.Lock()
+		pending := update.data
+		update.data = ""
+		update.scheduled = false
+		update.lastApplied = time.Now()
+		w.updatesMu.Unlock()
+		w.applyChange(dataId, handler, pending)
+	})
+}
+
+// applyChange passes the content to the handler, logging failures
+func (w *ConfigWatcher) applyChange(dataId string, handler ConfigChangeHandler, data string) {
+	if err := handler.OnChange(data); err != nil {
+		logger.Error("Failed to handle configuration change",
+			zap.Error(err),
+			zap.String("dataId", dataId))
+	}
+}
+
 // GetHandler 获取指定数据ID的处理器
 func (w *ConfigWatcher) GetHandler(dataId string) (ConfigChangeHandler, bool) {
 	w.mutex.RLock()
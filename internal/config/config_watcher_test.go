@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
@@ -134,3 +136,70 @@ activities:
 		t.Errorf("EndTime = %v, want %v", activity.EndTime, expectedEnd)
 	}
 }
+
+// recordingHandler records the content of every applied change
+type recordingHandler struct {
+	mu      sync.Mutex
+	applied []string
+}
+
+func (h *recordingHandler) GetDataId() string { return "chat-rag" }
+
+func (h *recordingHandler) GetConfig() interface{} { return nil }
+
+func (h *recordingHandler) OnChange(data string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.applied = append(h.applied, data)
+	return nil
+}
+
+func (h *recordingHandler) snapshot() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.applied...)
+}
+
+func TestConfigWatcher_dispatchChange_RejectsOversizedConfig(t *testing.T) {
+	w := NewConfigWatcher(NacosConfig{MaxConfigBytes: 10}, nil)
+	handler := &recordingHandler{}
+
+	w.dispatchChange("chat-rag", handler, "a: 1")
+	w.dispatchChange("chat-rag", handler, "a: 12345678901234")
+
+	if got := handler.snapshot(); len(got) != 1 || got[0] != "a: 1" {
+		t.Errorf("applied = %v, want only the config within the size limit", got)
+	}
+}
+
+func TestConfigWatcher_dispatchChange_DebouncesRapidUpdates(t *testing.T) {
+	w := NewConfigWatcher(NacosConfig{MinUpdateIntervalMs: 50}, nil)
+	handler := &recordingHandler{}
+
+	for i := 1; i <= 5; i++ {
+		w.dispatchChange("chat-rag", handler, fmt.Sprintf("version: %d", i))
+	}
+
+	if got := handler.snapshot(); len(got) != 1 || got[0] != "version: 1" {
+		t.Fatalf("applied = %v, want only the first change applied immediately", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	got := handler.snapshot()
+	if len(got) != 2 || got[1] != "version: 5" {
+		t.Errorf("applied = %v, want the latest change applied once after the interval", got)
+	}
+}
+
+func TestConfigWatcher_dispatchChange_NoInterval(t *testing.T) {
+	w := NewConfigWatcher(NacosConfig{}, nil)
+	handler := &recordingHandler{}
+
+	for i := 1; i <= 3; i++ {
+		w.dispatchChange("chat-rag", handler, fmt.Sprintf("version: %d", i))
+	}
+
+	if got := handler.snapshot(); len(got) != 3 {
+		t.Errorf("applied %d changes, want every change applied", len(got))
+	}
+}
@@ -2,9 +2,11 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/viper"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/types"
 	"go.uber.org/zap"
 )
 
@@ -78,6 +80,10 @@ func MustLoadConfig(configPath string) Config {
 		if !viper.IsSet("router.semantic.inputExtraction.maxHistoryMessages") || c.Router.Semantic.InputExtraction.MaxHistoryMessages == 0 {
 			c.Router.Semantic.InputExtraction.MaxHistoryMessages = 5
 		}
+		// inputExtraction.scope default
+		if c.Router.Semantic.InputExtraction.Scope == "" {
+			c.Router.Semantic.InputExtraction.Scope = InputScopeLastMessage
+		}
 	}
 	// Apply idle timeout defaults
 	if c != nil && c.LLMTimeout.IdleTimeoutMs <= 0 {
@@ -98,6 +104,9 @@ func MustLoadConfig(configPath string) Config {
 		c.LLMTimeout.RetryIntervalMs = 5000
 		logger.Info("llm retryIntervalMs not set, using default", zap.Int("retryIntervalMs", c.LLMTimeout.RetryIntervalMs))
 	}
+	if c != nil && c.LLMTimeout.StreamDropRetryCount < 0 {
+		c.LLMTimeout.StreamDropRetryCount = 0
+	}
 
 	// Apply forward configuration defaults
 	if c != nil {
@@ -109,6 +118,17 @@ func MustLoadConfig(configPath string) Config {
 		if !viper.IsSet("forward.defaultTarget") {
 			c.Forward.DefaultTarget = ""
 		}
+		// language.injectInstruction default (only when key not set)
+		if !viper.IsSet("language.injectInstruction") {
+			c.Language.InjectInstruction = true
+		}
+		// currentTime defaults
+		if c.CurrentTime.Header == "" {
+			c.CurrentTime.Header = "X-Timezone"
+		}
+		if c.CurrentTime.Format == "" {
+			c.CurrentTime.Format = "2006-01-02 15:04 MST (Monday)"
+		}
 		// vipPriority.enabled default (only when key not set)
 		if !viper.IsSet("vipPriority.enabled") {
 			c.VIPPriority.Enabled = false
@@ -116,6 +136,155 @@ func MustLoadConfig(configPath string) Config {
 		}
 	}
 
+	// Apply debug configuration defaults
+	if c != nil {
+		if c.Debug.TrustedHeader == "" {
+			c.Debug.TrustedHeader = "x-chat-rag-debug"
+		}
+		if c.Debug.MaxSystemPromptBytes <= 0 {
+			c.Debug.MaxSystemPromptBytes = 4096
+		}
+		if c.Debug.SystemPromptSplitStr == "" {
+			c.Debug.SystemPromptSplitStr = "====\n\nRULES"
+		}
+		if c.Debug.Enabled && c.Debug.TrustedToken == "" {
+			c.Debug.Enabled = false
+			logger.Warn("debug.enabled is set without debug.trustedToken, debug output disabled")
+		}
+		if !viper.IsSet("debug.toolTrace.maxResultBytes") {
+			c.Debug.ToolTrace.MaxResultBytes = 4096
+		}
+		if c.Debug.ToolTrace.Enabled && len(c.Debug.ToolTrace.AllowedUsers) == 0 {
+			logger.Warn("debug.toolTrace.enabled is set without allowedUsers, no caller receives tool traces")
+		}
+	}
+
+	// Apply response header suppression defaults (only when key not set)
+	if c != nil && !viper.IsSet("responseHeaders.suppress") {
+		c.ResponseHeaders.Suppress = types.DefaultSuppressedResponseHeaders
+		logger.Info("responseHeaders.suppress not set, using default",
+			zap.Strings("suppress", c.ResponseHeaders.Suppress))
+	}
+
+	// Apply tool history defaults
+	if c != nil && c.ToolHistory.Enabled {
+		if c.ToolHistory.TTLSec <= 0 {
+			c.ToolHistory.TTLSec = 3600
+		}
+		if c.ToolHistory.MaxEntries <= 0 {
+			c.ToolHistory.MaxEntries = 20
+		}
+		if c.ToolHistory.MaxResultBytes <= 0 {
+			c.ToolHistory.MaxResultBytes = 1024
+		}
+		logger.Info("tool history enabled",
+			zap.Int("ttlSec", c.ToolHistory.TTLSec),
+			zap.Int("maxEntries", c.ToolHistory.MaxEntries),
+			zap.Int("maxResultBytes", c.ToolHistory.MaxResultBytes))
+	}
+
+	// Apply echo model defaults, the name must be clearly reserved
+	if c != nil && c.EchoModel.Enabled {
+		if c.EchoModel.Name == "" {
+			c.EchoModel.Name = "__echo__"
+		}
+		if c.EchoModel.Response == "" {
+			c.EchoModel.Response = "pong"
+		}
+		if len(c.EchoModel.Name) <= 4 || !strings.HasPrefix(c.EchoModel.Name, "__") || !strings.HasSuffix(c.EchoModel.Name, "__") {
+			c.EchoModel.Enabled = false
+			logger.Warn("echoModel.name must start and end with \"__\", echo model disabled",
+				zap.String("name", c.EchoModel.Name))
+		} else {
+			logger.Info("echo model enabled", zap.String("name", c.EchoModel.Name))
+		}
+	}
+
+	// Apply tool audit defaults
+	if c != nil && c.ToolAudit.Enabled {
+		if c.ToolAudit.Destination == "" {
+			c.ToolAudit.Destination = "file"
+		}
+		if c.ToolAudit.FilePath == "" {
+			c.ToolAudit.FilePath = "logs/tool-audit.log"
+		}
+	}
+
+	// Apply content parts defaults
+	if c != nil && c.ContentParts.MaxDepth == 0 {
+		c.ContentParts.MaxDepth = 32
+	}
+
+	// Apply QA sampling defaults
+	if c != nil && c.QASampling.Enabled && c.QASampling.Directory == "" {
+		c.QASampling.Directory = "logs/qa"
+	}
+
+	// Apply retrieval feedback defaults
+	if c != nil && c.RetrievalProvenance.Enabled && c.RetrievalProvenance.MaxChunks <= 0 {
+		c.RetrievalProvenance.MaxChunks = 50
+	}
+	if c != nil && c.RetrievalFeedback.Enabled && c.RetrievalFeedback.TTLSec <= 0 {
+		c.RetrievalFeedback.TTLSec = 86400
+	}
+	if c != nil && c.ToolCallReplay.Enabled && c.ToolCallReplay.TTLSec <= 0 {
+		c.ToolCallReplay.TTLSec = 86400
+	}
+
+	// Apply stream backpressure defaults
+	if c != nil {
+		switch c.StreamBackpressure.Policy {
+		case "":
+			c.StreamBackpressure.Policy = StreamBackpressureBlock
+		case StreamBackpressureBlock, StreamBackpressureAbort:
+		default:
+			logger.Warn("unknown streamBackpressure.policy, using backpressure",
+				zap.String("policy", c.StreamBackpressure.Policy))
+			c.StreamBackpressure.Policy = StreamBackpressureBlock
+		}
+		if c.StreamBackpressure.WriteTimeoutMs <= 0 {
+			c.StreamBackpressure.WriteTimeoutMs = 30000
+		}
+	}
+
+	// Apply tool status batching defaults
+	if c != nil && c.Healthz.TimeoutMs <= 0 {
+		c.Healthz.TimeoutMs = 2000
+	}
+	if c != nil && c.ToolStatus.MaxTools <= 0 {
+		c.ToolStatus.MaxTools = 64
+	}
+	if c != nil && c.ToolStatus.BatchUpdates && c.ToolStatus.MaxDelayMs <= 0 {
+		c.ToolStatus.MaxDelayMs = 1000
+	}
+
+	// Apply Redis circuit breaker defaults
+	if c != nil && c.Redis.Breaker.Enabled {
+		if c.Redis.Breaker.FailureThreshold <= 0 {
+			c.Redis.Breaker.FailureThreshold = 3
+		}
+		if c.Redis.Breaker.CooldownMs <= 0 {
+			c.Redis.Breaker.CooldownMs = 10000
+		}
+	}
+
+	// The request tool cache is on unless explicitly disabled
+	if c != nil && !viper.IsSet("requestToolCache.enabled") {
+		c.RequestToolCache.Enabled = true
+	}
+
+	// Tokenizer warm-up is on unless explicitly disabled
+	if c != nil && !viper.IsSet("tokenizerWarmUp.enabled") {
+		c.TokenizerWarmUp.Enabled = true
+	}
+
+	// Validate temperature ranges, misconfigured sampling must not reach the models
+	if c != nil {
+		if err := ValidateTemperatureConfig(c.Temperature); err != nil {
+			panic("Invalid temperature config: " + err.Error())
+		}
+	}
+
 	// Apply timeout and retry defaults for routing (model degradation scenarios)
 	ApplyRouterDefaults(c)
 
@@ -172,3 +341,32 @@ func ApplyRouterDefaults(c *Config) {
 		}
 	}
 }
+
+// ValidateTemperatureConfig checks that every model's default lies within its allowed range
+func ValidateTemperatureConfig(tc TemperatureConfig) error {
+	seen := make(map[string]struct{})
+	for _, m := range tc.Models {
+		if m.Model == "" {
+			return fmt.Errorf("model name is required")
+		}
+		if _, ok := seen[m.Model]; ok {
+			return fmt.Errorf("model %s is configured more than once", m.Model)
+		}
+		seen[m.Model] = struct{}{}
+
+		for name, v := range map[string]*float64{"default": m.Default, "min": m.Min, "max": m.Max} {
+			if v != nil && (*v < 0 || *v > 2) {
+				return fmt.Errorf("model %s: %s temperature %v out of range [0, 2]", m.Model, name, *v)
+			}
+		}
+		if m.Min != nil && m.Max != nil && *m.Min > *m.Max {
+			return fmt.Errorf("model %s: min temperature %v greater than max %v", m.Model, *m.Min, *m.Max)
+		}
+		if m.Default != nil {
+			if (m.Min != nil && *m.Default < *m.Min) || (m.Max != nil && *m.Default > *m.Max) {
+				return fmt.Errorf("model %s: default temperature %v outside allowed range", m.Model, *m.Default)
+			}
+		}
+	}
+	return nil
+}
@@ -149,6 +149,11 @@ func (nl *NacosLoader) LoadConfig(dataId string, target interface{}) error {
 		return fmt.Errorf("%s config is empty in Nacos", dataId)
 	}
 
+	if nl.config.MaxConfigBytes > 0 && len(content) > nl.config.MaxConfigBytes {
+		return fmt.Errorf("%s config is %d bytes, larger than the %d bytes allowed",
+			dataId, len(content), nl.config.MaxConfigBytes)
+	}
+
 	// Use helper function to parse YAML
 	if err := unmarshalYAMLContent(content, target); err != nil {
 		return fmt.Errorf("failed to unmarshal %s config: %w", dataId, err)
@@ -0,0 +1,196 @@
+package functions
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// BroadenedResultPrefix marks tool results produced by a broadened retry
+const BroadenedResultPrefix = "[broadened search: no results for the original query, showing results for a broader query]\n"
+
+var (
+	codeFenceRegex  = regexp.MustCompile("(?s)```.*?```")
+	inlineCodeRegex = regexp.MustCompile("`[^`]*`")
+	keywordRegex    = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+)
+
+// The scope note follows BroadenedResultPrefix when the retry removed the path filter
+const (
+	scopeNotePrefix = `[no results under "`
+	scopeNoteSuffix = "\", showing results from the whole codebase]\n"
+)
+
+// IsBroadenedResult reports whether the tool result comes from a broadened retry
+func IsBroadenedResult(result string) bool {
+	return strings.HasPrefix(result, BroadenedResultPrefix)
+}
+
+// broadenedHeader returns the lines put before a broadened result, telling the model that the
+// results come from the whole codebase when the retry removed the path filter
+func broadenedHeader(removedPath string) string {
+	if removedPath == "" {
+		return BroadenedResultPrefix
+	}
+	return BroadenedResultPrefix + scopeNotePrefix + removedPath + scopeNoteSuffix
+}
+
+// splitBroadenedHeader separates the broadened marker and scope note from the tool result
+func splitBroadenedHeader(result string) (string, string) {
+	if !IsBroadenedResult(result) {
+		return "", result
+	}
+	headerLen := len(BroadenedResultPrefix)
+	if rest := result[headerLen:]; strings.HasPrefix(rest, scopeNotePrefix) {
+		if end := strings.Index(rest, scopeNoteSuffix); end != -1 {
+			headerLen += end + len(scopeNoteSuffix)
+		}
+	}
+	return result[:headerLen], result[headerLen:]
+}
+
+// broadenedBody returns the tool result without the broadened marker and scope note
+func broadenedBody(result string) string {
+	_, body := splitBroadenedHeader(result)
+	return body
+}
+
+// executeBroadened retries the tool once with a lowered score threshold, a simplified
+// query and/or without the path filter. It returns the result with the removed path filter,
+// and false when nothing could be broadened or the retry still produced no results.
+func (e *GenericToolExecutor) executeBroadened(
+	ctx context.Context,
+	toolClient client.GenericClientInterface,
+	toolConfig config.GenericToolConfig,
+	params map[string]interface{},
+) (string, string, bool) {
+	broadenedParams, changed := broadenParams(toolConfig.Broaden, params)
+	if !changed {
+		return "", "", false
+	}
+	removedPath := ""
+	if param := toolConfig.Broaden.DropPathParam; param != "" {
+		removedPath, _ = params[param].(string)
+		removedPath = strings.TrimSpace(removedPath)
+	}
+
+	logger.InfoC(ctx, "tool returned empty results, retrying with broadened query",
+		zap.String("tool", toolConfig.Name), zap.String("removedPath", removedPath))
+
+	result, err := toolClient.Execute(ctx, broadenedParams)
+	if err != nil {
+		logger.WarnC(ctx, "broadened tool execution failed",
+			zap.String("tool", toolConfig.Name), zap.Error(err))
+		return "", "", false
+	}
+	if client.IsEmptyResult(result) {
+		logger.InfoC(ctx, "broadened query also returned empty results",
+			zap.String("tool", toolConfig.Name))
+		return "", "", false
+	}
+
+	return result, removedPath, true
+}
+
+// BroadenedFromPath returns the path filter removed by the broadened retry that produced the
+// result, empty when the path was kept
+func BroadenedFromPath(result string) string {
+	header, _ := splitBroadenedHeader(result)
+	note := strings.TrimPrefix(header, BroadenedResultPrefix)
+	if note == "" {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(note, scopeNotePrefix), scopeNoteSuffix)
+}
+
+// broadenParams returns a copy of params with the configured score threshold
+// lowered, query simplified and path filter removed, and whether anything was changed
+func broadenParams(cfg config.GenericToolBroadenConfig, params map[string]interface{}) (map[string]interface{}, bool) {
+	broadened := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		broadened[k] = v
+	}
+
+	changed := false
+	if cfg.ScoreParam != "" && cfg.ScoreDelta > 0 {
+		if score, ok := toFloat(params[cfg.ScoreParam]); ok {
+			lowered := score - cfg.ScoreDelta
+			if lowered < 0 {
+				lowered = 0
+			}
+			if lowered != score {
+				broadened[cfg.ScoreParam] = lowered
+				changed = true
+			}
+		}
+	}
+
+	if cfg.QueryParam != "" {
+		if query, ok := params[cfg.QueryParam].(string); ok {
+			if simplified := simplifyQuery(query); simplified != "" && simplified != query {
+				broadened[cfg.QueryParam] = simplified
+				changed = true
+			}
+		}
+	}
+
+	if cfg.DropPathParam != "" {
+		if path, ok := params[cfg.DropPathParam].(string); ok && strings.TrimSpace(path) != "" {
+			delete(broadened, cfg.DropPathParam)
+			changed = true
+		}
+	}
+
+	return broadened, changed
+}
+
+// simplifyQuery strips code blocks and file paths from a query and keeps the keywords
+func simplifyQuery(query string) string {
+	query = codeFenceRegex.ReplaceAllString(query, " ")
+	query = inlineCodeRegex.ReplaceAllString(query, " ")
+
+	seen := make(map[string]struct{})
+	keywords := make([]string, 0)
+	for _, field := range strings.Fields(query) {
+		// Skip paths and file names, they over-constrain the search
+		if strings.ContainsAny(field, "/\\") || strings.Contains(strings.Trim(field, "."), ".") {
+			continue
+		}
+		for _, word := range keywordRegex.FindAllString(field, -1) {
+			key := strings.ToLower(word)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keywords = append(keywords, word)
+		}
+	}
+
+	return strings.Join(keywords, " ")
+}
+
+// toFloat converts numeric parameter values to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case string:
+		f, err := strconv.ParseFloat(val, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,132 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+func TestExecuteTools_BroadenedWithoutPath(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		requests = append(requests, params)
+		if _, scoped := params["path"]; scoped {
+			w.Write([]byte(`{"data": {"list": []}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"list": [{"filePath": "pkg/auth/login.go", "content": "func Login()"}]}}`))
+	}))
+	defer server.Close()
+
+	executor := NewGenericToolExecutor(&config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+		Name:      "codebase_search",
+		Method:    http.MethodPost,
+		Endpoints: config.GenericToolEndpoints{Search: server.URL, Ready: server.URL},
+		Parameters: []config.GenericToolParameter{
+			{Name: "query", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+			{Name: "path", Type: "string", Source: config.ParameterSourceLLM},
+		},
+		Broaden: config.GenericToolBroadenConfig{Enabled: true, DropPathParam: "path"},
+	}}})
+	ctx := context.WithValue(context.Background(), model.IdentityContextKey, &model.Identity{})
+
+	result, err := executor.ExecuteTools(ctx, "codebase_search",
+		"<codebase_search><query>login</query><path>src/wrong</path></codebase_search>")
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Contains(t, requests[0], "path")
+	assert.NotContains(t, requests[1], "path", "the retry searches the whole codebase")
+	assert.Equal(t, "login", requests[1]["query"])
+
+	assert.True(t, IsBroadenedResult(result))
+	// Paths are sent in Windows form unless the client reports another OS
+	assert.Contains(t, result, "\n[no results under \"src\\wrong\", showing results from the whole codebase]\n")
+	assert.Contains(t, result, "pkg/auth/login.go")
+	assert.Equal(t, `src\wrong`, BroadenedFromPath(result))
+
+	// Unscoped searches have nothing to remove and are not retried
+	requests = nil
+	result, err = executor.ExecuteTools(ctx, "codebase_search", "<codebase_search><query>login</query></codebase_search>")
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.False(t, IsBroadenedResult(result))
+	assert.Empty(t, BroadenedFromPath(result))
+}
+
+func TestExecuteTools_BroadenedSharesPostProcessing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		if _, scoped := params["path"]; scoped {
+			w.Write([]byte(`{"data": []}`))
+			return
+		}
+		w.Write([]byte(`{"data": [{"filePath": "pkg/auth/login.go", "lineNumber": 3, "content": "func Login()"}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewGenericToolExecutor(&config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+		Name:      "search_lines",
+		Method:    http.MethodPost,
+		Endpoints: config.GenericToolEndpoints{Search: server.URL, Ready: server.URL},
+		Parameters: []config.GenericToolParameter{
+			{Name: "regex", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+			{Name: "path", Type: "string", Source: config.ParameterSourceLLM},
+		},
+		Broaden:     config.GenericToolBroadenConfig{Enabled: true, DropPathParam: "path"},
+		LineMatches: config.GenericToolLineMatchesConfig{Enabled: true},
+	}}})
+	ctx := context.WithValue(context.Background(), model.IdentityContextKey, &model.Identity{})
+
+	result, err := executor.ExecuteTools(ctx, "search_lines",
+		"<search_lines><regex>Login</regex><path>src/wrong</path></search_lines>")
+	require.NoError(t, err)
+	assert.True(t, IsBroadenedResult(result))
+	assert.Contains(t, result, "pkg/auth/login.go:3: func Login()", "line matches apply to the broadened result")
+}
+
+func TestExecuteTools_BroadenedScopeNoteWithJSONOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		if _, scoped := params["path"]; scoped {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"filePath": "pkg/auth/login.go", "content": "func Login()", "score": 0.8}]`))
+	}))
+	defer server.Close()
+
+	executor := NewGenericToolExecutor(&config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+		Name:      "codebase_search",
+		Method:    http.MethodPost,
+		Endpoints: config.GenericToolEndpoints{Search: server.URL, Ready: server.URL},
+		Parameters: []config.GenericToolParameter{
+			{Name: "query", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+			{Name: "path", Type: "string", Source: config.ParameterSourceLLM},
+		},
+		Broaden:      config.GenericToolBroadenConfig{Enabled: true, DropPathParam: "path"},
+		OutputFormat: config.ToolOutputJSON,
+	}}})
+	ctx := context.WithValue(context.Background(), model.IdentityContextKey, &model.Identity{})
+
+	result, err := executor.ExecuteTools(ctx, "codebase_search",
+		"<codebase_search><query>login</query><path>src/wrong</path></codebase_search>")
+	require.NoError(t, err)
+	assert.Equal(t, `src\wrong`, BroadenedFromPath(result), "the scope note survives output formatting")
+
+	var items []jsonOutputItem
+	require.NoError(t, json.Unmarshal([]byte(broadenedBody(result)), &items), "the result list is not wrapped")
+	require.Len(t, items, 1)
+	assert.Equal(t, "pkg/auth/login.go", items[0].FilePath)
+}
@@ -0,0 +1,170 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	defaultChunkPathField      = "filePath"
+	defaultChunkStartLineField = "startLine"
+	defaultChunkEndLineField   = "endLine"
+)
+
+// chunkOmittedNoteField holds the note added to the result envelope when chunks were cut
+const chunkOmittedNoteField = "note"
+
+// chunkSpan is the line range of a kept chunk in one file
+type chunkSpan struct {
+	start, end float64
+}
+
+// limitChunks applies chunk deduplication and the character cap when enabled for the tool
+func limitChunks(ctx context.Context, toolConfig config.GenericToolConfig, result string) string {
+	if !toolConfig.ChunkDedupe.Enabled {
+		return result
+	}
+	limited, duplicates, omitted := dedupeChunks(toolConfig.ChunkDedupe, result)
+	if duplicates > 0 || omitted > 0 {
+		logger.InfoC(ctx, "tool result chunks deduplicated",
+			zap.String("tool", toolConfig.Name),
+			zap.Int("duplicates", duplicates),
+			zap.Int("omitted", omitted),
+			zap.Int("length", len(limited)))
+	}
+	return limited
+}
+
+// dedupeChunks removes chunks overlapping a higher scored chunk of the same file, then keeps
+// chunks in their original order until MaxChars is reached and notes how many were omitted.
+// It returns the result with the number of duplicates removed and of chunks omitted;
+// results without a result list are returned unchanged.
+func dedupeChunks(cfg config.GenericToolChunkDedupeConfig, result string) (string, int, int) {
+	prefix, body := splitBroadenedHeader(result)
+	var data interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &data); err != nil {
+		return result, 0, 0
+	}
+	items, ok := findResultList(data)
+	if !ok || len(items) == 0 {
+		return result, 0, 0
+	}
+
+	kept := removeOverlappingChunks(cfg, items)
+	duplicates := len(items) - len(kept)
+	kept, omitted := capChunkChars(kept, cfg.MaxChars)
+	if duplicates == 0 && omitted == 0 {
+		return result, 0, 0
+	}
+
+	envelope, isObject := replaceResultList(data, kept).(map[string]interface{})
+	if !isObject {
+		envelope = map[string]interface{}{"data": kept}
+	}
+	if omitted > 0 {
+		envelope[chunkOmittedNoteField] = fmt.Sprintf(
+			"%d more chunks omitted to keep the result within its size limit", omitted)
+	}
+	encoded, err := json.Marshal(envelope)
+	if err != nil {
+		return result, 0, 0
+	}
+	return prefix + string(encoded), duplicates, omitted
+}
+
+// removeOverlappingChunks visits chunks from the highest score down and drops each one whose
+// line range overlaps an already kept chunk of the same file. Chunks without a path or line
+// range are always kept. The kept chunks stay in their original order.
+func removeOverlappingChunks(cfg config.GenericToolChunkDedupeConfig, items []interface{}) []interface{} {
+	pathField := fieldOrDefault(cfg.PathField, defaultChunkPathField)
+	startField := fieldOrDefault(cfg.StartLineField, defaultChunkStartLineField)
+	endField := fieldOrDefault(cfg.EndLineField, defaultChunkEndLineField)
+	scoreField := fieldOrDefault(cfg.ScoreField, defaultScoreField)
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	score := func(i int) float64 {
+		fields, _ := items[i].(map[string]interface{})
+		s, _ := toFloat(fields[scoreField])
+		return s
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return score(order[i]) > score(order[j])
+	})
+
+	spans := make(map[string][]chunkSpan)
+	dropped := make(map[int]bool)
+	for _, index := range order {
+		fields, ok := items[index].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := fields[pathField].(string)
+		start, startOK := toFloat(fields[startField])
+		end, endOK := toFloat(fields[endField])
+		if path == "" || !startOK || !endOK {
+			continue
+		}
+
+		span := chunkSpan{start: start, end: end}
+		overlaps := false
+		for _, other := range spans[path] {
+			if span.start <= other.end && other.start <= span.end {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			dropped[index] = true
+			continue
+		}
+		spans[path] = append(spans[path], span)
+	}
+
+	kept := make([]interface{}, 0, len(items)-len(dropped))
+	for i, item := range items {
+		if !dropped[i] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// capChunkChars keeps whole chunks while their encoded size fits maxChars, the first chunk is
+// kept even when it alone is larger. It returns the kept chunks and the number omitted.
+func capChunkChars(items []interface{}, maxChars int) ([]interface{}, int) {
+	if maxChars <= 0 {
+		return items, 0
+	}
+
+	total := 0
+	for i, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return items, 0
+		}
+		total += len(encoded)
+		if total > maxChars && i > 0 {
+			return items[:i], len(items) - i
+		}
+	}
+	return items, 0
+}
+
+// fieldOrDefault returns the configured field name, or the default when none is set
+func fieldOrDefault(field, defaultField string) string {
+	if field == "" {
+		return defaultField
+	}
+	return field
+}
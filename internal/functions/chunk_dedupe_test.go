@@ -0,0 +1,61 @@
+package functions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestDedupeChunks_KeepsHighestScoredOverlap(t *testing.T) {
+	result := `{"data": [
+		{"filePath": "docs/a.md", "startLine": 1, "endLine": 20, "score": 0.6},
+		{"filePath": "docs/a.md", "startLine": 15, "endLine": 30, "score": 0.9},
+		{"filePath": "docs/a.md", "startLine": 31, "endLine": 40, "score": 0.5},
+		{"filePath": "docs/b.md", "startLine": 1, "endLine": 20, "score": 0.4},
+		{"filePath": "docs/c.md", "score": 0.3}
+	]}`
+
+	output, duplicates, omitted := dedupeChunks(config.GenericToolChunkDedupeConfig{Enabled: true}, result)
+	assert.Equal(t, 1, duplicates)
+	assert.Equal(t, 0, omitted)
+
+	var parsed struct {
+		Data []struct {
+			FilePath  string `json:"filePath"`
+			StartLine int    `json:"startLine"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	assert.Len(t, parsed.Data, 4)
+	assert.Equal(t, 15, parsed.Data[0].StartLine)
+	assert.Equal(t, 31, parsed.Data[1].StartLine)
+	assert.Equal(t, "docs/b.md", parsed.Data[2].FilePath)
+}
+
+func TestDedupeChunks_CapsAtChunkBoundary(t *testing.T) {
+	result := `[{"content": "aaaaaaaaaa"}, {"content": "bbbbbbbbbb"}, {"content": "cccccccccc"}]`
+
+	output, duplicates, omitted := dedupeChunks(config.GenericToolChunkDedupeConfig{Enabled: true, MaxChars: 50}, result)
+	assert.Equal(t, 0, duplicates)
+	assert.Equal(t, 1, omitted)
+	assert.JSONEq(t, `{"data": [{"content": "aaaaaaaaaa"}, {"content": "bbbbbbbbbb"}],
+		"note": "1 more chunks omitted to keep the result within its size limit"}`, output)
+}
+
+func TestDedupeChunks_Unchanged(t *testing.T) {
+	cfg := config.GenericToolChunkDedupeConfig{Enabled: true, MaxChars: 1000}
+
+	for _, result := range []string{
+		"plain text result",
+		`{"data": []}`,
+		`{"data": [{"filePath": "a.md", "startLine": 1, "endLine": 5}, {"filePath": "a.md", "startLine": 6, "endLine": 9}]}`,
+	} {
+		output, duplicates, omitted := dedupeChunks(cfg, result)
+		assert.Equal(t, result, output)
+		assert.Zero(t, duplicates)
+		assert.Zero(t, omitted)
+	}
+}
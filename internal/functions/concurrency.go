@@ -0,0 +1,110 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// ErrToolBusy is returned when a tool stays at its concurrency limit for the whole wait time
+var ErrToolBusy = errors.New("tool is busy with other requests, try again later")
+
+// toolLimiter bounds the concurrent calls of one tool and tracks how many callers wait
+type toolLimiter struct {
+	slots   chan struct{}
+	waiting int
+}
+
+// toolLimiters holds the limiters of the tools with a concurrency limit
+type toolLimiters struct {
+	mu           sync.Mutex
+	limiters     map[string]*toolLimiter
+	onQueueDepth func(toolName string, depth int)
+}
+
+// SetQueueDepthObserver registers a callback receiving the number of calls waiting for each tool
+func (e *GenericToolExecutor) SetQueueDepthObserver(observer func(toolName string, depth int)) {
+	e.limiters.mu.Lock()
+	defer e.limiters.mu.Unlock()
+	e.limiters.onQueueDepth = observer
+}
+
+// acquireToolSlot waits for a free slot of the tool, up to the configured wait time.
+// The returned release func must be called when the call is done.
+func (e *GenericToolExecutor) acquireToolSlot(ctx context.Context, toolConfig config.GenericToolConfig) (func(), error) {
+	maxConcurrent := toolConfig.Concurrency.MaxConcurrent
+	if maxConcurrent <= 0 {
+		return func() {}, nil
+	}
+
+	limiter := e.limiters.get(toolConfig.Name, maxConcurrent)
+	release := func() { <-limiter.slots }
+
+	// Fast path without touching the queue depth
+	select {
+	case limiter.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	maxWait := toolConfig.Concurrency.MaxWaitMs
+	if maxWait <= 0 {
+		logger.WarnC(ctx, "tool at concurrency limit, rejecting call",
+			zap.String("tool", toolConfig.Name), zap.Int("maxConcurrent", maxConcurrent))
+		return nil, ErrToolBusy
+	}
+
+	e.limiters.changeWaiting(toolConfig.Name, limiter, 1)
+	defer e.limiters.changeWaiting(toolConfig.Name, limiter, -1)
+
+	logger.InfoC(ctx, "tool at concurrency limit, waiting for a slot",
+		zap.String("tool", toolConfig.Name), zap.Int("maxConcurrent", maxConcurrent))
+
+	timer := time.NewTimer(time.Duration(maxWait) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case limiter.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		logger.WarnC(ctx, "tool still at concurrency limit, rejecting call",
+			zap.String("tool", toolConfig.Name), zap.Int("maxConcurrent", maxConcurrent))
+		return nil, ErrToolBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// get returns the tool's limiter, creating it on first use
+func (l *toolLimiters) get(toolName string, maxConcurrent int) *toolLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limiters == nil {
+		l.limiters = make(map[string]*toolLimiter)
+	}
+	limiter, ok := l.limiters[toolName]
+	if !ok {
+		limiter = &toolLimiter{slots: make(chan struct{}, maxConcurrent)}
+		l.limiters[toolName] = limiter
+	}
+	return limiter
+}
+
+// changeWaiting adjusts the number of waiting calls and reports the new queue depth
+func (l *toolLimiters) changeWaiting(toolName string, limiter *toolLimiter, delta int) {
+	l.mu.Lock()
+	limiter.waiting += delta
+	depth := limiter.waiting
+	observer := l.onQueueDepth
+	l.mu.Unlock()
+
+	if observer != nil {
+		observer(toolName, depth)
+	}
+}
@@ -0,0 +1,84 @@
+package functions
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestAcquireToolSlot_Unlimited(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{})
+	release, err := executor.acquireToolSlot(context.Background(), config.GenericToolConfig{Name: "codebase_search"})
+	assert.NoError(t, err)
+	release()
+}
+
+func TestAcquireToolSlot_RejectsWithoutWait(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{})
+	toolConfig := config.GenericToolConfig{
+		Name:        "search_references",
+		Concurrency: config.GenericToolConcurrencyConfig{MaxConcurrent: 1},
+	}
+
+	release, err := executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.NoError(t, err)
+
+	_, err = executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.ErrorIs(t, err, ErrToolBusy)
+
+	release()
+	release, err = executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.NoError(t, err)
+	release()
+
+	// Other tools are not affected by the limit
+	_, err = executor.acquireToolSlot(context.Background(), config.GenericToolConfig{Name: "codebase_search"})
+	assert.NoError(t, err)
+}
+
+func TestAcquireToolSlot_WaitsAndReportsQueueDepth(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{})
+	var mu sync.Mutex
+	var depths []int
+	executor.SetQueueDepthObserver(func(toolName string, depth int) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "search_references", toolName)
+		depths = append(depths, depth)
+	})
+	toolConfig := config.GenericToolConfig{
+		Name:        "search_references",
+		Concurrency: config.GenericToolConcurrencyConfig{MaxConcurrent: 1, MaxWaitMs: 1000},
+	}
+
+	release, err := executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.NoError(t, err)
+	time.AfterFunc(20*time.Millisecond, release)
+
+	release, err = executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.NoError(t, err, "the waiting call gets the freed slot")
+	release()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{1, 0}, depths)
+}
+
+func TestAcquireToolSlot_WaitTimesOut(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{})
+	toolConfig := config.GenericToolConfig{
+		Name:        "search_references",
+		Concurrency: config.GenericToolConcurrencyConfig{MaxConcurrent: 1, MaxWaitMs: 20},
+	}
+
+	release, err := executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.NoError(t, err)
+	defer release()
+
+	_, err = executor.acquireToolSlot(context.Background(), toolConfig)
+	assert.ErrorIs(t, err, ErrToolBusy)
+}
@@ -0,0 +1,118 @@
+package functions
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// ContextShareLimiter is optionally implemented by executors that can keep tool results
+// within a share of the context window
+type ContextShareLimiter interface {
+	// ContextShareCap returns the tokens the results of the tool may take in a context window
+	// of windowTokens. ok is false when the tool has no cap.
+	ContextShareCap(toolName string, windowTokens int) (capTokens int, ok bool)
+	// TrimToTokens drops the lowest scored results until the result fits maxTokens as measured
+	// by countTokens. It returns the result and the number of dropped results; results
+	// without a result list are returned unchanged.
+	TrimToTokens(toolName string, result string, maxTokens int, countTokens func(string) int) (string, int)
+}
+
+// ContextShareCap Compute the token cap of a tool's results from its share of the context window
+func (e *GenericToolExecutor) ContextShareCap(toolName string, windowTokens int) (int, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.ContextShare.Enabled || windowTokens <= 0 {
+		return 0, false
+	}
+	share := toolConfig.ContextShare.MaxShare
+	if share <= 0 || share > 1 {
+		return 0, false
+	}
+	return int(float64(windowTokens) * share), true
+}
+
+// TrimToTokens Drop the lowest scored results of a tool output until it fits the token budget
+func (e *GenericToolExecutor) TrimToTokens(toolName string, result string, maxTokens int,
+	countTokens func(string) int) (string, int) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil {
+		return result, 0
+	}
+	return trimResultsToTokens(toolConfig.ContextShare, result, maxTokens, countTokens)
+}
+
+// trimResultsToTokens removes result items, lowest score first and later items first among
+// equal scores, keeping the envelope of the result list intact
+func trimResultsToTokens(cfg config.GenericToolContextShareConfig, result string, maxTokens int,
+	countTokens func(string) int) (string, int) {
+	if countTokens(result) <= maxTokens {
+		return result, 0
+	}
+
+	prefix, body := splitBroadenedHeader(result)
+	var data interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &data); err != nil {
+		return result, 0
+	}
+	items, ok := findResultList(data)
+	if !ok || len(items) == 0 {
+		return result, 0
+	}
+
+	scoreField := cfg.ScoreField
+	if scoreField == "" {
+		scoreField = defaultScoreField
+	}
+	order := make([]int, len(items))
+	scores := make([]float64, len(items))
+	for i, item := range items {
+		order[i] = i
+		if fields, ok := item.(map[string]interface{}); ok {
+			scores[i], _ = toFloat(fields[scoreField])
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if scores[order[i]] != scores[order[j]] {
+			return scores[order[i]] < scores[order[j]]
+		}
+		return order[i] > order[j]
+	})
+
+	dropped := make(map[int]bool, len(items))
+	output := result
+	for _, index := range order {
+		dropped[index] = true
+		kept := make([]interface{}, 0, len(items)-len(dropped))
+		for i, item := range items {
+			if !dropped[i] {
+				kept = append(kept, item)
+			}
+		}
+		encoded, err := json.Marshal(replaceResultList(data, kept))
+		if err != nil {
+			return result, 0
+		}
+		output = prefix + string(encoded)
+		if countTokens(output) <= maxTokens {
+			break
+		}
+	}
+	return output, len(dropped)
+}
+
+// replaceResultList puts items in place of the result list found by findResultList
+func replaceResultList(v interface{}, items []interface{}) interface{} {
+	val, ok := v.(map[string]interface{})
+	if !ok {
+		return items
+	}
+	for _, key := range []string{"data", "list", "results", "items"} {
+		if inner, ok := val[key]; ok {
+			val[key] = replaceResultList(inner, items)
+			return val
+		}
+	}
+	return val
+}
@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestContextShare(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search", ContextShare: config.GenericToolContextShareConfig{Enabled: true, MaxShare: 0.25}},
+			{Name: "search_files"},
+		},
+	})
+
+	capTokens, ok := executor.ContextShareCap("codebase_search", 1000)
+	assert.True(t, ok)
+	assert.Equal(t, 250, capTokens)
+	_, ok = executor.ContextShareCap("codebase_search", 0)
+	assert.False(t, ok, "no cap without a context window")
+	_, ok = executor.ContextShareCap("search_files", 1000)
+	assert.False(t, ok, "cap is disabled for the tool")
+
+	// One token per byte keeps the budget easy to follow
+	countBytes := func(s string) int { return len(s) }
+	result := `{"data":{"list":[{"content":"aaaa","score":0.9},{"content":"bbbb","score":0.2},{"content":"cccc","score":0.5}]}}`
+
+	trimmed, dropped := executor.TrimToTokens("codebase_search", result, len(result), countBytes)
+	assert.Equal(t, result, trimmed, "results within the budget are kept")
+	assert.Equal(t, 0, dropped)
+
+	trimmed, dropped = executor.TrimToTokens("codebase_search", result, 70, countBytes)
+	assert.Equal(t, `{"data":{"list":[{"content":"aaaa","score":0.9}]}}`, trimmed, "lowest scores go first")
+	assert.Equal(t, 2, dropped)
+
+	trimmed, dropped = executor.TrimToTokens("codebase_search", BroadenedResultPrefix+result, 0, countBytes)
+	assert.Equal(t, BroadenedResultPrefix+`{"data":{"list":[]}}`, trimmed)
+	assert.Equal(t, 3, dropped)
+
+	trimmed, dropped = executor.TrimToTokens("codebase_search", "plain text result", 1, countBytes)
+	assert.Equal(t, "plain text result", trimmed, "results without a list are not trimmed")
+	assert.Equal(t, 0, dropped)
+}
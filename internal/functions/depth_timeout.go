@@ -0,0 +1,41 @@
+package functions
+
+import (
+	"time"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// defaultDepthTimeoutMinMs keeps a decreasing timeout from reaching zero in deep chains
+const defaultDepthTimeoutMinMs = 1000
+
+// DepthTimeout returns the timeout of a tool call at the given depth of the tool call chain,
+// 0 when no timeout is configured. Unknown modes are treated as constant.
+func DepthTimeout(cfg config.ToolDepthTimeoutConfig, depth int) time.Duration {
+	if cfg.BaseMs <= 0 {
+		return 0
+	}
+	if depth < 0 {
+		depth = 0
+	}
+
+	timeoutMs := cfg.BaseMs
+	switch cfg.Mode {
+	case config.ToolTimeoutIncreasing:
+		timeoutMs += cfg.StepMs * depth
+	case config.ToolTimeoutDecreasing:
+		timeoutMs -= cfg.StepMs * depth
+	}
+
+	minMs := cfg.MinMs
+	if minMs <= 0 {
+		minMs = defaultDepthTimeoutMinMs
+	}
+	if timeoutMs < minMs {
+		timeoutMs = minMs
+	}
+	if cfg.MaxMs > 0 && timeoutMs > cfg.MaxMs {
+		timeoutMs = cfg.MaxMs
+	}
+	return time.Duration(timeoutMs) * time.Millisecond
+}
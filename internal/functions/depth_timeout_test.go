@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestDepthTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.ToolDepthTimeoutConfig
+		depth    int
+		expected time.Duration
+	}{
+		{"not configured", config.ToolDepthTimeoutConfig{Mode: config.ToolTimeoutIncreasing}, 3, 0},
+		{"constant", config.ToolDepthTimeoutConfig{BaseMs: 4000, StepMs: 1000}, 3, 4 * time.Second},
+		{"unknown mode", config.ToolDepthTimeoutConfig{Mode: "random", BaseMs: 4000, StepMs: 1000}, 3, 4 * time.Second},
+		{"increasing", config.ToolDepthTimeoutConfig{Mode: config.ToolTimeoutIncreasing, BaseMs: 4000, StepMs: 1000},
+			2, 6 * time.Second},
+		{"increasing capped", config.ToolDepthTimeoutConfig{Mode: config.ToolTimeoutIncreasing, BaseMs: 4000,
+			StepMs: 1000, MaxMs: 5000}, 5, 5 * time.Second},
+		{"decreasing", config.ToolDepthTimeoutConfig{Mode: config.ToolTimeoutDecreasing, BaseMs: 4000, StepMs: 1000},
+			1, 3 * time.Second},
+		{"decreasing floored", config.ToolDepthTimeoutConfig{Mode: config.ToolTimeoutDecreasing, BaseMs: 4000,
+			StepMs: 1000}, 5, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DepthTimeout(tt.cfg, tt.depth))
+		})
+	}
+}
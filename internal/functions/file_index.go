@@ -0,0 +1,89 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+const (
+	defaultFileIndexPathField = "filePath"
+	defaultFileIndexMaxFiles  = 50
+	defaultFileIndexMinFiles  = 2
+)
+
+// FileIndexer is optionally implemented by executors that can list the files of a tool result
+type FileIndexer interface {
+	// FileIndex returns a compact list of the files in the result with their result counts and
+	// the number of files. ok is false when the index is disabled or the result has too few files.
+	FileIndex(toolName string, result string) (index string, files int, ok bool)
+}
+
+// FileIndex Build the file index of a tool result according to the tool's configuration
+func (e *GenericToolExecutor) FileIndex(toolName string, result string) (string, int, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.FileIndex.Enabled {
+		return "", 0, false
+	}
+
+	var data interface{}
+	trimmed := strings.TrimSpace(broadenedBody(result))
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return "", 0, false
+	}
+	items, ok := findResultList(data)
+	if !ok {
+		return "", 0, false
+	}
+	return buildFileIndex(items, toolConfig.FileIndex)
+}
+
+// buildFileIndex lists the distinct file paths of the result items in order of first
+// appearance, which keeps the most relevant files on top, with the number of results each
+func buildFileIndex(items []interface{}, cfg config.GenericToolFileIndexConfig) (string, int, bool) {
+	pathField := cfg.PathField
+	if pathField == "" {
+		pathField = defaultFileIndexPathField
+	}
+	maxFiles := cfg.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultFileIndexMaxFiles
+	}
+	minFiles := cfg.MinFiles
+	if minFiles <= 0 {
+		minFiles = defaultFileIndexMinFiles
+	}
+
+	var paths []string
+	counts := make(map[string]int)
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, ok := fields[pathField].(string)
+		if !ok || path == "" {
+			continue
+		}
+		if counts[path] == 0 {
+			paths = append(paths, path)
+		}
+		counts[path]++
+	}
+	if len(paths) < minFiles {
+		return "", 0, false
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Files in these results (%d):\n", len(paths))
+	for i, path := range paths {
+		if i == maxFiles {
+			fmt.Fprintf(&sb, "- ... and %d more files\n", len(paths)-maxFiles)
+			break
+		}
+		fmt.Fprintf(&sb, "- %s (%d)\n", path, counts[path])
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), len(paths), true
+}
@@ -0,0 +1,41 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestFileIndex(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search", FileIndex: config.GenericToolFileIndexConfig{Enabled: true, MaxFiles: 2}},
+			{Name: "search_files"},
+		},
+	})
+	result := `{"data":{"list":[
+		{"filePath":"auth/login.go","content":"func Login()"},
+		{"filePath":"auth/token.go","content":"func Parse()"},
+		{"filePath":"auth/login.go","content":"func Logout()"},
+		{"filePath":"api/routes.go","content":"router.POST"}
+	]}}`
+
+	index, files, ok := executor.FileIndex("codebase_search", result)
+	assert.True(t, ok)
+	assert.Equal(t, 3, files)
+	assert.Equal(t, "Files in these results (3):\n- auth/login.go (2)\n- auth/token.go (1)\n- ... and 1 more files", index)
+
+	_, _, ok = executor.FileIndex("codebase_search", BroadenedResultPrefix+result)
+	assert.True(t, ok, "broadened results are indexed too")
+
+	_, _, ok = executor.FileIndex("search_files", result)
+	assert.False(t, ok, "index is disabled for the tool")
+
+	_, _, ok = executor.FileIndex("codebase_search", `{"data":[{"filePath":"auth/login.go"},{"filePath":"auth/login.go"}]}`)
+	assert.False(t, ok, "a single file needs no index")
+
+	_, _, ok = executor.FileIndex("codebase_search", "no results")
+	assert.False(t, ok)
+}
@@ -0,0 +1,69 @@
+package functions
+
+import (
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// FunctionDefiner is optionally implemented by executors that can describe their tools as
+// OpenAI-style function definitions, for models calling tools natively instead of with XML tags
+type FunctionDefiner interface {
+	// ToolFunctions returns the function definitions of the named tools, unknown tools are skipped
+	ToolFunctions(toolNames []string) []types.Function
+}
+
+// ToolFunctions Describe the tools as functions whose parameters are the ones the model provides
+func (e *GenericToolExecutor) ToolFunctions(toolNames []string) []types.Function {
+	functions := make([]types.Function, 0, len(toolNames))
+	for _, name := range toolNames {
+		toolConfig, err := e.findToolConfig(name)
+		if err != nil {
+			continue
+		}
+
+		parameters := types.FunctionParameters{
+			Type:       "object",
+			Properties: make(map[string]types.PropertyDetails),
+			Required:   []string{},
+		}
+		for _, param := range toolConfig.Parameters {
+			if param.Source != config.ParameterSourceLLM {
+				continue
+			}
+			property := types.PropertyDetails{
+				Type:        functionParamType(param.Type),
+				Description: param.Description,
+				Default:     param.Default,
+			}
+			if property.Type == string(config.ParameterTypeArray) {
+				property.Items = &types.Items{Type: string(config.ParameterTypeString)}
+			}
+			parameters.Properties[param.Name] = property
+			if param.Required {
+				parameters.Required = append(parameters.Required, param.Name)
+			}
+		}
+
+		functions = append(functions, types.Function{
+			Type: "function",
+			Function: types.FunctionDefinition{
+				Name:        toolConfig.Name,
+				Description: toolConfig.Description,
+				Parameters:  parameters,
+			},
+		})
+	}
+	return functions
+}
+
+// functionParamType maps a tool parameter type to its JSON schema type, strings by default
+func functionParamType(paramType string) string {
+	switch config.ParameterType(paramType) {
+	case config.ParameterTypeInteger, config.ParameterTypeBoolean, config.ParameterTypeArray:
+		return paramType
+	case config.ParameterTypeFloat:
+		return "number"
+	default:
+		return string(config.ParameterTypeString)
+	}
+}
@@ -0,0 +1,90 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	defaultIndexTimestampField = "indexedAt"
+	defaultIndexMaxAgeSec      = 86400
+)
+
+// IndexAgeChecker is optionally implemented by executors that can tell how old the searched index is
+type IndexAgeChecker interface {
+	// IndexAge returns the age of the index that produced the result at the given time and whether
+	// it exceeds the configured maximum. ok is false when the check is disabled or no timestamp is reported.
+	IndexAge(toolName string, result string, now time.Time) (age time.Duration, stale bool, ok bool)
+}
+
+// IndexAge Check the index timestamp reported in a tool result against the configured maximum age
+func (e *GenericToolExecutor) IndexAge(toolName string, result string, now time.Time) (time.Duration, bool, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.IndexAge.Enabled {
+		return 0, false, false
+	}
+
+	field := toolConfig.IndexAge.TimestampField
+	if field == "" {
+		field = defaultIndexTimestampField
+	}
+	indexedAt, ok := findIndexTimestamp(result, field)
+	if !ok {
+		return 0, false, false
+	}
+
+	maxAgeSec := toolConfig.IndexAge.MaxAgeSec
+	if maxAgeSec <= 0 {
+		maxAgeSec = defaultIndexMaxAgeSec
+	}
+
+	age := now.Sub(indexedAt)
+	if age < 0 {
+		age = 0
+	}
+	return age, age > time.Duration(maxAgeSec)*time.Second, true
+}
+
+// findIndexTimestamp reads the index time from the top level or the "data" object of a JSON result
+func findIndexTimestamp(result string, field string) (time.Time, bool) {
+	trimmed := strings.TrimSpace(broadenedBody(result))
+	if !strings.HasPrefix(trimmed, "{") {
+		return time.Time{}, false
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return time.Time{}, false
+	}
+
+	value, exists := data[field]
+	if !exists {
+		if inner, ok := data["data"].(map[string]interface{}); ok {
+			value, exists = inner[field]
+		}
+	}
+	if !exists {
+		return time.Time{}, false
+	}
+	return parseIndexTimestamp(value)
+}
+
+// parseIndexTimestamp accepts RFC3339 strings and unix timestamps in seconds or milliseconds
+func parseIndexTimestamp(value interface{}) (time.Time, bool) {
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+	}
+
+	ts, ok := toFloat(value)
+	if !ok || ts <= 0 {
+		return time.Time{}, false
+	}
+	// Values this large can only be milliseconds
+	if ts > 1e12 {
+		return time.UnixMilli(int64(ts)), true
+	}
+	return time.Unix(int64(ts), 0), true
+}
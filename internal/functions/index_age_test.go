@@ -0,0 +1,72 @@
+package functions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestGenericToolExecutor_IndexAge(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search", IndexAge: config.GenericToolIndexAgeConfig{Enabled: true, MaxAgeSec: 3600}},
+			{Name: "knowledge_base_search"},
+		},
+	})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		tool        string
+		result      string
+		expectOK    bool
+		expectStale bool
+		expectAge   time.Duration
+	}{
+		{
+			name:      "fresh RFC3339 timestamp",
+			tool:      "codebase_search",
+			result:    `{"indexedAt": "2025-06-01T11:30:00Z", "data": []}`,
+			expectOK:  true,
+			expectAge: 30 * time.Minute,
+		},
+		{
+			name:        "stale unix seconds under data",
+			tool:        "codebase_search",
+			result:      `{"data": {"indexedAt": 1748692800, "list": []}}`,
+			expectOK:    true,
+			expectStale: true,
+			expectAge:   24 * time.Hour,
+		},
+		{
+			name:        "unix milliseconds",
+			tool:        "codebase_search",
+			result:      `{"indexedAt": 1748692800000}`,
+			expectOK:    true,
+			expectStale: true,
+			expectAge:   24 * time.Hour,
+		},
+		{
+			name:   "no timestamp reported",
+			tool:   "codebase_search",
+			result: `{"data": []}`,
+		},
+		{
+			name:   "check disabled for tool",
+			tool:   "knowledge_base_search",
+			result: `{"indexedAt": 1748692800}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, stale, ok := executor.IndexAge(tt.tool, tt.result, now)
+			assert.Equal(t, tt.expectOK, ok)
+			assert.Equal(t, tt.expectStale, stale)
+			assert.Equal(t, tt.expectAge, age)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+const (
+	defaultJSONOutputPathField      = "filePath"
+	defaultJSONOutputContentField   = "content"
+	defaultJSONOutputStartLineField = "startLine"
+	defaultJSONOutputEndLineField   = "endLine"
+)
+
+// jsonOutputItem is one search result in the JSON output format
+type jsonOutputItem struct {
+	FilePath  string  `json:"filePath"`
+	Score     float64 `json:"score"`
+	Content   string  `json:"content"`
+	StartLine int     `json:"startLine"`
+	EndLine   int     `json:"endLine"`
+}
+
+// formatOutput converts the result to the output format of the tool. Results without a
+// result list, such as no-results messages, are returned unchanged.
+func formatOutput(toolConfig config.GenericToolConfig, result string) string {
+	if toolConfig.OutputFormat != config.ToolOutputJSON {
+		return result
+	}
+
+	prefix, body := splitBroadenedHeader(result)
+	var data interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &data); err != nil {
+		return result
+	}
+	items, ok := findResultList(data)
+	if !ok {
+		return result
+	}
+
+	cfg := toolConfig.JSONOutput
+	pathField := fieldOrDefault(cfg.PathField, defaultJSONOutputPathField)
+	scoreField := fieldOrDefault(cfg.ScoreField, defaultScoreField)
+	contentField := fieldOrDefault(cfg.ContentField, defaultJSONOutputContentField)
+	startField := fieldOrDefault(cfg.StartLineField, defaultJSONOutputStartLineField)
+	endField := fieldOrDefault(cfg.EndLineField, defaultJSONOutputEndLineField)
+
+	output := make([]jsonOutputItem, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := fields[pathField].(string)
+		content, _ := fields[contentField].(string)
+		score, _ := toFloat(fields[scoreField])
+		start, _ := toFloat(fields[startField])
+		end, _ := toFloat(fields[endField])
+		output = append(output, jsonOutputItem{
+			FilePath:  path,
+			Score:     score,
+			Content:   content,
+			StartLine: int(start),
+			EndLine:   int(end),
+		})
+	}
+
+	encoded, err := utils.MarshalJSONWithoutEscapeHTML(output)
+	if err != nil {
+		return result
+	}
+	return prefix + encoded
+}
@@ -0,0 +1,43 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestFormatOutput(t *testing.T) {
+	result := `{"code":0,"data":{"list":[` +
+		`{"filePath":"a/auth.go","score":0.92,"content":"func Login() <T>","startLine":10,"endLine":24},` +
+		`{"file":"b/user.go","relevance":0.5,"snippet":"type User struct{}","startLine":3}]}}`
+
+	t.Run("text keeps the backend result", func(t *testing.T) {
+		assert.Equal(t, result, formatOutput(config.GenericToolConfig{}, result))
+		assert.Equal(t, result, formatOutput(config.GenericToolConfig{OutputFormat: config.ToolOutputText}, result))
+	})
+
+	t.Run("json with default fields", func(t *testing.T) {
+		toolConfig := config.GenericToolConfig{OutputFormat: config.ToolOutputJSON}
+		assert.Equal(t,
+			`[{"filePath":"a/auth.go","score":0.92,"content":"func Login() <T>","startLine":10,"endLine":24},`+
+				`{"filePath":"","score":0,"content":"","startLine":3,"endLine":0}]`,
+			formatOutput(toolConfig, result))
+	})
+
+	t.Run("json with mapped fields", func(t *testing.T) {
+		toolConfig := config.GenericToolConfig{
+			OutputFormat: config.ToolOutputJSON,
+			JSONOutput:   config.GenericToolJSONOutputConfig{PathField: "file", ScoreField: "relevance", ContentField: "snippet"},
+		}
+		assert.Contains(t, formatOutput(toolConfig, result),
+			`{"filePath":"b/user.go","score":0.5,"content":"type User struct{}","startLine":3,"endLine":0}`)
+	})
+
+	t.Run("json keeps the broadened prefix and unlisted results", func(t *testing.T) {
+		toolConfig := config.GenericToolConfig{OutputFormat: config.ToolOutputJSON}
+		assert.Equal(t, BroadenedResultPrefix+"[]", formatOutput(toolConfig, BroadenedResultPrefix+`{"data":[]}`))
+		assert.Equal(t, NoResultsMessage, formatOutput(toolConfig, NoResultsMessage))
+	})
+}
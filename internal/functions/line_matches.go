@@ -0,0 +1,84 @@
+package functions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+const (
+	defaultLineMatchPatternParam = "regex"
+	defaultLineMatchMax          = 50
+	defaultLineMatchPathField    = "filePath"
+	defaultLineMatchLineField    = "lineNumber"
+	defaultLineMatchTextField    = "content"
+)
+
+// checkLinePattern rejects a regex the backend could not compile, so the model gets a
+// correctable message instead of a backend error
+func checkLinePattern(cfg config.GenericToolLineMatchesConfig, params map[string]interface{}) error {
+	param := fieldOrDefault(cfg.PatternParam, defaultLineMatchPatternParam)
+	pattern, ok := params[param].(string)
+	if !ok {
+		return nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("parameter %s is not a valid regular expression: %w", param, err)
+	}
+	return nil
+}
+
+// lineMatchLimit returns the number of matches listed in the result
+func lineMatchLimit(cfg config.GenericToolLineMatchesConfig) int {
+	if cfg.MaxMatches > 0 {
+		return cfg.MaxMatches
+	}
+	return defaultLineMatchMax
+}
+
+// formatLineMatches renders the match list of a JSON result as "path:line: text" lines, at most
+// the configured number, noting how many more were found. Results without a match list are
+// returned unchanged.
+func formatLineMatches(cfg config.GenericToolLineMatchesConfig, result string) string {
+	items, err := parseResultList(result)
+	if err != nil {
+		return result
+	}
+	if len(items) == 0 {
+		return NoResultsMessage
+	}
+
+	pathField := fieldOrDefault(cfg.PathField, defaultLineMatchPathField)
+	lineField := fieldOrDefault(cfg.LineField, defaultLineMatchLineField)
+	textField := fieldOrDefault(cfg.TextField, defaultLineMatchTextField)
+	limit := lineMatchLimit(cfg)
+
+	var sb strings.Builder
+	listed := 0
+	for _, item := range items {
+		if listed == limit {
+			break
+		}
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _ := fields[pathField].(string)
+		text, _ := fields[textField].(string)
+		if line, ok := toFloat(fields[lineField]); ok {
+			fmt.Fprintf(&sb, "%s:%d: %s\n", path, int(line), strings.TrimRight(text, "\r\n"))
+		} else {
+			fmt.Fprintf(&sb, "%s: %s\n", path, strings.TrimRight(text, "\r\n"))
+		}
+		listed++
+	}
+	if listed == 0 {
+		return result
+	}
+	if more := len(items) - listed; more > 0 && listed == limit {
+		fmt.Fprintf(&sb, "(%d more matches not shown, narrow the pattern or path to see them)\n", more)
+	}
+	return sb.String()
+}
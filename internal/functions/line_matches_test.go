@@ -0,0 +1,40 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestCheckLinePattern(t *testing.T) {
+	cfg := config.GenericToolLineMatchesConfig{Enabled: true}
+
+	assert.NoError(t, checkLinePattern(cfg, map[string]interface{}{"regex": `func\s+Execute`}))
+	assert.NoError(t, checkLinePattern(cfg, map[string]interface{}{"path": "internal"}))
+	assert.ErrorContains(t, checkLinePattern(cfg, map[string]interface{}{"regex": `func(`}),
+		"parameter regex is not a valid regular expression")
+}
+
+func TestFormatLineMatches(t *testing.T) {
+	cfg := config.GenericToolLineMatchesConfig{Enabled: true, MaxMatches: 2}
+	result := `{"data": [
+		{"filePath": "internal/a.go", "lineNumber": 12, "content": "func Execute() {\n"},
+		{"filePath": "internal/b.go", "lineNumber": 40, "content": "\treturn Execute()"},
+		{"filePath": "internal/c.go", "lineNumber": 7, "content": "Execute"}
+	]}`
+
+	assert.Equal(t, "internal/a.go:12: func Execute() {\n"+
+		"internal/b.go:40: \treturn Execute()\n"+
+		"(1 more matches not shown, narrow the pattern or path to see them)\n",
+		formatLineMatches(cfg, result))
+}
+
+func TestFormatLineMatches_Passthrough(t *testing.T) {
+	cfg := config.GenericToolLineMatchesConfig{Enabled: true}
+
+	assert.Equal(t, NoResultsMessage, formatLineMatches(cfg, `{"data": []}`))
+	assert.Equal(t, "plain output", formatLineMatches(cfg, "plain output"))
+	assert.Equal(t, `{"data": ["a", "b"]}`, formatLineMatches(cfg, `{"data": ["a", "b"]}`))
+}
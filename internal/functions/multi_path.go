@@ -0,0 +1,220 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	defaultMultiPathParam = "path"
+	defaultMaxPaths       = 5
+)
+
+// multiPathResult is the merged output of a multi-path search
+type multiPathResult struct {
+	Results          []interface{}  `json:"results"`
+	PathResultCounts map[string]int `json:"pathResultCounts"`
+}
+
+// PathResultCounts returns the per-path result counts of a multi-path search output,
+// nil for any other output
+func PathResultCounts(result string) map[string]int {
+	if !strings.Contains(result, `"pathResultCounts"`) {
+		return nil
+	}
+	var merged struct {
+		PathResultCounts map[string]int `json:"pathResultCounts"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result)), &merged); err != nil {
+		return nil
+	}
+	return merged.PathResultCounts
+}
+
+// multiPathValues returns the distinct values of the repeated path parameter, capped at MaxPaths
+func (e *GenericToolExecutor) multiPathValues(toolConfig config.GenericToolConfig, content string, osType string) []string {
+	pathParam := toolConfig.MultiPath.PathParam
+	if pathParam == "" {
+		pathParam = defaultMultiPathParam
+	}
+	maxPaths := toolConfig.MultiPath.MaxPaths
+	if maxPaths <= 0 {
+		maxPaths = defaultMaxPaths
+	}
+
+	toolContent, err := extractXmlParam(content, toolConfig.Name)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, value := range extractXmlParamAll(toolContent, pathParam) {
+		value = e.parameterParser.processPathParameter(strings.TrimSpace(value), osType)
+		if value == "" || slices.Contains(paths, value) {
+			continue
+		}
+		if len(paths) == maxPaths {
+			logger.Warn("too many paths in multi-path search, extra paths ignored",
+				zap.String("tool", toolConfig.Name), zap.Int("maxPaths", maxPaths))
+			break
+		}
+		paths = append(paths, value)
+	}
+	return paths
+}
+
+// executeMultiPath runs one request per path concurrently and merges the result lists by score
+func (e *GenericToolExecutor) executeMultiPath(
+	ctx context.Context,
+	toolClient client.GenericClientInterface,
+	toolConfig config.GenericToolConfig,
+	params map[string]interface{},
+	paths []string,
+) (string, error) {
+	pathParam := toolConfig.MultiPath.PathParam
+	if pathParam == "" {
+		pathParam = defaultMultiPathParam
+	}
+
+	type pathResult struct {
+		items []interface{}
+		err   error
+	}
+	results := make([]pathResult, len(paths))
+
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		pathParams := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			pathParams[k] = v
+		}
+		pathParams[pathParam] = path
+
+		wg.Add(1)
+		go func(i int, pathParams map[string]interface{}) {
+			defer wg.Done()
+			output, err := toolClient.Execute(ctx, pathParams)
+			e.recordToolHealth(ctx, toolConfig, err)
+			if err == nil {
+				err = validateResult(toolConfig, output)
+			}
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			items, err := parseResultList(output)
+			results[i] = pathResult{items: items, err: err}
+		}(i, pathParams)
+	}
+	wg.Wait()
+
+	merged := multiPathResult{PathResultCounts: make(map[string]int, len(paths))}
+	var firstErr error
+	for i, path := range paths {
+		if results[i].err != nil {
+			logger.WarnC(ctx, "multi-path search failed for path",
+				zap.String("tool", toolConfig.Name), zap.String("path", path), zap.Error(results[i].err))
+			if firstErr == nil {
+				firstErr = results[i].err
+			}
+			continue
+		}
+		merged.PathResultCounts[path] = len(results[i].items)
+		merged.Results = append(merged.Results, results[i].items...)
+	}
+	if len(merged.PathResultCounts) == 0 {
+		return "", fmt.Errorf("tool execution failed for all %d paths: %w", len(paths), firstErr)
+	}
+
+	scoreField := toolConfig.MultiPath.ScoreField
+	if scoreField == "" {
+		scoreField = defaultScoreField
+	}
+	sortByScore(merged.Results, scoreField)
+	if maxResults := toolConfig.MultiPath.MaxResults; maxResults > 0 && len(merged.Results) > maxResults {
+		merged.Results = merged.Results[:maxResults]
+	}
+	if merged.Results == nil {
+		merged.Results = []interface{}{}
+	}
+
+	logger.InfoC(ctx, "multi-path search completed",
+		zap.String("tool", toolConfig.Name),
+		zap.Any("pathResultCounts", merged.PathResultCounts),
+		zap.Int("mergedResults", len(merged.Results)))
+
+	output, err := json.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal merged results: %w", err)
+	}
+	return string(output), nil
+}
+
+// parseResultList parses the result list out of a JSON tool output
+func parseResultList(output string) ([]interface{}, error) {
+	trimmed := strings.TrimSpace(output)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse tool output: %w", err)
+	}
+	items, ok := findResultList(data)
+	if !ok {
+		return nil, fmt.Errorf("tool output has no result list")
+	}
+	return items, nil
+}
+
+// sortByScore orders result items by descending score, items without a score go last
+func sortByScore(items []interface{}, scoreField string) {
+	score := func(item interface{}) (float64, bool) {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		return toFloat(fields[scoreField])
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		si, iok := score(items[i])
+		sj, jok := score(items[j])
+		if iok != jok {
+			return iok
+		}
+		return si > sj
+	})
+}
+
+// extractXmlParamAll returns the values of every occurrence of the parameter tag
+func extractXmlParamAll(content, paramName string) []string {
+	startTag := "<" + paramName + ">"
+	endTag := "</" + paramName + ">"
+
+	var values []string
+	for {
+		start := strings.Index(content, startTag)
+		if start == -1 {
+			return values
+		}
+		content = content[start+len(startTag):]
+		end := strings.Index(content, endTag)
+		if end == -1 {
+			return values
+		}
+		values = append(values, strings.ReplaceAll(content[:end], "\\\\", "\\"))
+		content = content[end+len(endTag):]
+	}
+}
@@ -0,0 +1,114 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// pathClient returns canned results keyed by the request's path parameter
+type pathClient struct {
+	results map[string]string
+}
+
+func (c *pathClient) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	path, _ := params["path"].(string)
+	result, ok := c.results[path]
+	if !ok {
+		return "", fmt.Errorf("unknown path %s", path)
+	}
+	return result, nil
+}
+
+func (c *pathClient) CheckReady(ctx context.Context, params map[string]interface{}) (bool, error) {
+	return true, nil
+}
+
+func TestMultiPathValues(t *testing.T) {
+	executor := &GenericToolExecutor{parameterParser: NewGenericParameterParser()}
+	toolConfig := config.GenericToolConfig{
+		Name:      "codebase_search",
+		MultiPath: config.GenericToolMultiPathConfig{Enabled: true, MaxPaths: 2},
+	}
+	content := "<codebase_search><query>auth</query><path>svc/a</path><path>svc/a</path>" +
+		"<path>svc/b</path><path>svc/c</path></codebase_search>"
+
+	assert.Equal(t, []string{"svc/a", "svc/b"}, executor.multiPathValues(toolConfig, content, "linux"))
+}
+
+func TestExecuteMultiPath_MergesByScore(t *testing.T) {
+	executor := &GenericToolExecutor{parameterParser: NewGenericParameterParser()}
+	toolConfig := config.GenericToolConfig{
+		Name:      "codebase_search",
+		MultiPath: config.GenericToolMultiPathConfig{Enabled: true, MaxResults: 3},
+	}
+	toolClient := &pathClient{results: map[string]string{
+		"svc/a": `{"data": [{"file": "a1", "score": 0.5}, {"file": "a2", "score": 0.9}]}`,
+		"svc/b": `{"data": [{"file": "b1", "score": 0.7}, {"file": "b2", "score": 0.1}]}`,
+	}}
+
+	output, err := executor.executeMultiPath(context.Background(), toolClient, toolConfig,
+		map[string]interface{}{"query": "auth"}, []string{"svc/a", "svc/b", "svc/missing"})
+	assert.NoError(t, err)
+
+	var merged multiPathResult
+	assert.NoError(t, json.Unmarshal([]byte(output), &merged))
+	var files []string
+	for _, item := range merged.Results {
+		files = append(files, item.(map[string]interface{})["file"].(string))
+	}
+	assert.Equal(t, []string{"a2", "b1", "a1"}, files)
+	assert.Equal(t, map[string]int{"svc/a": 2, "svc/b": 2}, merged.PathResultCounts)
+	assert.Equal(t, merged.PathResultCounts, PathResultCounts(output))
+}
+
+func TestExecuteMultiPath_AllPathsFail(t *testing.T) {
+	executor := &GenericToolExecutor{parameterParser: NewGenericParameterParser()}
+	toolConfig := config.GenericToolConfig{Name: "codebase_search"}
+
+	_, err := executor.executeMultiPath(context.Background(), &pathClient{}, toolConfig,
+		map[string]interface{}{}, []string{"svc/a", "svc/b"})
+	assert.Error(t, err)
+}
+
+func TestExecuteTools_MultiPathSharesPostProcessing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		score := "0.5"
+		if path, _ := params["path"].(string); strings.HasSuffix(path, "a") {
+			score = "0.9"
+		}
+		// Both paths return overlapping chunks of the same file
+		fmt.Fprintf(w, `{"data": [{"filePath": "svc/auth.go", "startLine": 1, "endLine": 20, "score": %s}]}`, score)
+	}))
+	defer server.Close()
+
+	executor := NewGenericToolExecutor(&config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+		Name:      "codebase_search",
+		Method:    http.MethodPost,
+		Endpoints: config.GenericToolEndpoints{Search: server.URL, Ready: server.URL},
+		Parameters: []config.GenericToolParameter{
+			{Name: "query", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+			{Name: "path", Type: "string", Source: config.ParameterSourceLLM},
+		},
+		MultiPath:   config.GenericToolMultiPathConfig{Enabled: true},
+		ChunkDedupe: config.GenericToolChunkDedupeConfig{Enabled: true},
+	}}})
+	ctx := context.WithValue(context.Background(), model.IdentityContextKey, &model.Identity{})
+	content := "<codebase_search><query>auth</query><path>svc/a</path><path>svc/b</path></codebase_search>"
+
+	result, err := executor.ExecuteTools(ctx, "codebase_search", content)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(result, `"filePath"`), "the merged result is deduplicated")
+	assert.Contains(t, result, `"score":0.9`)
+}
@@ -0,0 +1,49 @@
+package functions
+
+import (
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// hasParamRange reports whether bounds are configured for the parameter
+func hasParamRange(param config.GenericToolParameter) bool {
+	return param.Min != nil || param.Max != nil
+}
+
+// rangedParamValue reads a numeric parameter from the model and clamps it to the configured
+// bounds, so e.g. a topK of 500 becomes the maximum instead of reaching the backend.
+// Parameters of other types are converted unchanged.
+func (p *GenericParameterParser) rangedParamValue(param config.GenericToolParameter, toolContent string) (interface{}, error) {
+	if config.ParameterType(strings.ToLower(param.Type)) == config.ParameterTypeFloat {
+		value, err := extractXmlFloatParam(toolContent, param.Name)
+		if err != nil {
+			return nil, err
+		}
+		return clampParam(param, value), nil
+	}
+
+	value, err := extractXmlParam(toolContent, param.Name)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := p.ConvertParameterType(strings.TrimSpace(value), param.Type)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := converted.(int); ok {
+		return int(clampParam(param, float64(v))), nil
+	}
+	return converted, nil
+}
+
+// clampParam limits value to the parameter's bounds
+func clampParam(param config.GenericToolParameter, value float64) float64 {
+	if param.Min != nil && value < *param.Min {
+		value = *param.Min
+	}
+	if param.Max != nil && value > *param.Max {
+		value = *param.Max
+	}
+	return value
+}
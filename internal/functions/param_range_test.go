@@ -0,0 +1,57 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestExtractParametersWithContext_ParamRange(t *testing.T) {
+	bound := func(v float64) *float64 { return &v }
+	toolConfig := config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{
+				Name: "knowledge_base_search",
+				Parameters: []config.GenericToolParameter{
+					{Name: "query", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+					{Name: "topK", Type: "integer", Default: 10, Source: config.ParameterSourceLLM,
+						Min: bound(1), Max: bound(50)},
+					{Name: "scoreThreshold", Type: "float", Default: 0.3, Source: config.ParameterSourceLLM,
+						Min: bound(0), Max: bound(1)},
+				},
+			},
+		},
+	}
+	parser := NewGenericParameterParser()
+
+	tests := []struct {
+		name      string
+		params    string
+		wantTopK  interface{}
+		wantScore interface{}
+	}{
+		{"absent", "", 10, 0.3},
+		{"within range", "<topK>20</topK><scoreThreshold>0.75</scoreThreshold>", 20, 0.75},
+		{"clamped", "<topK>500</topK><scoreThreshold>-2</scoreThreshold>", 50, 0.0},
+		{"clamped up", "<topK>0</topK><scoreThreshold>1.5</scoreThreshold>", 1, 1.0},
+		{"invalid", "<topK>many</topK><scoreThreshold>NaN</scoreThreshold>", 10, 0.3},
+		{"padded", "<topK> 5 </topK>", 5, 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<knowledge_base_search><query>retry policy</query>" + tt.params + "</knowledge_base_search>"
+			params, err := parser.ExtractParametersWithContext(toolConfig, "knowledge_base_search", content, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTopK, params["topK"])
+			assert.Equal(t, tt.wantScore, params["scoreThreshold"])
+		})
+	}
+
+	toolConfig.GenericTools[0].Parameters[1].Required = true
+	_, err := parser.ExtractParametersWithContext(toolConfig, "knowledge_base_search",
+		"<knowledge_base_search><query>q</query><topK>many</topK></knowledge_base_search>", nil)
+	assert.ErrorContains(t, err, "failed to convert parameter topK")
+}
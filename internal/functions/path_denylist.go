@@ -0,0 +1,123 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// filterDeniedPaths drops result items whose file path matches a denied pattern of the tool
+func filterDeniedPaths(ctx context.Context, toolConfig config.GenericToolConfig, result string) string {
+	cfg := toolConfig.PathDenylist
+	if len(cfg.Patterns) == 0 {
+		return result
+	}
+	patterns := compileDenyPatterns(ctx, toolConfig.Name, cfg.Patterns)
+	filtered, removed := removeDeniedItems(cfg, patterns, result)
+	if removed > 0 {
+		logger.InfoC(ctx, "tool results from denied paths removed",
+			zap.String("tool", toolConfig.Name),
+			zap.Int("removed", removed))
+	}
+	return filtered
+}
+
+// removeDeniedItems returns the result without the items of denied paths and the number removed.
+// Results without a result list are returned unchanged.
+func removeDeniedItems(cfg config.GenericToolPathDenylistConfig, patterns []*regexp.Regexp,
+	result string) (string, int) {
+	prefix, body := splitBroadenedHeader(result)
+	var data interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &data); err != nil {
+		return result, 0
+	}
+	items, ok := findResultList(data)
+	if !ok || len(items) == 0 {
+		return result, 0
+	}
+
+	pathField := fieldOrDefault(cfg.PathField, defaultChunkPathField)
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		fields, _ := item.(map[string]interface{})
+		path, _ := fields[pathField].(string)
+		if path != "" && pathDenied(patterns, path) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if len(kept) == len(items) {
+		return result, 0
+	}
+
+	encoded, err := json.Marshal(replaceResultList(data, kept))
+	if err != nil {
+		return result, 0
+	}
+	return prefix + string(encoded), len(items) - len(kept)
+}
+
+// pathDenied reports whether the path matches any of the patterns
+func pathDenied(patterns []*regexp.Regexp, path string) bool {
+	normalized := strings.ToLower(strings.ReplaceAll(path, "\\", "/"))
+	for _, pattern := range patterns {
+		if pattern.MatchString(normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileDenyPatterns compiles the glob patterns, skipping any that cannot be compiled
+func compileDenyPatterns(ctx context.Context, toolName string, globs []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		pattern, err := regexp.Compile(globToRegexp(glob))
+		if err != nil {
+			logger.WarnC(ctx, "skipping invalid path denylist pattern",
+				zap.String("tool", toolName), zap.String("pattern", glob), zap.Error(err))
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// globToRegexp translates a glob to an anchored expression over lowercase slash separated
+// paths. The glob may start at any directory of the path, so "vendor/**" matches
+// "/repo/vendor/x.go" as well as "vendor/x.go".
+func globToRegexp(glob string) string {
+	glob = strings.TrimPrefix(strings.ToLower(strings.ReplaceAll(glob, "\\", "/")), "/")
+
+	var b strings.Builder
+	b.WriteString("^(?:.*/)?")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" also matches no directory at all
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestPathDenied(t *testing.T) {
+	patterns := compileDenyPatterns(context.Background(), "codebase_search",
+		[]string{"vendor/**", "**/*_test.go", "*.pb.go", "testdata/fixture?.json"})
+
+	for path, want := range map[string]bool{
+		"vendor/github.com/x/y.go":         true,
+		"/repo/vendor/x.go":                true,
+		`C:\Repo\Vendor\lib\x.go`:          true,
+		"internal/logic/chat_test.go":      true,
+		`D:\code\internal\Chat_Test.GO`:    true,
+		"api/v1/service.pb.go":             true,
+		"pkg/testdata/fixture1.json":       true,
+		"pkg/testdata/fixture12.json":      false,
+		"internal/logic/chat.go":           false,
+		"vendors/x.go":                     false,
+		"internal/vendor_notes.md":         false,
+		`C:\Repo\internal\logic\chat.go`:   false,
+		"internal/logic/chat_test.go.orig": false,
+	} {
+		assert.Equal(t, want, pathDenied(patterns, path), path)
+	}
+}
+
+func TestFilterDeniedPaths(t *testing.T) {
+	toolConfig := config.GenericToolConfig{
+		Name:         "codebase_search",
+		PathDenylist: config.GenericToolPathDenylistConfig{Patterns: []string{"vendor/**"}},
+	}
+	result := `{"code": 0, "data": {"list": [
+		{"filePath": "vendor/x/a.go", "score": 0.9},
+		{"filePath": "internal/b.go", "score": 0.8},
+		{"content": "no path", "score": 0.7}
+	]}}`
+
+	filtered := filterDeniedPaths(context.Background(), toolConfig, result)
+	count, _, ok := countToolResults(filtered, defaultScoreField, 0)
+	require.True(t, ok)
+	assert.Equal(t, 2, count, "the count reflects the filtered set")
+
+	var parsed struct {
+		Code int `json:"code"`
+		Data struct {
+			List []map[string]interface{} `json:"list"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(filtered), &parsed))
+	assert.Equal(t, "internal/b.go", parsed.Data.List[0]["filePath"])
+
+	broadened := BroadenedResultPrefix + `[{"filePath": "vendor/a.go"}]`
+	assert.Equal(t, BroadenedResultPrefix+"[]", filterDeniedPaths(context.Background(), toolConfig, broadened))
+
+	untouched := `[{"filePath": "internal/b.go"}]`
+	assert.Equal(t, untouched, filterDeniedPaths(context.Background(), toolConfig, untouched))
+	assert.Equal(t, "plain text", filterDeniedPaths(context.Background(), toolConfig, "plain text"))
+}
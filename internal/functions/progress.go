@@ -0,0 +1,68 @@
+package functions
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// progressPollInterval is the interval between progress queries of a running tool
+const progressPollInterval = time.Second
+
+// pollProgress periodically queries the tool's progress and reports changed values through
+// onProgress. The returned stop function blocks until polling has ended, so onProgress is
+// never called after it returns.
+func pollProgress(
+	ctx context.Context,
+	reporter client.ProgressReporter,
+	toolName string,
+	params map[string]interface{},
+	onProgress func(percent int),
+) func() {
+	pollCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(progressPollInterval)
+		defer ticker.Stop()
+
+		last := -1
+		for {
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			percent, ok, err := reporter.Progress(pollCtx, params)
+			if err != nil {
+				if pollCtx.Err() == nil {
+					logger.DebugC(ctx, "failed to query tool progress",
+						zap.String("tool", toolName), zap.Error(err))
+				}
+				continue
+			}
+			if !ok {
+				// Backend does not report progress, stay indeterminate
+				return
+			}
+			if percent != last {
+				last = percent
+				onProgress(percent)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
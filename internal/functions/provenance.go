@@ -0,0 +1,62 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// ChunkReporter is optionally implemented by executors that can list the chunks of a tool result
+type ChunkReporter interface {
+	// ResultChunks returns the file path and score of each chunk in the result, ok is false
+	// when the result has no result list
+	ResultChunks(toolName string, result string) (chunks []model.RetrievedChunk, ok bool)
+}
+
+// ResultChunks List the chunks of a tool result for retrieval provenance
+func (e *GenericToolExecutor) ResultChunks(toolName string, result string) ([]model.RetrievedChunk, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil {
+		return nil, false
+	}
+
+	var data interface{}
+	trimmed := strings.TrimSpace(broadenedBody(result))
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return nil, false
+	}
+	items, ok := findResultList(data)
+	if !ok {
+		return nil, false
+	}
+
+	pathField, scoreField := chunkFields(toolConfig)
+	chunks := make([]model.RetrievedChunk, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		chunk := model.RetrievedChunk{Source: model.ChunkSourceTool, Tool: toolName}
+		chunk.FilePath, _ = fields[pathField].(string)
+		if score, ok := toFloat(fields[scoreField]); ok {
+			chunk.Score = &score
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, true
+}
+
+// chunkFields returns the result item fields holding the file path and score. The JSON output
+// format renames them to the defaults, other results keep the names the backend uses.
+func chunkFields(toolConfig config.GenericToolConfig) (string, string) {
+	if toolConfig.OutputFormat == config.ToolOutputJSON {
+		return defaultJSONOutputPathField, defaultScoreField
+	}
+	pathField := fieldOrDefault(toolConfig.ChunkDedupe.PathField, defaultChunkPathField)
+	scoreField := fieldOrDefault(toolConfig.ResultStats.ScoreField,
+		fieldOrDefault(toolConfig.ChunkDedupe.ScoreField, defaultScoreField))
+	return pathField, scoreField
+}
@@ -0,0 +1,38 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+func TestGenericToolExecutor_ResultChunks(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search"},
+			{Name: "knowledge_base_search", ResultStats: config.GenericToolResultStatsConfig{ScoreField: "relevance"},
+				ChunkDedupe: config.GenericToolChunkDedupeConfig{PathField: "path"}},
+		},
+	})
+	score := func(v float64) *float64 { return &v }
+
+	chunks, ok := executor.ResultChunks("codebase_search",
+		BroadenedResultPrefix+`{"data": [{"filePath": "a.go", "score": 0.9}, {"filePath": "b.go"}]}`)
+	assert.True(t, ok)
+	assert.Equal(t, []model.RetrievedChunk{
+		{FilePath: "a.go", Score: score(0.9), Source: model.ChunkSourceTool, Tool: "codebase_search"},
+		{FilePath: "b.go", Source: model.ChunkSourceTool, Tool: "codebase_search"},
+	}, chunks)
+
+	chunks, ok = executor.ResultChunks("knowledge_base_search", `{"list": [{"path": "docs/auth.md", "relevance": 0.5}]}`)
+	assert.True(t, ok)
+	assert.Equal(t, []model.RetrievedChunk{
+		{FilePath: "docs/auth.md", Score: score(0.5), Source: model.ChunkSourceTool, Tool: "knowledge_base_search"},
+	}, chunks)
+
+	_, ok = executor.ResultChunks("codebase_search", NoResultsMessage)
+	assert.False(t, ok)
+}
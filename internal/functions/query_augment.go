@@ -0,0 +1,91 @@
+package functions
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// defaultAugmentWeight is used when summary augmentation is enabled without a weight
+const defaultAugmentWeight = 0.5
+
+var thinkingRegex = regexp.MustCompile(`(?s)<(thinking|think)>(.*?)</(thinking|think)>`)
+
+// QueryAugmenter is optionally implemented by executors that can blend the model's
+// thinking summary into the tool query
+type QueryAugmenter interface {
+	// AugmentQuery returns the tool content with its query augmented by the summary and the
+	// augmented query. ok is false when augmentation is disabled or nothing was added.
+	AugmentQuery(toolName string, content string, summary string) (augmentedContent string, augmentedQuery string, ok bool)
+}
+
+// ExtractThinkingSummary returns the content of the last <thinking> or <think> block
+func ExtractThinkingSummary(content string) string {
+	matches := thinkingRegex.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(matches[len(matches)-1][2])
+}
+
+// AugmentQuery Append keywords from the summary that the query does not contain yet
+func (e *GenericToolExecutor) AugmentQuery(toolName string, content string, summary string) (string, string, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.SummaryAugment.Enabled || toolConfig.SummaryAugment.QueryParam == "" {
+		return content, "", false
+	}
+	if strings.TrimSpace(summary) == "" {
+		return content, "", false
+	}
+
+	startTag := "<" + toolConfig.SummaryAugment.QueryParam + ">"
+	endTag := "</" + toolConfig.SummaryAugment.QueryParam + ">"
+	start := strings.Index(content, startTag)
+	end := strings.Index(content, endTag)
+	if start == -1 || end < start+len(startTag) {
+		return content, "", false
+	}
+	query := content[start+len(startTag) : end]
+
+	weight := toolConfig.SummaryAugment.Weight
+	if weight <= 0 {
+		weight = defaultAugmentWeight
+	}
+	keywords := summaryKeywords(query, summary, weight)
+	if len(keywords) == 0 {
+		return content, "", false
+	}
+
+	augmented := strings.TrimSpace(query) + " " + strings.Join(keywords, " ")
+	return content[:start+len(startTag)] + augmented + content[end:], augmented, true
+}
+
+// summaryKeywords picks summary keywords missing from the query, at most weight times
+// the number of query keywords (and at least one)
+func summaryKeywords(query string, summary string, weight float64) []string {
+	queryKeywords := strings.Fields(simplifyQuery(query))
+	limit := int(math.Round(float64(len(queryKeywords)) * weight))
+	if limit < 1 {
+		limit = 1
+	}
+
+	seen := make(map[string]struct{}, len(queryKeywords))
+	for _, word := range queryKeywords {
+		seen[strings.ToLower(word)] = struct{}{}
+	}
+
+	keywords := make([]string, 0, limit)
+	for _, word := range strings.Fields(simplifyQuery(summary)) {
+		if len(keywords) >= limit {
+			break
+		}
+		key := strings.ToLower(word)
+		// Short words are mostly stop words
+		if _, ok := seen[key]; ok || len([]rune(word)) < 4 {
+			continue
+		}
+		seen[key] = struct{}{}
+		keywords = append(keywords, word)
+	}
+	return keywords
+}
@@ -0,0 +1,65 @@
+package functions
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultReadyCacheTTL is used when the readiness cache is enabled without a TTL
+const defaultReadyCacheTTL = 5 * time.Second
+
+type readyCacheEntry struct {
+	ready     bool
+	expiresAt time.Time
+}
+
+// readyCache caches tool readiness results per client, project and tool
+type readyCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]readyCacheEntry
+}
+
+func newReadyCache(ttl time.Duration) *readyCache {
+	if ttl <= 0 {
+		ttl = defaultReadyCacheTTL
+	}
+	return &readyCache{
+		ttl:     ttl,
+		entries: make(map[string]readyCacheEntry),
+	}
+}
+
+func readyCacheKey(clientID, projectPath, toolName string) string {
+	return clientID + "\x00" + projectPath + "\x00" + toolName
+}
+
+// get returns the cached readiness result if it has not expired
+func (c *readyCache) get(key string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return false, false
+	}
+	return entry.ready, true
+}
+
+// set stores a readiness result and drops expired entries
+func (c *readyCache) set(key string, ready bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = readyCacheEntry{ready: ready, expiresAt: now.Add(c.ttl)}
+}
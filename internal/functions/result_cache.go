@@ -0,0 +1,65 @@
+package functions
+
+import "time"
+
+const (
+	defaultResultCacheTTLSec     = 600
+	defaultResultVersionCheckSec = 60
+)
+
+// ResultCacher is optionally implemented by executors whose tool results may be shared across requests
+type ResultCacher interface {
+	// ResultCacheTTL returns how long results of the tool are reused. ok is false when caching
+	// is disabled for the tool.
+	ResultCacheTTL(toolName string) (ttl time.Duration, ok bool)
+	// ResultIndexVersion returns the version of the index that produced the result, the index
+	// time in unix seconds. ok is false when the result reports none.
+	ResultIndexVersion(toolName string, result string) (version int64, ok bool)
+	// ResultVersionCheckInterval returns how long an index version learned from a result is
+	// trusted before a call goes to the backend again to check it
+	ResultVersionCheckInterval(toolName string) time.Duration
+}
+
+// ResultCacheTTL Get the cache lifetime of the tool's results
+func (e *GenericToolExecutor) ResultCacheTTL(toolName string) (time.Duration, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.ResultCache.Enabled {
+		return 0, false
+	}
+
+	ttlSec := toolConfig.ResultCache.TTLSec
+	if ttlSec <= 0 {
+		ttlSec = defaultResultCacheTTLSec
+	}
+	return time.Duration(ttlSec) * time.Second, true
+}
+
+// ResultVersionCheckInterval Get how long the index version of the tool's results is trusted
+func (e *GenericToolExecutor) ResultVersionCheckInterval(toolName string) time.Duration {
+	checkSec := defaultResultVersionCheckSec
+	if toolConfig, err := e.findToolConfig(toolName); err == nil && toolConfig.ResultCache.VersionCheckSec > 0 {
+		checkSec = toolConfig.ResultCache.VersionCheckSec
+	}
+	return time.Duration(checkSec) * time.Second
+}
+
+// ResultIndexVersion Read the index time reported in a result as its cache version
+func (e *GenericToolExecutor) ResultIndexVersion(toolName string, result string) (int64, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil {
+		return 0, false
+	}
+
+	field := toolConfig.ResultCache.IndexVersionField
+	if field == "" {
+		field = toolConfig.IndexAge.TimestampField
+	}
+	if field == "" {
+		field = defaultIndexTimestampField
+	}
+	indexedAt, ok := findIndexTimestamp(result, field)
+	if !ok {
+		return 0, false
+	}
+	return indexedAt.Unix(), true
+}
@@ -0,0 +1,41 @@
+package functions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestGenericToolExecutor_ResultCache(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search", ResultCache: config.GenericToolResultCacheConfig{Enabled: true}},
+			{Name: "knowledge_base_search", ResultCache: config.GenericToolResultCacheConfig{
+				Enabled: true, TTLSec: 60, IndexVersionField: "builtAt", VersionCheckSec: 10}},
+			{Name: "file_search"},
+		},
+	})
+
+	ttl, ok := executor.ResultCacheTTL("codebase_search")
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Minute, ttl, "default TTL")
+	ttl, ok = executor.ResultCacheTTL("knowledge_base_search")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, ttl)
+	_, ok = executor.ResultCacheTTL("file_search")
+	assert.False(t, ok, "cache not enabled")
+	assert.Equal(t, time.Minute, executor.ResultVersionCheckInterval("codebase_search"), "default check interval")
+	assert.Equal(t, 10*time.Second, executor.ResultVersionCheckInterval("knowledge_base_search"))
+
+	version, ok := executor.ResultIndexVersion("codebase_search", `{"data": {"indexedAt": 1748692800}}`)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1748692800), version)
+	version, ok = executor.ResultIndexVersion("knowledge_base_search", `{"builtAt": "2025-06-01T12:00:00Z"}`)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC).Unix(), version)
+	_, ok = executor.ResultIndexVersion("codebase_search", `{"data": []}`)
+	assert.False(t, ok, "result without index time")
+}
@@ -0,0 +1,81 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// defaultScoreField is the score field of result items when none is configured
+const defaultScoreField = "score"
+
+// ResultCounter is optionally implemented by executors that can count tool results
+type ResultCounter interface {
+	// CountResults returns the number of results in the tool output and how many of them
+	// pass the score threshold. ok is false when counting is disabled or the output has no result list.
+	CountResults(toolName string, result string) (count int, aboveThreshold int, ok bool)
+}
+
+// CountResults Count results of a tool output according to the tool's result stats configuration
+func (e *GenericToolExecutor) CountResults(toolName string, result string) (int, int, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.ResultStats.Enabled {
+		return 0, 0, false
+	}
+
+	scoreField := toolConfig.ResultStats.ScoreField
+	if scoreField == "" {
+		scoreField = defaultScoreField
+	}
+	return countToolResults(result, scoreField, toolConfig.ResultStats.ScoreThreshold)
+}
+
+// countToolResults counts the items of the result list found in a JSON tool output
+func countToolResults(result string, scoreField string, threshold float64) (int, int, bool) {
+	trimmed := strings.TrimSpace(broadenedBody(result))
+	if trimmed == "" {
+		return 0, 0, true
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return 0, 0, false
+	}
+
+	items, ok := findResultList(data)
+	if !ok {
+		return 0, 0, false
+	}
+
+	above := 0
+	for _, item := range items {
+		if threshold <= 0 {
+			above++
+			continue
+		}
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if score, ok := toFloat(fields[scoreField]); ok && score >= threshold {
+			above++
+		}
+	}
+	return len(items), above, true
+}
+
+// findResultList looks for the result list in the usual envelope fields
+func findResultList(v interface{}) ([]interface{}, bool) {
+	switch val := v.(type) {
+	case nil:
+		return nil, true
+	case []interface{}:
+		return val, true
+	case map[string]interface{}:
+		for _, key := range []string{"data", "list", "results", "items"} {
+			if inner, ok := val[key]; ok {
+				return findResultList(inner)
+			}
+		}
+	}
+	return nil, false
+}
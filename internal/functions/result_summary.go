@@ -0,0 +1,33 @@
+package functions
+
+import "github.com/zgsm-ai/chat-rag/internal/config"
+
+const (
+	defaultResultSummaryMaxTokens = 8000
+	defaultResultSummaryTimeoutMs = 20000
+)
+
+// ResultSummaryConfigurer is optionally implemented by executors whose tools may have
+// oversized results summarized instead of truncated
+type ResultSummaryConfigurer interface {
+	// ResultSummaryConfig returns the tool's summary settings with defaults applied.
+	// ok is false when summarizing is disabled for the tool.
+	ResultSummaryConfig(toolName string) (cfg config.GenericToolResultSummaryConfig, ok bool)
+}
+
+// ResultSummaryConfig Get the tool's result summary settings
+func (e *GenericToolExecutor) ResultSummaryConfig(toolName string) (config.GenericToolResultSummaryConfig, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.ResultSummary.Enabled {
+		return config.GenericToolResultSummaryConfig{}, false
+	}
+
+	cfg := toolConfig.ResultSummary
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultResultSummaryMaxTokens
+	}
+	if cfg.TimeoutMs <= 0 {
+		cfg.TimeoutMs = defaultResultSummaryTimeoutMs
+	}
+	return cfg, true
+}
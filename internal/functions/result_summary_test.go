@@ -0,0 +1,28 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestResultSummaryConfig(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search", ResultSummary: config.GenericToolResultSummaryConfig{Enabled: true}},
+			{Name: "search_files"},
+		},
+	})
+
+	cfg, ok := executor.ResultSummaryConfig("codebase_search")
+	assert.True(t, ok)
+	assert.Equal(t, defaultResultSummaryMaxTokens, cfg.MaxTokens)
+	assert.Equal(t, defaultResultSummaryTimeoutMs, cfg.TimeoutMs)
+
+	_, ok = executor.ResultSummaryConfig("search_files")
+	assert.False(t, ok)
+	_, ok = executor.ResultSummaryConfig("unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// NoResultsMessage replaces results that failed validation
+const NoResultsMessage = "No results found."
+
+// Result validation formats
+const (
+	resultFormatJSON = "json"
+	resultFormatText = "text"
+)
+
+// Reasons a result failed validation, used as metric label values
+const (
+	InvalidResultEmpty        = "empty"
+	InvalidResultErrorPage    = "error_page"
+	InvalidResultMalformed    = "malformed_json"
+	InvalidResultMissingField = "missing_field"
+	InvalidResultUnknown      = "unknown_format"
+)
+
+// ResultValidationError describes why a tool result was rejected
+type ResultValidationError struct {
+	Reason string
+	Detail string
+}
+
+func (e *ResultValidationError) Error() string {
+	return fmt.Sprintf("invalid tool result (%s): %s", e.Reason, e.Detail)
+}
+
+// resultValidators Validators by format, each checks one kind of backend response
+var resultValidators = map[string]func(cfg config.GenericToolResultValidateConfig, result string) error{
+	resultFormatJSON: validateJSONResult,
+	resultFormatText: validateTextResult,
+}
+
+// validateResult Check a backend response with the validator configured for the tool. Failures are
+// returned as *ResultValidationError, which callers inject as NoResultsMessage instead of as an error
+func validateResult(toolConfig config.GenericToolConfig, result string) error {
+	if !toolConfig.ResultValidate.Enabled {
+		return nil
+	}
+
+	format := toolConfig.ResultValidate.Format
+	if format == "" {
+		format = resultFormatJSON
+	}
+	validate, ok := resultValidators[format]
+	if !ok {
+		return &ResultValidationError{Reason: InvalidResultUnknown, Detail: fmt.Sprintf("no validator for format %q", format)}
+	}
+	return validate(toolConfig.ResultValidate, result)
+}
+
+// validateJSONResult Accept results parsing as JSON and holding the required top-level fields
+func validateJSONResult(cfg config.GenericToolResultValidateConfig, result string) error {
+	trimmed := strings.TrimSpace(result)
+	if trimmed == "" {
+		return &ResultValidationError{Reason: InvalidResultEmpty, Detail: "empty response"}
+	}
+	if looksLikeErrorPage(trimmed) {
+		return &ResultValidationError{Reason: InvalidResultErrorPage, Detail: "HTML page instead of JSON"}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return &ResultValidationError{Reason: InvalidResultMalformed, Detail: err.Error()}
+	}
+	if len(cfg.RequiredFields) == 0 {
+		return nil
+	}
+
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return &ResultValidationError{Reason: InvalidResultMissingField, Detail: "response is not a JSON object"}
+	}
+	for _, field := range cfg.RequiredFields {
+		if _, ok := object[field]; !ok {
+			return &ResultValidationError{Reason: InvalidResultMissingField, Detail: fmt.Sprintf("field %q is missing", field)}
+		}
+	}
+	return nil
+}
+
+// validateTextResult Accept any non-empty text that is not an error page
+func validateTextResult(_ config.GenericToolResultValidateConfig, result string) error {
+	trimmed := strings.TrimSpace(result)
+	if trimmed == "" {
+		return &ResultValidationError{Reason: InvalidResultEmpty, Detail: "empty response"}
+	}
+	if looksLikeErrorPage(trimmed) {
+		return &ResultValidationError{Reason: InvalidResultErrorPage, Detail: "HTML page instead of a result"}
+	}
+	return nil
+}
+
+// looksLikeErrorPage reports whether the response is an HTML page, as returned by proxies and gateways on errors
+func looksLikeErrorPage(result string) bool {
+	head := strings.ToLower(result[:min(len(result), 64)])
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+}
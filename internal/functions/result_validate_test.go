@@ -0,0 +1,51 @@
+package functions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestValidateResult(t *testing.T) {
+	jsonTool := config.GenericToolConfig{
+		Name:           "search_references",
+		ResultValidate: config.GenericToolResultValidateConfig{Enabled: true, RequiredFields: []string{"data"}},
+	}
+	textTool := config.GenericToolConfig{
+		Name:           "search_files",
+		ResultValidate: config.GenericToolResultValidateConfig{Enabled: true, Format: "text"},
+	}
+
+	tests := []struct {
+		name       string
+		toolConfig config.GenericToolConfig
+		result     string
+		wantReason string
+	}{
+		{"valid json", jsonTool, `{"data":{"list":[{"filePath":"a.go"}]}}`, ""},
+		{"truncated json", jsonTool, `{"data":{"list":[{"filePath":"a.go"`, InvalidResultMalformed},
+		{"gateway error page", jsonTool, "<!DOCTYPE html><html><body>502 Bad Gateway</body></html>", InvalidResultErrorPage},
+		{"missing field", jsonTool, `{"code":500,"message":"index not ready"}`, InvalidResultMissingField},
+		{"json array", jsonTool, `[1,2]`, InvalidResultMissingField},
+		{"empty body", jsonTool, "  ", InvalidResultEmpty},
+		{"valid text", textTool, "a.go:10: func main()", ""},
+		{"text error page", textTool, "<html><body>503</body></html>", InvalidResultErrorPage},
+		{"unknown format", config.GenericToolConfig{ResultValidate: config.GenericToolResultValidateConfig{Enabled: true, Format: "xml"}}, "<a/>", InvalidResultUnknown},
+		{"disabled", config.GenericToolConfig{}, "<html>", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResult(tt.toolConfig, tt.result)
+			if tt.wantReason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ResultValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.wantReason, validationErr.Reason)
+		})
+	}
+}
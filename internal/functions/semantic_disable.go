@@ -0,0 +1,30 @@
+package functions
+
+import (
+	"context"
+	"slices"
+)
+
+// defaultSemanticTools are the tools skipped when a request disables semantic search
+var defaultSemanticTools = []string{"codebase_search"}
+
+type semanticDisabledContextKey struct{}
+
+// WithSemanticDisabled returns a context marking the request as not wanting semantic search
+func WithSemanticDisabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, semanticDisabledContextKey{}, true)
+}
+
+// SemanticDisabled reports whether the request disabled semantic search
+func SemanticDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(semanticDisabledContextKey{}).(bool)
+	return disabled
+}
+
+// IsSemanticTool reports whether the tool is one of the configured semantic search tools
+func IsSemanticTool(semanticTools []string, toolName string) bool {
+	if len(semanticTools) == 0 {
+		semanticTools = defaultSemanticTools
+	}
+	return slices.Contains(semanticTools, toolName)
+}
@@ -0,0 +1,113 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	// signatureModeValue is the mode value requesting declarations only
+	signatureModeValue = "signature"
+
+	defaultSignatureModeParam    = "mode"
+	defaultSignatureContentField = "content"
+
+	// maxSignatureLines bounds how far a declaration is searched for its body opening
+	maxSignatureLines = 10
+)
+
+// signatureModeDescription is appended to the tool description when signature mode is enabled
+const signatureModeDescription = "\n- %s: (optional) `signature` returns only the declarations/signatures of the definitions " +
+	"without their bodies, useful for a quick overview. Omit it to get the full definitions."
+
+// signatureModeParam returns the configured mode parameter name
+func signatureModeParam(cfg config.GenericToolSignatureModeConfig) string {
+	if cfg.ModeParam == "" {
+		return defaultSignatureModeParam
+	}
+	return cfg.ModeParam
+}
+
+// requestedSignatureMode reports whether the model asked for signatures only
+func requestedSignatureMode(toolConfig config.GenericToolConfig, content string) bool {
+	if !toolConfig.SignatureMode.Enabled {
+		return false
+	}
+	toolContent, err := extractXmlParam(content, toolConfig.Name)
+	if err != nil {
+		return false
+	}
+	mode, err := extractXmlParam(toolContent, signatureModeParam(toolConfig.SignatureMode))
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(mode), signatureModeValue)
+}
+
+// toSignatureResult reduces the code of every definition in a JSON tool result to its
+// declaration. The full result is returned when it has no recognizable result list.
+func toSignatureResult(toolConfig config.GenericToolConfig, result string) string {
+	contentField := toolConfig.SignatureMode.ContentField
+	if contentField == "" {
+		contentField = defaultSignatureContentField
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result)), &data); err != nil {
+		logger.Warn("signature mode not applicable to tool result, returning full definitions",
+			zap.String("tool", toolConfig.Name), zap.Error(err))
+		return result
+	}
+	items, ok := findResultList(data)
+	if !ok || len(items) == 0 {
+		return result
+	}
+
+	reduced := 0
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if code, ok := fields[contentField].(string); ok {
+			fields[contentField] = extractSignature(code)
+			reduced++
+		}
+	}
+	if reduced == 0 {
+		logger.Warn("tool result has no definition content, returning full definitions",
+			zap.String("tool", toolConfig.Name), zap.String("contentField", contentField))
+		return result
+	}
+
+	output, err := json.Marshal(data)
+	if err != nil {
+		return result
+	}
+	return string(output)
+}
+
+// extractSignature returns the declaration of a definition: the lines up to where its body
+// opens ("{" or a trailing ":"), or the first line when no body opening is found
+func extractSignature(code string) string {
+	lines := strings.Split(strings.TrimSpace(code), "\n")
+	for i, line := range lines {
+		if i == maxSignatureLines {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if idx := strings.Index(line, "{"); idx >= 0 {
+			lines[i] = strings.TrimRight(line[:idx], " \t")
+			return strings.Join(lines[:i+1], "\n")
+		}
+		if strings.HasSuffix(trimmed, ":") {
+			return strings.Join(lines[:i+1], "\n")
+		}
+	}
+	return lines[0]
+}
@@ -0,0 +1,53 @@
+package functions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestExtractSignature(t *testing.T) {
+	assert.Equal(t, "func Add(a, b int) int",
+		extractSignature("func Add(a, b int) int {\n\treturn a + b\n}"))
+	assert.Equal(t, "def add(a,\n        b):",
+		extractSignature("def add(a,\n        b):\n    return a + b"))
+	assert.Equal(t, "const Limit = 10", extractSignature("const Limit = 10\n"))
+}
+
+func TestRequestedSignatureMode(t *testing.T) {
+	toolConfig := config.GenericToolConfig{
+		Name:          "search_definitions",
+		SignatureMode: config.GenericToolSignatureModeConfig{Enabled: true},
+	}
+
+	assert.True(t, requestedSignatureMode(toolConfig,
+		"<search_definitions><name>Add</name><mode>signature</mode></search_definitions>"))
+	assert.False(t, requestedSignatureMode(toolConfig,
+		"<search_definitions><name>Add</name></search_definitions>"))
+
+	toolConfig.SignatureMode.Enabled = false
+	assert.False(t, requestedSignatureMode(toolConfig,
+		"<search_definitions><mode>signature</mode></search_definitions>"))
+}
+
+func TestToSignatureResult(t *testing.T) {
+	toolConfig := config.GenericToolConfig{
+		Name:          "search_definitions",
+		SignatureMode: config.GenericToolSignatureModeConfig{Enabled: true},
+	}
+
+	result := `{"data":[{"file":"a.go","content":"func Add(a, b int) int {\n\treturn a + b\n}"}]}`
+	var parsed struct {
+		Data []map[string]string `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(toSignatureResult(toolConfig, result)), &parsed))
+	assert.Equal(t, "func Add(a, b int) int", parsed.Data[0]["content"])
+	assert.Equal(t, "a.go", parsed.Data[0]["file"])
+
+	// Results that cannot be reduced fall back to the full definitions
+	assert.Equal(t, "plain text", toSignatureResult(toolConfig, "plain text"))
+	noContent := `{"data":[{"file":"a.go"}]}`
+	assert.Equal(t, noContent, toSignatureResult(toolConfig, noContent))
+}
@@ -0,0 +1,112 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	defaultStreamPreviewField = "filePath"
+	defaultStreamMaxPreviews  = 5
+)
+
+// PartialResultToolExecutor is optionally implemented by executors that can show findings
+// of streaming tools before the call completes
+type PartialResultToolExecutor interface {
+	// ExecuteToolsWithPartial executes tools like ExecuteToolsWithProgress and additionally calls
+	// onPartial with a short preview of each distinct early finding. Both callbacks may be nil.
+	ExecuteToolsWithPartial(ctx context.Context, toolName string, content string,
+		onProgress func(percent int), onPartial func(finding string)) (string, error)
+}
+
+// ExecuteToolsWithPartial Execute tools, previewing streamed findings through onPartial
+func (e *GenericToolExecutor) ExecuteToolsWithPartial(ctx context.Context, toolName string, content string,
+	onProgress func(percent int), onPartial func(finding string)) (string, error) {
+	return e.executeTools(ctx, toolName, content, onProgress, onPartial)
+}
+
+// executeSearch runs the tool request, reading results from the stream endpoint when streaming
+// is enabled and the client supports it. A stream failing before its first result falls back
+// to the blocking request, one failing later is reported since results were already shown.
+func executeSearch(ctx context.Context, toolClient client.GenericClientInterface, toolConfig config.GenericToolConfig,
+	params map[string]interface{}, onPartial func(finding string)) (string, error) {
+	streamer, ok := toolClient.(client.StreamingClient)
+	if !toolConfig.Streaming.Enabled || !ok {
+		return toolClient.Execute(ctx, params)
+	}
+
+	previewer := newStreamPreviewer(toolConfig.Streaming, onPartial)
+	received := 0
+	result, ok, err := streamer.ExecuteStream(ctx, params, func(item json.RawMessage) {
+		received++
+		previewer.add(item)
+	})
+	if !ok {
+		return toolClient.Execute(ctx, params)
+	}
+	if err != nil && received == 0 && ctx.Err() == nil {
+		logger.WarnC(ctx, "tool stream failed before the first result, using blocking request",
+			zap.String("tool", toolConfig.Name), zap.Error(err))
+		return toolClient.Execute(ctx, params)
+	}
+	if err != nil {
+		return "", fmt.Errorf("stream failed after %d results: %w", received, err)
+	}
+
+	logger.InfoC(ctx, "tool results streamed",
+		zap.String("tool", toolConfig.Name), zap.Int("results", received), zap.Int("previews", previewer.shown))
+	return result, nil
+}
+
+// streamPreviewer reports the preview field of streamed items, skipping repeats and
+// stopping after the configured number of findings
+type streamPreviewer struct {
+	field     string
+	max       int
+	onPartial func(finding string)
+	seen      map[string]bool
+	shown     int
+}
+
+func newStreamPreviewer(cfg config.GenericToolStreamingConfig, onPartial func(finding string)) *streamPreviewer {
+	field := cfg.PreviewField
+	if field == "" {
+		field = defaultStreamPreviewField
+	}
+	maxPreviews := cfg.MaxPreviews
+	if maxPreviews <= 0 {
+		maxPreviews = defaultStreamMaxPreviews
+	}
+	return &streamPreviewer{
+		field:     field,
+		max:       maxPreviews,
+		onPartial: onPartial,
+		seen:      make(map[string]bool),
+	}
+}
+
+func (p *streamPreviewer) add(item json.RawMessage) {
+	if p.onPartial == nil || p.shown >= p.max {
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(item, &fields); err != nil {
+		return
+	}
+	finding, _ := fields[p.field].(string)
+	if finding == "" || p.seen[finding] {
+		return
+	}
+
+	p.seen[finding] = true
+	p.shown++
+	p.onPartial(finding)
+}
@@ -0,0 +1,102 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// streamClient streams canned items, failing after failAfter items when streamErr is set
+type streamClient struct {
+	items     []string
+	streamErr error
+	failAfter int
+	noStream  bool
+	executed  bool
+}
+
+func (c *streamClient) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	c.executed = true
+	return `{"data": ["blocking"]}`, nil
+}
+
+func (c *streamClient) CheckReady(ctx context.Context, params map[string]interface{}) (bool, error) {
+	return true, nil
+}
+
+func (c *streamClient) ExecuteStream(ctx context.Context, params map[string]interface{},
+	onItem func(item json.RawMessage)) (string, bool, error) {
+	if c.noStream {
+		return "", false, nil
+	}
+	for i, item := range c.items {
+		if c.streamErr != nil && i == c.failAfter {
+			return "", true, c.streamErr
+		}
+		onItem(json.RawMessage(item))
+	}
+	if c.streamErr != nil {
+		return "", true, c.streamErr
+	}
+	return `{"data": "streamed"}`, true, nil
+}
+
+func streamingToolConfig() config.GenericToolConfig {
+	return config.GenericToolConfig{
+		Name:      "codebase_search",
+		Streaming: config.GenericToolStreamingConfig{Enabled: true, MaxPreviews: 2},
+	}
+}
+
+func TestExecuteSearch_StreamsPreviews(t *testing.T) {
+	toolClient := &streamClient{items: []string{
+		`{"filePath": "a.go"}`, `{"filePath": "a.go"}`, `{"score": 1}`, `{"filePath": "b.go"}`, `{"filePath": "c.go"}`,
+	}}
+
+	var findings []string
+	result, err := executeSearch(context.Background(), toolClient, streamingToolConfig(), nil,
+		func(finding string) { findings = append(findings, finding) })
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"data": "streamed"}`, result)
+	assert.Equal(t, []string{"a.go", "b.go"}, findings)
+	assert.False(t, toolClient.executed)
+}
+
+func TestExecuteSearch_FallsBackToBlocking(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.GenericToolConfig
+		client *streamClient
+	}{
+		{"streaming disabled", config.GenericToolConfig{Name: "codebase_search"}, &streamClient{}},
+		{"no stream endpoint", streamingToolConfig(), &streamClient{noStream: true}},
+		{"fails before first result", streamingToolConfig(), &streamClient{streamErr: errors.New("refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executeSearch(context.Background(), tt.client, tt.cfg, nil, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, `{"data": ["blocking"]}`, result)
+			assert.True(t, tt.client.executed)
+		})
+	}
+}
+
+func TestExecuteSearch_FailureAfterResults(t *testing.T) {
+	toolClient := &streamClient{
+		items:     []string{`{"filePath": "a.go"}`, `{"filePath": "b.go"}`},
+		streamErr: errors.New("connection reset"),
+		failAfter: 1,
+	}
+
+	_, err := executeSearch(context.Background(), toolClient, streamingToolConfig(), nil, nil)
+	assert.ErrorContains(t, err, "after 1 results")
+	assert.False(t, toolClient.executed)
+}
@@ -0,0 +1,65 @@
+package functions
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// defaultSymbolParam is the symbol parameter normalized when none is configured
+const defaultSymbolParam = "symbolName"
+
+// symbolSeparators split qualifiers from the symbol name, longest first
+var symbolSeparators = []string{"::", "."}
+
+// normalizeSymbolParams rewrites the configured symbol parameters to their plain form in place
+func normalizeSymbolParams(ctx context.Context, toolConfig config.GenericToolConfig, params map[string]interface{}) {
+	names := toolConfig.SymbolNormalize.Params
+	if len(names) == 0 {
+		names = []string{defaultSymbolParam}
+	}
+	for _, name := range names {
+		value, ok := params[name].(string)
+		if !ok || value == "" {
+			continue
+		}
+		symbols := strings.Split(value, ",")
+		for i, symbol := range symbols {
+			symbols[i] = normalizeSymbol(strings.TrimSpace(symbol), toolConfig.SymbolNormalize.Lowercase)
+		}
+		normalized := strings.Join(symbols, ",")
+		if normalized == value {
+			continue
+		}
+		params[name] = normalized
+		logger.InfoC(ctx, "symbol name normalized",
+			zap.String("tool", toolConfig.Name),
+			zap.String("param", name),
+			zap.String("original", value),
+			zap.String("normalized", normalized))
+	}
+}
+
+// normalizeSymbol drops everything before the last qualifier separator, so
+// "types.QueryCallGraphOptions" and "std::vector" become "QueryCallGraphOptions" and "vector"
+func normalizeSymbol(symbol string, lowercase bool) string {
+	cut := -1
+	sepLen := 0
+	for _, sep := range symbolSeparators {
+		if idx := strings.LastIndex(symbol, sep); idx > cut {
+			cut, sepLen = idx, len(sep)
+		}
+	}
+	// A trailing separator leaves no name, keep the symbol as sent
+	if cut >= 0 && cut+sepLen < len(symbol) {
+		symbol = symbol[cut+sepLen:]
+	}
+	if lowercase {
+		symbol = strings.ToLower(symbol)
+	}
+	return symbol
+}
@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestNormalizeSymbol(t *testing.T) {
+	assert.Equal(t, "QueryCallGraphOptions", normalizeSymbol("types.QueryCallGraphOptions", false))
+	assert.Equal(t, "vector", normalizeSymbol("std::vector", false))
+	assert.Equal(t, "Parse", normalizeSymbol("pkg::sub.Parse", false))
+	assert.Equal(t, "Login", normalizeSymbol("Login", false))
+	assert.Equal(t, "types.", normalizeSymbol("types.", false), "no name after the separator")
+	assert.Equal(t, "querycallgraphoptions", normalizeSymbol("types.QueryCallGraphOptions", true))
+}
+
+func TestNormalizeSymbolParams(t *testing.T) {
+	toolConfig := config.GenericToolConfig{
+		Name:            "search_definitions",
+		SymbolNormalize: config.GenericToolSymbolNormalizeConfig{Enabled: true},
+	}
+
+	params := map[string]interface{}{"symbolName": "types.Options, std::vector,Login", "path": "internal/types.go"}
+	normalizeSymbolParams(context.Background(), toolConfig, params)
+	assert.Equal(t, "Options,vector,Login", params["symbolName"])
+	assert.Equal(t, "internal/types.go", params["path"], "other parameters are untouched")
+
+	toolConfig.SymbolNormalize.Params = []string{"name"}
+	params = map[string]interface{}{"name": "types.Options", "symbolName": "types.Options"}
+	normalizeSymbolParams(context.Background(), toolConfig, params)
+	assert.Equal(t, "Options", params["name"])
+	assert.Equal(t, "types.Options", params["symbolName"])
+}
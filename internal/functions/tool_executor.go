@@ -2,15 +2,57 @@ package functions
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
+
+	"go.uber.org/zap"
 
 	"github.com/zgsm-ai/chat-rag/internal/client"
 	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
 )
 
+// ProgressToolExecutor is optionally implemented by executors that can report tool progress
+type ProgressToolExecutor interface {
+	// ExecuteToolsWithProgress executes tools and calls onProgress with the completion percentage
+	// whenever the backend reports it. onProgress is never called when progress is unavailable.
+	ExecuteToolsWithProgress(ctx context.Context, toolName string, content string, onProgress func(percent int)) (string, error)
+}
+
+// ToolTimeoutError reports a call that exceeded the tool's own timeout. Callers inject it as
+// an empty search, not as a tool failure, and never cache it
+type ToolTimeoutError struct {
+	TimeoutMs int
+	Err       error
+}
+
+func (e *ToolTimeoutError) Error() string {
+	return fmt.Sprintf("tool call timed out after %dms: %v", e.TimeoutMs, e.Err)
+}
+
+func (e *ToolTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ParameterExtractor is optionally implemented by executors that can parse tool parameters for logging
+type ParameterExtractor interface {
+	// ExtractToolParams returns the parsed parameters of a tool invocation. On malformed input it
+	// returns the parameters that could be parsed together with an error describing the rest.
+	ExtractToolParams(toolName string, content string) (map[string]interface{}, error)
+}
+
+// PartialTagDetector is optionally implemented by executors that can recognize a tool tag
+// cut off at the end of streamed content
+type PartialTagDetector interface {
+	// PartialToolTagStart returns the offset of a trailing incomplete opening tag of a tool, or -1
+	PartialToolTagStart(content string) int
+}
+
 type ToolExecutor interface {
 	DetectTools(ctx context.Context, content string) (bool, string)
 
@@ -33,18 +75,25 @@ type GenericToolExecutor struct {
 	toolConfig      *config.ToolConfig
 	clientFactory   *client.GenericClientFactory
 	parameterParser *GenericParameterParser
+	readyCache      *readyCache
+	limiters        toolLimiters
+	health          toolHealth
 }
 
 // NewGenericToolExecutor Create new generic tool executor
 func NewGenericToolExecutor(toolConfig *config.ToolConfig) *GenericToolExecutor {
-	return &GenericToolExecutor{
+	executor := &GenericToolExecutor{
 		toolConfig:      toolConfig,
 		clientFactory:   client.NewGenericClientFactory(),
 		parameterParser: NewGenericParameterParser(),
 	}
+	if toolConfig.ReadyCache.Enabled {
+		executor.readyCache = newReadyCache(time.Duration(toolConfig.ReadyCache.TTLSec) * time.Second)
+	}
+	return executor
 }
 
-// DetectTools Detect tool invocation
+// DetectTools Detect tool invocation. Tags escaped by EscapeToolTags are not matched.
 func (e *GenericToolExecutor) DetectTools(ctx context.Context, content string) (bool, string) {
 	for _, toolConfig := range e.toolConfig.GenericTools {
 		if strings.Contains(content, "<"+toolConfig.Name+">") {
@@ -54,14 +103,61 @@ func (e *GenericToolExecutor) DetectTools(ctx context.Context, content string) (
 	return false, ""
 }
 
+// PartialToolTagStart returns the offset where content ends with the beginning of a tool's opening
+// tag, such as "<codebase_sea", or -1 when no tool tag can start in its tail. Complete tags are
+// left to DetectTools.
+func (e *GenericToolExecutor) PartialToolTagStart(content string) int {
+	start := -1
+	for _, toolConfig := range e.toolConfig.GenericTools {
+		tag := "<" + toolConfig.Name + ">"
+		for n := min(len(tag)-1, len(content)); n > 0; n-- {
+			if strings.HasSuffix(content, tag[:n]) {
+				if pos := len(content) - n; start == -1 || pos < start {
+					start = pos
+				}
+				break
+			}
+		}
+	}
+	return start
+}
+
 // ExecuteTools Execute tools
 func (e *GenericToolExecutor) ExecuteTools(ctx context.Context, toolName string, content string) (string, error) {
+	return e.executeTools(ctx, toolName, content, nil, nil)
+}
+
+// ExecuteToolsWithProgress Execute tools, reporting backend progress through onProgress
+func (e *GenericToolExecutor) ExecuteToolsWithProgress(ctx context.Context, toolName string, content string,
+	onProgress func(percent int)) (string, error) {
+	return e.executeTools(ctx, toolName, content, onProgress, nil)
+}
+
+// executeTools Execute tools, onProgress and onPartial are optional
+func (e *GenericToolExecutor) executeTools(ctx context.Context, toolName string, content string,
+	onProgress func(percent int), onPartial func(finding string)) (result string, err error) {
 	// Find tool configuration
 	toolConfig, err := e.findToolConfig(toolName)
 	if err != nil {
 		return "", fmt.Errorf("tool not found: %w", err)
 	}
 
+	// A call exceeding the tool's own timeout is reported as a ToolTimeoutError, a cancelled
+	// or expired request context stays a plain error
+	if toolConfig.TimeoutMs > 0 {
+		requestCtx := ctx
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(toolConfig.TimeoutMs)*time.Millisecond)
+		defer cancel()
+		defer func() {
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && requestCtx.Err() == nil {
+				logger.WarnC(ctx, "tool call timed out",
+					zap.String("tool", toolName), zap.Int("timeoutMs", toolConfig.TimeoutMs), zap.Error(err))
+				result, err = "", &ToolTimeoutError{TimeoutMs: toolConfig.TimeoutMs, Err: err}
+			}
+		}()
+	}
+
 	// Get context parameters
 	genericParams, err := e.getGenericParameters(ctx)
 	if err != nil {
@@ -83,10 +179,38 @@ func (e *GenericToolExecutor) ExecuteTools(ctx context.Context, toolName string,
 		allParams[k] = v
 	}
 
+	// Result count requested by the caller, capped again in case the context was set directly
+	if topK, ok := topKFromContext(ctx); ok {
+		if effective, ok := e.EffectiveTopK(toolName, topK); ok {
+			allParams[topKParam(toolConfig.TopKOverride.Param)] = effective
+		}
+	}
+
+	// Models do not always send symbols in the plain form the backend matches
+	if toolConfig.SymbolNormalize.Enabled {
+		normalizeSymbolParams(ctx, toolConfig, allParams)
+	}
+
+	// Only backends that understand signature mode receive it, others get it applied to the result
+	signatureMode := requestedSignatureMode(toolConfig, content)
+	if signatureMode && toolConfig.SignatureMode.BackendSupported {
+		allParams[signatureModeParam(toolConfig.SignatureMode)] = signatureModeValue
+	} else if toolConfig.SignatureMode.Enabled && !toolConfig.SignatureMode.BackendSupported {
+		delete(allParams, signatureModeParam(toolConfig.SignatureMode))
+	}
+
 	// Validate parameters
 	if err := e.parameterParser.ValidateParameters(toolConfig, allParams); err != nil {
 		return "", fmt.Errorf("parameter validation failed: %w", err)
 	}
+	if toolConfig.LineMatches.Enabled {
+		if err := checkLinePattern(toolConfig.LineMatches, allParams); err != nil {
+			return "", fmt.Errorf("parameter validation failed: %w", err)
+		}
+		if param := toolConfig.LineMatches.MaxMatchesParam; param != "" {
+			allParams[param] = lineMatchLimit(toolConfig.LineMatches)
+		}
+	}
 
 	// Get or create client
 	toolClient, err := e.clientFactory.CreateClient(toolConfig)
@@ -94,13 +218,90 @@ func (e *GenericToolExecutor) ExecuteTools(ctx context.Context, toolName string,
 		return "", fmt.Errorf("failed to create client: %w", err)
 	}
 
-	// Execute tool invocation
-	result, err := toolClient.Execute(ctx, allParams)
+	// Wait for a free slot of tools with a concurrency limit
+	release, err := e.acquireToolSlot(ctx, toolConfig)
 	if err != nil {
-		return "", fmt.Errorf("tool execution failed: %w", err)
+		return "", err
+	}
+	defer release()
+
+	// Poll progress while the tool runs when the client supports it
+	if reporter, ok := toolClient.(client.ProgressReporter); ok && onProgress != nil {
+		stop := pollProgress(ctx, reporter, toolName, allParams, onProgress)
+		defer stop()
 	}
 
-	return result, nil
+	var paths []string
+	if toolConfig.MultiPath.Enabled {
+		paths = e.multiPathValues(toolConfig, content, getOSType(genericParams))
+	}
+	if len(paths) > 1 {
+		// Search every path concurrently when the model passed more than one, each path
+		// records the tool health and has its output validated
+		result, err = e.executeMultiPath(ctx, toolClient, toolConfig, allParams, paths)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// Execute tool invocation, streaming results when the tool supports it
+		result, err = executeSearch(ctx, toolClient, toolConfig, allParams, onPartial)
+		e.recordToolHealth(ctx, toolConfig, err)
+		if err != nil {
+			return "", fmt.Errorf("tool execution failed: %w", err)
+		}
+		if err := validateResult(toolConfig, result); err != nil {
+			logger.WarnC(ctx, "tool result rejected by validation",
+				zap.String("tool", toolName), zap.Error(err), zap.Int("resultLength", len(result)))
+			return "", err
+		}
+	}
+	result = filterDeniedPaths(ctx, toolConfig, result)
+
+	// Retry once with a broadened query when nothing was found, the broadened result goes
+	// through the same steps and gets its marker and scope note after output formatting
+	broadenedPrefix := ""
+	if toolConfig.Broaden.Enabled && client.IsEmptyResult(result) {
+		if broadened, removedPath, ok := e.executeBroadened(ctx, toolClient, toolConfig, allParams); ok {
+			// A malformed broadened response falls back to the valid empty one
+			if err := validateResult(toolConfig, broadened); err == nil {
+				result = filterDeniedPaths(ctx, toolConfig, broadened)
+				broadenedPrefix = broadenedHeader(removedPath)
+			} else {
+				logger.WarnC(ctx, "broadened tool result rejected by validation",
+					zap.String("tool", toolName), zap.Error(err))
+			}
+		}
+	}
+
+	result = limitChunks(ctx, toolConfig, result)
+
+	if toolConfig.LineMatches.Enabled {
+		result = formatLineMatches(toolConfig.LineMatches, result)
+	}
+
+	if signatureMode && !toolConfig.SignatureMode.BackendSupported {
+		result = toSignatureResult(toolConfig, result)
+	}
+
+	if toolConfig.TreeLimit.Enabled {
+		result = limitTreeResult(toolConfig, result)
+	}
+
+	return broadenedPrefix + formatOutput(toolConfig, result), nil
+}
+
+// ExtractToolParams Extract the tool's parameters from its XML invocation for logging
+func (e *GenericToolExecutor) ExtractToolParams(toolName string, content string) (map[string]interface{}, error) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil {
+		return nil, err
+	}
+
+	params, notes := e.parameterParser.ExtractParametersLenient(toolConfig, content)
+	if len(notes) > 0 {
+		return params, fmt.Errorf("malformed tool input: %s", strings.Join(notes, "; "))
+	}
+	return params, nil
 }
 
 // CheckToolReady Check tool readiness status
@@ -123,8 +324,27 @@ func (e *GenericToolExecutor) CheckToolReady(ctx context.Context, toolName strin
 		return false, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	// Check service readiness status
-	return toolClient.CheckReady(ctx, contextParams)
+	if e.readyCache == nil {
+		// Check service readiness status
+		return toolClient.CheckReady(ctx, contextParams)
+	}
+
+	clientID, _ := contextParams[client.CommonParamClientID].(string)
+	projectPath, _ := contextParams[client.CommonParamCodebasePath].(string)
+	cacheKey := readyCacheKey(clientID, projectPath, toolName)
+	if ready, ok := e.readyCache.get(cacheKey); ok {
+		logger.InfoC(ctx, "tool readiness served from cache",
+			zap.String("tool", toolName), zap.Bool("ready", ready))
+		return ready, nil
+	}
+
+	// Check service readiness status, errors are not cached so the next call probes again
+	ready, err := toolClient.CheckReady(ctx, contextParams)
+	if err != nil {
+		return ready, err
+	}
+	e.readyCache.set(cacheKey, ready)
+	return ready, nil
 }
 
 // GetToolDescription Get tool description
@@ -134,7 +354,11 @@ func (e *GenericToolExecutor) GetToolDescription(toolName string) (string, error
 		return "", err
 	}
 
-	return fmt.Sprintf("## %s\n%s", toolName, toolConfig.Description), nil
+	description := toolConfig.Description
+	if toolConfig.SignatureMode.Enabled {
+		description += fmt.Sprintf(signatureModeDescription, signatureModeParam(toolConfig.SignatureMode))
+	}
+	return fmt.Sprintf("## %s\n%s", toolName, description), nil
 }
 
 // GetToolCapability Get tool capability description
@@ -244,6 +468,30 @@ func (p *GenericParameterParser) ExtractParametersWithContext(toolConfig config.
 				continue
 			}
 
+			// An empty required parameter would send a meaningless request to the backend
+			if param.Required && strings.TrimSpace(value) == "" {
+				return nil, fmt.Errorf("required parameter %s is empty, provide a non-empty value in <%s></%s>",
+					param.Name, param.Name, param.Name)
+			}
+
+			// Numbers from the model are kept within the configured bounds
+			if hasParamRange(param) {
+				rangedValue, err := p.rangedParamValue(param, toolContent)
+				if err == nil {
+					params[param.Name] = rangedValue
+					continue
+				}
+				if param.Required {
+					return nil, fmt.Errorf("failed to convert parameter %s: %w", param.Name, err)
+				}
+				logger.Warn("invalid tool parameter from model, using default",
+					zap.String("tool", toolName), zap.String("param", param.Name), zap.Error(err))
+				if param.Default != nil {
+					params[param.Name] = param.Default
+				}
+				continue
+			}
+
 			// Special handling for path parameters
 			if strings.Contains(strings.ToLower(param.Name), "path") {
 				value = p.processPathParameter(value, osType)
@@ -269,6 +517,63 @@ func (p *GenericParameterParser) ExtractParametersWithContext(toolConfig config.
 	return params, nil
 }
 
+// ExtractParametersLenient Extract LLM parameters for logging, keeping whatever could be parsed.
+// Problems are returned as notes instead of failing the whole extraction
+func (p *GenericParameterParser) ExtractParametersLenient(toolConfig config.GenericToolConfig, content string) (map[string]interface{}, []string) {
+	params := make(map[string]interface{})
+	var notes []string
+
+	toolContent, err := extractXmlParam(content, toolConfig.Name)
+	if err != nil {
+		// Fall back to the raw content, the closing tool tag may be missing
+		toolContent = content
+		notes = append(notes, fmt.Sprintf("tool tag: %v", err))
+	}
+
+	for _, param := range toolConfig.Parameters {
+		if param.Source != config.ParameterSourceLLM {
+			continue
+		}
+
+		value, err := extractXmlParam(toolContent, param.Name)
+		if err != nil {
+			if param.Required {
+				notes = append(notes, fmt.Sprintf("%s: %v", param.Name, err))
+			}
+			continue
+		}
+
+		convertedValue, err := p.ConvertParameterType(value, param.Type)
+		if err != nil {
+			// Keep the raw value so it can still be aggregated
+			params[param.Name] = value
+			notes = append(notes, fmt.Sprintf("%s: %v", param.Name, err))
+			continue
+		}
+		params[param.Name] = convertedValue
+	}
+
+	return params, notes
+}
+
+// EscapeToolTags escapes the opening and closing tags of the given tools as "&lt;name&gt;",
+// which DetectTools does not match. It returns the escaped text and the number of tags escaped.
+func EscapeToolTags(text string, toolNames []string) (string, int) {
+	escaped := 0
+	for _, name := range toolNames {
+		if name == "" {
+			continue
+		}
+		for _, tag := range []string{"<" + name + ">", "</" + name + ">"} {
+			if n := strings.Count(text, tag); n > 0 {
+				text = strings.ReplaceAll(text, tag, "&lt;"+tag[1:len(tag)-1]+"&gt;")
+				escaped += n
+			}
+		}
+	}
+	return text, escaped
+}
+
 func extractXmlParam(content, paramName string) (string, error) {
 	startTag := "<" + paramName + ">"
 	endTag := "</" + paramName + ">"
@@ -291,6 +596,23 @@ func extractXmlParam(content, paramName string) (string, error) {
 	return paramValue, nil
 }
 
+// extractXmlFloatParam extracts a parameter and parses it as a finite float
+func extractXmlFloatParam(content, paramName string) (float64, error) {
+	value, err := extractXmlParam(content, paramName)
+	if err != nil {
+		return 0, err
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("parameter %s is empty", paramName)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("parameter %s is not a number: %q", paramName, value)
+	}
+	return f, nil
+}
+
 // getOSType Get OS type
 func getOSType(contextParams map[string]interface{}) string {
 	osType := "windows"
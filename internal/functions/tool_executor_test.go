@@ -0,0 +1,144 @@
+package functions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestExtractParametersWithContext_EmptyRequiredParam(t *testing.T) {
+	toolConfig := config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{
+				Name: "codebase_search",
+				Parameters: []config.GenericToolParameter{
+					{Name: "query", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+					{Name: "topK", Type: "string", Source: config.ParameterSourceLLM},
+				},
+			},
+		},
+	}
+	parser := NewGenericParameterParser()
+
+	tests := []struct {
+		name      string
+		content   string
+		expectErr bool
+		expected  map[string]interface{}
+	}{
+		{
+			name:     "non-empty required param",
+			content:  "<codebase_search><query>auth middleware</query></codebase_search>",
+			expected: map[string]interface{}{"query": "auth middleware"},
+		},
+		{
+			name:      "empty required param",
+			content:   "<codebase_search><query></query></codebase_search>",
+			expectErr: true,
+		},
+		{
+			name:      "whitespace-only required param",
+			content:   "<codebase_search><query> \n\t </query></codebase_search>",
+			expectErr: true,
+		},
+		{
+			name:     "empty optional param is allowed",
+			content:  "<codebase_search><query>auth</query><topK></topK></codebase_search>",
+			expected: map[string]interface{}{"query": "auth", "topK": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := parser.ExtractParametersWithContext(toolConfig, "codebase_search", tt.content, nil)
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "required parameter query is empty")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, params)
+		})
+	}
+}
+
+func TestEscapeToolTags(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{{Name: "codebase_search"}},
+	})
+	text := "see <codebase_search> and </codebase_search>, keep <codebase_search_x>"
+
+	escaped, count := EscapeToolTags(text, []string{"", "codebase_search"})
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "see &lt;codebase_search&gt; and &lt;/codebase_search&gt;, keep <codebase_search_x>", escaped)
+
+	detected, _ := executor.DetectTools(context.Background(), escaped)
+	assert.False(t, detected)
+}
+
+func TestPartialToolTagStart(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{{Name: "codebase_search"}, {Name: "search_files"}},
+	})
+
+	tests := []struct {
+		content  string
+		expected int
+	}{
+		{"looking it up <codebase_sea", 14},
+		{"looking it up <", 14},
+		{"a <search_files", 2},
+		{"a <codebase_search>", -1},
+		{"a <codebase_search> <q", -1},
+		{"x < y", -1},
+		{"a <codebase_x", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, executor.PartialToolTagStart(tt.content), tt.content)
+	}
+
+	// A tag fragmented over chunks is tracked until it completes
+	var window string
+	for _, chunk := range []string{"Let me check", " <", "code", "base_sea", "rch", ">"} {
+		window += chunk
+		if detected, _ := executor.DetectTools(context.Background(), window); detected {
+			break
+		}
+		assert.Equal(t, strings.Index(window, "<"), executor.PartialToolTagStart(window), window)
+	}
+	detected, name := executor.DetectTools(context.Background(), window)
+	assert.True(t, detected)
+	assert.Equal(t, "codebase_search", name)
+}
+
+func TestExtractXmlFloatParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    float64
+		wantErr string
+	}{
+		{"valid", "<scoreThreshold>0.75</scoreThreshold>", 0.75, ""},
+		{"padded", "<scoreThreshold> 1e-1\n</scoreThreshold>", 0.1, ""},
+		{"trailing garbage", "<scoreThreshold>0.75abc</scoreThreshold>", 0, `parameter scoreThreshold is not a number: "0.75abc"`},
+		{"empty tag", "<scoreThreshold></scoreThreshold>", 0, "parameter scoreThreshold is empty"},
+		{"not finite", "<scoreThreshold>Inf</scoreThreshold>", 0, "parameter scoreThreshold is not a number"},
+		{"missing", "<topK>5</topK>", 0, "start tag not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractXmlFloatParam(tt.content, "scoreThreshold")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	defaultHealthFailureThreshold = 3
+	defaultHealthCooldownSec      = 30
+)
+
+// ToolHealthChecker is optionally implemented by executors tracking the health of tool backends
+type ToolHealthChecker interface {
+	// ToolHealthy reports whether the tool's backend is usable. Tools without
+	// a health gate are always healthy.
+	ToolHealthy(toolName string) bool
+}
+
+// toolHealthState counts the consecutive failures of one tool
+type toolHealthState struct {
+	failures    int
+	unhealthyAt time.Time
+}
+
+// toolHealth holds the health of the tools with a health gate
+type toolHealth struct {
+	mu     sync.Mutex
+	states map[string]*toolHealthState
+	now    func() time.Time
+}
+
+// ToolHealthy Report whether the tool may be offered to the model. An unhealthy tool is offered
+// again once the cooldown passed, so the next call probes whether its backend recovered.
+func (e *GenericToolExecutor) ToolHealthy(toolName string) bool {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.HealthGate.Enabled {
+		return true
+	}
+
+	e.health.mu.Lock()
+	defer e.health.mu.Unlock()
+	state, ok := e.health.states[toolName]
+	if !ok || state.failures < healthFailureThreshold(toolConfig.HealthGate) {
+		return true
+	}
+	return e.health.clock().Sub(state.unhealthyAt) >= healthCooldown(toolConfig.HealthGate)
+}
+
+// recordToolHealth updates the health of the tool from the outcome of a backend call.
+// Canceled calls say nothing about the backend and are ignored.
+func (e *GenericToolExecutor) recordToolHealth(ctx context.Context, toolConfig config.GenericToolConfig, err error) {
+	if !toolConfig.HealthGate.Enabled || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	e.health.mu.Lock()
+	defer e.health.mu.Unlock()
+	if e.health.states == nil {
+		e.health.states = make(map[string]*toolHealthState)
+	}
+	state, ok := e.health.states[toolConfig.Name]
+	if !ok {
+		state = &toolHealthState{}
+		e.health.states[toolConfig.Name] = state
+	}
+
+	threshold := healthFailureThreshold(toolConfig.HealthGate)
+	if err == nil {
+		if state.failures >= threshold {
+			logger.InfoC(ctx, "tool backend recovered", zap.String("tool", toolConfig.Name))
+		}
+		state.failures = 0
+		return
+	}
+
+	state.failures++
+	if state.failures >= threshold {
+		// A failed probe starts a new cooldown
+		state.unhealthyAt = e.health.clock()
+		logger.WarnC(ctx, "tool backend unhealthy, tool is left out of prompts",
+			zap.String("tool", toolConfig.Name), zap.Int("failures", state.failures), zap.Error(err))
+	}
+}
+
+// clock returns the current time, replaceable in tests
+func (h *toolHealth) clock() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+func healthFailureThreshold(cfg config.GenericToolHealthGateConfig) int {
+	if cfg.FailureThreshold <= 0 {
+		return defaultHealthFailureThreshold
+	}
+	return cfg.FailureThreshold
+}
+
+func healthCooldown(cfg config.GenericToolHealthGateConfig) time.Duration {
+	if cfg.CooldownSec <= 0 {
+		return defaultHealthCooldownSec * time.Second
+	}
+	return time.Duration(cfg.CooldownSec) * time.Second
+}
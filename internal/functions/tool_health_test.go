@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestToolHealth(t *testing.T) {
+	toolConfig := config.GenericToolConfig{
+		Name:       "codebase_search",
+		HealthGate: config.GenericToolHealthGateConfig{Enabled: true, FailureThreshold: 2, CooldownSec: 30},
+	}
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{toolConfig, {Name: "search_files"}},
+	})
+	now := time.Now()
+	executor.health.now = func() time.Time { return now }
+	ctx := context.Background()
+	backendErr := errors.New("connection refused")
+
+	assert.True(t, executor.ToolHealthy("codebase_search"))
+	executor.recordToolHealth(ctx, toolConfig, backendErr)
+	assert.True(t, executor.ToolHealthy("codebase_search"), "below the failure threshold")
+	executor.recordToolHealth(ctx, toolConfig, context.Canceled)
+	executor.recordToolHealth(ctx, toolConfig, backendErr)
+	assert.False(t, executor.ToolHealthy("codebase_search"), "canceled calls do not reset the count")
+	assert.True(t, executor.ToolHealthy("search_files"), "tools without a health gate stay healthy")
+
+	// After the cooldown the tool is offered again, a failed probe hides it for another cooldown
+	now = now.Add(30 * time.Second)
+	assert.True(t, executor.ToolHealthy("codebase_search"))
+	executor.recordToolHealth(ctx, toolConfig, backendErr)
+	assert.False(t, executor.ToolHealthy("codebase_search"))
+
+	now = now.Add(30 * time.Second)
+	executor.recordToolHealth(ctx, toolConfig, nil)
+	assert.True(t, executor.ToolHealthy("codebase_search"))
+	executor.recordToolHealth(ctx, toolConfig, backendErr)
+	assert.True(t, executor.ToolHealthy("codebase_search"), "a success resets the failure count")
+}
@@ -0,0 +1,53 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+func TestExecuteTools_ToolTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	newExecutor := func(timeoutMs int) *GenericToolExecutor {
+		return NewGenericToolExecutor(&config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+			Name:      "search_references",
+			Method:    http.MethodPost,
+			Endpoints: config.GenericToolEndpoints{Search: server.URL, Ready: server.URL},
+			Parameters: []config.GenericToolParameter{
+				{Name: "symbol", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+			},
+			TimeoutMs: timeoutMs,
+		}}})
+	}
+	ctx := context.WithValue(context.Background(), model.IdentityContextKey, &model.Identity{})
+	content := "<search_references><symbol>Execute</symbol></search_references>"
+
+	result, err := newExecutor(50).ExecuteTools(ctx, "search_references", content)
+	var timeoutErr *ToolTimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, 50, timeoutErr.TimeoutMs)
+	assert.Empty(t, result)
+
+	// Without a tool timeout the expired request context is still an error
+	requestCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = newExecutor(0).ExecuteTools(requestCtx, "search_references", content)
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &timeoutErr))
+}
@@ -0,0 +1,17 @@
+package functions
+
+import "context"
+
+type toolsDisabledContextKey struct{}
+
+// WithToolsDisabled returns a context marking the request as not allowing tool calls,
+// as requested with tool_choice "none"
+func WithToolsDisabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, toolsDisabledContextKey{}, true)
+}
+
+// ToolsDisabled reports whether the request does not allow tool calls
+func ToolsDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(toolsDisabledContextKey{}).(bool)
+	return disabled
+}
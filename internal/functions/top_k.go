@@ -0,0 +1,50 @@
+package functions
+
+import "context"
+
+const (
+	defaultTopKParam = "topK"
+	defaultTopKMax   = 50
+)
+
+// TopKOverrider is optionally implemented by executors that accept a per-request result count
+type TopKOverrider interface {
+	// EffectiveTopK returns the result count sent to the tool for the requested one, capped at
+	// the configured maximum. ok is false when the tool does not accept overrides.
+	EffectiveTopK(toolName string, requested int) (topK int, ok bool)
+}
+
+type topKContextKey struct{}
+
+// WithTopK returns a context carrying the result count for the tool calls made with it
+func WithTopK(ctx context.Context, topK int) context.Context {
+	return context.WithValue(ctx, topKContextKey{}, topK)
+}
+
+// topKFromContext returns the result count set by WithTopK
+func topKFromContext(ctx context.Context) (int, bool) {
+	topK, ok := ctx.Value(topKContextKey{}).(int)
+	return topK, ok && topK > 0
+}
+
+// EffectiveTopK Cap the requested result count at the tool's maximum
+func (e *GenericToolExecutor) EffectiveTopK(toolName string, requested int) (int, bool) {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil || !toolConfig.TopKOverride.Enabled || requested <= 0 {
+		return 0, false
+	}
+
+	maxTopK := toolConfig.TopKOverride.Max
+	if maxTopK <= 0 {
+		maxTopK = defaultTopKMax
+	}
+	return min(requested, maxTopK), true
+}
+
+// topKParam returns the parameter name receiving the result count
+func topKParam(name string) string {
+	if name == "" {
+		return defaultTopKParam
+	}
+	return name
+}
@@ -0,0 +1,47 @@
+package functions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestEffectiveTopK(t *testing.T) {
+	executor := NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{
+			{Name: "codebase_search", TopKOverride: config.GenericToolTopKOverrideConfig{Enabled: true, Max: 30}},
+			{Name: "search_files", TopKOverride: config.GenericToolTopKOverrideConfig{Enabled: true}},
+			{Name: "knowledge_base_search"},
+		},
+	})
+
+	topK, ok := executor.EffectiveTopK("codebase_search", 20)
+	assert.True(t, ok)
+	assert.Equal(t, 20, topK)
+
+	topK, ok = executor.EffectiveTopK("codebase_search", 100)
+	assert.True(t, ok)
+	assert.Equal(t, 30, topK, "capped at the configured maximum")
+
+	topK, ok = executor.EffectiveTopK("search_files", 100)
+	assert.True(t, ok)
+	assert.Equal(t, defaultTopKMax, topK)
+
+	_, ok = executor.EffectiveTopK("knowledge_base_search", 20)
+	assert.False(t, ok, "override is disabled for the tool")
+
+	_, ok = executor.EffectiveTopK("codebase_search", 0)
+	assert.False(t, ok)
+}
+
+func TestTopKFromContext(t *testing.T) {
+	_, ok := topKFromContext(context.Background())
+	assert.False(t, ok)
+
+	topK, ok := topKFromContext(WithTopK(context.Background(), 25))
+	assert.True(t, ok)
+	assert.Equal(t, 25, topK)
+}
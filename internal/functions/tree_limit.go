@@ -0,0 +1,145 @@
+package functions
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// defaultTreeMaxBytes keeps pruned output below the generic tool result truncation
+const defaultTreeMaxBytes = 80_000
+
+// prunedNode marks a removed node until its array is compacted
+type prunedNode struct{}
+
+// treeNode is a prunable object inside an array of the parsed result
+type treeNode struct {
+	array []interface{}
+	index int
+	depth int
+	score float64
+	size  int
+}
+
+// limitTreeResult prunes whole nodes of a JSON tool result until it fits the configured size,
+// so the output stays valid JSON instead of being cut mid-structure. Nodes nested deepest go
+// first, then the lowest scored, then the last in their list. Non-JSON results are returned as is.
+func limitTreeResult(toolConfig config.GenericToolConfig, result string) string {
+	maxBytes := toolConfig.TreeLimit.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultTreeMaxBytes
+	}
+	if len(result) <= maxBytes {
+		return result
+	}
+
+	trimmed := strings.TrimSpace(result)
+	var data interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return result
+	}
+
+	var nodes []*treeNode
+	collectTreeNodes(data, 0, toolConfig.TreeLimit.ScoreField, &nodes)
+	if len(nodes) == 0 {
+		return result
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].depth != nodes[j].depth {
+			return nodes[i].depth > nodes[j].depth
+		}
+		if nodes[i].score != nodes[j].score {
+			return nodes[i].score < nodes[j].score
+		}
+		return nodes[i].index > nodes[j].index
+	})
+
+	// Subtract estimated sizes to avoid re-encoding the whole tree for every removed node,
+	// then confirm with a real encoding and keep going if the estimate was optimistic
+	size := len(trimmed)
+	pruned := 0
+	var output []byte
+	for {
+		for size > maxBytes && pruned < len(nodes) {
+			node := nodes[pruned]
+			if _, removed := node.array[node.index].(prunedNode); !removed {
+				node.array[node.index] = prunedNode{}
+				size -= node.size + 1
+			}
+			pruned++
+		}
+
+		compacted := compactTree(data)
+		if root, ok := compacted.(map[string]interface{}); ok && pruned > 0 {
+			root["prunedNodes"] = pruned
+		}
+		encoded, err := json.Marshal(compacted)
+		if err != nil {
+			return result
+		}
+		output = encoded
+		if len(output) <= maxBytes || pruned == len(nodes) {
+			break
+		}
+		size = len(output)
+	}
+
+	logger.Info("tool result pruned to fit size limit",
+		zap.String("tool", toolConfig.Name),
+		zap.Int("originalBytes", len(result)),
+		zap.Int("prunedBytes", len(output)),
+		zap.Int("prunedNodes", pruned))
+	return string(output)
+}
+
+// collectTreeNodes records every object held in an array, with its nesting depth
+func collectTreeNodes(v interface{}, depth int, scoreField string, nodes *[]*treeNode) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, inner := range val {
+			collectTreeNodes(inner, depth, scoreField, nodes)
+		}
+	case []interface{}:
+		for i, item := range val {
+			fields, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			encoded, _ := json.Marshal(fields)
+			node := &treeNode{array: val, index: i, depth: depth, size: len(encoded)}
+			if scoreField != "" {
+				node.score, _ = toFloat(fields[scoreField])
+			}
+			*nodes = append(*nodes, node)
+			collectTreeNodes(fields, depth+1, scoreField, nodes)
+		}
+	}
+}
+
+// compactTree returns a copy of the tree without the pruned markers, leaving the
+// marked arrays in place for further pruning
+func compactTree(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(val))
+		for key, inner := range val {
+			copied[key] = compactTree(inner)
+		}
+		return copied
+	case []interface{}:
+		kept := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			if _, removed := item.(prunedNode); removed {
+				continue
+			}
+			kept = append(kept, compactTree(item))
+		}
+		return kept
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func relationTree(fanout, depth int) map[string]interface{} {
+	node := map[string]interface{}{"name": strings.Repeat("x", 40)}
+	if depth == 0 {
+		return node
+	}
+	children := make([]interface{}, fanout)
+	for i := range children {
+		child := relationTree(fanout, depth-1)
+		child["score"] = float64(i)
+		children[i] = child
+	}
+	node["children"] = children
+	return node
+}
+
+func TestLimitTreeResult_PrunesToValidJSON(t *testing.T) {
+	data, _ := json.Marshal(map[string]interface{}{"list": []interface{}{relationTree(4, 4)}})
+	toolConfig := config.GenericToolConfig{
+		Name:      "code_reference_search",
+		TreeLimit: config.GenericToolTreeLimitConfig{Enabled: true, MaxBytes: 2000, ScoreField: "score"},
+	}
+
+	result := limitTreeResult(toolConfig, string(data))
+	assert.LessOrEqual(t, len(result), 2000)
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(result), &parsed), "pruned output must parse")
+	assert.Greater(t, parsed["prunedNodes"], float64(0))
+
+	// The deepest level is pruned first, upper levels stay intact
+	root := parsed["list"].([]interface{})[0].(map[string]interface{})
+	children := root["children"].([]interface{})
+	assert.Len(t, children, 4)
+	grandChildren := children[0].(map[string]interface{})["children"].([]interface{})
+	assert.Len(t, grandChildren, 4)
+	for _, level3 := range grandChildren {
+		for _, leaf := range level3.(map[string]interface{})["children"].([]interface{}) {
+			_, hasChildren := leaf.(map[string]interface{})["children"]
+			assert.False(t, hasChildren, "deepest nodes must be pruned first")
+		}
+	}
+}
+
+func TestLimitTreeResult_LowestScoreFirst(t *testing.T) {
+	data, _ := json.Marshal(relationTree(4, 1))
+	toolConfig := config.GenericToolConfig{
+		TreeLimit: config.GenericToolTreeLimitConfig{Enabled: true, MaxBytes: len(data) - 10, ScoreField: "score"},
+	}
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(limitTreeResult(toolConfig, string(data))), &parsed))
+	children := parsed["children"].([]interface{})
+	assert.Len(t, children, 3)
+	assert.Equal(t, float64(1), children[0].(map[string]interface{})["score"])
+}
+
+func TestLimitTreeResult_Unchanged(t *testing.T) {
+	toolConfig := config.GenericToolConfig{TreeLimit: config.GenericToolTreeLimitConfig{Enabled: true, MaxBytes: 10}}
+
+	small := `{"list":[]}`
+	assert.Equal(t, small, limitTreeResult(toolConfig, small))
+
+	notJSON := fmt.Sprintf("plain text %s", strings.Repeat("y", 50))
+	assert.Equal(t, notJSON, limitTreeResult(toolConfig, notJSON))
+}
@@ -0,0 +1,81 @@
+package functions
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+const (
+	truncatedTailMarker = "... (truncated due to excessive length)"
+	truncatedHeadMarker = "(truncated due to excessive length) ..."
+	truncatedMiddleNote = "\n... (%d bytes omitted from the middle) ...\n"
+)
+
+// ResultTruncator is optionally implemented by executors whose tools choose which part of
+// an oversized result is kept
+type ResultTruncator interface {
+	// TruncationStrategy returns the tool's truncation strategy, config.ToolTruncateHead by default
+	TruncationStrategy(toolName string) string
+}
+
+// TruncationStrategy Get the tool's truncation strategy, unknown values keep the head
+func (e *GenericToolExecutor) TruncationStrategy(toolName string) string {
+	toolConfig, err := e.findToolConfig(toolName)
+	if err != nil {
+		return config.ToolTruncateHead
+	}
+	switch toolConfig.Truncation {
+	case config.ToolTruncateTail, config.ToolTruncateHeadTail:
+		return toolConfig.Truncation
+	default:
+		return config.ToolTruncateHead
+	}
+}
+
+// TruncateResult keeps maxBytes of the result according to the strategy and marks where
+// content was dropped; cuts never split a multi-byte character. Results within maxBytes are
+// returned unchanged.
+func TruncateResult(result string, maxBytes int, strategy string) string {
+	if len(result) <= maxBytes {
+		return result
+	}
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+
+	switch strategy {
+	case config.ToolTruncateTail:
+		return truncatedHeadMarker + keepTail(result, maxBytes)
+	case config.ToolTruncateHeadTail:
+		head := keepHead(result, maxBytes/2)
+		tail := keepTail(result, maxBytes-len(head))
+		return head + fmt.Sprintf(truncatedMiddleNote, len(result)-len(head)-len(tail)) + tail
+	default:
+		return keepHead(result, maxBytes) + truncatedTailMarker
+	}
+}
+
+// keepHead returns at most maxBytes from the start of s
+func keepHead(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
+// keepTail returns at most maxBytes from the end of s
+func keepTail(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	start := len(s) - maxBytes
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return s[start:]
+}
@@ -0,0 +1,52 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestTruncateResult(t *testing.T) {
+	result := "HEAD " + strings.Repeat("middle ", 100) + "root cause: nil pointer"
+
+	head := TruncateResult(result, 40, config.ToolTruncateHead)
+	assert.True(t, strings.HasPrefix(head, "HEAD middle"))
+	assert.True(t, strings.HasSuffix(head, truncatedTailMarker))
+	assert.NotContains(t, head, "root cause")
+
+	tail := TruncateResult(result, 40, config.ToolTruncateTail)
+	assert.True(t, strings.HasPrefix(tail, truncatedHeadMarker))
+	assert.True(t, strings.HasSuffix(tail, "root cause: nil pointer"))
+	assert.NotContains(t, tail, "HEAD")
+
+	headTail := TruncateResult(result, 40, config.ToolTruncateHeadTail)
+	assert.True(t, strings.HasPrefix(headTail, "HEAD middle"))
+	assert.True(t, strings.HasSuffix(headTail, "nil pointer"))
+	assert.Contains(t, headTail, fmt.Sprintf("\n... (%d bytes omitted from the middle) ...\n", len(result)-40))
+
+	assert.Equal(t, "short", TruncateResult("short", 40, config.ToolTruncateTail), "results within the limit are kept")
+}
+
+func TestTruncateResult_KeepsCharactersWhole(t *testing.T) {
+	result := strings.Repeat("检索结果", 20)
+	for _, strategy := range []string{config.ToolTruncateHead, config.ToolTruncateTail, config.ToolTruncateHeadTail} {
+		assert.True(t, utf8.ValidString(TruncateResult(result, 25, strategy)), strategy)
+	}
+}
+
+func TestTruncationStrategy(t *testing.T) {
+	executor := &GenericToolExecutor{toolConfig: &config.ToolConfig{GenericTools: []config.GenericToolConfig{
+		{Name: "run_tests", Truncation: config.ToolTruncateTail},
+		{Name: "codebase_search"},
+		{Name: "search_files", Truncation: "middle"},
+	}}}
+	assert.Equal(t, config.ToolTruncateTail, executor.TruncationStrategy("run_tests"))
+	assert.Equal(t, config.ToolTruncateHead, executor.TruncationStrategy("codebase_search"))
+	assert.Equal(t, config.ToolTruncateHead, executor.TruncationStrategy("search_files"), "unknown strategies keep the head")
+	assert.Equal(t, config.ToolTruncateHead, executor.TruncationStrategy("unknown"))
+}
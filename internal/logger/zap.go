@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/zgsm-ai/chat-rag/internal/types"
 	"go.uber.org/zap"
@@ -23,6 +24,45 @@ func init() {
 	}
 }
 
+// stdout receives structured entries meant for container log collectors, built on first use
+var (
+	stdoutOnce  sync.Once
+	stdout      *zap.Logger
+	environment string
+)
+
+// SetEnvironment tags every entry of the global logger with the deployment environment.
+// It is meant to be called once at startup; an empty environment leaves the logger unchanged
+func SetEnvironment(env string) {
+	if env != "" {
+		environment = env
+		L = L.With(zap.String("environment", env))
+	}
+}
+
+// Stdout returns a JSON logger writing to stdout, whereas L writes to stderr
+func Stdout() *zap.Logger {
+	stdoutOnce.Do(func() {
+		config := zap.NewProductionConfig()
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		config.OutputPaths = []string{"stdout"}
+		config.DisableCaller = true
+		config.DisableStacktrace = true
+		config.Sampling = nil
+
+		var err error
+		if stdout, err = config.Build(); err != nil {
+			L.Error("Failed to initialize stdout logger, using the global logger", zap.Error(err))
+			stdout = L
+			return
+		}
+		if environment != "" {
+			stdout = stdout.With(zap.String("environment", environment))
+		}
+	})
+	return stdout
+}
+
 func WithRequestID(ctx context.Context) *zap.Logger {
 	if requestID := ctx.Value(types.HeaderRequestId); requestID != nil {
 		if id, ok := requestID.(string); ok && id != "" {
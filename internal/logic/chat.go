@@ -7,13 +7,17 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
 	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
 	"github.com/zgsm-ai/chat-rag/internal/functions"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
@@ -39,6 +43,30 @@ type ChatCompletionLogic struct {
 	orderedModels   []string
 	streamCommitted bool
 	originalModel   string
+	responseFilter  *responseFilter
+	// Set once the stale index advisory was sent, it is shown at most once per request
+	indexStaleAdvised bool
+	// Hash of the most recently injected tool result
+	lastToolResultHash string
+	// Held back tool status updates, set when batching is enabled
+	toolStatusBatch *toolStatusBatch
+	// Tools in the status hash of the request, oldest first
+	toolStatusTools []string
+	// Results of the request's tool calls by tool and parameters, reused by repeated calls
+	requestToolResults map[string]string
+	// Result count requested via extra_body for search tools, 0 when not overridden
+	semanticTopK int
+	// Client messages of a request sampled for QA, nil when not sampled
+	qaMessages []types.Message
+	// Tool call traces requested by an authorized caller
+	toolTrace toolTraceMode
+	// Tokens of results injected by tools with a context share cap
+	contextShareTokens int
+	// Tool call depth resolved for the prompt mode of the request, 0 until resolved
+	toolCallDepth int
+	// Temperature sent by the caller, before model defaults and clamping were applied
+	requestedTemperature any
+	temperatureRequested bool
 }
 
 func NewChatCompletionLogic(
@@ -59,6 +87,7 @@ func NewChatCompletionLogic(
 		headers:         headers,
 		toolExecutor:    svcCtx.ToolExecutor,
 		originalModel:   request.Model,
+		responseFilter:  newResponseFilter(svcCtx.Config.ResponseFilters),
 	}
 }
 
@@ -87,9 +116,29 @@ func (l *ChatCompletionLogic) processRequest() (*model.ChatLog, *ds.ProcessedPro
 		}
 	}
 
+	l.semanticTopK = l.takeSemanticTopK()
+	if l.takeDisableSemantic() {
+		l.ctx = functions.WithSemanticDisabled(l.ctx)
+	}
+	if l.toolChoiceNone() {
+		l.ctx = functions.WithToolsDisabled(l.ctx)
+	}
+	l.toolTrace = l.takeToolTraceMode()
+	l.sampleQARequest()
+
 	// Initialize chat log
 	chatLog := l.newChatLog(startTime)
 
+	// Fail fast on prompts that no compression can bring within limits
+	if maxTokens := l.svcCtx.Config.MaxPromptTokens; maxTokens > 0 && chatLog.Tokens.Original.All > maxTokens {
+		logger.WarnC(l.ctx, "prompt exceeds maximum size, rejecting request",
+			zap.Int("tokens", chatLog.Tokens.Original.All),
+			zap.Int("maxPromptTokens", maxTokens))
+		err := types.NewPromptTooLargeError(chatLog.Tokens.Original.All, maxTokens)
+		chatLog.AddError(types.ErrPromptTooLarge, err)
+		return chatLog, nil, err
+	}
+
 	promptArranger := promptflow.NewPromptProcessor(
 		l.ctx,
 		l.svcCtx,
@@ -105,11 +154,20 @@ func (l *ChatCompletionLogic) processRequest() (*model.ChatLog, *ds.ProcessedPro
 
 	// Update chat log with processed prompt info
 	l.updateChatLog(chatLog, processedPrompt)
+	l.setSystemPromptDebugHeaders(processedPrompt)
+	l.setAgentHeader(processedPrompt.Agent)
+	l.setMessagesDroppedHeader(len(processedPrompt.DroppedMessages))
+	l.applyOutputTokenLimits(processedPrompt.Agent, chatLog)
+	l.applyTemperature(chatLog)
+	l.setEffectiveConfigDebug(processedPrompt, chatLog)
+	l.limitContentParts(processedPrompt.Messages, chatLog)
 
 	// Reject requests where any user message has empty content, to avoid model inference errors.
 	for _, msg := range processedPrompt.Messages {
 		if msg.Role == types.RoleUser && isEmptyContent(msg.Content) {
-			return chatLog, processedPrompt, types.NewEmptyMessageContentError()
+			err := types.NewEmptyMessageContentError()
+			chatLog.AddError(types.ErrInvalidArgument, err)
+			return chatLog, processedPrompt, err
 		}
 	}
 
@@ -120,9 +178,12 @@ func (l *ChatCompletionLogic) newChatLog(startTime time.Time) *model.ChatLog {
 	userTokens := l.countTokensInMessages(utils.GetUserMsgs(l.request.Messages))
 	allTokens := l.countTokensInMessages(l.request.Messages)
 
-	// Create a deep copy of the original messages to avoid reference issues
-	// originalPrompt := make([]types.Message, len(l.request.Messages))
-	// copy(originalPrompt, l.request.Messages)
+	// Copy the original messages, the request messages grow with every tool call
+	var originalPrompt []types.Message
+	if l.svcCtx.Config.Log.PromptStorage == config.PromptStorageDiff {
+		originalPrompt = make([]types.Message, len(l.request.Messages))
+		copy(originalPrompt, l.request.Messages)
+	}
 
 	modelName := l.originalModel
 	if modelName == "" {
@@ -133,8 +194,9 @@ func (l *ChatCompletionLogic) newChatLog(startTime time.Time) *model.ChatLog {
 		Identity:  *l.identity,
 		Timestamp: startTime,
 		Params: model.RequestParams{
-			Model:     modelName,
-			LlmParams: l.request.LLMRequestParams,
+			Model:         modelName,
+			LlmParams:     l.request.LLMRequestParams,
+			OriginalModel: l.identity.OriginalModel,
 		},
 		Tokens: types.TokenMetrics{
 			Original: types.TokenStats{
@@ -143,7 +205,7 @@ func (l *ChatCompletionLogic) newChatLog(startTime time.Time) *model.ChatLog {
 				All:          allTokens,
 			},
 		},
-		// OriginalPrompt: originalPrompt,
+		OriginalPrompt: originalPrompt,
 	}
 }
 
@@ -163,11 +225,33 @@ func (l *ChatCompletionLogic) updateChatLog(chatLog *model.ChatLog, processedPro
 
 	chatLog.ProcessedPrompt = processedPrompt.Messages
 	chatLog.Agent = processedPrompt.Agent
+	chatLog.InjectedTools = processedPrompt.InjectedTools
+	chatLog.UnhealthyTools = processedPrompt.UnhealthyTools
+	chatLog.SplitUserMessages = processedPrompt.SplitUserMessages
+	chatLog.DedupedTokens = processedPrompt.DedupedTokens
+	chatLog.NoToolsSavedTokens = processedPrompt.NoToolsSavedTokens
+	chatLog.SemanticSkipped = processedPrompt.SemanticSkipped
+	chatLog.DroppedMessages = processedPrompt.DroppedMessages
+	chatLog.QuestionReinjected = processedPrompt.QuestionReinjected
+	chatLog.CompressionSkipped = processedPrompt.CompressionSkipped
+	chatLog.CompressionFallback = processedPrompt.CompressionFallback
+	chatLog.CompressionDropped = processedPrompt.CompressionDropped
+	chatLog.SummaryModel = processedPrompt.SummaryModel
+	chatLog.CompressionStrategy = processedPrompt.CompressionStrategy
+	chatLog.CurrentTimeInjected = processedPrompt.CurrentTimeInjected
+	if l.svcCtx.Config.Metrics.SystemPromptChecksumLabel {
+		chatLog.SystemPromptChecksum = systemPromptChecksum(processedPrompt.Messages)
+	}
 }
 
 func (l *ChatCompletionLogic) logCompletion(chatLog *model.ChatLog) {
 	chatLog.Latency.TotalLatency = time.Since(chatLog.Timestamp).Milliseconds()
 	chatLog.Params.RoutedModel = l.request.Model
+	l.recordQASample(chatLog)
+	l.rememberRetrieval(chatLog)
+	if l.svcCtx.Config.Log.StdoutSummary {
+		logRequestSummary(logger.Stdout(), chatLog)
+	}
 	if l.svcCtx.LoggerService != nil {
 		l.svcCtx.LoggerService.LogAsync(chatLog, l.headers)
 	}
@@ -175,6 +259,14 @@ func (l *ChatCompletionLogic) logCompletion(chatLog *model.ChatLog) {
 
 // ChatCompletion handles chat completion requests
 func (l *ChatCompletionLogic) ChatCompletion() (resp *types.ChatCompletionResponse, err error) {
+	// The handler writes the JSON response after return, strip suppressed headers first
+	defer l.stripSuppressedResponseHeaders()
+
+	// The echo model measures transport overhead only, nothing else runs
+	if l.isEchoRequest() {
+		return l.echoCompletion(), nil
+	}
+
 	// Router: select model before prompt processing & LLM client creation
 	origModel := l.request.Model
 	if l.svcCtx.Config.Router != nil && l.svcCtx.Config.Router.Enabled && strings.EqualFold(l.request.Model, "auto") {
@@ -220,7 +312,9 @@ func (l *ChatCompletionLogic) ChatCompletion() (resp *types.ChatCompletionRespon
 		chatLog.IsPromptProceed = true
 	} else {
 		logger.ErrorC(l.ctx, "failed to process request", zap.Error(err))
-		chatLog.AddError(types.ErrServerError, err)
+		if !isRequestRejection(err) {
+			chatLog.AddError(types.ErrServerError, err)
+		}
 		chatLog.IsPromptProceed = false
 		return nil, err
 	}
@@ -236,7 +330,7 @@ func (l *ChatCompletionLogic) ChatCompletion() (resp *types.ChatCompletionRespon
 		logger.InfoC(l.ctx, "degradation: attempting ordered models",
 			zap.Strings("ordered", l.orderedModels),
 		)
-		resp, derr := l.callWithDegradation(l.request.LLMRequestParams, idleTracker)
+		resp, derr := l.callWithDegradation(l.request.LLMRequestParams, idleTracker, chatLog)
 		if derr != nil {
 			chatLog.AddError(types.ErrApiError, derr)
 			return nil, derr
@@ -263,6 +357,11 @@ func (l *ChatCompletionLogic) ChatCompletion() (resp *types.ChatCompletionRespon
 
 	// Extract response content and usage information
 	l.responseHandler.extractResponseInfo(chatLog, &response)
+
+	if l.responseFilter != nil {
+		l.responseFilter.filterResponseChoices(response.Choices)
+		l.recordResponseFilters(chatLog)
+	}
 	return &response, nil
 }
 
@@ -296,6 +395,25 @@ func (l *ChatCompletionLogic) getRetryConfig() (maxRetryCount int, retryInterval
 
 // ChatCompletionStream handles streaming chat completion with SSE
 func (l *ChatCompletionLogic) ChatCompletionStream() error {
+	if l.isEchoRequest() {
+		return l.echoCompletionStream()
+	}
+	defer l.flushToolStatus()
+
+	// Reject requests without any user message before routing and prompt processing,
+	// responding with a single well-formed SSE error chunk followed by [DONE]
+	if !hasUserMessage(l.request.Messages) {
+		invalidErr := types.NewNoUserMessageError()
+		logger.WarnC(l.ctx, "request contains no user message",
+			zap.Int("messages", len(l.request.Messages)))
+		l.responseHandler.sendSSEError(l.ctx, l.writer, invalidErr)
+
+		chatLog := l.newChatLog(time.Now())
+		chatLog.AddError(types.ErrInvalidRequest, invalidErr)
+		l.logCompletion(chatLog)
+		return nil
+	}
+
 	// Router: select model before streaming LLM client creation
 	origModel := l.request.Model
 	if l.svcCtx.Config.Router != nil && l.svcCtx.Config.Router.Enabled && strings.EqualFold(l.request.Model, "auto") {
@@ -339,9 +457,14 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 	if err == nil {
 		l.request.Messages = processedPrompt.Messages
 		chatLog.IsPromptProceed = true
+		l.toolCallDepth = l.resolveToolCallDepth()
 	} else {
 		logger.ErrorC(l.ctx, "failed to process request in streaming", zap.Error(err))
 		chatLog.IsPromptProceed = false
+		if isRequestRejection(err) {
+			l.responseHandler.sendSSEError(l.ctx, l.writer, err)
+			return nil
+		}
 		return l.handleStreamError(err, chatLog)
 	}
 
@@ -365,8 +488,9 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 			chatLog.AddError(types.ErrServerError, err)
 			return fmt.Errorf("LLM client creation failed: %w", err)
 		}
-		llmClient.SetTools(processedPrompt.Tools)
-		for attempt := 0; attempt <= maxRetryCount; attempt++ {
+		llmClient.SetTools(l.offeredTools(processedPrompt))
+		attempt := 0
+		for attempt <= maxRetryCount {
 			logger.InfoC(l.ctx, "single-model retry(stream): attempting model",
 				zap.String("model", l.request.Model),
 				zap.Int("attempt", attempt+1),
@@ -374,8 +498,7 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 			)
 
 			l.streamCommitted = false
-
-			err = l.handleStreamingWithTools(l.ctx, llmClient, flusher, chatLog, MaxToolCallDepth, idleTracker)
+			err = l.handleStreamingWithTools(l.ctx, llmClient, flusher, chatLog, l.toolCallDepthLimit(), idleTracker)
 			if err == nil {
 				return nil
 			}
@@ -384,6 +507,9 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 			if l.streamCommitted {
 				return l.handleStreamError(err, chatLog)
 			}
+			if l.retryStreamDrop(err, chatLog, idleTracker) {
+				continue
+			}
 
 			retryable := isRetryableAPIError(err)
 			logger.WarnC(l.ctx, "single-model retry(stream): attempt failed before first token",
@@ -402,6 +528,7 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 					break
 				}
 				time.Sleep(retryInterval)
+				attempt++
 				continue
 			}
 
@@ -428,7 +555,7 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 				zap.String("model", modelName), zap.Error(err))
 			continue
 		}
-		llmClient.SetTools(processedPrompt.Tools)
+		llmClient.SetTools(l.offeredTools(processedPrompt))
 
 		attempt := 0
 		for attempt <= maxRetryCount {
@@ -439,9 +566,10 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 			)
 
 			l.request.Model = modelName
-			l.streamCommitted = false
+			l.applyModelTemperature(modelName, chatLog)
 
-			err = l.handleStreamingWithTools(l.ctx, llmClient, flusher, chatLog, MaxToolCallDepth, idleTracker)
+			l.streamCommitted = false
+			err = l.handleStreamingWithTools(l.ctx, llmClient, flusher, chatLog, l.toolCallDepthLimit(), idleTracker)
 			if err == nil {
 				return nil
 			}
@@ -451,6 +579,9 @@ func (l *ChatCompletionLogic) ChatCompletionStream() error {
 				// Already started streaming; report error to client and stop
 				return l.handleStreamError(err, chatLog)
 			}
+			if l.retryStreamDrop(err, chatLog, idleTracker) {
+				continue
+			}
 
 			retryable := isRetryableAPIError(err)
 			logger.WarnC(l.ctx, "degradation(stream): attempt failed before first token",
@@ -495,6 +626,14 @@ type streamState struct {
 	modelStart   time.Time
 	firstToken   bool // Flag to track if first token has been received
 	windowSent   bool // Flag to track if first token has been sent to client
+	// Native tool calls are collected from tool_calls deltas instead of forwarded
+	funcCalling bool
+	funcCalls   map[int]*functionCall
+	// Withheld tool_calls lines and their finish chunk, released when no server tool runs
+	funcCallLines      []string
+	funcCallFinish     string
+	toolCallsForwarded bool // Withheld tool_calls lines were sent to the client
+	roleSent           bool // A chunk announcing the assistant role was forwarded
 }
 
 func newStreamState() *streamState {
@@ -515,7 +654,7 @@ func (l *ChatCompletionLogic) handleStreamingWithTools(
 ) error {
 	logger.InfoC(ctx, "starting to handle streaming with tools",
 		zap.Int("remainingDepth", remainingDepth),
-		zap.Int("MaxToolCallDepth", MaxToolCallDepth),
+		zap.Int("MaxToolCallDepth", l.toolCallDepthLimit()),
 		zap.String("promptMode", string(l.request.ExtraBody.PromptMode)),
 	)
 
@@ -544,6 +683,7 @@ func (l *ChatCompletionLogic) handleStreamingWithTools(
 	}
 
 	state := newStreamState()
+	state.funcCalling = l.offersServerFunctions()
 
 	// Phase 1: Process streaming response
 	toolDetected, err := l.processStream(ctx, llmClient, flusher, state, remainingDepth, chatLog, idleTracker)
@@ -551,12 +691,28 @@ func (l *ChatCompletionLogic) handleStreamingWithTools(
 		// Do not send SSE error here; let caller decide based on commit status
 		return err
 	}
+	if !toolDetected && len(state.funcCalls) > 0 {
+		switch {
+		case remainingDepth > 0:
+			err = l.dispatchFunctionCall(ctx, flusher, state, chatLog)
+		case state.serverFunctionCall(chatLog.InjectedTools) != nil:
+			// Out of depth, the server call is not run
+			err = l.releaseFunctionCallFinish(flusher, state)
+		default:
+			err = l.releaseWithheldLines(flusher, state, state.funcCallLines)
+		}
+		if err != nil {
+			return err
+		}
+		toolDetected = state.toolDetected
+	}
 
 	// Phase 2: Handle tool execution or complete response
 	if toolDetected {
 		return l.handleToolExecution(ctx, llmClient, flusher, chatLog, state, remainingDepth, idleTracker)
 	}
 
+	l.logToolChainEnd(ctx, chatLog, state, remainingDepth)
 	return l.completeStreamResponse(flusher, chatLog, state)
 }
 
@@ -604,7 +760,13 @@ func (l *ChatCompletionLogic) processStream(
 
 // handleResonseHeaders Set the specified request header to the response
 func (l *ChatCompletionLogic) handleResonseHeaders(header *http.Header, requiredHeaders []string, chatLog *model.ChatLog) {
+	// Strip suppressed headers before anything is written to the client
+	defer l.stripSuppressedResponseHeaders()
+
 	for _, headerName := range requiredHeaders {
+		if l.isSuppressedResponseHeader(headerName) {
+			continue
+		}
 		if headerValue := header.Get(headerName); headerValue != "" {
 			if l.writer.Header().Get(headerName) != "" {
 				continue
@@ -621,6 +783,42 @@ func (l *ChatCompletionLogic) handleResonseHeaders(header *http.Header, required
 	}
 }
 
+// isSuppressedResponseHeader checks whether the header is in the configured deny-list
+func (l *ChatCompletionLogic) isSuppressedResponseHeader(headerName string) bool {
+	for _, suppressed := range l.svcCtx.Config.ResponseHeaders.Suppress {
+		if strings.EqualFold(suppressed, headerName) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripSuppressedResponseHeaders removes deny-listed headers from the client response
+func (l *ChatCompletionLogic) stripSuppressedResponseHeaders() {
+	if l.writer == nil {
+		return
+	}
+	for _, suppressed := range l.svcCtx.Config.ResponseHeaders.Suppress {
+		l.writer.Header().Del(suppressed)
+	}
+}
+
+// setAgentHeader tells the client which agent handled the request, when enabled
+func (l *ChatCompletionLogic) setAgentHeader(agent string) {
+	if !l.svcCtx.Config.ResponseHeaders.IncludeAgent || l.writer == nil || agent == "" {
+		return
+	}
+	l.writer.Header().Set(types.HeaderAgent, agent)
+}
+
+// setMessagesDroppedHeader tells the client how many of its messages did not reach the model
+func (l *ChatCompletionLogic) setMessagesDroppedHeader(dropped int) {
+	if !l.svcCtx.Config.ResponseHeaders.IncludeMessagesDropped || l.writer == nil {
+		return
+	}
+	l.writer.Header().Set(types.HeaderMessagesDropped, strconv.Itoa(dropped))
+}
+
 // handleStreamChunk processes individual streaming chunks
 func (l *ChatCompletionLogic) handleStreamChunk(
 	ctx context.Context,
@@ -638,7 +836,13 @@ func (l *ChatCompletionLogic) handleStreamChunk(
 	if usage != nil {
 		l.usage = usage
 	}
+	if state.funcCalling && content == "" && collectToolCallDeltas(rawLine, state) {
+		return nil
+	}
 	if content == "" {
+		if l.svcCtx.Config.SuppressEmptyDeltas && isEmptyDelta(rawLine, state) {
+			return nil
+		}
 		return l.sendRawLine(flusher, rawLine)
 	}
 
@@ -670,11 +874,17 @@ func (l *ChatCompletionLogic) handleStreamChunk(
 	}
 
 	// Check for tool detection
-	if !state.toolDetected && l.toolExecutor != nil && remainingDepth > 0 &&
-		l.svcCtx.Config.Tools != nil && !l.svcCtx.Config.Tools.DisableTools {
-		if err := l.detectAndHandleTool(ctx, flusher, state); err != nil {
+	detecting := l.toolExecutor != nil && remainingDepth > 0 &&
+		l.svcCtx.Config.Tools != nil && !l.svcCtx.Config.Tools.DisableTools
+	if !state.toolDetected && detecting {
+		if err := l.detectAndHandleTool(ctx, flusher, state, chatLog); err != nil {
 			return err
 		}
+		if state.toolDetected {
+			if err := l.checkClientConnected(ctx, state.toolName); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Send content beyond window
@@ -688,17 +898,47 @@ func (l *ChatCompletionLogic) handleStreamChunk(
 				zap.Duration("firstWindowTokenLatency", windowLatency))
 		}
 
-		if err := l.sendStreamContent(flusher, state.response, state.window[0]); err != nil {
+		return l.sendWindowHead(flusher, state, detecting)
+	}
+
+	return nil
+}
+
+// sendWindowHead sends the oldest chunks of the window until it is back under its size. Text from
+// the start of a tool tag cut off at the end of the window is held back until the following chunks
+// complete the tag or rule it out.
+func (l *ChatCompletionLogic) sendWindowHead(flusher http.Flusher, state *streamState, detecting bool) error {
+	holdFrom := -1
+	if detector, ok := l.toolExecutor.(functions.PartialTagDetector); ok && detecting {
+		holdFrom = detector.PartialToolTagStart(strings.Join(state.window, ""))
+	}
+
+	for len(state.window) >= state.windowSize {
+		head := state.window[0]
+		if holdFrom >= 0 && len(head) > holdFrom {
+			if holdFrom > 0 {
+				if err := l.sendModelContent(flusher, state.response, head[:holdFrom]); err != nil {
+					return err
+				}
+				state.window[0] = head[holdFrom:]
+			}
+			return nil
+		}
+
+		if err := l.sendModelContent(flusher, state.response, head); err != nil {
 			return err
 		}
 		state.window = state.window[1:]
+		if holdFrom >= 0 {
+			holdFrom -= len(head)
+		}
 	}
-
 	return nil
 }
 
 // detectAndHandleTool handles tool detection and pre-tool content sending
-func (l *ChatCompletionLogic) detectAndHandleTool(ctx context.Context, flusher http.Flusher, state *streamState) error {
+func (l *ChatCompletionLogic) detectAndHandleTool(ctx context.Context, flusher http.Flusher,
+	state *streamState, chatLog *model.ChatLog) error {
 	currentContent := strings.Join(state.window, "")
 	hasTool, name := l.toolExecutor.DetectTools(ctx, currentContent)
 
@@ -706,6 +946,17 @@ func (l *ChatCompletionLogic) detectAndHandleTool(ctx context.Context, flusher h
 		return nil
 	}
 
+	// Pass the tool tag through as text when no more distinct tools are allowed
+	if l.distinctToolLimitReached(chatLog, name) {
+		if !slices.Contains(chatLog.SkippedTools, name) {
+			chatLog.SkippedTools = append(chatLog.SkippedTools, name)
+			logger.InfoC(ctx, "distinct tool limit reached, passing tool through as text",
+				zap.String("name", name),
+				zap.Int("maxDistinctTools", l.svcCtx.Config.Tools.MaxDistinctTools))
+		}
+		return nil
+	}
+
 	state.toolDetected = true
 	state.toolName = name
 	logger.InfoC(ctx, "detected server xml tool", zap.String("name", name))
@@ -714,7 +965,7 @@ func (l *ChatCompletionLogic) detectAndHandleTool(ctx context.Context, flusher h
 	toolStartIndex := strings.Index(currentContent, "<"+name+">")
 	if toolStartIndex > 0 {
 		preToolContent := currentContent[:toolStartIndex]
-		if err := l.sendStreamContent(flusher, state.response, preToolContent); err != nil {
+		if err := l.sendModelContent(flusher, state.response, preToolContent); err != nil {
 			logger.ErrorC(ctx, "failed to sendStreamContent when detecting tool",
 				zap.String("preToolContent", preToolContent), zap.Error(err))
 			return err
@@ -725,6 +976,71 @@ func (l *ChatCompletionLogic) detectAndHandleTool(ctx context.Context, flusher h
 	return nil
 }
 
+// resolveToolCallDepth returns the tool call depth configured for the prompt mode of the
+// request, falling back to MaxToolCallDepth for modes without a positive entry
+func (l *ChatCompletionLogic) resolveToolCallDepth() int {
+	if l.svcCtx.Config.Tools == nil {
+		return MaxToolCallDepth
+	}
+	mode := string(l.request.ExtraBody.PromptMode)
+	if depth, ok := l.svcCtx.Config.Tools.MaxToolCallDepthByMode[mode]; ok && depth > 0 {
+		return depth
+	}
+	return MaxToolCallDepth
+}
+
+// toolCallDepthLimit returns the tool call depth of this request
+func (l *ChatCompletionLogic) toolCallDepthLimit() int {
+	if l.toolCallDepth > 0 {
+		return l.toolCallDepth
+	}
+	return l.resolveToolCallDepth()
+}
+
+// logToolChainEnd records how the tool call chain ended: either the model stopped calling
+// tools by itself, or it still asked for one after the depth was used up
+func (l *ChatCompletionLogic) logToolChainEnd(ctx context.Context, chatLog *model.ChatLog,
+	state *streamState, remainingDepth int) {
+	maxDepth := l.toolCallDepthLimit()
+	if remainingDepth <= 0 && l.toolsEnabled() {
+		hasTool, name := l.toolExecutor.DetectTools(ctx, state.fullContent.String())
+		if call := state.serverFunctionCall(chatLog.InjectedTools); !hasTool && call != nil {
+			hasTool, name = true, call.name
+		}
+		if hasTool {
+			chatLog.ToolDepthLimited = true
+			logger.WarnC(ctx, "tool call depth limit reached, tool call not executed",
+				zap.String("name", name),
+				zap.Int("maxToolCallDepth", maxDepth),
+				zap.String("promptMode", string(l.request.ExtraBody.PromptMode)))
+			return
+		}
+	}
+	if len(chatLog.ToolCalls) > 0 {
+		logger.InfoC(ctx, "tool call chain finished",
+			zap.Int("depth", maxDepth-remainingDepth),
+			zap.Int("maxToolCallDepth", maxDepth))
+	}
+}
+
+// distinctToolLimitReached reports whether calling the tool would exceed the configured
+// number of distinct tools in this request. Tools already called are always allowed.
+func (l *ChatCompletionLogic) distinctToolLimitReached(chatLog *model.ChatLog, name string) bool {
+	maxTools := l.svcCtx.Config.Tools.MaxDistinctTools
+	if maxTools <= 0 {
+		return false
+	}
+
+	used := make(map[string]struct{}, len(chatLog.ToolCalls))
+	for _, call := range chatLog.ToolCalls {
+		used[call.ToolName] = struct{}{}
+	}
+	if _, ok := used[name]; ok {
+		return false
+	}
+	return len(used) >= maxTools
+}
+
 // handleToolExecution executes the detected tool and continues processing
 func (l *ChatCompletionLogic) handleToolExecution(
 	ctx context.Context,
@@ -742,7 +1058,38 @@ func (l *ChatCompletionLogic) handleToolExecution(
 		ToolInput: toolContent,
 	}
 
-	l.updateToolStatus(state.toolName, types.ToolStatusRunning)
+	if extractor, ok := l.toolExecutor.(functions.ParameterExtractor); ok {
+		params, err := extractor.ExtractToolParams(state.toolName, toolContent)
+		toolCall.ToolParams = params
+		if err != nil {
+			toolCall.ToolParamsError = err.Error()
+		}
+	}
+
+	// Bias the query toward areas not covered by the model's latest summary
+	if augmenter, ok := l.toolExecutor.(functions.QueryAugmenter); ok {
+		summary := functions.ExtractThinkingSummary(state.fullContent.String())
+		if augmented, query, ok := augmenter.AugmentQuery(state.toolName, toolContent, summary); ok {
+			logger.InfoC(ctx, "tool query augmented with thinking summary",
+				zap.String("tool", state.toolName), zap.String("query", query))
+			toolContent = augmented
+			toolCall.AugmentedQuery = query
+		}
+	}
+
+	// Caller requested result count for tools accepting it
+	if overrider, ok := l.toolExecutor.(functions.TopKOverrider); ok && l.semanticTopK > 0 {
+		if topK, ok := overrider.EffectiveTopK(state.toolName, l.semanticTopK); ok {
+			ctx = functions.WithTopK(ctx, topK)
+			toolCall.EffectiveTopK = &topK
+		}
+	}
+
+	l.updateToolStatus(chatLog, state.toolName, types.ToolStatusRunning)
+	// Model output held back by the response filters goes out before the tool status
+	if err := l.flushModelContent(flusher, state.response); err != nil {
+		return err
+	}
 	// Send tool use information to client page
 	if err := l.sendStreamContent(flusher, state.response,
 		fmt.Sprintf("%s`%s` %s", types.StrFilterToolSearchStart, state.toolName,
@@ -758,12 +1105,115 @@ func (l *ChatCompletionLogic) handleToolExecution(
 		time.Sleep(600 * time.Millisecond)
 	}
 
+	// Deadline scaled by the position of this call in the tool call chain
+	toolCtx := ctx
+	if l.svcCtx.Config.Tools != nil {
+		depth := l.toolCallDepthLimit() - remainingDepth
+		if timeout := functions.DepthTimeout(l.svcCtx.Config.Tools.DepthTimeout, depth); timeout > 0 {
+			var cancel context.CancelFunc
+			toolCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+			toolCall.TimeoutMs = timeout.Milliseconds()
+			logger.InfoC(ctx, "tool call timeout",
+				zap.String("tool", state.toolName), zap.Int("depth", depth), zap.Duration("timeout", timeout))
+		}
+	}
+
+	// Tools with failover backends record the endpoint that served the call
+	toolCtx, servedEndpoint := client.WithServedEndpoint(toolCtx)
+
+	// The status dots above take a few seconds, the client may have left meanwhile
+	if err := l.checkClientConnected(ctx, state.toolName); err != nil {
+		l.updateToolStatus(chatLog, state.toolName, types.ToolStatusFailed)
+		return err
+	}
+
 	// execute and record tool call latency
 	toolStart := time.Now()
-	result, err := l.toolExecutor.ExecuteTools(ctx, state.toolName, toolContent)
+	var result string
+	var err error
+	// Progress is reported from the polling goroutine, so the two callbacks share a lock
+	var statusMu sync.Mutex
+	onProgress := func(percent int) {
+		statusMu.Lock()
+		defer statusMu.Unlock()
+		if sendErr := l.sendStreamContent(flusher, state.response,
+			fmt.Sprintf(" %d%%", percent)); sendErr != nil {
+			logger.WarnC(ctx, "failed to send tool progress", zap.Error(sendErr))
+		}
+	}
+	// A call repeated within the request is answered from memory, identical calls for the
+	// same client and project reuse results of the current index from Redis
+	cachedResult, cacheHit := l.requestToolResult(ctx, &toolCall)
+	var resultCache *toolResultCache
+	if !cacheHit {
+		resultCache, cachedResult, cacheHit = l.lookupToolResult(ctx, &toolCall)
+		if resultCache != nil {
+			toolCall.ResultCache = resultCacheMiss
+		}
+		if cacheHit {
+			toolCall.ResultCache = resultCacheHit
+		}
+	}
+	if cacheHit {
+		result = cachedResult
+	} else if partialExecutor, ok := l.toolExecutor.(functions.PartialResultToolExecutor); ok {
+		// Show early findings of streaming tools while the rest of the results arrive
+		result, err = partialExecutor.ExecuteToolsWithPartial(toolCtx, state.toolName, toolContent, onProgress,
+			func(finding string) {
+				statusMu.Lock()
+				defer statusMu.Unlock()
+				if sendErr := l.sendStreamContent(flusher, state.response,
+					fmt.Sprintf(" `%s`", finding)); sendErr != nil {
+					logger.WarnC(ctx, "failed to send tool finding", zap.Error(sendErr))
+				}
+			})
+	} else if progressExecutor, ok := l.toolExecutor.(functions.ProgressToolExecutor); ok {
+		// Report backend progress as percentages, the dots above remain when it is unavailable
+		result, err = progressExecutor.ExecuteToolsWithProgress(toolCtx, state.toolName, toolContent, onProgress)
+	} else {
+		result, err = l.toolExecutor.ExecuteTools(toolCtx, state.toolName, toolContent)
+	}
+	// A malformed backend response is injected as an empty result, not as a tool failure
+	var invalidResult *functions.ResultValidationError
+	if errors.As(err, &invalidResult) {
+		result, err = functions.NoResultsMessage, nil
+		toolCall.InvalidResult = invalidResult.Reason
+	}
+	// A call exceeding the tool's own timeout reads like an empty search to the model
+	var timeoutErr *functions.ToolTimeoutError
+	if errors.As(err, &timeoutErr) {
+		result, err = functions.NoResultsMessage, nil
+		toolCall.TimedOut = true
+	}
+	if err == nil {
+		l.cacheToolResult(ctx, &toolCall, resultCache, result, cacheHit)
+	}
 	toolLatency := time.Since(toolStart).Milliseconds()
 	toolCall.Latency = toolLatency
+	toolCall.ServedEndpoint = servedEndpoint.Endpoint()
 	toolCall.ToolOutput = result
+	toolCall.Broadened = functions.IsBroadenedResult(result)
+	if toolCall.Broadened {
+		toolCall.BroadenedFromPath = functions.BroadenedFromPath(result)
+	}
+	if counter, ok := l.toolExecutor.(functions.ResultCounter); ok && err == nil {
+		if count, above, ok := counter.CountResults(state.toolName, result); ok {
+			toolCall.ResultCount = &count
+			toolCall.AboveThresholdCount = &above
+		}
+	}
+	if err == nil {
+		toolCall.PathResultCounts = functions.PathResultCounts(result)
+		l.recordRetrievedChunks(chatLog, state.toolName, result)
+	}
+	if checker, ok := l.toolExecutor.(functions.IndexAgeChecker); ok && err == nil {
+		if age, stale, ok := checker.IndexAge(state.toolName, result, time.Now()); ok {
+			ageSec := int64(age.Seconds())
+			toolCall.IndexAgeSec = &ageSec
+			toolCall.IndexStale = stale
+		}
+	}
 
 	status := types.ToolStatusSuccess
 	if err != nil {
@@ -779,15 +1229,45 @@ func (l *ChatCompletionLogic) handleToolExecution(
 		logger.InfoC(ctx, "tool execute succeed", zap.String("tool", state.toolName),
 			zap.String("result", logResult), zap.Int("result length", len(result)))
 
+		result = l.limitContextShare(ctx, state.toolName, result, &toolCall)
+		result, toolCall.ResultReduction = l.reduceOversizedToolResult(ctx, state.toolName, result)
 		if len(result) > MaxToolResultLength {
 			logger.WarnC(ctx, "tool result truncated due to excessive length",
 				zap.String("tool", state.toolName),
 				zap.Int("original_length", len(result)),
 				zap.Int("truncated_length", MaxToolResultLength))
-			result = result[:MaxToolResultLength] + "... (truncated due to excessive length)"
+			result = l.truncateToolResult(state.toolName, result, MaxToolResultLength)
+			toolCall.ResultReduction = resultReductionTruncated
+		}
+		if toolCall.ResultReduction == resultReductionTruncated {
+			toolCall.TruncationStrategy = l.toolTruncationStrategy(state.toolName)
 		}
 	}
 	toolCall.ResultStatus = string(status)
+	result, toolCall.EscapedTags = l.sanitizeToolResult(state.toolName, result)
+	result, toolCall.DuplicateResult = l.dedupeToolResult(state.toolName, result)
+
+	resultContent := []model.Content{
+		{
+			Type: model.ContTypeText,
+			Text: fmt.Sprintf("[%s] Result:", state.toolName),
+		},
+	}
+	// Files of the results listed above them, the result itself stays unchanged
+	if indexer, ok := l.toolExecutor.(functions.FileIndexer); ok && err == nil && !toolCall.DuplicateResult {
+		if index, files, ok := indexer.FileIndex(state.toolName, result); ok {
+			resultContent = append(resultContent, model.Content{Type: model.ContTypeText, Text: index})
+			toolCall.IndexedFiles = files
+		}
+	}
+	resultContent = append(resultContent, model.Content{Type: model.ContTypeText, Text: result})
+	instruction, variant := l.toolFollowUpInstruction(state.toolName, chatLog.Agent)
+	placement := l.followUpPlacement(chatLog.Agent)
+	resultContent = placeFollowUpInstruction(resultContent, instruction, placement)
+	toolCall.FollowUpVariant = variant
+	if instruction != "" {
+		toolCall.FollowUpPlacement = placement
+	}
 
 	l.request.Messages = append(l.request.Messages,
 		types.Message{
@@ -795,25 +1275,27 @@ func (l *ChatCompletionLogic) handleToolExecution(
 			Content: state.fullContent.String(),
 		},
 		types.Message{
-			Role: types.RoleUser,
-			Content: []model.Content{
-				{
-					Type: model.ContTypeText,
-					Text: fmt.Sprintf("[%s] Result:", state.toolName),
-				}, {
-					Type: model.ContTypeText,
-					Text: result,
-				}, {
-					Type: model.ContTypeText,
-					Text: fmt.Sprintf("Please summarize the key findings and/or code from the results above within the <think></think> tags. No need to summarize error messages. \nIf the search failed, don't say 'failed', describe this outcome as 'did not found relevant results' instead - MUST NOT using terms like 'failure', 'error', or 'unsuccessful' in your description. \nIn your summary, must include the name of the tool used and specify which tools you intend to use next. \nWhen appropriate, prioritize using these tools: %s", l.toolExecutor.GetAllTools()),
-				},
-			},
+			Role:    types.RoleUser,
+			Content: resultContent,
 		},
 	)
+	l.limitContentParts(l.request.Messages[len(l.request.Messages)-1:], chatLog)
 
-	l.updateToolStatus(state.toolName, status)
+	l.updateToolStatus(chatLog, state.toolName, status)
 	chatLog.ProcessedPrompt = l.request.Messages
 	chatLog.ToolCalls = append(chatLog.ToolCalls, toolCall)
+	l.recordToolHistory(toolCall)
+	l.recordToolCallReplay(toolCall)
+	l.recordToolAudit(toolCall)
+
+	if toolCall.IndexStale && !l.indexStaleAdvised {
+		l.indexStaleAdvised = true
+		logger.InfoC(ctx, "stale search index reported, advising user",
+			zap.String("tool", state.toolName), zap.Int64p("indexAgeSec", toolCall.IndexAgeSec))
+		if err := l.sendStreamContent(flusher, state.response, types.StrFilterToolIndexStale); err != nil {
+			return err
+		}
+	}
 
 	// sending tool call ending response to client page
 	if err := l.sendStreamContent(flusher, state.response, types.StrFilterToolAnalyzing); err != nil {
@@ -829,7 +1311,10 @@ func (l *ChatCompletionLogic) handleToolExecution(
 		return err
 	}
 
-	// Recursive processing
+	// Recursive processing, unless nobody is waiting for the answer anymore
+	if err := l.checkClientConnected(ctx, ""); err != nil {
+		return err
+	}
 	return l.handleStreamingWithTools(
 		ctx,
 		llmClient,
@@ -840,6 +1325,17 @@ func (l *ChatCompletionLogic) handleToolExecution(
 	)
 }
 
+// checkClientConnected returns the context error once the request is cancelled, so the tool
+// chain stops before spending backend capacity on a client that disconnected
+func (l *ChatCompletionLogic) checkClientConnected(ctx context.Context, toolName string) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	logger.WarnC(ctx, "client disconnected, aborting tool chain",
+		zap.String("tool", toolName), zap.Error(ctx.Err()))
+	return ctx.Err()
+}
+
 // completeStreamResponse sends remaining content and updates statistics
 func (l *ChatCompletionLogic) completeStreamResponse(
 	flusher http.Flusher,
@@ -853,7 +1349,7 @@ func (l *ChatCompletionLogic) completeStreamResponse(
 	fullContentStr := state.fullContent.String()
 	trimmedContent := strings.ReplaceAll(fullContentStr, "\n", "")
 
-	if state.response == nil || trimmedContent == "" {
+	if state.response == nil || (trimmedContent == "" && !state.toolCallsForwarded) {
 		logger.WarnC(l.ctx, "detected invalid or empty response")
 
 		// Send error response
@@ -869,6 +1365,9 @@ func (l *ChatCompletionLogic) completeStreamResponse(
 		}
 
 		endContent := strings.Join(state.window, "")
+		if l.responseFilter != nil {
+			endContent = l.responseFilter.Push(endContent) + l.responseFilter.Flush()
+		}
 
 		if l.usage != nil {
 			state.response.Usage = *l.usage
@@ -880,9 +1379,18 @@ func (l *ChatCompletionLogic) completeStreamResponse(
 			return err
 		}
 
+		// Statistics are final once all content is sent, the summary event reports them
+		l.updateStreamStats(chatLog, state)
+		if l.svcCtx.Config.StreamSummary.Enabled || l.toolTrace != toolTraceOff {
+			if err := l.sendStreamSummary(flusher, chatLog, state); err != nil {
+				return err
+			}
+		}
+
 		if err := l.sendRawLine(flusher, "[DONE]"); err != nil {
 			return err
 		}
+		return nil
 	}
 
 	l.updateStreamStats(chatLog, state)
@@ -890,6 +1398,39 @@ func (l *ChatCompletionLogic) completeStreamResponse(
 	return nil
 }
 
+// sendStreamSummary sends the structured summary event, it must directly precede [DONE].
+// It is also sent without streamSummary enabled when tool traces were requested
+func (l *ChatCompletionLogic) sendStreamSummary(flusher http.Flusher, chatLog *model.ChatLog, state *streamState) error {
+	ratio := chatLog.Tokens.Ratios.AllRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+
+	event := types.StreamSummaryEvent{
+		Object: types.ObjectStreamSummary,
+		Summary: types.StreamSummary{
+			Model:            l.request.Model,
+			Usage:            chatLog.Usage,
+			TotalLatencyMs:   time.Since(chatLog.Timestamp).Milliseconds(),
+			ToolCalls:        len(chatLog.ToolCalls),
+			CompressionRatio: ratio,
+		},
+	}
+	if l.toolTrace != toolTraceOff {
+		event.Summary.ToolTraces = l.buildToolTraces(chatLog.ToolCalls)
+	}
+	if state.response != nil {
+		event.ID = state.response.Id
+		event.Created = state.response.Created
+	}
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream summary: %w", err)
+	}
+	return l.sendRawLine(flusher, string(jsonData))
+}
+
 // handleStreamError handles streaming errors with appropriate error responses
 func (l *ChatCompletionLogic) handleStreamError(err error, chatLog *model.ChatLog) error {
 	// Check if it's a context cancellation (client disconnect)
@@ -898,6 +1439,12 @@ func (l *ChatCompletionLogic) handleStreamError(err error, chatLog *model.ChatLo
 		return nil
 	}
 
+	// The client is not reading, an error event would not reach it either
+	if errors.Is(err, errSlowClient) {
+		chatLog.AddError(types.ErrSlowClient, err)
+		return nil
+	}
+
 	logger.ErrorC(l.ctx, "ChatLLMWithMessagesStreamRaw error", zap.Error(err))
 
 	if l.isContextLengthError(err) {
@@ -913,6 +1460,14 @@ func (l *ChatCompletionLogic) handleStreamError(err error, chatLog *model.ChatLo
 	return nil
 }
 
+// isRequestRejection reports whether err rejects the request itself with a client error.
+// processRequest records those in the chat log where it rejects the request.
+func isRequestRejection(err error) bool {
+	var apiErr *types.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusBadRequest &&
+		apiErr.StatusCode < http.StatusInternalServerError
+}
+
 // updateStreamStats updates chat log with streaming statistics
 func (l *ChatCompletionLogic) updateStreamStats(chatLog *model.ChatLog, state *streamState) {
 	endTime := time.Since(state.modelStart)
@@ -921,6 +1476,9 @@ func (l *ChatCompletionLogic) updateStreamStats(chatLog *model.ChatLog, state *s
 	chatLog.ResponseContent = &types.ResponseContent{
 		Content: state.fullContent.String(),
 	}
+	if l.responseFilter != nil {
+		l.recordResponseFilters(chatLog)
+	}
 
 	if l.usage != nil {
 		chatLog.Usage = *l.usage
@@ -940,9 +1498,7 @@ func (l *ChatCompletionLogic) sendRawLine(flusher http.Flusher, raw string) erro
 		raw = "data: " + raw
 	}
 
-	_, err := fmt.Fprintf(l.writer, "%s\n\n", raw)
-	flusher.Flush()
-	return err
+	return l.writeStream(flusher, []byte(raw+"\n\n"))
 }
 
 func (l *ChatCompletionLogic) sendStreamContent(flusher http.Flusher, response *types.ChatCompletionResponse, content string) error {
@@ -958,9 +1514,39 @@ func (l *ChatCompletionLogic) sendStreamContent(flusher http.Flusher, response *
 	}}
 	jsonData, _ := json.Marshal(response)
 
-	_, err := fmt.Fprintf(l.writer, "data: %s\n\n", jsonData)
-	flusher.Flush()
-	return err
+	return l.writeStream(flusher, fmt.Appendf(nil, "data: %s\n\n", jsonData))
+}
+
+// sendModelContent sends model output through the response filters, if any are configured
+func (l *ChatCompletionLogic) sendModelContent(flusher http.Flusher, response *types.ChatCompletionResponse, content string) error {
+	if l.responseFilter != nil {
+		content = l.responseFilter.Push(content)
+		if content == "" {
+			return nil
+		}
+	}
+	return l.sendStreamContent(flusher, response, content)
+}
+
+// flushModelContent sends the model output still held back by the response filters
+func (l *ChatCompletionLogic) flushModelContent(flusher http.Flusher, response *types.ChatCompletionResponse) error {
+	if l.responseFilter == nil {
+		return nil
+	}
+	if content := l.responseFilter.Flush(); content != "" {
+		return l.sendStreamContent(flusher, response, content)
+	}
+	return nil
+}
+
+// recordResponseFilters records which response filters rewrote the response
+func (l *ChatCompletionLogic) recordResponseFilters(chatLog *model.ChatLog) {
+	fired := l.responseFilter.Fired()
+	if len(fired) == 0 {
+		return
+	}
+	chatLog.ResponseFilters = fired
+	logger.InfoC(l.ctx, "response filters applied", zap.Strings("filters", fired))
 }
 
 // Helper methods
@@ -991,14 +1577,23 @@ func (l *ChatCompletionLogic) getOrCreateRouterStrategy() router.Strategy {
 	return strategy
 }
 
-func (l *ChatCompletionLogic) updateToolStatus(toolName string, status types.ToolStatus) {
+func (l *ChatCompletionLogic) updateToolStatus(chatLog *model.ChatLog, toolName string, status types.ToolStatus) {
 	if l.identity.RequestID == "" {
 		logger.WarnC(l.ctx, "requestID is empty, skip updating tool status")
 		return
 	}
 	toolStatusKey := types.ToolStatusRedisKeyPrefix + l.identity.RequestID
+	l.evictToolStatuses(chatLog, toolStatusKey, toolName)
 
-	if err := l.svcCtx.RedisClient.SetHashField(l.ctx, toolStatusKey, toolName, string(status), 5*time.Minute); err != nil {
+	if l.svcCtx.Config.ToolStatus.BatchUpdates {
+		if l.toolStatusBatch == nil {
+			l.toolStatusBatch = newToolStatusBatch(l.ctx, l.svcCtx.RedisClient, toolStatusKey,
+				time.Duration(l.svcCtx.Config.ToolStatus.MaxDelayMs)*time.Millisecond)
+		}
+		l.toolStatusBatch.Update(toolName, status)
+	} else if err := l.svcCtx.RedisClient.SetHashField(l.ctx, toolStatusKey, toolName, string(status), toolStatusExpiration); errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(l.ctx, "redis unavailable, skip updating tool status", zap.String("toolName", toolName))
+	} else if err != nil {
 		logger.ErrorC(l.ctx, "failed to update tool status in redis",
 			zap.String("toolName", toolName),
 			zap.String("status", string(status)),
@@ -1090,7 +1685,9 @@ func (l *ChatCompletionLogic) callModelWithRetry(modelName string, params types.
 
 // callWithDegradation attempts models in l.orderedModels with idle timeout control.
 // Retry the same model once (after 5s sleep) on timeout or 5xx errors; otherwise move to next.
-func (l *ChatCompletionLogic) callWithDegradation(params types.LLMRequestParams, idleTracker *timeout.IdleTracker) (types.ChatCompletionResponse, error) {
+// The temperature is resolved again for each model and recorded in chatLog.
+func (l *ChatCompletionLogic) callWithDegradation(params types.LLMRequestParams, idleTracker *timeout.IdleTracker,
+	chatLog *model.ChatLog) (types.ChatCompletionResponse, error) {
 	nilResp := types.ChatCompletionResponse{}
 	if len(l.orderedModels) == 0 {
 		return nilResp, fmt.Errorf("degradation: ordered models is empty")
@@ -1118,6 +1715,8 @@ func (l *ChatCompletionLogic) callWithDegradation(params types.LLMRequestParams,
 			zap.String("model", modelName),
 		)
 
+		l.applyModelTemperature(modelName, chatLog)
+		params.Extra = l.request.Extra
 		resp, err := l.callModelWithRetry(modelName, params, idleTracker)
 		if err == nil {
 			logger.InfoC(l.ctx, "degradation: model succeeded", zap.String("model", modelName))
@@ -1151,7 +1750,8 @@ func isRetryableAPIError(err error) bool {
 	if errors.Is(err, context.Canceled) {
 		return false
 	}
-	if apiErr, ok := err.(*types.APIError); ok {
+	var apiErr *types.APIError
+	if errors.As(err, &apiErr) {
 		if apiErr.StatusCode >= http.StatusInternalServerError ||
 			apiErr.Code == types.ErrCodeServerBusy ||
 			apiErr.Code == types.ErrCodeModelServiceUnavailable {
@@ -1229,16 +1829,19 @@ func (l *ChatCompletionLogic) handleRawModeStream(
 				}
 			}
 
-			if _, err := fmt.Fprintf(l.writer, "%s\n\n", llmResp.ResonseLine); err != nil {
+			if err := l.writeStream(flusher, []byte(llmResp.ResonseLine+"\n\n")); err != nil {
 				return err
 			}
-			flusher.Flush()
 		}
 
 		return nil
 	})
 
 	if err != nil {
+		if errors.Is(err, errSlowClient) {
+			chatLog.AddError(types.ErrSlowClient, err)
+			return nil
+		}
 		if l.isContextLengthError(err) {
 			logger.ErrorC(ctx, "Input context too long in raw mode", zap.Error(err))
 			lengthErr := types.NewContextTooLongError()
@@ -1342,6 +1945,16 @@ func sanitizeHeaderValue(val string) string {
 	return out
 }
 
+// hasUserMessage reports whether messages contain at least one user message
+func hasUserMessage(messages []types.Message) bool {
+	for _, msg := range messages {
+		if msg.Role == types.RoleUser {
+			return true
+		}
+	}
+	return false
+}
+
 func isEmptyContent(content any) bool {
 	if content == nil {
 		return true
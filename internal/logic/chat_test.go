@@ -2,17 +2,32 @@ package logic
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
 	"github.com/zgsm-ai/chat-rag/internal/client"
+	clientmocks "github.com/zgsm-ai/chat-rag/internal/client/mocks"
 	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
 	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/ds"
+	"github.com/zgsm-ai/chat-rag/internal/service"
 	"github.com/zgsm-ai/chat-rag/internal/service/mocks"
+	"github.com/zgsm-ai/chat-rag/internal/timeout"
 	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
 	"github.com/zgsm-ai/chat-rag/internal/types"
 )
@@ -286,3 +301,906 @@ func TestChatCompletionLogic_ChatCompletion_StreamingRequest(t *testing.T) {
 	assert.Greater(t, len(testWriter.data), 0, "Expected response attempt data")
 	assert.True(t, testWriter.flushed, "Expected response flush attempt")
 }
+
+func TestChatCompletionLogic_SuppressedResponseHeaders(t *testing.T) {
+	ctrl, loggerMock, metricsMock := createTestServiceMock(t)
+	defer ctrl.Finish()
+
+	svcCtx := &bootstrap.ServiceContext{
+		Config: config.Config{
+			LLMTimeout: config.LLMTimeoutConfig{
+				IdleTimeoutMs:      60000,
+				TotalIdleTimeoutMs: 60000,
+			},
+			ResponseHeaders: config.ResponseHeadersConfig{
+				Suppress: append([]string{types.HeaderOneAPIReqId}, types.DefaultSuppressedResponseHeaders...),
+			},
+		},
+		LoggerService:  loggerMock,
+		MetricsService: metricsMock,
+	}
+
+	// Mock upstream returning both forwarded and internal headers
+	upstreamHeader := make(http.Header)
+	upstreamHeader.Set(types.HeaderOneAPIReqId, "req-123")
+	upstreamHeader.Set(types.HeaderSelectLLm, "upstream-model")
+	upstreamHeader.Set("x-oneapi-cost", "0.42")
+
+	llmMock := clientmocks.NewMockLLMClientInterface(ctrl)
+	llmMock.EXPECT().ChatLLMWithMessagesStreamRaw(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ types.LLMRequestParams, _ *timeout.IdleTimer, callback func(client.LLMResponse) error) error {
+			return callback(client.LLMResponse{
+				Header:      &upstreamHeader,
+				ResonseLine: `data: {"choices":[{"delta":{"content":"hi"}}]}`,
+			})
+		})
+
+	writer := &mockResponseWriter{}
+	// Internal header set earlier in the request must not reach the client either
+	writer.Header().Set("x-envoy-upstream-host", "10.0.0.1")
+
+	headers := make(http.Header)
+	logic := NewChatCompletionLogic(createTestContext(), svcCtx,
+		createTestRequest("test-model", []types.Message{{Role: "user", Content: "Hello"}}, true),
+		writer, &headers, createTestIdentity())
+
+	chatLog := &model.ChatLog{}
+	err := logic.handleRawModeStream(createTestContext(), llmMock, writer, chatLog, timeout.NewIdleTracker(time.Minute))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "upstream-model", writer.Header().Get(types.HeaderSelectLLm))
+	assert.Empty(t, writer.Header().Get(types.HeaderOneAPIReqId))
+	assert.Empty(t, writer.Header().Get("x-oneapi-cost"))
+	assert.Empty(t, writer.Header().Get("x-envoy-upstream-host"))
+	assert.Contains(t, string(writer.data), "hi")
+}
+
+func TestChatCompletionLogic_ChatCompletionStream_NoUserMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []types.Message
+	}{
+		{name: "empty messages", messages: []types.Message{}},
+		{name: "system message only", messages: []types.Message{{Role: types.RoleSystem, Content: "You are an assistant"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &mockResponseWriter{}
+			logic, _ := setupTestLogic(t, &config.Config{}, nil, "test-model", tt.messages, writer)
+
+			err := logic.ChatCompletionStream()
+			assert.NoError(t, err)
+
+			output := string(writer.data)
+			assert.Contains(t, output, types.ErrCodeNoUserMessage)
+			assert.Contains(t, output, string(types.ErrInvalidRequest))
+			assert.True(t, strings.HasSuffix(output, "data: [DONE]\n\n"), "stream should end with [DONE]")
+			assert.Equal(t, 2, strings.Count(output, "data: "), "expected one error chunk and [DONE]")
+		})
+	}
+}
+
+func TestChatCompletionLogic_PromptTooLarge(t *testing.T) {
+	messages := []types.Message{{Role: types.RoleUser, Content: strings.Repeat("a very long prompt ", 200)}}
+
+	newLogic := func(t *testing.T, writer http.ResponseWriter) (*ChatCompletionLogic, **model.ChatLog) {
+		var chatLog *model.ChatLog
+		ctrl := gomock.NewController(t)
+		loggerMock := mocks.NewMockLoggerInterface(ctrl)
+		loggerMock.EXPECT().LogAsync(gomock.Any(), gomock.Any()).Do(func(log *model.ChatLog, _ *http.Header) {
+			chatLog = log
+		})
+		headers := make(http.Header)
+		svcCtx := &bootstrap.ServiceContext{Config: config.Config{MaxPromptTokens: 50}, LoggerService: loggerMock}
+		logic := NewChatCompletionLogic(createTestContext(), svcCtx, createTestRequest("test-model", messages, false),
+			writer, &headers, createTestIdentity())
+		return logic, &chatLog
+	}
+
+	t.Run("non-stream", func(t *testing.T) {
+		logic, chatLog := newLogic(t, &mockResponseWriter{})
+
+		resp, err := logic.ChatCompletion()
+		assert.Nil(t, resp)
+		var apiErr *types.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, apiErr.StatusCode)
+		require.NotNil(t, *chatLog)
+		assert.Equal(t, []map[types.ErrorType]string{{types.ErrPromptTooLarge: err.Error()}}, (*chatLog).Error,
+			"the rejection is recorded once")
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		writer := &mockResponseWriter{}
+		logic, chatLog := newLogic(t, writer)
+
+		assert.NoError(t, logic.ChatCompletionStream())
+		assert.Contains(t, string(writer.data), "data: ")
+		require.NotNil(t, *chatLog)
+		require.Len(t, (*chatLog).Error, 1, "the rejection is recorded once")
+		assert.Contains(t, (*chatLog).Error[0], types.ErrPromptTooLarge)
+	})
+}
+
+// stubToolExecutor detects any of its tools by tag and executes nothing
+type stubToolExecutor struct {
+	functions.ToolExecutor
+	tools []string
+}
+
+func (s *stubToolExecutor) DetectTools(_ context.Context, content string) (bool, string) {
+	for _, tool := range s.tools {
+		if strings.Contains(content, "<"+tool+">") {
+			return true, tool
+		}
+	}
+	return false, ""
+}
+
+func (s *stubToolExecutor) GetAllTools() []string {
+	return s.tools
+}
+
+func TestChatCompletionLogic_applyModelTemperature(t *testing.T) {
+	low, high, fallbackDefault := 0.2, 0.7, 0.5
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "primary-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	svcCtx.Config.Temperature = config.TemperatureConfig{Models: []config.ModelTemperatureConfig{
+		{Model: "primary-model", Max: &high},
+		{Model: "fallback-model", Min: &low, Default: &fallbackDefault},
+	}}
+	logic.request.Extra = map[string]any{"temperature": 0.9}
+	chatLog := &model.ChatLog{}
+
+	logic.applyTemperature(chatLog)
+	assert.Equal(t, 0.7, logic.request.Extra["temperature"], "clamped to the primary model")
+
+	logic.applyModelTemperature("fallback-model", chatLog)
+	assert.Equal(t, 0.9, logic.request.Extra["temperature"], "the caller's value, not the primary's clamp")
+	assert.Equal(t, 0.9, *chatLog.Params.EffectiveTemperature)
+
+	logic.applyModelTemperature("unconfigured-model", chatLog)
+	assert.Equal(t, 0.9, logic.request.Extra["temperature"])
+	assert.Nil(t, chatLog.Params.EffectiveTemperature)
+
+	// Without a requested value each model gets its own default, or none
+	logic.request.Extra = map[string]any{}
+	logic.applyTemperature(chatLog)
+	assert.NotContains(t, logic.request.Extra, "temperature")
+	logic.applyModelTemperature("fallback-model", chatLog)
+	assert.Equal(t, 0.5, logic.request.Extra["temperature"])
+	logic.applyModelTemperature("primary-model", chatLog)
+	assert.NotContains(t, logic.request.Extra, "temperature", "the fallback default is not kept")
+}
+
+func TestChatCompletionLogic_resolveToolCallDepth(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	assert.Equal(t, MaxToolCallDepth, logic.resolveToolCallDepth(), "no tool config")
+
+	svcCtx.Config.Tools = &config.ToolConfig{MaxToolCallDepthByMode: map[string]int{
+		string(types.Cost):        2,
+		string(types.Performance): 10,
+		string(types.Balanced):    0,
+	}}
+	for mode, want := range map[types.PromptMode]int{
+		types.Cost:        2,
+		types.Performance: 10,
+		types.Balanced:    MaxToolCallDepth,
+		types.Strict:      MaxToolCallDepth,
+	} {
+		logic.request.ExtraBody.PromptMode = mode
+		assert.Equal(t, want, logic.resolveToolCallDepth(), "mode %s", mode)
+	}
+}
+
+func TestChatCompletionLogic_logToolChainEnd_DepthLimited(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	svcCtx.Config.Tools = &config.ToolConfig{}
+	logic.toolExecutor = &stubToolExecutor{tools: []string{"codebase_search"}}
+
+	state := newStreamState()
+	state.fullContent.WriteString("Searching again <codebase_search><query>x</query></codebase_search>")
+
+	chatLog := &model.ChatLog{}
+	logic.logToolChainEnd(logic.ctx, chatLog, state, 1)
+	assert.False(t, chatLog.ToolDepthLimited, "depth left, the tag was not held back by the limit")
+
+	logic.logToolChainEnd(logic.ctx, chatLog, state, 0)
+	assert.True(t, chatLog.ToolDepthLimited)
+
+	chatLog = &model.ChatLog{}
+	plain := newStreamState()
+	plain.fullContent.WriteString("All done.")
+	logic.logToolChainEnd(logic.ctx, chatLog, plain, 0)
+	assert.False(t, chatLog.ToolDepthLimited, "natural termination")
+}
+
+func TestChatCompletionLogic_detectAndHandleTool_MaxDistinctTools(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	svcCtx.Config.Tools = &config.ToolConfig{MaxDistinctTools: 2}
+	logic.toolExecutor = &stubToolExecutor{tools: []string{"codebase_search", "knowledge_base_search", "search_files"}}
+
+	chatLog := &model.ChatLog{}
+	detect := func(content string) bool {
+		state := newStreamState()
+		state.window = []string{content}
+		assert.NoError(t, logic.detectAndHandleTool(logic.ctx, nil, state, chatLog))
+		if state.toolDetected {
+			chatLog.ToolCalls = append(chatLog.ToolCalls, model.ToolCall{ToolName: state.toolName})
+		}
+		return state.toolDetected
+	}
+
+	assert.True(t, detect("<codebase_search>"), "first tool is allowed")
+	assert.True(t, detect("<knowledge_base_search>"), "second distinct tool is allowed")
+	assert.False(t, detect("<search_files>"), "third distinct tool exceeds the limit")
+	assert.True(t, detect("<codebase_search>"), "already used tools can still be called")
+	assert.False(t, detect("<search_files>"))
+
+	assert.Equal(t, []string{"search_files"}, chatLog.SkippedTools)
+}
+
+func TestChatCompletionLogic_handleStreamChunk_FragmentedToolTag(t *testing.T) {
+	stream := func(t *testing.T, chunks []string) (*streamState, string) {
+		writer := &mockResponseWriter{}
+		logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+			[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+		svcCtx.Config.Tools = &config.ToolConfig{GenericTools: []config.GenericToolConfig{{Name: "codebase_search"}}}
+		logic.toolExecutor = functions.NewGenericToolExecutor(svcCtx.Config.Tools)
+
+		state := newStreamState()
+		state.firstToken = false
+		state.response = &types.ChatCompletionResponse{Id: "chatcmpl-1"}
+		for _, chunk := range chunks {
+			data, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]any{"content": chunk}}}})
+			assert.NoError(t, logic.handleStreamChunk(logic.ctx, writer, "data: "+string(data), state, 1, &model.ChatLog{}, nil))
+			if state.toolDetected {
+				break
+			}
+		}
+
+		var sent strings.Builder
+		for _, line := range strings.Split(strings.TrimSpace(string(writer.data)), "\n\n") {
+			var resp types.ChatCompletionResponse
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &resp) == nil && len(resp.Choices) > 0 {
+				sent.WriteString(resp.Choices[0].Delta.Content)
+			}
+		}
+		return state, sent.String()
+	}
+
+	t.Run("tag split over more chunks than the window", func(t *testing.T) {
+		chunks := []string{"Let", " me", " look", " <", "co", "de", "ba", "se", "_s", "ea", "rc", "h>", "<query>"}
+		state, sent := stream(t, chunks)
+		assert.True(t, state.toolDetected)
+		assert.Equal(t, "codebase_search", state.toolName)
+		assert.Equal(t, "Let me look ", sent, "no part of the tag reaches the client")
+		assert.Equal(t, "<codebase_search>", strings.Join(state.window, ""))
+	})
+
+	t.Run("partial tag ruled out", func(t *testing.T) {
+		chunks := []string{"a", " <", "co", "de", "ba", "x>", " b", " c", " d", " e", " f", " g", " h"}
+		state, sent := stream(t, chunks)
+		assert.False(t, state.toolDetected)
+		assert.Equal(t, "a <codebax> b c", sent, "held text is released once the tag is ruled out")
+		assert.Len(t, state.window, state.windowSize-1)
+	})
+}
+
+func TestChatCompletionLogic_handleStreamChunk_ClientDisconnected(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+	svcCtx.Config.Tools = &config.ToolConfig{GenericTools: []config.GenericToolConfig{{Name: "codebase_search"}}}
+	logic.toolExecutor = functions.NewGenericToolExecutor(svcCtx.Config.Tools)
+
+	ctx, cancel := context.WithCancel(logic.ctx)
+	state := newStreamState()
+	state.firstToken = false
+	state.response = &types.ChatCompletionResponse{Id: "chatcmpl-1"}
+	chunk := func(content string) string {
+		data, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]any{"content": content}}}})
+		return "data: " + string(data)
+	}
+
+	assert.NoError(t, logic.handleStreamChunk(ctx, writer, chunk("Let me search."), state, 1, &model.ChatLog{}, nil))
+	cancel()
+	err := logic.handleStreamChunk(ctx, writer, chunk("<codebase_search><query>auth</query></codebase_search>"),
+		state, 1, &model.ChatLog{}, nil)
+	assert.True(t, state.toolDetected)
+	assert.ErrorIs(t, err, context.Canceled, "no tool runs for a disconnected client")
+}
+
+func TestChatCompletionLogic_completeStreamResponse_StreamSummary(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+	svcCtx.Config.StreamSummary.Enabled = true
+	logic.usage = &types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+	state := newStreamState()
+	state.response = &types.ChatCompletionResponse{Id: "chatcmpl-1"}
+	state.fullContent.WriteString("Hi there")
+	state.window = []string{"Hi", " there", "[DONE]"}
+	chatLog := &model.ChatLog{Timestamp: time.Now(), ToolCalls: []model.ToolCall{{ToolName: "codebase_search"}}}
+
+	assert.NoError(t, logic.completeStreamResponse(writer, chatLog, state))
+
+	lines := strings.Split(strings.TrimSpace(string(writer.data)), "\n\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "data: [DONE]", lines[2])
+
+	var event types.StreamSummaryEvent
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event))
+	assert.Equal(t, types.ObjectStreamSummary, event.Object)
+	assert.Equal(t, "chatcmpl-1", event.ID)
+	assert.Equal(t, "test-model", event.Summary.Model)
+	assert.Equal(t, 15, event.Summary.Usage.TotalTokens)
+	assert.Equal(t, 1, event.Summary.ToolCalls)
+	assert.Equal(t, float64(1), event.Summary.CompressionRatio)
+}
+
+func TestChatCompletionLogic_ToolTrace(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+	logic.identity.UserName = "alice"
+	svcCtx.Config.Debug.ToolTrace = config.DebugToolTraceConfig{Enabled: true, AllowedUsers: []string{"alice"}, MaxResultBytes: 4}
+
+	logic.request.ExtraBody.Extra = map[string]any{extraBodyToolTrace: true}
+	assert.Equal(t, toolTraceRedacted, logic.takeToolTraceMode())
+	assert.NotContains(t, logic.request.ExtraBody.Extra, extraBodyToolTrace, "the flag is not forwarded")
+
+	logic.identity.UserName = "bob"
+	logic.request.ExtraBody.Extra = map[string]any{extraBodyToolTrace: toolTraceWithResults}
+	assert.Equal(t, toolTraceOff, logic.takeToolTraceMode(), "users outside the allow-list get no traces")
+
+	logic.identity.UserName = "alice"
+	logic.request.ExtraBody.Extra = map[string]any{extraBodyToolTrace: toolTraceWithResults}
+	logic.toolTrace = logic.takeToolTraceMode()
+	assert.Equal(t, toolTraceFull, logic.toolTrace)
+
+	state := newStreamState()
+	state.response = &types.ChatCompletionResponse{Id: "chatcmpl-1"}
+	state.fullContent.WriteString("Hi")
+	state.window = []string{"Hi", "[DONE]"}
+	chatLog := &model.ChatLog{Timestamp: time.Now(), ToolCalls: []model.ToolCall{{
+		ToolName:     "codebase_search",
+		ToolParams:   map[string]interface{}{"query": "auth"},
+		ToolOutput:   "auth.go:10",
+		ResultStatus: string(types.ToolStatusSuccess),
+		Latency:      42,
+	}}}
+	assert.NoError(t, logic.completeStreamResponse(writer, chatLog, state))
+
+	lines := strings.Split(strings.TrimSpace(string(writer.data)), "\n\n")
+	assert.Len(t, lines, 3, "the summary event carries the traces without streamSummary enabled")
+	var event types.StreamSummaryEvent
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event))
+	assert.Equal(t, []types.ToolTrace{{
+		Name:         "codebase_search",
+		Params:       map[string]any{"query": "auth"},
+		LatencyMs:    42,
+		Status:       "success",
+		ResultLength: 10,
+		Result:       "auth...[truncated]",
+	}}, event.Summary.ToolTraces)
+
+	logic.toolTrace = toolTraceRedacted
+	assert.Empty(t, logic.buildToolTraces(chatLog.ToolCalls)[0].Result, "results are redacted by default")
+}
+
+func TestChatCompletionLogic_EchoModel(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "__echo__",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+	svcCtx.Config.EchoModel = config.EchoModelConfig{Enabled: true, Name: "__echo__", Response: "pong"}
+
+	resp, err := logic.ChatCompletion()
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", resp.Choices[0].Message.Content)
+	assert.Equal(t, "__echo__", resp.Model)
+
+	assert.NoError(t, logic.ChatCompletionStream())
+	lines := strings.Split(strings.TrimSpace(string(writer.data)), "\n\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"content":"pong"`)
+	assert.Equal(t, "data: [DONE]", lines[1])
+}
+
+func TestChatCompletionLogic_EchoModel_Disabled(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "__echo__",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	assert.False(t, logic.isEchoRequest())
+
+	svcCtx.Config.EchoModel = config.EchoModelConfig{Enabled: true, Name: "__echo__"}
+	logic.request.Model = "gpt-4o"
+	assert.False(t, logic.isEchoRequest())
+}
+
+func TestChatCompletionLogic_dedupeToolResult(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+
+	result, dup := logic.dedupeToolResult("codebase_search", "found a.go")
+	assert.False(t, dup, "deduplication is disabled without tools config")
+	assert.Equal(t, "found a.go", result)
+
+	svcCtx.Config.Tools = &config.ToolConfig{DedupeResults: true}
+	_, dup = logic.dedupeToolResult("codebase_search", "found a.go")
+	assert.False(t, dup)
+
+	result, dup = logic.dedupeToolResult("codebase_search", "found a.go")
+	assert.True(t, dup)
+	assert.Contains(t, result, "same result as the previous codebase_search call")
+
+	_, dup = logic.dedupeToolResult("knowledge_base_search", "found a.go")
+	assert.False(t, dup, "same text from another tool is not a duplicate")
+
+	_, dup = logic.dedupeToolResult("knowledge_base_search", "found b.go")
+	assert.False(t, dup)
+}
+
+func TestChatCompletionLogic_sanitizeToolResult(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	logic.toolExecutor = &stubToolExecutor{tools: []string{"codebase_search"}}
+	searchResult := `prompt.go:12: const example = "<codebase_search><query>auth</query></codebase_search>"
+prompt.go:20: // finish with <attempt_completion>`
+
+	result, escaped := logic.sanitizeToolResult("codebase_search", searchResult)
+	assert.Equal(t, searchResult, result, "sanitizing is disabled by default")
+	assert.Zero(t, escaped)
+
+	svcCtx.Config.Tools = &config.ToolConfig{SanitizeResults: config.ToolResultSanitizeConfig{
+		Enabled: true,
+		Tags:    []string{"attempt_completion"},
+	}}
+	result, escaped = logic.sanitizeToolResult("codebase_search", searchResult)
+	assert.Equal(t, 3, escaped)
+	assert.Contains(t, result, "&lt;codebase_search&gt;<query>auth</query>&lt;/codebase_search&gt;")
+	assert.Contains(t, result, "&lt;attempt_completion&gt;")
+
+	// The model repeating the injected result must not trigger a tool call
+	detected, _ := logic.toolExecutor.DetectTools(logic.ctx, result)
+	assert.False(t, detected)
+	detected, _ = logic.toolExecutor.DetectTools(logic.ctx, searchResult)
+	assert.True(t, detected)
+}
+
+func TestChatCompletionLogic_takeSemanticTopK(t *testing.T) {
+	logic, _ := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+
+	assert.Equal(t, 0, logic.takeSemanticTopK(), "no override without the field")
+
+	logic.request.ExtraBody.Extra = map[string]any{"semantic_top_k": float64(40), "other": "kept"}
+	assert.Equal(t, 40, logic.takeSemanticTopK())
+	assert.NotContains(t, logic.request.ExtraBody.Extra, "semantic_top_k", "field is not forwarded to the model")
+	assert.Contains(t, logic.request.ExtraBody.Extra, "other")
+
+	logic.request.ExtraBody.Extra = map[string]any{"semantic_top_k": "15"}
+	assert.Equal(t, 15, logic.takeSemanticTopK())
+
+	for _, invalid := range []any{float64(-3), float64(2.5), "many", true} {
+		logic.request.ExtraBody.Extra = map[string]any{"semantic_top_k": invalid}
+		assert.Equal(t, 0, logic.takeSemanticTopK(), "invalid value %v is ignored", invalid)
+	}
+}
+
+func TestChatCompletionLogic_recordQASample(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "How does auth work?"}}, &mockResponseWriter{})
+	chatLog := &model.ChatLog{
+		Timestamp:       time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC),
+		Identity:        model.Identity{RequestID: "req-1", UserName: "alice", AuthToken: "secret-token"},
+		Params:          model.RequestParams{RoutedModel: "test-model"},
+		ResponseContent: &types.ResponseContent{Content: "It uses JWT."},
+		Usage:           types.Usage{PromptTokens: 10, CompletionTokens: 4, TotalTokens: 14},
+	}
+
+	// Sampling disabled is a no-op
+	logic.sampleQARequest()
+	logic.recordQASample(chatLog)
+
+	dir := t.TempDir()
+	sampler, err := service.NewQASampler(config.QASamplingConfig{Enabled: true, Rate: 1, Directory: dir})
+	assert.NoError(t, err)
+	svcCtx.QASampler = sampler
+	logic.sampleQARequest()
+	logic.request.Messages = append(logic.request.Messages, types.Message{Role: "user", Content: "[tool] Result:"})
+
+	logic.recordQASample(chatLog)
+	failedLog := *chatLog
+	failedLog.Error = []map[types.ErrorType]string{{types.ErrServerError: "boom"}}
+	logic.recordQASample(&failedLog)
+	assert.NoError(t, sampler.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "qa-2024-05-06.jsonl"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 1, "failed requests are not sampled")
+
+	var record service.QASampleRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "req-1", record.RequestID)
+	assert.Equal(t, "It uses JWT.", record.Response)
+	assert.Equal(t, 14, record.Usage.TotalTokens)
+	assert.Len(t, record.Messages, 1, "only the client messages are recorded")
+	assert.NotContains(t, lines[0], "secret-token")
+	assert.NotContains(t, lines[0], "alice")
+}
+
+func TestChatCompletionLogic_newChatLog_OriginalModel(t *testing.T) {
+	logic, _ := setupTestLogic(t, &config.Config{}, nil, "remapped-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+
+	chatLog := logic.newChatLog(time.Now())
+	assert.Empty(t, chatLog.Params.OriginalModel)
+
+	logic.identity.OriginalModel = "user-model"
+	chatLog = logic.newChatLog(time.Now())
+	assert.Equal(t, "user-model", chatLog.Params.OriginalModel)
+	assert.Equal(t, "remapped-model", chatLog.Params.Model)
+}
+
+// summaryToolExecutor is a stub executor with result summarizing enabled for every tool
+type summaryToolExecutor struct {
+	stubToolExecutor
+	cfg config.GenericToolResultSummaryConfig
+}
+
+func (s *summaryToolExecutor) ResultSummaryConfig(string) (config.GenericToolResultSummaryConfig, bool) {
+	return s.cfg, true
+}
+
+func TestChatCompletionLogic_reduceOversizedToolResult(t *testing.T) {
+	summaryStatus := http.StatusOK
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(summaryStatus)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"auth.go:10 Login validates the JWT"}}]}`))
+	}))
+	defer llmServer.Close()
+
+	cfg := &config.Config{LLM: config.LLMConfig{Endpoint: llmServer.URL}}
+	logic, _ := setupTestLogic(t, cfg, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	largeResult := strings.Repeat("auth.go:10 func Login(token string) error { ... }\n", 200)
+
+	// Executors without summarizing keep the result
+	logic.toolExecutor = &stubToolExecutor{}
+	result, reduction := logic.reduceOversizedToolResult(logic.ctx, "codebase_search", largeResult)
+	assert.Equal(t, largeResult, result)
+	assert.Empty(t, reduction)
+
+	logic.toolExecutor = &summaryToolExecutor{cfg: config.GenericToolResultSummaryConfig{
+		Enabled: true, MaxTokens: 100, TimeoutMs: 5000, Model: "summary-model",
+	}}
+	result, reduction = logic.reduceOversizedToolResult(logic.ctx, "codebase_search", "small result")
+	assert.Equal(t, "small result", result, "results within the budget are kept")
+	assert.Empty(t, reduction)
+
+	result, reduction = logic.reduceOversizedToolResult(logic.ctx, "codebase_search", largeResult)
+	assert.Equal(t, resultReductionSummarized, reduction)
+	assert.Equal(t, "auth.go:10 Login validates the JWT", result)
+
+	// A failing summary model falls back to truncation within the budget
+	summaryStatus = http.StatusInternalServerError
+	result, reduction = logic.reduceOversizedToolResult(logic.ctx, "codebase_search", largeResult)
+	assert.Equal(t, resultReductionTruncated, reduction)
+	assert.Less(t, len(result), len(largeResult))
+	assert.LessOrEqual(t, logic.countTextTokens(result), 110)
+}
+
+func TestChatCompletionLogic_limitContentParts(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	parts := make([]any, 0, 20)
+	for i := 0; i < 20; i++ {
+		parts = append(parts, map[string]any{"type": "text", "text": "line"})
+	}
+	messages := []types.Message{
+		{Role: types.RoleSystem, Content: "system"},
+		{Role: types.RoleUser, Content: parts},
+	}
+	chatLog := &model.ChatLog{}
+
+	logic.limitContentParts(messages, chatLog)
+	assert.Len(t, messages[1].Content, 20, "no limit by default")
+
+	svcCtx.Config.ContentParts.MaxPerMessage = 5
+	logic.limitContentParts(messages, chatLog)
+	assert.Len(t, messages[1].Content, 1)
+	assert.Equal(t, "system", messages[0].Content)
+	assert.Equal(t, 1, chatLog.MergedContentMessages)
+}
+
+func TestChatCompletionLogic_ChatCompletionStream_DropRetry(t *testing.T) {
+	drops := 0
+	requests := 0
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= drops {
+			// Break the connection before any byte of the response is sent
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n"))
+	}))
+	defer llmServer.Close()
+
+	run := func(maxRetryCount, maxDropRetries int) (*model.ChatLog, *httptest.ResponseRecorder) {
+		requests = 0
+		var chatLog *model.ChatLog
+		ctrl := gomock.NewController(t)
+		loggerMock := mocks.NewMockLoggerInterface(ctrl)
+		loggerMock.EXPECT().LogAsync(gomock.Any(), gomock.Any()).Do(func(log *model.ChatLog, _ *http.Header) {
+			chatLog = log
+		})
+		svcCtx := &bootstrap.ServiceContext{
+			Config: config.Config{
+				LLM: config.LLMConfig{Endpoint: llmServer.URL},
+				LLMTimeout: config.LLMTimeoutConfig{
+					IdleTimeoutMs:        5000,
+					TotalIdleTimeoutMs:   60000,
+					MaxRetryCount:        maxRetryCount,
+					StreamDropRetryCount: maxDropRetries,
+				},
+			},
+			LoggerService: loggerMock,
+		}
+		svcCtx.Config.PreciseContextConfig = &config.PreciseContextConfig{}
+		recorder := httptest.NewRecorder()
+		headers := make(http.Header)
+		logic := NewChatCompletionLogic(createTestContext(), svcCtx,
+			createTestRequest("test-model", []types.Message{{Role: "user", Content: "Hello"}}, true),
+			recorder, &headers, createTestIdentity())
+		logic.toolExecutor = &stubToolExecutor{}
+
+		require.NoError(t, logic.ChatCompletionStream())
+		require.NotNil(t, chatLog)
+		return chatLog, recorder
+	}
+
+	drops = 1
+	chatLog, recorder := run(0, 0)
+	assert.Equal(t, 0, chatLog.StreamDropRetries, "retries are disabled by default")
+	assert.Contains(t, recorder.Body.String(), types.ErrCodeModelServiceUnavailable)
+
+	chatLog, recorder = run(0, 2)
+	assert.Equal(t, 1, chatLog.StreamDropRetries)
+	assert.Equal(t, 2, requests)
+	assert.Contains(t, recorder.Body.String(), "hi")
+
+	drops = 10
+	chatLog, _ = run(0, 2)
+	assert.Equal(t, 2, chatLog.StreamDropRetries, "retries are bounded")
+	assert.Equal(t, 3, requests)
+
+	chatLog, _ = run(2, 2)
+	assert.Equal(t, 2, chatLog.StreamDropRetries)
+	assert.Equal(t, 5, requests, "drop retries add to the regular attempts instead of multiplying them")
+}
+
+func TestIsUpstreamDropError(t *testing.T) {
+	assert.True(t, isUpstreamDropError(types.NewUpstreamDropError(types.NewNetWorkError())))
+	assert.True(t, isUpstreamDropError(fmt.Errorf("stream: %w", types.NewUpstreamDropError(types.NewModelServiceUnavailableError()))))
+	assert.False(t, isUpstreamDropError(types.NewHTTPStatusError(http.StatusBadGateway, "bad gateway")),
+		"upstream error responses are not drops")
+	assert.False(t, isUpstreamDropError(context.Canceled))
+	assert.False(t, isUpstreamDropError(types.NewStreamIdleTimeoutError()))
+	assert.False(t, isUpstreamDropError(errors.New("callback error")))
+}
+
+func TestChatCompletionLogic_limitContextShare(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	logic.toolExecutor = functions.NewGenericToolExecutor(&config.ToolConfig{
+		GenericTools: []config.GenericToolConfig{{
+			Name:         "codebase_search",
+			ContextShare: config.GenericToolContextShareConfig{Enabled: true, MaxShare: 0.5},
+		}},
+	})
+	result := `{"data":[{"content":"func Login(token string) error","score":0.9},` +
+		`{"content":"func Logout(session string) error","score":0.4}]}`
+	full := logic.countTextTokens(result)
+
+	var toolCall model.ToolCall
+	assert.Equal(t, result, logic.limitContextShare(logic.ctx, "codebase_search", result, &toolCall),
+		"no cap without a context window")
+	assert.Zero(t, toolCall.ContextShareCap)
+
+	svcCtx.Config.ContextCompressConfig.TokenThreshold = full * 2
+	assert.Equal(t, result, logic.limitContextShare(logic.ctx, "codebase_search", result, &toolCall))
+	assert.Equal(t, full, toolCall.ContextShareCap)
+	assert.Zero(t, toolCall.TrimmedResults)
+
+	// The second call only gets what the first one left of the cap
+	toolCall = model.ToolCall{}
+	trimmed := logic.limitContextShare(logic.ctx, "codebase_search", result, &toolCall)
+	assert.NotContains(t, trimmed, "Logout")
+	assert.Equal(t, 2, toolCall.TrimmedResults)
+}
+
+func TestChatCompletionLogic_recordToolAudit(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	toolCall := model.ToolCall{
+		ToolName:     "codebase_search",
+		ToolParams:   map[string]interface{}{"query": "auth"},
+		ToolOutput:   "secret result",
+		ResultStatus: string(types.ToolStatusFailed),
+		Error:        "timeout",
+		Latency:      120,
+	}
+
+	// Disabled audit log is a no-op
+	logic.recordToolAudit(toolCall)
+
+	auditPath := filepath.Join(t.TempDir(), "audit", "tool-audit.log")
+	auditLogger, err := service.NewToolAuditLogger(config.ToolAuditConfig{Destination: "file", FilePath: auditPath})
+	assert.NoError(t, err)
+	svcCtx.ToolAuditLogger = auditLogger
+	logic.identity.UserName = "alice"
+
+	logic.recordToolAudit(toolCall)
+	logic.recordToolAudit(toolCall)
+	assert.NoError(t, auditLogger.Close())
+
+	data, err := os.ReadFile(auditPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.NotContains(t, string(data), "secret result", "tool results must not be audited")
+
+	var record service.ToolAuditRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "alice", record.UserName)
+	assert.Equal(t, "codebase_search", record.Tool)
+	assert.Equal(t, "auth", record.Params["query"])
+	assert.Equal(t, string(types.ToolStatusFailed), record.Status)
+	assert.Equal(t, "timeout", record.Error)
+	assert.Equal(t, int64(120), record.LatencyMs)
+}
+
+func TestChatCompletionLogic_setAgentHeader(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+
+	logic.setAgentHeader("code")
+	assert.Empty(t, writer.Header().Get(types.HeaderAgent), "agent header is opt-in")
+
+	svcCtx.Config.ResponseHeaders.IncludeAgent = true
+	logic.setAgentHeader("")
+	assert.Empty(t, writer.Header().Get(types.HeaderAgent))
+
+	logic.setAgentHeader("code")
+	assert.Equal(t, "code", writer.Header().Get(types.HeaderAgent))
+}
+
+func TestChatCompletionLogic_setMessagesDroppedHeader(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+
+	logic.setMessagesDroppedHeader(3)
+	assert.Empty(t, writer.Header().Get(types.HeaderMessagesDropped), "dropped messages header is opt-in")
+
+	svcCtx.Config.ResponseHeaders.IncludeMessagesDropped = true
+	logic.setMessagesDroppedHeader(0)
+	assert.Equal(t, "0", writer.Header().Get(types.HeaderMessagesDropped))
+
+	logic.setMessagesDroppedHeader(3)
+	assert.Equal(t, "3", writer.Header().Get(types.HeaderMessagesDropped))
+}
+
+func TestChatCompletionLogic_EffectiveConfigDebug(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, recorder)
+	svcCtx.Config.Debug = config.DebugConfig{Enabled: true, TrustedHeader: "x-chat-rag-debug", TrustedToken: "secret"}
+	svcCtx.Config.ContextCompressConfig.TokenThreshold = 5000
+	logic.semanticTopK = 8
+	processedPrompt := &ds.ProcessedPrompt{Agent: "code", InjectedTools: []string{"codebase_search"}}
+	chatLog := &model.ChatLog{Params: model.RequestParams{Model: "auto", EffectiveMaxTokens: 4096}}
+
+	logic.headers.Set("x-chat-rag-debug", "secret")
+	logic.setEffectiveConfigDebug(processedPrompt, chatLog)
+	assert.Empty(t, recorder.Header().Get(types.HeaderDebugEffectiveConfig), "disabled by default")
+
+	svcCtx.Config.Debug.EffectiveConfig = true
+	logic.headers.Set("x-chat-rag-debug", "wrong")
+	logic.setEffectiveConfigDebug(processedPrompt, chatLog)
+	assert.Empty(t, recorder.Header().Get(types.HeaderDebugEffectiveConfig), "untrusted callers get nothing")
+
+	logic.headers.Set("x-chat-rag-debug", "secret")
+	logic.setEffectiveConfigDebug(processedPrompt, chatLog)
+	data, err := base64.StdEncoding.DecodeString(recorder.Header().Get(types.HeaderDebugEffectiveConfig))
+	require.NoError(t, err)
+
+	var effective effectiveConfig
+	require.NoError(t, json.Unmarshal(data, &effective))
+	assert.Equal(t, "code", effective.Agent)
+	assert.Equal(t, "auto", effective.Model)
+	assert.Equal(t, "test-model", effective.RoutedModel)
+	assert.Equal(t, 4096, effective.MaxTokens)
+	assert.Equal(t, 5000, effective.CompressTokenThreshold)
+	assert.Equal(t, 8, effective.SemanticTopK)
+	assert.Equal(t, MaxToolCallDepth, effective.MaxToolCallDepth)
+	assert.Equal(t, []string{"codebase_search"}, effective.InjectedTools)
+	assert.Len(t, effective.ConfigChecksum, systemPromptChecksumLength)
+}
+
+// stalledResponseWriter is a response writer whose client stopped reading
+type stalledResponseWriter struct {
+	mockResponseWriter
+	deadlines []time.Time
+}
+
+func (w *stalledResponseWriter) SetWriteDeadline(deadline time.Time) error {
+	w.deadlines = append(w.deadlines, deadline)
+	return nil
+}
+
+func (w *stalledResponseWriter) Write(data []byte) (int, error) {
+	return 0, fmt.Errorf("write tcp: %w", os.ErrDeadlineExceeded)
+}
+
+func TestChatCompletionLogic_SlowClient(t *testing.T) {
+	writer := &stalledResponseWriter{}
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+
+	svcCtx.Config.StreamBackpressure = config.StreamBackpressureConfig{Policy: config.StreamBackpressureBlock, WriteTimeoutMs: 100}
+	err := logic.sendRawLine(writer, "[DONE]")
+	assert.NotErrorIs(t, err, errSlowClient, "the backpressure policy waits for the client")
+	assert.Empty(t, writer.deadlines)
+
+	svcCtx.Config.StreamBackpressure.Policy = config.StreamBackpressureAbort
+	err = logic.sendRawLine(writer, "[DONE]")
+	assert.ErrorIs(t, err, errSlowClient)
+	require.Len(t, writer.deadlines, 2, "the deadline is set and cleared")
+	assert.False(t, writer.deadlines[0].IsZero())
+	assert.True(t, writer.deadlines[1].IsZero())
+
+	chatLog := &model.ChatLog{}
+	assert.NoError(t, logic.handleStreamError(err, chatLog))
+	assert.Equal(t, []map[types.ErrorType]string{{types.ErrSlowClient: err.Error()}}, chatLog.Error)
+}
+
+func TestChatCompletionLogic_takeDisableSemantic(t *testing.T) {
+	logic, _ := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+
+	assert.False(t, logic.takeDisableSemantic(), "semantic search stays on without the field")
+
+	logic.request.ExtraBody.Extra = map[string]any{"disable_semantic": true}
+	assert.True(t, logic.takeDisableSemantic())
+	assert.NotContains(t, logic.request.ExtraBody.Extra, "disable_semantic", "field is not forwarded to the model")
+
+	logic.request.ExtraBody.Extra = map[string]any{"disable_semantic": "yes"}
+	assert.False(t, logic.takeDisableSemantic())
+}
+
+func TestChatCompletionLogic_toolChoiceNone(t *testing.T) {
+	logic, _ := setupTestLogic(t, &config.Config{}, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+
+	assert.False(t, logic.toolChoiceNone())
+
+	logic.request.Extra["tool_choice"] = "auto"
+	assert.False(t, logic.toolChoiceNone())
+
+	logic.request.Extra["tool_choice"] = "none"
+	assert.True(t, logic.toolChoiceNone())
+	assert.Equal(t, "none", logic.request.Extra["tool_choice"], "tool_choice is forwarded to the model")
+}
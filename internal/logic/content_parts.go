@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+// limitContentParts merges text parts of messages above the configured part limit in place
+// and counts the merged messages in chatLog
+func (l *ChatCompletionLogic) limitContentParts(messages []types.Message, chatLog *model.ChatLog) {
+	maxParts := l.svcCtx.Config.ContentParts.MaxPerMessage
+	if maxParts <= 0 {
+		return
+	}
+
+	for i := range messages {
+		content, merged := utils.LimitContentParts(messages[i].Content, maxParts)
+		if !merged {
+			continue
+		}
+		messages[i].Content = content
+		chatLog.MergedContentMessages++
+		logger.InfoC(l.ctx, "merged content parts of message",
+			zap.Int("index", i), zap.String("role", messages[i].Role), zap.Int("maxParts", maxParts))
+	}
+}
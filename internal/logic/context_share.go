@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// limitContextShare keeps a tool result within the tool's share of the context window. Results
+// of capped tools injected earlier in the request count against the cap, so retrieved context
+// cannot crowd out the conversation over several calls. Applied cap and trimming are recorded.
+func (l *ChatCompletionLogic) limitContextShare(ctx context.Context, toolName string, result string,
+	toolCall *model.ToolCall) string {
+	limiter, ok := l.toolExecutor.(functions.ContextShareLimiter)
+	if !ok {
+		return result
+	}
+	capTokens, ok := limiter.ContextShareCap(toolName, l.svcCtx.Config.ContextCompressConfig.TokenThreshold)
+	if !ok {
+		return result
+	}
+
+	budget := max(capTokens-l.contextShareTokens, 0)
+	trimmed, dropped := limiter.TrimToTokens(toolName, result, budget, l.countTextTokens)
+	toolCall.ContextShareCap = capTokens
+	toolCall.TrimmedResults = dropped
+	l.contextShareTokens += l.countTextTokens(trimmed)
+	if dropped > 0 {
+		logger.InfoC(ctx, "tool results trimmed to context share",
+			zap.String("tool", toolName),
+			zap.Int("capTokens", capTokens),
+			zap.Int("budgetTokens", budget),
+			zap.Int("droppedResults", dropped))
+	}
+	return trimmed
+}
@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/ds"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/processor"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+// systemPromptChecksumLength is the number of hex characters kept for the system prompt checksum
+const systemPromptChecksumLength = 8
+
+// isTrustedDebugRequest reports whether debug output is enabled and the request
+// carries the configured trusted header value
+func (l *ChatCompletionLogic) isTrustedDebugRequest() bool {
+	debugCfg := l.svcCtx.Config.Debug
+	if !debugCfg.Enabled || debugCfg.TrustedToken == "" || l.headers == nil {
+		return false
+	}
+
+	token := l.headers.Get(debugCfg.TrustedHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(debugCfg.TrustedToken)) == 1
+}
+
+// setSystemPromptDebugHeaders exposes the processed system prompt to trusted debug requests,
+// with the SystemPromptCache key of its compressed region when it has one
+func (l *ChatCompletionLogic) setSystemPromptDebugHeaders(processedPrompt *ds.ProcessedPrompt) {
+	if l.writer == nil || processedPrompt == nil || !l.isTrustedDebugRequest() {
+		return
+	}
+
+	systemPrompt := systemPromptContent(processedPrompt.Messages)
+
+	header := l.writer.Header()
+	if cacheKey, ok := processor.SystemPromptCacheKey(systemPrompt, l.svcCtx.Config.Debug.SystemPromptSplitStr); ok {
+		header.Set(types.HeaderDebugSystemPromptCacheKey, cacheKey)
+	}
+	header.Set(types.HeaderDebugSystemPromptLength, strconv.Itoa(len(systemPrompt)))
+
+	if l.svcCtx.Config.Debug.IncludeSystemPrompt {
+		maxBytes := l.svcCtx.Config.Debug.MaxSystemPromptBytes
+		if maxBytes > 0 && len(systemPrompt) > maxBytes {
+			systemPrompt = systemPrompt[:maxBytes]
+		}
+		header.Set(types.HeaderDebugSystemPrompt, base64.StdEncoding.EncodeToString([]byte(systemPrompt)))
+	}
+
+	logger.InfoC(l.ctx, "system prompt debug headers set",
+		zap.String("user", l.identity.UserName),
+		zap.Bool("includeText", l.svcCtx.Config.Debug.IncludeSystemPrompt))
+}
+
+// systemPromptChecksum returns the leading characters of the SHA256 checksum of the whole system
+// prompt. The current time instruction is left out, so the checksum stays stable across requests.
+func systemPromptChecksum(messages []types.Message) string {
+	sum := sha256.Sum256([]byte(processor.WithoutCurrentTime(systemPromptContent(messages))))
+	return hex.EncodeToString(sum[:])[:systemPromptChecksumLength]
+}
+
+// systemPromptContent returns the text of the first system message
+func systemPromptContent(messages []types.Message) string {
+	for _, msg := range messages {
+		if msg.Role == types.RoleSystem {
+			return utils.GetContentAsString(msg.Content)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// echoCategory marks echo requests in logs and the category metric label
+const echoCategory = "echo"
+
+// isEchoRequest reports whether the request targets the reserved echo model
+func (l *ChatCompletionLogic) isEchoRequest() bool {
+	echoCfg := l.svcCtx.Config.EchoModel
+	return echoCfg.Enabled && echoCfg.Name != "" && l.request.Model == echoCfg.Name
+}
+
+// newEchoResponse builds the canned response without touching the prompt
+func (l *ChatCompletionLogic) newEchoResponse(start time.Time) types.ChatCompletionResponse {
+	return types.ChatCompletionResponse{
+		Id:      "echo-" + l.identity.RequestID,
+		Object:  "chat.completion",
+		Created: start.Unix(),
+		Model:   l.request.Model,
+		Choices: []types.Choice{{
+			Message:      types.Message{Role: types.RoleAssistant, Content: l.svcCtx.Config.EchoModel.Response},
+			FinishReason: "stop",
+		}},
+	}
+}
+
+// echoCompletion answers a non-streamed echo request
+func (l *ChatCompletionLogic) echoCompletion() *types.ChatCompletionResponse {
+	start := time.Now()
+	response := l.newEchoResponse(start)
+	l.logEcho(start)
+	return &response
+}
+
+// echoCompletionStream answers a streamed echo request with one content chunk and [DONE]
+func (l *ChatCompletionLogic) echoCompletionStream() error {
+	start := time.Now()
+	defer l.logEcho(start)
+
+	flusher, ok := l.writer.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming not supported")
+	}
+
+	response := l.newEchoResponse(start)
+	response.Object = "chat.completion.chunk"
+	response.Choices = []types.Choice{{
+		Delta:        types.Delta{Role: types.RoleAssistant, Content: l.svcCtx.Config.EchoModel.Response},
+		FinishReason: "stop",
+	}}
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal echo response: %w", err)
+	}
+
+	if err := l.sendRawLine(flusher, string(jsonData)); err != nil {
+		return err
+	}
+	return l.sendRawLine(flusher, "[DONE]")
+}
+
+// logEcho records the echo request without counting tokens or storing a prompt
+func (l *ChatCompletionLogic) logEcho(start time.Time) {
+	logger.InfoC(l.ctx, "echo model request answered",
+		zap.String("model", l.request.Model),
+		zap.Duration("latency", time.Since(start)))
+
+	l.logCompletion(&model.ChatLog{
+		Identity:  *l.identity,
+		Timestamp: start,
+		Params:    model.RequestParams{Model: l.request.Model},
+		Category:  echoCategory,
+	})
+}
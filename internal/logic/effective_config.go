@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/ds"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// effectiveConfig is the subset of settings resolved for one request
+type effectiveConfig struct {
+	// Checksum of the whole service config, changes with every Nacos update
+	ConfigChecksum string   `json:"config_checksum"`
+	PromptMode     string   `json:"prompt_mode"`
+	Agent          string   `json:"agent,omitempty"`
+	Model          string   `json:"model"`
+	RoutedModel    string   `json:"routed_model"`
+	MaxTokens      int      `json:"max_tokens,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+
+	EnableCompress         bool `json:"enable_compress"`
+	EnableUserCompressor   bool `json:"enable_user_compressor"`
+	CompressTokenThreshold int  `json:"compress_token_threshold"`
+	SplitUserMessageTokens int  `json:"split_user_message_tokens,omitempty"`
+	MaxPromptTokens        int  `json:"max_prompt_tokens,omitempty"`
+
+	DisableTools     bool     `json:"disable_tools"`
+	InjectedTools    []string `json:"injected_tools,omitempty"`
+	UnhealthyTools   []string `json:"unhealthy_tools,omitempty"`
+	MaxDistinctTools int      `json:"max_distinct_tools,omitempty"`
+	MaxToolCallDepth int      `json:"max_tool_call_depth"`
+	SemanticTopK     int      `json:"semantic_top_k,omitempty"`
+
+	IdleTimeoutMs        int `json:"idle_timeout_ms"`
+	StreamDropRetryCount int `json:"stream_drop_retry_count,omitempty"`
+}
+
+// setEffectiveConfigDebug returns the settings applied to the request to trusted debug callers,
+// as base64 encoded JSON in a response header, and logs them
+func (l *ChatCompletionLogic) setEffectiveConfigDebug(processedPrompt *ds.ProcessedPrompt, chatLog *model.ChatLog) {
+	if !l.svcCtx.Config.Debug.EffectiveConfig || l.writer == nil || processedPrompt == nil ||
+		!l.isTrustedDebugRequest() {
+		return
+	}
+
+	cfg := l.svcCtx.Config
+	effective := effectiveConfig{
+		ConfigChecksum:         configChecksum(cfg),
+		PromptMode:             string(l.request.ExtraBody.PromptMode),
+		Agent:                  processedPrompt.Agent,
+		Model:                  chatLog.Params.Model,
+		RoutedModel:            l.request.Model,
+		MaxTokens:              chatLog.Params.EffectiveMaxTokens,
+		Temperature:            chatLog.Params.EffectiveTemperature,
+		EnableCompress:         cfg.ContextCompressConfig.EnableCompress,
+		EnableUserCompressor:   cfg.ContextCompressConfig.EnableUserCompressor,
+		CompressTokenThreshold: cfg.ContextCompressConfig.TokenThreshold,
+		SplitUserMessageTokens: cfg.ContextCompressConfig.SplitUserMessageTokens,
+		MaxPromptTokens:        cfg.MaxPromptTokens,
+		InjectedTools:          processedPrompt.InjectedTools,
+		UnhealthyTools:         processedPrompt.UnhealthyTools,
+		MaxToolCallDepth:       l.toolCallDepthLimit(),
+		SemanticTopK:           l.semanticTopK,
+		IdleTimeoutMs:          cfg.LLMTimeout.IdleTimeoutMs,
+		StreamDropRetryCount:   cfg.LLMTimeout.StreamDropRetryCount,
+	}
+	if cfg.Tools != nil {
+		effective.DisableTools = cfg.Tools.DisableTools
+		effective.MaxDistinctTools = cfg.Tools.MaxDistinctTools
+	}
+
+	data, err := json.Marshal(effective)
+	if err != nil {
+		logger.WarnC(l.ctx, "failed to encode effective config", zap.Error(err))
+		return
+	}
+	l.writer.Header().Set(types.HeaderDebugEffectiveConfig, base64.StdEncoding.EncodeToString(data))
+	logger.InfoC(l.ctx, "effective config resolved",
+		zap.String("user", l.identity.UserName),
+		zap.String("effectiveConfig", string(data)))
+}
+
+// configChecksum returns a short hash of the service config, shortened like the system prompt checksum
+func configChecksum(cfg config.Config) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:systemPromptChecksumLength]
+}
@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// emptyDeltaMetadataKeys are the chunk fields that carry nothing for the client on their own
+var emptyDeltaMetadataKeys = map[string]bool{
+	"id": true, "object": true, "created": true, "model": true, "system_fingerprint": true,
+	"choices": true, "usage": true,
+}
+
+// isEmptyDelta reports whether a stream line with no content can be dropped: blank lines,
+// SSE comments used as keepalives and chunks whose deltas are empty. A chunk with usage,
+// a finish reason, other delta fields or unknown fields is kept, as is the first role chunk.
+func isEmptyDelta(rawLine string, state *streamState) bool {
+	line := strings.TrimSpace(rawLine)
+	if line == "" || strings.HasPrefix(line, ":") {
+		return true
+	}
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok {
+		return false
+	}
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return true
+	}
+
+	var chunk map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return false
+	}
+	for key, value := range chunk {
+		if !emptyDeltaMetadataKeys[key] {
+			return false
+		}
+		if key == "usage" && !isJSONNull(value) {
+			return false
+		}
+	}
+
+	var choices []struct {
+		Delta        map[string]json.RawMessage `json:"delta"`
+		FinishReason json.RawMessage            `json:"finish_reason"`
+	}
+	if raw, ok := chunk["choices"]; ok && !isJSONNull(raw) {
+		if err := json.Unmarshal(raw, &choices); err != nil {
+			return false
+		}
+	}
+
+	hasRole := false
+	for _, choice := range choices {
+		if !isJSONNull(choice.FinishReason) {
+			return false
+		}
+		for key, value := range choice.Delta {
+			switch {
+			case key == "role":
+				hasRole = true
+			case isJSONNull(value) || string(value) == `""`:
+			default:
+				return false
+			}
+		}
+	}
+	if hasRole && !state.roleSent {
+		state.roleSent = true
+		return false
+	}
+	return true
+}
+
+// isJSONNull reports whether a raw JSON value is absent or null
+func isJSONNull(value json.RawMessage) bool {
+	return len(value) == 0 || string(value) == "null"
+}
@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestHandleStreamChunk_SuppressEmptyDeltas(t *testing.T) {
+	lines := []string{
+		`data: {"id":"c1","choices":[{"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+		`data: {"id":"c1","choices":[{"delta":{"content":""},"finish_reason":null}]}`,
+		`: keepalive`,
+		`data: {"id":"c1","choices":[{"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+		`data: {"id":"c1","choices":[{"delta":{"reasoning_content":"thinking"},"finish_reason":null}]}`,
+		`data: {"id":"c1","choices":[{"delta":{"content":null},"finish_reason":null}]}`,
+		`data: {"id":"c1","choices":[{"delta":{"content":""},"finish_reason":"stop"}]}`,
+		`data: {"id":"c1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`,
+	}
+
+	for _, suppress := range []bool{false, true} {
+		writer := &mockResponseWriter{}
+		logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "test-model",
+			[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+		svcCtx.Config.SuppressEmptyDeltas = suppress
+		state := newStreamState()
+		for _, raw := range lines {
+			require.NoError(t, logic.handleStreamChunk(logic.ctx, writer, raw, state, 0, &model.ChatLog{}, nil))
+		}
+
+		sent := strings.Split(strings.TrimSpace(string(writer.data)), "\n\n")
+		if !suppress {
+			assert.Len(t, sent, len(lines))
+			continue
+		}
+		assert.Equal(t, []string{lines[0], lines[4], lines[6], lines[7]}, sent,
+			"only the first role chunk, reasoning, finish reason and usage are forwarded")
+		require.NotNil(t, logic.usage)
+		assert.Equal(t, 4, logic.usage.TotalTokens)
+	}
+}
+
+func TestIsEmptyDelta(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{"blank", "", true},
+		{"empty data", "data: ", true},
+		{"no choices", `data: {"id":"c1"}`, true},
+		{"null usage", `data: {"id":"c1","choices":[{"delta":{}}],"usage":null}`, true},
+		{"tool calls", `data: {"choices":[{"delta":{"tool_calls":[{"index":0}]}}]}`, false},
+		{"unknown field", `data: {"error":{"message":"overloaded"}}`, false},
+		{"not json", "data: oops", false},
+		{"other event", "event: ping", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isEmptyDelta(tt.line, newStreamState()))
+		})
+	}
+}
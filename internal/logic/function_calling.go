@@ -0,0 +1,279 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/ds"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// functionCall is a tool call assembled from the tool_calls deltas of a stream
+type functionCall struct {
+	name      string
+	arguments strings.Builder
+}
+
+// usesFunctionCalling reports whether the request's model calls server tools natively
+// (one of LLM.FuncCallingModels) rather than with XML tags
+func (l *ChatCompletionLogic) usesFunctionCalling() bool {
+	for _, name := range l.svcCtx.Config.LLM.FuncCallingModels {
+		if strings.EqualFold(name, l.request.Model) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolChoiceNone reports whether the caller does not allow tool calls with tool_choice "none"
+func (l *ChatCompletionLogic) toolChoiceNone() bool {
+	choice, _ := l.request.Extra["tool_choice"].(string)
+	return choice == "none"
+}
+
+// offersServerFunctions reports whether the injected server tools are offered to the model as
+// functions. Callers sending their own tools handle tool calls themselves and get none added,
+// the LLM client leaves their tools as they are
+func (l *ChatCompletionLogic) offersServerFunctions() bool {
+	if !l.usesFunctionCalling() || !l.toolsEnabled() {
+		return false
+	}
+	if _, ok := l.request.Extra["tools"]; ok {
+		return false
+	}
+	_, ok := l.toolExecutor.(functions.FunctionDefiner)
+	return ok
+}
+
+// offeredTools returns the tools sent to the model: the processed prompt's tools, plus the
+// injected server tools as functions when the model calls tools natively
+func (l *ChatCompletionLogic) offeredTools(processedPrompt *ds.ProcessedPrompt) []types.Function {
+	if !l.offersServerFunctions() {
+		return processedPrompt.Tools
+	}
+	definer := l.toolExecutor.(functions.FunctionDefiner)
+	return append(processedPrompt.Tools, definer.ToolFunctions(processedPrompt.InjectedTools)...)
+}
+
+// toolsEnabled reports whether server tools may run for this request
+func (l *ChatCompletionLogic) toolsEnabled() bool {
+	return l.toolExecutor != nil && l.svcCtx.Config.Tools != nil && !l.svcCtx.Config.Tools.DisableTools
+}
+
+// collectToolCallDeltas adds the tool_calls deltas of a stream line to the state. It reports
+// whether the line belongs to a tool call, such lines are withheld from the client until the
+// calls are known to be for server tools.
+func collectToolCallDeltas(rawLine string, state *streamState) bool {
+	data, ok := strings.CutPrefix(rawLine, "data: ")
+	if !ok || !strings.Contains(data, "tool_calls") {
+		return false
+	}
+
+	var chunk struct {
+		Choices []struct {
+			Delta struct {
+				ToolCalls []struct {
+					Index    int `json:"index"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"delta"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil || len(chunk.Choices) == 0 {
+		return false
+	}
+
+	choice := chunk.Choices[0]
+	for _, delta := range choice.Delta.ToolCalls {
+		if state.funcCalls == nil {
+			state.funcCalls = make(map[int]*functionCall)
+		}
+		call := state.funcCalls[delta.Index]
+		if call == nil {
+			call = &functionCall{}
+			state.funcCalls[delta.Index] = call
+		}
+		if delta.Function.Name != "" {
+			call.name = delta.Function.Name
+		}
+		call.arguments.WriteString(delta.Function.Arguments)
+	}
+	if len(choice.Delta.ToolCalls) == 0 && choice.FinishReason != "tool_calls" {
+		return false
+	}
+	state.funcCallLines = append(state.funcCallLines, rawLine)
+	if choice.FinishReason == "tool_calls" {
+		state.funcCallFinish = rawLine
+	}
+	return true
+}
+
+// serverFunctionCall returns the call with the lowest index among those for the server tools,
+// tools run one per turn
+func (s *streamState) serverFunctionCall(serverTools []string) *functionCall {
+	indexes := make([]int, 0, len(s.funcCalls))
+	for index, call := range s.funcCalls {
+		if slices.Contains(serverTools, call.name) {
+			indexes = append(indexes, index)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	sort.Ints(indexes)
+	return s.funcCalls[indexes[0]]
+}
+
+// dispatchFunctionCall turns the model's native call of an injected server tool into the XML
+// tool content the executor parses, so it runs through the same tool execution as XML tags.
+// Content still held back in the window goes to the client first. Calls of other tools are
+// forwarded unchanged, and a dropped server call still ends the stream with its finish chunk.
+func (l *ChatCompletionLogic) dispatchFunctionCall(ctx context.Context, flusher http.Flusher,
+	state *streamState, chatLog *model.ChatLog) error {
+	call := state.serverFunctionCall(chatLog.InjectedTools)
+	if call == nil {
+		logger.InfoC(ctx, "function calls are not for server tools, forwarded to the client",
+			zap.Int("calls", len(state.funcCalls)))
+		return l.releaseWithheldLines(flusher, state, state.funcCallLines)
+	}
+	if l.distinctToolLimitReached(chatLog, call.name) {
+		chatLog.SkippedTools = append(chatLog.SkippedTools, call.name)
+		logger.InfoC(ctx, "distinct tool limit reached, function call ignored", zap.String("name", call.name))
+		return l.releaseFunctionCallFinish(flusher, state)
+	}
+
+	toolContent, err := functionCallToXML(call.name, call.arguments.String())
+	if err != nil {
+		logger.WarnC(ctx, "failed to convert function call arguments",
+			zap.String("name", call.name), zap.Error(err))
+		return l.releaseFunctionCallFinish(flusher, state)
+	}
+
+	pending := strings.TrimSuffix(strings.Join(state.window, ""), "[DONE]")
+	if pending != "" {
+		if err := l.sendModelContent(flusher, state.response, pending); err != nil {
+			return err
+		}
+	}
+
+	logger.InfoC(ctx, "detected function call", zap.String("name", call.name),
+		zap.Int("calls", len(state.funcCalls)))
+	state.toolDetected = true
+	state.toolName = call.name
+	state.window = []string{toolContent}
+	state.fullContent.WriteString(toolContent)
+	return nil
+}
+
+// releaseFunctionCallFinish sends the withheld finish chunk of a server tool call that is not
+// run. The call itself never reached the client, so the chunk finishes with "stop" instead.
+func (l *ChatCompletionLogic) releaseFunctionCallFinish(flusher http.Flusher, state *streamState) error {
+	if state.funcCallFinish == "" {
+		return nil
+	}
+	return l.releaseWithheldLines(flusher, state, []string{finishAsStop(state.funcCallFinish)})
+}
+
+// finishAsStop rewrites the finish reason of a "tool_calls" finish chunk to "stop",
+// the line is returned as is when it cannot be decoded
+func finishAsStop(rawLine string) string {
+	var chunk map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(rawLine, "data: ")), &chunk); err != nil {
+		return rawLine
+	}
+	choices, _ := chunk["choices"].([]any)
+	for _, choice := range choices {
+		if c, ok := choice.(map[string]any); ok && c["finish_reason"] == "tool_calls" {
+			c["finish_reason"] = "stop"
+		}
+	}
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return rawLine
+	}
+	return "data: " + string(data)
+}
+
+// releaseWithheldLines sends the content held back in the window, then the withheld
+// tool_calls lines, so the client receives them in stream order
+func (l *ChatCompletionLogic) releaseWithheldLines(flusher http.Flusher, state *streamState, lines []string) error {
+	window := strings.Join(state.window, "")
+	pending := strings.TrimSuffix(window, "[DONE]")
+	if pending != "" {
+		if err := l.sendModelContent(flusher, state.response, pending); err != nil {
+			return err
+		}
+	}
+	state.window = nil
+	if pending != window {
+		state.window = []string{"[DONE]"}
+	}
+
+	for _, line := range lines {
+		if err := l.sendRawLine(flusher, line); err != nil {
+			return err
+		}
+	}
+	state.toolCallsForwarded = len(lines) > 0
+	return nil
+}
+
+// functionCallToXML renders function call arguments as the XML tool tags models write,
+// array values become one tag per element
+func functionCallToXML(name string, arguments string) (string, error) {
+	args := map[string]interface{}{}
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("arguments are not a JSON object: %w", err)
+		}
+	}
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%s>\n", name)
+	for _, key := range keys {
+		values, isList := args[key].([]interface{})
+		if !isList {
+			values = []interface{}{args[key]}
+		}
+		for _, value := range values {
+			fmt.Fprintf(&b, "<%s>%s</%s>\n", key, xmlParamValue(value), key)
+		}
+	}
+	fmt.Fprintf(&b, "</%s>", name)
+	return b.String(), nil
+}
+
+// xmlParamValue formats an argument value as parameter text
+func xmlParamValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case map[string]interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
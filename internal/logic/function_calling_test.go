@@ -0,0 +1,177 @@
+package logic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/ds"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestFunctionCallToXML(t *testing.T) {
+	xml, err := functionCallToXML("codebase_search",
+		`{"query": "login handler", "paths": ["internal/api", "internal/logic"], "topK": 5}`)
+	require.NoError(t, err)
+	assert.Equal(t, "<codebase_search>\n"+
+		"<paths>internal/api</paths>\n<paths>internal/logic</paths>\n"+
+		"<query>login handler</query>\n<topK>5</topK>\n"+
+		"</codebase_search>", xml)
+
+	xml, err = functionCallToXML("list_files", "")
+	require.NoError(t, err)
+	assert.Equal(t, "<list_files>\n</list_files>", xml)
+
+	_, err = functionCallToXML("codebase_search", `{"query": "unterminated`)
+	assert.Error(t, err)
+}
+
+func TestChatCompletionLogic_FunctionCallDispatch(t *testing.T) {
+	writer := &mockResponseWriter{}
+	cfg := &config.Config{LLM: config.LLMConfig{FuncCallingModels: []string{"Test-Model"}}}
+	logic, svcCtx := setupTestLogic(t, cfg, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+	svcCtx.Config.Tools = &config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+		Name:        "codebase_search",
+		Description: "Semantic code search",
+		Parameters: []config.GenericToolParameter{
+			{Name: "query", Type: "string", Required: true, Source: config.ParameterSourceLLM},
+			{Name: "clientId", Type: "string", Source: config.ParameterSourceManual},
+		},
+	}}}
+	logic.toolExecutor = functions.NewGenericToolExecutor(svcCtx.Config.Tools)
+
+	offered := logic.offeredTools(&ds.ProcessedPrompt{InjectedTools: []string{"codebase_search"}})
+	require.Len(t, offered, 1)
+	assert.Equal(t, "codebase_search", offered[0].Function.Name)
+	assert.Equal(t, []string{"query"}, offered[0].Function.Parameters.Required)
+	assert.NotContains(t, offered[0].Function.Parameters.Properties, "clientId", "only model provided parameters")
+
+	state := newStreamState()
+	state.firstToken = false
+	state.funcCalling = logic.offersServerFunctions()
+	state.response = &types.ChatCompletionResponse{Id: "chatcmpl-1"}
+	require.True(t, state.funcCalling)
+
+	line := func(delta map[string]any, finishReason any) string {
+		data, _ := json.Marshal(map[string]any{"choices": []any{
+			map[string]any{"delta": delta, "finish_reason": finishReason}}})
+		return "data: " + string(data)
+	}
+	toolDelta := func(name, arguments string) map[string]any {
+		return map[string]any{"content": nil, "tool_calls": []any{map[string]any{
+			"index": 0, "function": map[string]any{"name": name, "arguments": arguments}}}}
+	}
+	lines := []string{
+		line(map[string]any{"content": "Searching"}, nil),
+		line(toolDelta("codebase_search", `{"que`), nil),
+		line(toolDelta("", `ry": "login"}`), nil),
+		line(map[string]any{}, "tool_calls"),
+		"data: [DONE]",
+	}
+	chatLog := &model.ChatLog{InjectedTools: []string{"codebase_search"}}
+	for _, raw := range lines {
+		require.NoError(t, logic.handleStreamChunk(logic.ctx, writer, raw, state, 1, chatLog, nil))
+	}
+	assert.False(t, state.toolDetected, "native calls are dispatched after the stream")
+	assert.NotContains(t, string(writer.data), "tool_calls", "tool call deltas are not forwarded")
+
+	require.NoError(t, logic.dispatchFunctionCall(logic.ctx, writer, state, chatLog))
+	assert.True(t, state.toolDetected)
+	assert.Equal(t, "codebase_search", state.toolName)
+	assert.Equal(t, "<codebase_search>\n<query>login</query>\n</codebase_search>", strings.Join(state.window, ""))
+	assert.Contains(t, string(writer.data), "Searching", "held back content is sent before the tool runs")
+
+	params, err := logic.toolExecutor.(functions.ParameterExtractor).ExtractToolParams(state.toolName, state.window[0])
+	require.NoError(t, err)
+	assert.Equal(t, "login", params["query"])
+}
+
+// setupFunctionCallingLogic returns logic for a function calling model with codebase_search
+// injected, and a helper rendering a stream line
+func setupFunctionCallingLogic(t *testing.T, writer *mockResponseWriter) (*ChatCompletionLogic, func(delta map[string]any, finishReason any) string) {
+	cfg := &config.Config{LLM: config.LLMConfig{FuncCallingModels: []string{"test-model"}}}
+	logic, svcCtx := setupTestLogic(t, cfg, nil, "test-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, writer)
+	svcCtx.Config.Tools = &config.ToolConfig{GenericTools: []config.GenericToolConfig{{
+		Name:       "codebase_search",
+		Parameters: []config.GenericToolParameter{{Name: "query", Type: "string", Source: config.ParameterSourceLLM}},
+	}}}
+	logic.toolExecutor = functions.NewGenericToolExecutor(svcCtx.Config.Tools)
+
+	line := func(delta map[string]any, finishReason any) string {
+		data, _ := json.Marshal(map[string]any{"choices": []any{
+			map[string]any{"delta": delta, "finish_reason": finishReason}}})
+		return "data: " + string(data)
+	}
+	return logic, line
+}
+
+func toolCallDelta(name, arguments string) map[string]any {
+	return map[string]any{"tool_calls": []any{map[string]any{
+		"index": 0, "function": map[string]any{"name": name, "arguments": arguments}}}}
+}
+
+func TestChatCompletionLogic_FunctionCallingWithCallerTools(t *testing.T) {
+	writer := &mockResponseWriter{}
+	logic, line := setupFunctionCallingLogic(t, writer)
+	assert.True(t, logic.offersServerFunctions())
+
+	logic.request.Extra = map[string]any{"tools": []any{}}
+	assert.False(t, logic.offersServerFunctions(), "the caller handles tool calls itself")
+	assert.Empty(t, logic.offeredTools(&ds.ProcessedPrompt{InjectedTools: []string{"codebase_search"}}))
+
+	state := newStreamState()
+	state.firstToken = false
+	state.funcCalling = logic.offersServerFunctions()
+	chatLog := &model.ChatLog{InjectedTools: []string{"codebase_search"}}
+	require.NoError(t, logic.handleStreamChunk(logic.ctx, writer,
+		line(toolCallDelta("get_weather", `{"city": "Paris"}`), nil), state, 1, chatLog, nil))
+	require.NoError(t, logic.handleStreamChunk(logic.ctx, writer, line(map[string]any{}, "tool_calls"), state, 1, chatLog, nil))
+	assert.Contains(t, string(writer.data), "get_weather", "caller tool calls are forwarded")
+	assert.Contains(t, string(writer.data), `"finish_reason":"tool_calls"`)
+	assert.Empty(t, state.funcCalls)
+}
+
+func TestChatCompletionLogic_FunctionCallRelease(t *testing.T) {
+	stream := func(t *testing.T, name string, arguments string) (*ChatCompletionLogic, *mockResponseWriter, *streamState) {
+		writer := &mockResponseWriter{}
+		logic, line := setupFunctionCallingLogic(t, writer)
+		state := newStreamState()
+		state.firstToken = false
+		state.funcCalling = logic.offersServerFunctions()
+		state.response = &types.ChatCompletionResponse{Id: "chatcmpl-1"}
+		chatLog := &model.ChatLog{InjectedTools: []string{"codebase_search"}}
+		for _, raw := range []string{
+			line(toolCallDelta(name, arguments), nil),
+			line(map[string]any{}, "tool_calls"),
+			"data: [DONE]",
+		} {
+			require.NoError(t, logic.handleStreamChunk(logic.ctx, writer, raw, state, 1, chatLog, nil))
+		}
+		assert.NotContains(t, string(writer.data), "tool_calls", "calls are withheld until dispatched")
+		require.NoError(t, logic.dispatchFunctionCall(logic.ctx, writer, state, chatLog))
+		return logic, writer, state
+	}
+
+	t.Run("not a server tool", func(t *testing.T) {
+		_, writer, state := stream(t, "get_weather", `{"city": "Paris"}`)
+		assert.False(t, state.toolDetected)
+		assert.Contains(t, string(writer.data), "get_weather", "forwarded unchanged")
+		assert.Contains(t, string(writer.data), `"finish_reason":"tool_calls"`)
+		assert.Equal(t, []string{"[DONE]"}, state.window)
+	})
+
+	t.Run("malformed arguments", func(t *testing.T) {
+		_, writer, state := stream(t, "codebase_search", `{"query": "unterminated`)
+		assert.False(t, state.toolDetected)
+		assert.NotContains(t, string(writer.data), "codebase_search", "server calls are not forwarded")
+		assert.Contains(t, string(writer.data), `"finish_reason":"stop"`, "the finish chunk is still sent")
+	})
+}
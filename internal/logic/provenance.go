@@ -0,0 +1,27 @@
+package logic
+
+import (
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// recordRetrievedChunks adds the chunks of a tool result to the chat log, keeping at most
+// RetrievalProvenance.MaxChunks per request and counting the rest
+func (l *ChatCompletionLogic) recordRetrievedChunks(chatLog *model.ChatLog, toolName string, result string) {
+	cfg := l.svcCtx.Config.RetrievalProvenance
+	reporter, ok := l.toolExecutor.(functions.ChunkReporter)
+	if !cfg.Enabled || !ok {
+		return
+	}
+	chunks, ok := reporter.ResultChunks(toolName, result)
+	if !ok {
+		return
+	}
+
+	room := max(cfg.MaxChunks-len(chatLog.RetrievedChunks), 0)
+	if len(chunks) > room {
+		chatLog.RetrievedChunksOmitted += len(chunks) - room
+		chunks = chunks[:room]
+	}
+	chatLog.RetrievedChunks = append(chatLog.RetrievedChunks, chunks...)
+}
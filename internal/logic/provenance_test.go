@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// chunkToolExecutor reports one chunk per character of the result
+type chunkToolExecutor struct {
+	stubToolExecutor
+}
+
+func (e *chunkToolExecutor) ResultChunks(toolName string, result string) ([]model.RetrievedChunk, bool) {
+	chunks := make([]model.RetrievedChunk, 0, len(result))
+	for _, path := range result {
+		chunks = append(chunks, model.RetrievedChunk{FilePath: string(path), Source: model.ChunkSourceTool, Tool: toolName})
+	}
+	return chunks, true
+}
+
+func TestRecordRetrievedChunks(t *testing.T) {
+	svcCtx := &bootstrap.ServiceContext{}
+	l := &ChatCompletionLogic{svcCtx: svcCtx, toolExecutor: &chunkToolExecutor{}}
+	chatLog := &model.ChatLog{}
+
+	l.recordRetrievedChunks(chatLog, "codebase_search", "ab")
+	assert.Empty(t, chatLog.RetrievedChunks, "disabled")
+
+	svcCtx.Config.RetrievalProvenance = config.RetrievalProvenanceConfig{Enabled: true, MaxChunks: 3}
+	l.recordRetrievedChunks(chatLog, "codebase_search", "ab")
+	l.recordRetrievedChunks(chatLog, "knowledge_base_search", "cde")
+	l.recordRetrievedChunks(chatLog, "codebase_search", "f")
+
+	var paths []string
+	for _, chunk := range chatLog.RetrievedChunks {
+		paths = append(paths, chunk.Tool+":"+chunk.FilePath)
+	}
+	assert.Equal(t, []string{"codebase_search:a", "codebase_search:b", "knowledge_base_search:c"}, paths)
+	assert.Equal(t, 3, chatLog.RetrievedChunksOmitted)
+}
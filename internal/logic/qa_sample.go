@@ -0,0 +1,38 @@
+package logic
+
+import (
+	"slices"
+
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/service"
+)
+
+// sampleQARequest decides whether the request is recorded for QA and keeps the
+// client's messages, before prompt processing and tool results change them
+func (l *ChatCompletionLogic) sampleQARequest() {
+	if l.svcCtx.QASampler == nil || !l.svcCtx.QASampler.Sample() {
+		return
+	}
+	l.qaMessages = slices.Clone(l.request.Messages)
+}
+
+// recordQASample writes the sampled request with its final response. Failed requests
+// and requests without a response are not useful for evaluation and are skipped
+func (l *ChatCompletionLogic) recordQASample(chatLog *model.ChatLog) {
+	if l.qaMessages == nil || l.svcCtx.QASampler == nil {
+		return
+	}
+	if len(chatLog.Error) > 0 || chatLog.ResponseContent == nil || chatLog.ResponseContent.Content == "" {
+		return
+	}
+
+	l.svcCtx.QASampler.Record(service.QASampleRecord{
+		Timestamp: chatLog.Timestamp,
+		RequestID: chatLog.Identity.RequestID,
+		Model:     chatLog.Params.RoutedModel,
+		Agent:     chatLog.Agent,
+		Messages:  l.qaMessages,
+		Response:  chatLog.ResponseContent.Content,
+		Usage:     chatLog.Usage,
+	})
+}
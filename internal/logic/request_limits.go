@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// Request fields that bound the number of generated tokens
+var outputTokenFields = []string{"max_tokens", "max_completion_tokens"}
+
+// outputTokenLimit resolves the output token limit for the agent, falling back to the global limit
+func outputTokenLimit(cfg config.OutputTokensConfig, agent string) config.OutputTokenLimit {
+	limit := cfg.OutputTokenLimit
+	if agent == "" {
+		return limit
+	}
+
+	// Map keys are lower-cased by the config loader
+	if agentLimit, ok := cfg.Agents[strings.ToLower(agent)]; ok {
+		if agentLimit.DefaultMaxTokens > 0 {
+			limit.DefaultMaxTokens = agentLimit.DefaultMaxTokens
+		}
+		if agentLimit.MaxTokens > 0 {
+			limit.MaxTokens = agentLimit.MaxTokens
+		}
+	}
+	return limit
+}
+
+// applyOutputTokenLimits sets a default max_tokens when the request omits it and clamps
+// requested values above the configured maximum. The effective limit is recorded in chatLog.
+func (l *ChatCompletionLogic) applyOutputTokenLimits(agent string, chatLog *model.ChatLog) {
+	limit := outputTokenLimit(l.svcCtx.Config.OutputTokens, agent)
+	if limit.DefaultMaxTokens <= 0 && limit.MaxTokens <= 0 {
+		return
+	}
+
+	if l.request.Extra == nil {
+		l.request.Extra = make(map[string]any)
+	}
+
+	effective := 0
+	for _, field := range outputTokenFields {
+		value, ok := l.request.Extra[field]
+		if !ok || value == nil {
+			continue
+		}
+		requested, ok := toInt(value)
+		if !ok {
+			continue
+		}
+
+		if limit.MaxTokens > 0 && requested > limit.MaxTokens {
+			logger.InfoC(l.ctx, "requested output tokens exceed limit, clamping",
+				zap.String("field", field),
+				zap.String("agent", agent),
+				zap.Int("requested", requested),
+				zap.Int("max", limit.MaxTokens))
+			requested = limit.MaxTokens
+			l.request.Extra[field] = requested
+		}
+		effective = requested
+	}
+
+	// No output limit in the request, apply the configured default
+	if effective == 0 && limit.DefaultMaxTokens > 0 {
+		effective = limit.DefaultMaxTokens
+		if limit.MaxTokens > 0 && effective > limit.MaxTokens {
+			effective = limit.MaxTokens
+		}
+		l.request.Extra["max_tokens"] = effective
+		logger.InfoC(l.ctx, "max_tokens not set, using default",
+			zap.String("agent", agent),
+			zap.Int("maxTokens", effective))
+	}
+
+	chatLog.Params.EffectiveMaxTokens = effective
+}
+
+// toInt converts a JSON number to int
+func toInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+// modelTemperature finds the temperature settings of the model
+func modelTemperature(cfg config.TemperatureConfig, modelName string) (config.ModelTemperatureConfig, bool) {
+	for _, m := range cfg.Models {
+		if strings.EqualFold(m.Model, modelName) {
+			return m, true
+		}
+	}
+	return config.ModelTemperatureConfig{}, false
+}
+
+// applyTemperature applies the model's default temperature when the request omits it and
+// clamps requested values into the allowed range. The effective value is recorded in chatLog.
+func (l *ChatCompletionLogic) applyTemperature(chatLog *model.ChatLog) {
+	if l.request.Extra == nil {
+		l.request.Extra = make(map[string]any)
+	}
+	// Keep the caller's value, every degradation model is resolved from it again
+	l.requestedTemperature, l.temperatureRequested = l.request.Extra["temperature"]
+	l.applyModelTemperature(l.request.Model, chatLog)
+}
+
+// applyModelTemperature resolves the temperature of modelName from the caller's value, so a
+// fallback model does not inherit the default or clamping of the model tried before it
+func (l *ChatCompletionLogic) applyModelTemperature(modelName string, chatLog *model.ChatLog) {
+	if l.temperatureRequested {
+		l.request.Extra["temperature"] = l.requestedTemperature
+	} else {
+		delete(l.request.Extra, "temperature")
+	}
+	chatLog.Params.EffectiveTemperature = nil
+
+	tempCfg, ok := modelTemperature(l.svcCtx.Config.Temperature, modelName)
+	if !ok {
+		return
+	}
+
+	var effective float64
+	requested, exists := l.request.Extra["temperature"].(float64)
+	switch {
+	case exists:
+		effective = requested
+		if tempCfg.Min != nil && effective < *tempCfg.Min {
+			effective = *tempCfg.Min
+		}
+		if tempCfg.Max != nil && effective > *tempCfg.Max {
+			effective = *tempCfg.Max
+		}
+		if effective != requested {
+			logger.InfoC(l.ctx, "requested temperature out of range, clamping",
+				zap.String("model", modelName),
+				zap.Float64("requested", requested),
+				zap.Float64("effective", effective))
+		}
+	case tempCfg.Default != nil:
+		effective = *tempCfg.Default
+		logger.InfoC(l.ctx, "temperature not set, using model default",
+			zap.String("model", modelName),
+			zap.Float64("temperature", effective))
+	default:
+		return
+	}
+
+	l.request.Extra["temperature"] = effective
+	chatLog.Params.EffectiveTemperature = &effective
+}
@@ -0,0 +1,39 @@
+package logic
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// logRequestSummary writes one compact entry per completed request, taken from the chat log
+func logRequestSummary(log *zap.Logger, chatLog *model.ChatLog) {
+	errorMessages := make([]string, 0, len(chatLog.Error))
+	for _, entry := range chatLog.Error {
+		for errType, message := range entry {
+			errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", errType, message))
+		}
+	}
+
+	log.Info("request completed",
+		zap.String("request_id", chatLog.Identity.RequestID),
+		zap.String("user", chatLog.Identity.UserName),
+		zap.String("client_ide", chatLog.Identity.ClientIDE),
+		zap.String("model", chatLog.Params.Model),
+		zap.String("routed_model", chatLog.Params.RoutedModel),
+		zap.String("prompt_mode", string(chatLog.Params.LlmParams.ExtraBody.PromptMode)),
+		zap.String("agent", chatLog.Agent),
+		zap.Int("original_tokens", chatLog.Tokens.Original.All),
+		zap.Int("processed_tokens", chatLog.Tokens.Processed.All),
+		zap.Int("prompt_tokens", chatLog.Usage.PromptTokens),
+		zap.Int("completion_tokens", chatLog.Usage.CompletionTokens),
+		zap.Int64("first_token_latency_ms", chatLog.Latency.FirstTokenLatency),
+		zap.Int64("main_model_latency_ms", chatLog.Latency.MainModelLatency),
+		zap.Int64("total_latency_ms", chatLog.Latency.TotalLatency),
+		zap.Int("tool_calls", len(chatLog.ToolCalls)),
+		zap.String("category", chatLog.Category),
+		zap.Strings("errors", errorMessages),
+	)
+}
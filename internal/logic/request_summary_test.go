@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestLogRequestSummary(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	chatLog := &model.ChatLog{
+		Identity: model.Identity{RequestID: "req-1", UserName: "alice"},
+		Params: model.RequestParams{Model: "auto", RoutedModel: "test-model", LlmParams: types.LLMRequestParams{
+			ExtraBody: types.ExtraBody{PromptMode: types.Performance},
+		}},
+		Latency:   model.LatencyMetrics{FirstTokenLatency: 120, TotalLatency: 900},
+		ToolCalls: []model.ToolCall{{ToolName: "codebase_search"}, {ToolName: "search_files"}},
+		Usage:     types.Usage{PromptTokens: 1000, CompletionTokens: 50},
+	}
+	chatLog.AddError(types.ErrServerError, assert.AnError)
+
+	logRequestSummary(zap.New(core), chatLog)
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, "alice", fields["user"])
+	assert.Equal(t, "test-model", fields["routed_model"])
+	assert.Equal(t, "performance", fields["prompt_mode"])
+	assert.Equal(t, int64(1000), fields["prompt_tokens"])
+	assert.Equal(t, int64(120), fields["first_token_latency_ms"])
+	assert.Equal(t, int64(2), fields["tool_calls"])
+	assert.Len(t, fields["errors"], 1)
+}
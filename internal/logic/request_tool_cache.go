@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// requestToolResult returns the result of an identical earlier call in this request's tool chain
+func (l *ChatCompletionLogic) requestToolResult(ctx context.Context, toolCall *model.ToolCall) (string, bool) {
+	key, ok := l.requestToolCacheKey(toolCall)
+	if !ok {
+		return "", false
+	}
+	result, ok := l.requestToolResults[key]
+	if !ok {
+		return "", false
+	}
+
+	toolCall.RequestCacheHit = true
+	logger.InfoC(ctx, "repeated tool call answered from request cache, backend call skipped",
+		zap.String("tool", toolCall.ToolName), zap.Int("resultLength", len(result)))
+	return result, true
+}
+
+// rememberRequestToolResult keeps a successful result for repeated calls later in the request
+func (l *ChatCompletionLogic) rememberRequestToolResult(toolCall *model.ToolCall, result string) {
+	key, ok := l.requestToolCacheKey(toolCall)
+	if !ok {
+		return
+	}
+	if l.requestToolResults == nil {
+		l.requestToolResults = make(map[string]string)
+	}
+	l.requestToolResults[key] = result
+}
+
+// requestToolCacheKey identifies a call by tool and normalized parameters, ok is false when
+// the cache is disabled or the parameters are unknown
+func (l *ChatCompletionLogic) requestToolCacheKey(toolCall *model.ToolCall) (string, bool) {
+	if !l.svcCtx.Config.RequestToolCache.Enabled {
+		return "", false
+	}
+	paramsHash, ok := toolCallParamsHash(toolCall, collapseWhitespace)
+	if !ok {
+		return "", false
+	}
+	return toolCall.ToolName + ":" + paramsHash, true
+}
+
+// collapseWhitespace trims the value and reduces inner whitespace runs to single spaces. Only
+// the in-request cache uses it, the Redis result cache keeps its trimmed keys
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
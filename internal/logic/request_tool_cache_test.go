@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+func TestRequestToolCache(t *testing.T) {
+	svcCtx := &bootstrap.ServiceContext{Config: config.Config{RequestToolCache: config.RequestToolCacheConfig{Enabled: true}}}
+	l := &ChatCompletionLogic{svcCtx: svcCtx}
+	call := func(tool string, query string, path string) *model.ToolCall {
+		return &model.ToolCall{ToolName: tool, ToolParams: map[string]interface{}{"query": query, "path": path}}
+	}
+
+	_, hit := l.requestToolResult(context.Background(), call("codebase_search", "auth flow", "src"))
+	assert.False(t, hit)
+	l.rememberRequestToolResult(call("codebase_search", "auth flow", "src"), "results")
+
+	repeated := call("codebase_search", "  auth   flow ", "src")
+	result, hit := l.requestToolResult(context.Background(), repeated)
+	assert.True(t, hit, "whitespace differences are ignored")
+	assert.Equal(t, "results", result)
+	assert.True(t, repeated.RequestCacheHit)
+
+	_, hit = l.requestToolResult(context.Background(), call("codebase_search", "auth flow", "lib"))
+	assert.False(t, hit, "different path")
+	_, hit = l.requestToolResult(context.Background(), call("knowledge_base_search", "auth flow", "src"))
+	assert.False(t, hit, "different tool")
+	_, hit = l.requestToolResult(context.Background(), &model.ToolCall{ToolName: "codebase_search"})
+	assert.False(t, hit, "unknown parameters")
+
+	other := &ChatCompletionLogic{svcCtx: svcCtx}
+	_, hit = other.requestToolResult(context.Background(), call("codebase_search", "auth flow", "src"))
+	assert.False(t, hit, "results are not shared between requests")
+
+	svcCtx.Config.RequestToolCache.Enabled = false
+	_, hit = l.requestToolResult(context.Background(), call("codebase_search", "auth flow", "src"))
+	assert.False(t, hit, "disabled")
+}
@@ -0,0 +1,141 @@
+package logic
+
+import (
+	"regexp"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"go.uber.org/zap"
+)
+
+// defaultMaxMatchBytes is used when the filters config does not set MaxMatchBytes
+const defaultMaxMatchBytes = 256
+
+type compiledResponseFilter struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// responseFilter rewrites model output with the configured regex rules.
+// Streamed content is pushed chunk by chunk; the tail that could still be the
+// beginning of a match is held back until more content arrives or Flush is called.
+type responseFilter struct {
+	filters       []compiledResponseFilter
+	maxMatchBytes int
+	pending       string
+	fired         []string
+}
+
+// newResponseFilter compiles the configured rules, returning nil when there is nothing to apply.
+// Invalid patterns are logged and skipped so a bad rule never breaks the response.
+func newResponseFilter(cfg *config.ResponseFiltersConfig) *responseFilter {
+	if cfg == nil || len(cfg.Filters) == 0 {
+		return nil
+	}
+
+	f := &responseFilter{maxMatchBytes: cfg.MaxMatchBytes}
+	if f.maxMatchBytes <= 0 {
+		f.maxMatchBytes = defaultMaxMatchBytes
+	}
+
+	for _, rule := range cfg.Filters {
+		if rule.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			logger.Warn("invalid response filter pattern, skipped",
+				zap.String("name", rule.Name), zap.String("pattern", rule.Pattern), zap.Error(err))
+			continue
+		}
+		name := rule.Name
+		if name == "" {
+			name = rule.Pattern
+		}
+		f.filters = append(f.filters, compiledResponseFilter{
+			name:        name,
+			pattern:     re,
+			replacement: rule.Replacement,
+		})
+	}
+
+	if len(f.filters) == 0 {
+		return nil
+	}
+	return f
+}
+
+// Apply filters a complete piece of text
+func (f *responseFilter) Apply(text string) string {
+	for _, filter := range f.filters {
+		if !filter.pattern.MatchString(text) {
+			continue
+		}
+		text = filter.pattern.ReplaceAllString(text, filter.replacement)
+		f.markFired(filter.name)
+	}
+	return text
+}
+
+// Push adds streamed content and returns the filtered part that is safe to send
+func (f *responseFilter) Push(content string) string {
+	f.pending += content
+
+	cut := len(f.pending) - f.maxMatchBytes
+	if cut <= 0 {
+		return ""
+	}
+
+	// Never split a match that is already visible in the buffer
+	for _, filter := range f.filters {
+		for _, loc := range filter.pattern.FindAllStringIndex(f.pending, -1) {
+			if loc[0] < cut && cut < loc[1] {
+				cut = loc[0]
+			}
+		}
+	}
+	for cut > 0 && !utf8.RuneStart(f.pending[cut]) {
+		cut--
+	}
+	if cut <= 0 {
+		return ""
+	}
+
+	ready := f.pending[:cut]
+	f.pending = f.pending[cut:]
+	return f.Apply(ready)
+}
+
+// Flush filters and returns everything still held back
+func (f *responseFilter) Flush() string {
+	if f.pending == "" {
+		return ""
+	}
+	ready := f.pending
+	f.pending = ""
+	return f.Apply(ready)
+}
+
+// Fired returns the names of the filters that rewrote any content so far
+func (f *responseFilter) Fired() []string {
+	return f.fired
+}
+
+func (f *responseFilter) markFired(name string) {
+	if !slices.Contains(f.fired, name) {
+		f.fired = append(f.fired, name)
+	}
+}
+
+// filterResponseChoices applies the filters to the message content of a non-streamed response
+func (f *responseFilter) filterResponseChoices(choices []types.Choice) {
+	for i := range choices {
+		if content, ok := choices[i].Message.Content.(string); ok {
+			choices[i].Message.Content = f.Apply(content)
+		}
+	}
+}
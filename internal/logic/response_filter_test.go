@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestNewResponseFilter_NoRules(t *testing.T) {
+	assert.Nil(t, newResponseFilter(nil))
+	assert.Nil(t, newResponseFilter(&config.ResponseFiltersConfig{}))
+	assert.Nil(t, newResponseFilter(&config.ResponseFiltersConfig{
+		Filters: []config.ResponseFilterRule{{Name: "bad", Pattern: "("}},
+	}))
+}
+
+func TestResponseFilter_StreamSplitAcrossChunks(t *testing.T) {
+	f := newResponseFilter(&config.ResponseFiltersConfig{
+		Filters: []config.ResponseFilterRule{
+			{Name: "internal-url", Pattern: `https://internal\.example\.com/\S*`, Replacement: "[redacted]"},
+		},
+		MaxMatchBytes: 40,
+	})
+	assert.NotNil(t, f)
+
+	chunks := []string{"See https://inter", "nal.example.com/do", "cs/page for details. ", "More text follows here."}
+	var out strings.Builder
+	for _, chunk := range chunks {
+		out.WriteString(f.Push(chunk))
+	}
+	out.WriteString(f.Flush())
+
+	assert.Equal(t, "See [redacted] for details. More text follows here.", out.String())
+	assert.Equal(t, []string{"internal-url"}, f.Fired())
+}
+
+func TestResponseFilter_NotFiredWithoutMatch(t *testing.T) {
+	f := newResponseFilter(&config.ResponseFiltersConfig{
+		Filters: []config.ResponseFilterRule{{Name: "term", Pattern: "foo", Replacement: "bar"}},
+	})
+
+	got := f.Push("nothing to see here") + f.Flush()
+	assert.Equal(t, "nothing to see here", got)
+	assert.Empty(t, f.Fired())
+}
+
+func TestResponseFilter_FilterResponseChoices(t *testing.T) {
+	f := newResponseFilter(&config.ResponseFiltersConfig{
+		Filters: []config.ResponseFilterRule{
+			{Name: "term", Pattern: `(?i)project (\w+)`, Replacement: "project-$1"},
+		},
+	})
+	choices := []types.Choice{{Message: types.Message{Role: types.RoleAssistant, Content: "About Project Atlas"}}}
+
+	f.filterResponseChoices(choices)
+	assert.Equal(t, "About project-Atlas", choices[0].Message.Content)
+	assert.Equal(t, []string{"term"}, f.Fired())
+}
@@ -3,6 +3,7 @@ package logic
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -33,11 +34,13 @@ func (h *ResponseHandler) extractResponseInfo(chatLog *model.ChatLog, response *
 		zap.Int("choicesCount", len(response.Choices)),
 	)
 
-	// Extract response content from choices
+	// Extract response content from choices, the full content is only used for counting tokens
+	var fullContent string
 	if len(response.Choices) > 0 {
-		contentStr := utils.GetContentAsString(response.Choices[0].Message.Content)
+		content := response.Choices[0].Message.Content
+		fullContent = utils.GetContentAsString(content)
 		chatLog.ResponseContent = &types.ResponseContent{
-			Content: contentStr,
+			Content: utils.GetContentAsStringCapped(content, h.svcCtx.Config.Log.MaxContentBytes),
 		}
 	}
 
@@ -52,7 +55,7 @@ func (h *ResponseHandler) extractResponseInfo(chatLog *model.ChatLog, response *
 		chatLog.Usage = response.Usage
 	} else {
 		// Calculate usage if not provided
-		chatLog.Usage = h.calculateUsage(chatLog.Tokens.Processed.All, chatLog.ResponseContent.Content)
+		chatLog.Usage = h.calculateUsage(chatLog.Tokens.Processed.All, fullContent)
 		logger.Info("calculated usage",
 			zap.Int("totalTokens", chatLog.Usage.TotalTokens),
 		)
@@ -272,6 +275,7 @@ func (h *ResponseHandler) sendSSEError(ctx context.Context, w http.ResponseWrite
 	errType := "server_error"
 	statusCode := http.StatusInternalServerError
 
+	var apiErr *types.APIError
 	// Check if the error is an IdleTimeoutError
 	if idleErr, ok := err.(*types.IdleTimeoutError); ok {
 		errorCode = idleErr.Code
@@ -280,7 +284,7 @@ func (h *ResponseHandler) sendSSEError(ctx context.Context, w http.ResponseWrite
 		statusCode = idleErr.StatusCode
 		// Set HTTP status header
 		w.WriteHeader(statusCode)
-	} else if apiErr, ok := err.(*types.APIError); ok {
+	} else if errors.As(err, &apiErr) {
 		// Check if the error is an APIError with a specific status code
 		errorCode = apiErr.Code
 		if apiErr.Type != "" {
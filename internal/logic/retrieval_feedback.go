@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// Fields of the retrieval feedback hash of a request
+const (
+	retrievalFieldTools  = "tools"
+	retrievalFieldUseful = "useful"
+)
+
+// rememberRetrieval keeps the tools that returned results for the request in Redis, so that
+// feedback sent later for the request ID can be attributed to them
+func (l *ChatCompletionLogic) rememberRetrieval(chatLog *model.ChatLog) {
+	feedbackCfg := l.svcCtx.Config.RetrievalFeedback
+	if !feedbackCfg.Enabled || l.svcCtx.RedisClient == nil || l.identity == nil || l.identity.RequestID == "" {
+		return
+	}
+
+	var tools []string
+	for _, toolCall := range chatLog.ToolCalls {
+		if toolCall.ResultStatus == string(types.ToolStatusSuccess) && !slices.Contains(tools, toolCall.ToolName) {
+			tools = append(tools, toolCall.ToolName)
+		}
+	}
+	if len(tools) == 0 {
+		return
+	}
+
+	// The request context may already be done when the turn ends
+	ctx := context.WithoutCancel(l.ctx)
+	key := types.RetrievalFeedbackRedisKeyPrefix + l.identity.RequestID
+	err := l.svcCtx.RedisClient.SetHashField(ctx, key, retrievalFieldTools, strings.Join(tools, ","),
+		time.Duration(feedbackCfg.TTLSec)*time.Second)
+	if errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(l.ctx, "redis unavailable, retrieval feedback not possible for request")
+	} else if err != nil {
+		logger.WarnC(l.ctx, "failed to remember retrieval for feedback", zap.Error(err))
+	}
+}
+
+var (
+	// ErrRetrievalNotFound is returned for feedback on requests without retrieval or past the TTL
+	ErrRetrievalNotFound = errors.New("no retrieval found for the request")
+	// ErrFeedbackExists is returned when the request already received feedback
+	ErrFeedbackExists = errors.New("feedback already submitted for the request")
+)
+
+// SubmitRetrievalFeedback records whether the context retrieved for a request was useful.
+// The verdict is stored next to the request's tools, so each request counts once, and is
+// counted per tool in the retrieval feedback metric.
+func SubmitRetrievalFeedback(ctx context.Context, svcCtx *bootstrap.ServiceContext, requestID string,
+	feedback types.RetrievalFeedbackRequest) error {
+	key := types.RetrievalFeedbackRedisKeyPrefix + requestID
+	stored, err := svcCtx.RedisClient.GetHash(ctx, key)
+	if errors.Is(err, client.ErrRedisNotFound) || (err == nil && stored[retrievalFieldTools] == "") {
+		return ErrRetrievalNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, exists := stored[retrievalFieldUseful]; exists {
+		return ErrFeedbackExists
+	}
+
+	fields := map[string]interface{}{retrievalFieldUseful: strconv.FormatBool(*feedback.Useful)}
+	if err := svcCtx.RedisClient.SetHashFields(ctx, key, fields,
+		time.Duration(svcCtx.Config.RetrievalFeedback.TTLSec)*time.Second); err != nil {
+		return err
+	}
+
+	tools := strings.Split(stored[retrievalFieldTools], ",")
+	if svcCtx.MetricsService != nil {
+		svcCtx.MetricsService.RecordRetrievalFeedback(tools, *feedback.Useful)
+	}
+	logger.InfoC(ctx, "retrieval feedback received",
+		zap.String("requestID", requestID),
+		zap.Strings("tools", tools),
+		zap.Bool("useful", *feedback.Useful),
+		zap.String("comment", feedback.Comment))
+	return nil
+}
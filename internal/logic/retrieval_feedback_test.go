@@ -0,0 +1,108 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/service/mocks"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// hashRedis keeps hashes in memory, other methods are not used
+type hashRedis struct {
+	client.RedisInterface
+	hashes map[string]map[string]string
+}
+
+func (r *hashRedis) SetHashField(ctx context.Context, key string, field string, value interface{},
+	expiration time.Duration) error {
+	return r.SetHashFields(ctx, key, map[string]interface{}{field: value}, expiration)
+}
+
+func (r *hashRedis) SetHashFields(ctx context.Context, key string, fields map[string]interface{},
+	expiration time.Duration) error {
+	if r.hashes[key] == nil {
+		r.hashes[key] = make(map[string]string)
+	}
+	for k, v := range fields {
+		r.hashes[key][k] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+func (r *hashRedis) DeleteHashFields(ctx context.Context, key string, fields ...string) error {
+	for _, field := range fields {
+		delete(r.hashes[key], field)
+	}
+	return nil
+}
+
+func (r *hashRedis) RaiseHashField(ctx context.Context, key string, field string, value int64,
+	extra map[string]interface{}, expiration time.Duration) (int64, error) {
+	previous, _ := strconv.ParseInt(r.hashes[key][field], 10, 64)
+	if previous > value {
+		return previous, nil
+	}
+	fields := map[string]interface{}{field: value}
+	maps.Copy(fields, extra)
+	return previous, r.SetHashFields(ctx, key, fields, expiration)
+}
+
+func (r *hashRedis) GetHashField(ctx context.Context, key string, field string) (string, error) {
+	value, ok := r.hashes[key][field]
+	if !ok {
+		return "", fmt.Errorf("hash field does not exist: %w", client.ErrRedisNotFound)
+	}
+	return value, nil
+}
+
+func (r *hashRedis) GetHash(ctx context.Context, key string) (map[string]string, error) {
+	if len(r.hashes[key]) == 0 {
+		return nil, fmt.Errorf("hash does not exist: %w", client.ErrRedisNotFound)
+	}
+	return r.hashes[key], nil
+}
+
+func TestRetrievalFeedback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	metricsMock := mocks.NewMockMetricsInterface(ctrl)
+	redis := &hashRedis{hashes: make(map[string]map[string]string)}
+	svcCtx := &bootstrap.ServiceContext{
+		Config:         config.Config{RetrievalFeedback: config.RetrievalFeedbackConfig{Enabled: true, TTLSec: 60}},
+		RedisClient:    redis,
+		MetricsService: metricsMock,
+	}
+	l := &ChatCompletionLogic{ctx: context.Background(), svcCtx: svcCtx, identity: &model.Identity{RequestID: "req-1"}}
+	useful := true
+	feedback := types.RetrievalFeedbackRequest{Useful: &useful}
+
+	// Requests without successful tool calls retrieved nothing
+	l.rememberRetrieval(&model.ChatLog{ToolCalls: []model.ToolCall{
+		{ToolName: "codebase_search", ResultStatus: string(types.ToolStatusFailed)},
+	}})
+	assert.ErrorIs(t, SubmitRetrievalFeedback(context.Background(), svcCtx, "req-1", feedback), ErrRetrievalNotFound)
+
+	l.rememberRetrieval(&model.ChatLog{ToolCalls: []model.ToolCall{
+		{ToolName: "codebase_search", ResultStatus: string(types.ToolStatusSuccess)},
+		{ToolName: "codebase_search", ResultStatus: string(types.ToolStatusSuccess)},
+		{ToolName: "knowledge_base_search", ResultStatus: string(types.ToolStatusSuccess)},
+	}})
+	metricsMock.EXPECT().RecordRetrievalFeedback([]string{"codebase_search", "knowledge_base_search"}, true)
+	assert.NoError(t, SubmitRetrievalFeedback(context.Background(), svcCtx, "req-1", feedback))
+	assert.Equal(t, "true", redis.hashes["retrieval_feedback:req-1"]["useful"])
+
+	assert.ErrorIs(t, SubmitRetrievalFeedback(context.Background(), svcCtx, "req-1", feedback), ErrFeedbackExists,
+		"each request counts once")
+	assert.ErrorIs(t, SubmitRetrievalFeedback(context.Background(), svcCtx, "req-2", feedback), ErrRetrievalNotFound)
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/timeout"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// retryStreamDrop reports whether a stream that failed before its first token should be repeated
+// right away because the upstream connection dropped. Nothing has reached the client at that point,
+// so the retry is invisible to it. Drop retries are bounded by StreamDropRetryCount per request and
+// do not use up the regular MaxRetryCount attempts.
+func (l *ChatCompletionLogic) retryStreamDrop(err error, chatLog *model.ChatLog, idleTracker *timeout.IdleTracker) bool {
+	maxRetries := l.svcCtx.Config.LLMTimeout.StreamDropRetryCount
+	if chatLog.StreamDropRetries >= maxRetries || !isUpstreamDropError(err) {
+		return false
+	}
+	if l.ctx.Err() != nil || idleTracker.Remaining() <= 0 {
+		return false
+	}
+
+	chatLog.StreamDropRetries++
+	logger.WarnC(l.ctx, "upstream dropped before first token, retrying stream",
+		zap.String("model", l.request.Model),
+		zap.Int("retry", chatLog.StreamDropRetries),
+		zap.Int("maxRetries", maxRetries),
+		zap.Error(err),
+	)
+	return true
+}
+
+// isUpstreamDropError reports whether the upstream connection failed or broke off,
+// as opposed to the upstream answering with an error or the request being canceled
+func isUpstreamDropError(err error) bool {
+	var dropErr *types.UpstreamDropError
+	return errors.As(err, &dropErr)
+}
@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// errSlowClient marks stream writes that did not complete within the write deadline
+var errSlowClient = errors.New("client did not read the stream in time")
+
+// writeStream writes one SSE chunk and flushes it. Under the abort policy the write and flush
+// share a deadline, so a client that stops reading ends the stream instead of holding it open.
+func (l *ChatCompletionLogic) writeStream(flusher http.Flusher, data []byte) error {
+	cfg := l.svcCtx.Config.StreamBackpressure
+	if cfg.Policy != config.StreamBackpressureAbort || cfg.WriteTimeoutMs <= 0 {
+		_, err := l.writer.Write(data)
+		flusher.Flush()
+		return err
+	}
+
+	controller := http.NewResponseController(l.writer)
+	if err := controller.SetWriteDeadline(time.Now().Add(time.Duration(cfg.WriteTimeoutMs) * time.Millisecond)); err != nil {
+		// Writers without deadline support, e.g. in tests, are written without one
+		_, err := l.writer.Write(data)
+		flusher.Flush()
+		return err
+	}
+	defer controller.SetWriteDeadline(time.Time{})
+
+	if _, err := l.writer.Write(data); err != nil {
+		return l.slowClientError(err)
+	}
+	return l.slowClientError(controller.Flush())
+}
+
+// slowClientError marks deadline errors as slow-client aborts
+func (l *ChatCompletionLogic) slowClientError(err error) error {
+	if err == nil || !errors.Is(err, os.ErrDeadlineExceeded) {
+		return err
+	}
+	logger.WarnC(l.ctx, "stream aborted, client did not read within the write timeout",
+		zap.Int("writeTimeoutMs", l.svcCtx.Config.StreamBackpressure.WriteTimeoutMs))
+	return fmt.Errorf("%w: %v", errSlowClient, err)
+}
@@ -0,0 +1,31 @@
+package logic
+
+import (
+	"time"
+
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/service"
+)
+
+// recordToolAudit writes the audit record of one tool execution when auditing is enabled
+func (l *ChatCompletionLogic) recordToolAudit(toolCall model.ToolCall) {
+	auditLogger := l.svcCtx.ToolAuditLogger
+	if auditLogger == nil || l.identity == nil {
+		return
+	}
+
+	auditLogger.Record(service.ToolAuditRecord{
+		Timestamp:   time.Now(),
+		RequestID:   l.identity.RequestID,
+		TaskID:      l.identity.TaskID,
+		UserName:    l.identity.UserName,
+		ClientID:    l.identity.ClientID,
+		ClientIDE:   l.identity.ClientIDE,
+		ProjectPath: l.identity.ProjectPath,
+		Tool:        toolCall.ToolName,
+		Params:      toolCall.ToolParams,
+		Status:      toolCall.ResultStatus,
+		Error:       toolCall.Error,
+		LatencyMs:   toolCall.Latency,
+	})
+}
@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// ErrToolCallsNotFound is returned for requests without stored tool calls or past the TTL
+var ErrToolCallsNotFound = errors.New("no tool calls stored for the request")
+
+// recordToolCallReplay stores the tool call under the request ID when replay is enabled
+func (l *ChatCompletionLogic) recordToolCallReplay(toolCall model.ToolCall) {
+	cfg := l.svcCtx.Config.ToolCallReplay
+	if !cfg.Enabled || l.svcCtx.RedisClient == nil || l.identity == nil || l.identity.RequestID == "" {
+		return
+	}
+
+	record, err := json.Marshal(toolCall)
+	if err != nil {
+		logger.WarnC(l.ctx, "failed to marshal tool call for replay", zap.Error(err))
+		return
+	}
+
+	// The request context may already be done when the tool call finished
+	ctx := context.WithoutCancel(l.ctx)
+	key := types.ToolCallReplayRedisKeyPrefix + l.identity.RequestID
+	if err := l.svcCtx.RedisClient.PushList(ctx, key, string(record), 0,
+		time.Duration(cfg.TTLSec)*time.Second); errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(l.ctx, "redis unavailable, skip storing tool call for replay", zap.String("tool", toolCall.ToolName))
+	} else if err != nil {
+		logger.WarnC(l.ctx, "failed to store tool call for replay",
+			zap.String("tool", toolCall.ToolName), zap.Error(err))
+	}
+}
+
+// GetToolCallReplay returns the stored tool calls of a request, oldest first
+func GetToolCallReplay(ctx context.Context, redisClient client.RedisInterface, requestID string) ([]json.RawMessage, error) {
+	values, err := redisClient.GetListRange(ctx, types.ToolCallReplayRedisKeyPrefix+requestID, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]json.RawMessage, 0, len(values))
+	for _, value := range values {
+		if !json.Valid([]byte(value)) {
+			logger.WarnC(ctx, "skipping malformed tool call record", zap.String("requestId", requestID))
+			continue
+		}
+		records = append(records, json.RawMessage(value))
+	}
+	if len(records) == 0 {
+		return nil, ErrToolCallsNotFound
+	}
+
+	// New calls are pushed to the head of the list
+	slices.Reverse(records)
+	return records, nil
+}
@@ -0,0 +1,62 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// listRedis keeps lists in memory, other methods are not used
+type listRedis struct {
+	client.RedisInterface
+	lists map[string][]string
+	ttls  map[string]time.Duration
+}
+
+func (r *listRedis) PushList(ctx context.Context, key string, value interface{}, maxLen int64,
+	expiration time.Duration) error {
+	r.lists[key] = append([]string{value.(string)}, r.lists[key]...)
+	r.ttls[key] = expiration
+	return nil
+}
+
+func (r *listRedis) GetListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.lists[key], nil
+}
+
+func TestToolCallReplay(t *testing.T) {
+	redis := &listRedis{lists: make(map[string][]string), ttls: make(map[string]time.Duration)}
+	svcCtx := &bootstrap.ServiceContext{RedisClient: redis}
+	l := &ChatCompletionLogic{ctx: context.Background(), svcCtx: svcCtx, identity: &model.Identity{RequestID: "req-1"}}
+
+	l.recordToolCallReplay(model.ToolCall{ToolName: "codebase_search"})
+	assert.Empty(t, redis.lists, "disabled by default")
+
+	svcCtx.Config.ToolCallReplay = config.ToolCallReplayConfig{Enabled: true, TTLSec: 60}
+	l.recordToolCallReplay(model.ToolCall{ToolName: "codebase_search", ToolInput: "<codebase_search>", Latency: 12})
+	l.recordToolCallReplay(model.ToolCall{ToolName: "search_files", ResultStatus: "failed", Error: "timeout"})
+	assert.Equal(t, time.Minute, redis.ttls["tool_calls:req-1"])
+
+	records, err := GetToolCallReplay(context.Background(), redis, "req-1")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	var first, second model.ToolCall
+	require.NoError(t, json.Unmarshal(records[0], &first))
+	require.NoError(t, json.Unmarshal(records[1], &second))
+	assert.Equal(t, "codebase_search", first.ToolName, "oldest call first")
+	assert.Equal(t, int64(12), first.Latency)
+	assert.Equal(t, "timeout", second.Error)
+
+	_, err = GetToolCallReplay(context.Background(), redis, "req-unknown")
+	assert.ErrorIs(t, err, ErrToolCallsNotFound)
+}
@@ -0,0 +1,101 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// defaultFollowUpVariant names the built-in instruction in tool call logs
+const defaultFollowUpVariant = "default"
+
+// defaultToolFollowUpInstruction is appended after tool results unless a configured variant matches
+const defaultToolFollowUpInstruction = "Please summarize the key findings and/or code from the results above within the <think></think> tags. No need to summarize error messages. \nIf the search failed, don't say 'failed', describe this outcome as 'did not found relevant results' instead - MUST NOT using terms like 'failure', 'error', or 'unsuccessful' in your description. \nIn your summary, must include the name of the tool used and specify which tools you intend to use next. \nWhen appropriate, prioritize using these tools: %s"
+
+// toolFollowUpInstruction returns the instruction appended after a tool result and the name
+// of the variant used. An empty instruction means nothing is appended.
+func (l *ChatCompletionLogic) toolFollowUpInstruction(toolName string, agent string) (string, string) {
+	tools := l.toolExecutor.GetAllTools()
+	defaultInstruction := fmt.Sprintf(defaultToolFollowUpInstruction, tools)
+
+	variant := l.followUpVariant(agent)
+	if variant == nil {
+		return defaultInstruction, defaultFollowUpVariant
+	}
+	if variant.Template == "" {
+		return "", variant.Name
+	}
+
+	tmpl, err := template.New(variant.Name).Parse(variant.Template)
+	if err != nil {
+		logger.WarnC(l.ctx, "invalid tool follow-up instruction template, using default",
+			zap.String("variant", variant.Name), zap.Error(err))
+		return defaultInstruction, defaultFollowUpVariant
+	}
+	var buf bytes.Buffer
+	data := map[string]interface{}{"Tool": toolName, "Tools": strings.Join(tools, ", ")}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		logger.WarnC(l.ctx, "failed to render tool follow-up instruction, using default",
+			zap.String("variant", variant.Name), zap.Error(err))
+		return defaultInstruction, defaultFollowUpVariant
+	}
+	return buf.String(), variant.Name
+}
+
+// followUpVariant returns the first configured variant matching the model or agent, nil when
+// the built-in instruction applies
+func (l *ChatCompletionLogic) followUpVariant(agent string) *config.ToolFollowUpInstruction {
+	toolsCfg := l.svcCtx.Config.Tools
+	if toolsCfg == nil {
+		return nil
+	}
+	for i, variant := range toolsCfg.FollowUpInstructions {
+		if containsFold(variant.Models, l.request.Model) || containsFold(variant.Agents, agent) {
+			return &toolsCfg.FollowUpInstructions[i]
+		}
+	}
+	return nil
+}
+
+// followUpPlacement returns where the follow-up instruction goes relative to the tool result.
+// The built-in instruction refers to "the results above" and is always appended.
+func (l *ChatCompletionLogic) followUpPlacement(agent string) string {
+	variant := l.followUpVariant(agent)
+	if variant == nil || !strings.EqualFold(variant.Placement, config.FollowUpPrepend) {
+		return config.FollowUpAppend
+	}
+	return config.FollowUpPrepend
+}
+
+// placeFollowUpInstruction adds the instruction to the content of a tool result message,
+// after the result or, when prepended, in front of the "[tool] Result:" header
+func placeFollowUpInstruction(resultContent []model.Content, instruction, placement string) []model.Content {
+	if instruction == "" {
+		return resultContent
+	}
+	part := model.Content{Type: model.ContTypeText, Text: instruction}
+	if placement == config.FollowUpPrepend {
+		return append([]model.Content{part}, resultContent...)
+	}
+	return append(resultContent, part)
+}
+
+// containsFold reports whether values contains target, ignoring case
+func containsFold(values []string, target string) bool {
+	if target == "" {
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
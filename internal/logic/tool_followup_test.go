@@ -0,0 +1,73 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestChatCompletionLogic_toolFollowUpInstruction(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "capable-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+	logic.toolExecutor = &stubToolExecutor{tools: []string{"codebase_search", "search_files"}}
+
+	instruction, variant := logic.toolFollowUpInstruction("codebase_search", "code")
+	assert.Equal(t, defaultFollowUpVariant, variant)
+	assert.Contains(t, instruction, "[codebase_search search_files]")
+
+	svcCtx.Config.Tools = &config.ToolConfig{FollowUpInstructions: []config.ToolFollowUpInstruction{
+		{Name: "none", Models: []string{"Capable-Model"}},
+		{Name: "short", Agents: []string{"ask"}, Template: "Next tools: {{.Tools}} (after {{.Tool}})"},
+	}}
+
+	instruction, variant = logic.toolFollowUpInstruction("codebase_search", "code")
+	assert.Equal(t, "none", variant)
+	assert.Empty(t, instruction)
+
+	logic.request.Model = "other-model"
+	instruction, variant = logic.toolFollowUpInstruction("codebase_search", "ask")
+	assert.Equal(t, "short", variant)
+	assert.Equal(t, "Next tools: codebase_search, search_files (after codebase_search)", instruction)
+
+	_, variant = logic.toolFollowUpInstruction("codebase_search", "code")
+	assert.Equal(t, defaultFollowUpVariant, variant)
+}
+
+func TestChatCompletionLogic_followUpPlacement(t *testing.T) {
+	logic, svcCtx := setupTestLogic(t, &config.Config{}, nil, "capable-model",
+		[]types.Message{{Role: "user", Content: "Hello"}}, &mockResponseWriter{})
+
+	assert.Equal(t, config.FollowUpAppend, logic.followUpPlacement("code"))
+
+	svcCtx.Config.Tools = &config.ToolConfig{FollowUpInstructions: []config.ToolFollowUpInstruction{
+		{Name: "first", Models: []string{"capable-model"}, Template: "Answer the question.", Placement: "Prepend"},
+		{Name: "after", Agents: []string{"ask"}, Template: "Answer the question."},
+	}}
+	assert.Equal(t, config.FollowUpPrepend, logic.followUpPlacement("code"))
+	logic.request.Model = "other-model"
+	assert.Equal(t, config.FollowUpAppend, logic.followUpPlacement("ask"))
+
+	result := func() []model.Content {
+		return []model.Content{
+			{Type: model.ContTypeText, Text: "[codebase_search] Result:"},
+			{Type: model.ContTypeText, Text: "retrieved context"},
+		}
+	}
+	texts := func(contents []model.Content) []string {
+		var out []string
+		for _, c := range contents {
+			out = append(out, c.Text)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"[codebase_search] Result:", "retrieved context", "Answer the question."},
+		texts(placeFollowUpInstruction(result(), "Answer the question.", config.FollowUpAppend)))
+	assert.Equal(t, []string{"Answer the question.", "[codebase_search] Result:", "retrieved context"},
+		texts(placeFollowUpInstruction(result(), "Answer the question.", config.FollowUpPrepend)))
+	assert.Equal(t, []string{"[codebase_search] Result:", "retrieved context"},
+		texts(placeFollowUpInstruction(result(), "", config.FollowUpPrepend)))
+}
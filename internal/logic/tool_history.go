@@ -0,0 +1,108 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// ToolHistoryRecord is the compact form of a tool call kept in the session history
+type ToolHistoryRecord struct {
+	ToolName  string                 `json:"tool_name"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Status    string                 `json:"status"`
+	Result    string                 `json:"result,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// ToolHistoryKey derives the session key of the tool-call history. A session is the
+// user's task (conversation); without a task id it falls back to the client and project.
+func ToolHistoryKey(identity *model.Identity) string {
+	if identity == nil {
+		return ""
+	}
+	if identity.TaskID != "" {
+		return fmt.Sprintf("%s%s:%s", types.ToolHistoryRedisKeyPrefix, identity.UserName, identity.TaskID)
+	}
+	if identity.ClientID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s%s:%s:%s", types.ToolHistoryRedisKeyPrefix,
+		identity.UserName, identity.ClientID, identity.ProjectPath)
+}
+
+// GetRecentToolCalls returns up to limit of the session's most recent tool calls, newest first
+func GetRecentToolCalls(ctx context.Context, redisClient client.RedisInterface,
+	identity *model.Identity, limit int) ([]ToolHistoryRecord, error) {
+	key := ToolHistoryKey(identity)
+	if key == "" || limit <= 0 {
+		return nil, nil
+	}
+
+	values, err := redisClient.GetListRange(ctx, key, 0, int64(limit-1))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]ToolHistoryRecord, 0, len(values))
+	for _, value := range values {
+		var record ToolHistoryRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			logger.WarnC(ctx, "skipping malformed tool history record", zap.Error(err))
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// recordToolHistory appends the tool call to the session history when enabled
+func (l *ChatCompletionLogic) recordToolHistory(toolCall model.ToolCall) {
+	cfg := l.svcCtx.Config.ToolHistory
+	if !cfg.Enabled || l.svcCtx.RedisClient == nil {
+		return
+	}
+
+	key := ToolHistoryKey(l.identity)
+	if key == "" {
+		logger.WarnC(l.ctx, "no session identity, skip recording tool history")
+		return
+	}
+
+	result := toolCall.ToolOutput
+	if toolCall.Error != "" {
+		result = toolCall.Error
+	}
+	if cfg.MaxResultBytes > 0 && len(result) > cfg.MaxResultBytes {
+		result = result[:cfg.MaxResultBytes] + "..."
+	}
+
+	record, err := json.Marshal(ToolHistoryRecord{
+		ToolName:  toolCall.ToolName,
+		Params:    toolCall.ToolParams,
+		Status:    toolCall.ResultStatus,
+		Result:    result,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		logger.WarnC(l.ctx, "failed to marshal tool history record", zap.Error(err))
+		return
+	}
+
+	if err := l.svcCtx.RedisClient.PushList(l.ctx, key, string(record), int64(cfg.MaxEntries),
+		time.Duration(cfg.TTLSec)*time.Second); errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(l.ctx, "redis unavailable, skip recording tool history", zap.String("tool", toolCall.ToolName))
+	} else if err != nil {
+		logger.WarnC(l.ctx, "failed to record tool history",
+			zap.String("tool", toolCall.ToolName), zap.Error(err))
+	}
+}
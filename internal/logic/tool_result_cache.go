@@ -0,0 +1,202 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+const (
+	resultCacheHit  = "hit"
+	resultCacheMiss = "miss"
+
+	// Index versions outlive the entries they version by far, so an expired version cannot make
+	// entries of an older index reachable again
+	toolResultCacheVersionTTL = 7 * 24 * time.Hour
+)
+
+// toolResultCache locates the cached results of one tool call
+type toolResultCache struct {
+	toolName   string
+	ttl        time.Duration
+	scope      string // Hash of the client and project the results belong to
+	paramsHash string
+	version    int64 // Newest index version seen for the scope, 0 when none was reported
+}
+
+// versionKey holds the newest index version of the tool for the scope and when a backend result
+// last confirmed it
+func (c *toolResultCache) versionKey() string {
+	return types.ToolResultCacheVersionRedisKeyPrefix + c.toolName + ":" + c.scope
+}
+
+// entryKey holds the result of the call produced by the given index version
+func (c *toolResultCache) entryKey(version int64) string {
+	return types.ToolResultCacheRedisKeyPrefix + c.toolName + ":" +
+		hashKey(c.scope, strconv.FormatInt(version, 10), c.paramsHash)
+}
+
+// lookupToolResult returns the result of an identical earlier call for the same client and
+// project, produced by the newest index seen. Once the version was last confirmed longer ago
+// than the check interval, the call goes to the backend so a reindex is noticed even while
+// older entries live on. The cache is nil when caching does not apply.
+func (l *ChatCompletionLogic) lookupToolResult(ctx context.Context, toolCall *model.ToolCall) (*toolResultCache, string, bool) {
+	cacher, ok := l.toolExecutor.(functions.ResultCacher)
+	if !ok || l.svcCtx.RedisClient == nil || l.identity == nil {
+		return nil, "", false
+	}
+	ttl, ok := cacher.ResultCacheTTL(toolCall.ToolName)
+	if !ok {
+		return nil, "", false
+	}
+	// Without a client or project results could leak between unrelated workspaces
+	if l.identity.ClientID == "" && l.identity.ProjectPath == "" {
+		return nil, "", false
+	}
+	paramsHash, ok := toolCallParamsHash(toolCall, strings.TrimSpace)
+	if !ok {
+		return nil, "", false
+	}
+
+	cache := &toolResultCache{
+		toolName:   toolCall.ToolName,
+		ttl:        ttl,
+		scope:      hashKey(l.identity.ClientID, l.identity.ProjectPath),
+		paramsHash: paramsHash,
+	}
+
+	redisClient := l.svcCtx.RedisClient
+	versionFields, err := redisClient.GetHash(ctx, cache.versionKey())
+	switch {
+	case err == nil:
+	case errors.Is(err, client.ErrRedisNotFound):
+		return cache, "", false
+	default:
+		logToolCacheError(ctx, "failed to read tool result cache version", toolCall.ToolName, err)
+		return nil, "", false
+	}
+	cache.version, _ = strconv.ParseInt(versionFields["version"], 10, 64)
+	checkedAt, _ := strconv.ParseInt(versionFields["checkedAt"], 10, 64)
+	if time.Since(time.Unix(checkedAt, 0)) > cacher.ResultVersionCheckInterval(toolCall.ToolName) {
+		logger.InfoC(ctx, "tool index version check due, cached results bypassed",
+			zap.String("tool", toolCall.ToolName), zap.Int64("version", cache.version))
+		return cache, "", false
+	}
+
+	result, err := redisClient.GetHashField(ctx, cache.entryKey(cache.version), "result")
+	switch {
+	case err == nil:
+		return cache, result, true
+	case errors.Is(err, client.ErrRedisNotFound):
+	default:
+		logToolCacheError(ctx, "failed to read tool result cache", toolCall.ToolName, err)
+	}
+	return cache, "", false
+}
+
+// cacheToolResult keeps the result for repeated calls in the request and stores it in Redis
+// unless it was served from there. No-results messages replacing an invalid response or a
+// timed-out call are not kept, so the next call asks the backend again.
+func (l *ChatCompletionLogic) cacheToolResult(ctx context.Context, toolCall *model.ToolCall,
+	cache *toolResultCache, result string, cacheHit bool) {
+	if toolCall.InvalidResult != "" || toolCall.TimedOut {
+		return
+	}
+	l.rememberRequestToolResult(toolCall, result)
+	if !cacheHit {
+		l.storeToolResult(ctx, cache, result)
+	}
+}
+
+// storeToolResult caches a fresh result under the index version it reports. The version is
+// raised atomically and marked as confirmed first; a newer index leaves the entries of the older
+// one unreachable, and a result of an older index than already seen, e.g. from a lagging
+// replica, is not cached.
+func (l *ChatCompletionLogic) storeToolResult(ctx context.Context, cache *toolResultCache, result string) {
+	cacher, ok := l.toolExecutor.(functions.ResultCacher)
+	if !ok || cache == nil {
+		return
+	}
+
+	// The request context may already be done when the tool call finished
+	ctx = context.WithoutCancel(ctx)
+	redisClient := l.svcCtx.RedisClient
+	version, _ := cacher.ResultIndexVersion(cache.toolName, result)
+	previous, err := redisClient.RaiseHashField(ctx, cache.versionKey(), "version", version,
+		map[string]interface{}{"checkedAt": time.Now().Unix()}, toolResultCacheVersionTTL)
+	if err != nil {
+		logToolCacheError(ctx, "failed to store tool result cache version", cache.toolName, err)
+		return
+	}
+	if previous > version {
+		logger.InfoC(ctx, "tool result from an older index, not cached",
+			zap.String("tool", cache.toolName), zap.Int64("version", version), zap.Int64("cachedVersion", previous))
+		return
+	}
+	if previous < version {
+		logger.InfoC(ctx, "tool index version changed, older cached results dropped",
+			zap.String("tool", cache.toolName), zap.Int64("version", version), zap.Int64("previousVersion", previous))
+	}
+	if err := redisClient.SetHashField(ctx, cache.entryKey(version), "result", result, cache.ttl); err != nil {
+		logToolCacheError(ctx, "failed to store tool result cache", cache.toolName, err)
+	}
+}
+
+// toolCallParamsHash hashes what determines the result of a call: its parameters with string
+// values passed through normalize, the augmented query and the effective top-k. ok is false
+// when the parameters are unknown
+func toolCallParamsHash(toolCall *model.ToolCall, normalize func(string) string) (string, bool) {
+	if toolCall.ToolParams == nil || toolCall.ToolParamsError != "" {
+		return "", false
+	}
+	params, err := json.Marshal(struct {
+		Params         map[string]interface{} `json:"params"`
+		AugmentedQuery string                 `json:"augmented_query,omitempty"`
+		TopK           *int                   `json:"top_k,omitempty"`
+	}{normalizeCacheParams(toolCall.ToolParams, normalize), toolCall.AugmentedQuery, toolCall.EffectiveTopK})
+	if err != nil {
+		return "", false
+	}
+	return hashKey(string(params)), true
+}
+
+// normalizeCacheParams applies normalize to string parameters so calls differing only in
+// whitespace share results
+func normalizeCacheParams(params map[string]interface{}, normalize func(string) string) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if s, ok := v.(string); ok {
+			v = normalize(s)
+		}
+		normalized[k] = v
+	}
+	return normalized
+}
+
+// hashKey joins the parts into a hex SHA-256 hash for use in Redis keys
+func hashKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// logToolCacheError logs Redis failures of the result cache, quietly while Redis is down
+func logToolCacheError(ctx context.Context, msg string, toolName string, err error) {
+	if errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(ctx, msg, zap.String("tool", toolName), zap.Error(err))
+		return
+	}
+	logger.WarnC(ctx, msg, zap.String("tool", toolName), zap.Error(err))
+}
@@ -0,0 +1,150 @@
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// cachingToolExecutor caches codebase_search results, reporting the configured index version
+type cachingToolExecutor struct {
+	stubToolExecutor
+	version int64
+}
+
+func (e *cachingToolExecutor) ResultCacheTTL(toolName string) (time.Duration, bool) {
+	return time.Minute, toolName == "codebase_search"
+}
+
+func (e *cachingToolExecutor) ResultIndexVersion(toolName string, result string) (int64, bool) {
+	return e.version, e.version > 0
+}
+
+func (e *cachingToolExecutor) ResultVersionCheckInterval(toolName string) time.Duration {
+	return time.Minute
+}
+
+func TestToolResultCache(t *testing.T) {
+	executor := &cachingToolExecutor{version: 100}
+	redis := &hashRedis{hashes: make(map[string]map[string]string)}
+	l := &ChatCompletionLogic{
+		ctx:          context.Background(),
+		svcCtx:       &bootstrap.ServiceContext{RedisClient: redis},
+		toolExecutor: executor,
+		identity:     &model.Identity{ClientID: "client-1", ProjectPath: "/repo"},
+	}
+	call := func(tool string, query string) *model.ToolCall {
+		return &model.ToolCall{ToolName: tool, ToolParams: map[string]interface{}{"query": query}}
+	}
+
+	cache, _, hit := l.lookupToolResult(context.Background(), call("codebase_search", "auth"))
+	assert.NotNil(t, cache)
+	assert.False(t, hit)
+	l.storeToolResult(context.Background(), cache, "v100 results")
+
+	_, result, hit := l.lookupToolResult(context.Background(), call("codebase_search", " auth "))
+	assert.True(t, hit, "whitespace in parameters is ignored")
+	assert.Equal(t, "v100 results", result)
+
+	_, _, hit = l.lookupToolResult(context.Background(), call("codebase_search", "login"))
+	assert.False(t, hit)
+	cache, _, _ = l.lookupToolResult(context.Background(), call("file_read", "auth"))
+	assert.Nil(t, cache, "tool without result cache")
+
+	other := *l
+	other.identity = &model.Identity{ClientID: "client-1", ProjectPath: "/other"}
+	_, _, hit = other.lookupToolResult(context.Background(), call("codebase_search", "auth"))
+	assert.False(t, hit, "results are scoped to the project")
+
+	// A reindex makes results of the previous index unreachable
+	cache, _, _ = l.lookupToolResult(context.Background(), call("codebase_search", "login"))
+	executor.version = 200
+	l.storeToolResult(context.Background(), cache, "v200 results")
+	_, _, hit = l.lookupToolResult(context.Background(), call("codebase_search", "auth"))
+	assert.False(t, hit)
+	_, result, hit = l.lookupToolResult(context.Background(), call("codebase_search", "login"))
+	assert.True(t, hit)
+	assert.Equal(t, "v200 results", result)
+
+	// Results of an older index than already seen are not cached
+	cache, _, _ = l.lookupToolResult(context.Background(), call("codebase_search", "auth"))
+	executor.version = 100
+	l.storeToolResult(context.Background(), cache, "stale results")
+	_, _, hit = l.lookupToolResult(context.Background(), call("codebase_search", "auth"))
+	assert.False(t, hit)
+	assert.Equal(t, "200", redis.hashes[cache.versionKey()]["version"], "the version never moves back")
+}
+
+func TestToolResultCache_ReindexBetweenCalls(t *testing.T) {
+	executor := &cachingToolExecutor{version: 100}
+	redis := &hashRedis{hashes: make(map[string]map[string]string)}
+	l := &ChatCompletionLogic{
+		ctx:          context.Background(),
+		svcCtx:       &bootstrap.ServiceContext{RedisClient: redis},
+		toolExecutor: executor,
+		identity:     &model.Identity{ClientID: "client-1", ProjectPath: "/repo"},
+	}
+	call := &model.ToolCall{ToolName: "codebase_search", ToolParams: map[string]interface{}{"query": "auth"}}
+
+	cache, _, _ := l.lookupToolResult(context.Background(), call)
+	l.storeToolResult(context.Background(), cache, "v100 results")
+	_, result, hit := l.lookupToolResult(context.Background(), call)
+	assert.True(t, hit)
+	assert.Equal(t, "v100 results", result)
+
+	// The index is rebuilt, no result has reported the new version yet
+	executor.version = 200
+	_, _, hit = l.lookupToolResult(context.Background(), call)
+	assert.True(t, hit, "the version is trusted within the check interval")
+
+	// Once the check interval passed, the identical call goes to the backend
+	redis.hashes[cache.versionKey()]["checkedAt"] = strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10)
+	cache, _, hit = l.lookupToolResult(context.Background(), call)
+	assert.False(t, hit)
+	l.storeToolResult(context.Background(), cache, "v200 results")
+	_, result, hit = l.lookupToolResult(context.Background(), call)
+	assert.True(t, hit)
+	assert.Equal(t, "v200 results", result)
+}
+
+func TestCacheToolResult_SkipsTimedOutCalls(t *testing.T) {
+	redis := &hashRedis{hashes: make(map[string]map[string]string)}
+	l := &ChatCompletionLogic{
+		ctx: context.Background(),
+		svcCtx: &bootstrap.ServiceContext{
+			Config:      config.Config{RequestToolCache: config.RequestToolCacheConfig{Enabled: true}},
+			RedisClient: redis,
+		},
+		toolExecutor: &cachingToolExecutor{version: 100},
+		identity:     &model.Identity{ClientID: "client-1", ProjectPath: "/repo"},
+	}
+	call := func() *model.ToolCall {
+		return &model.ToolCall{ToolName: "codebase_search", ToolParams: map[string]interface{}{"query": "auth"}}
+	}
+
+	timedOut := call()
+	timedOut.TimedOut = true
+	cache, _, _ := l.lookupToolResult(context.Background(), timedOut)
+	l.cacheToolResult(context.Background(), timedOut, cache, functions.NoResultsMessage, false)
+	_, hit := l.requestToolResult(context.Background(), call())
+	assert.False(t, hit, "the request cache skips timed-out calls")
+	_, _, hit = l.lookupToolResult(context.Background(), call())
+	assert.False(t, hit, "the Redis cache skips timed-out calls")
+	assert.Empty(t, redis.hashes[cache.versionKey()], "the version check time is not refreshed")
+
+	answered := call()
+	cache, _, _ = l.lookupToolResult(context.Background(), answered)
+	l.cacheToolResult(context.Background(), answered, cache, "results", false)
+	_, hit = l.requestToolResult(context.Background(), call())
+	assert.True(t, hit)
+	_, _, hit = l.lookupToolResult(context.Background(), call())
+	assert.True(t, hit)
+}
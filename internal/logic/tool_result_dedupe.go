@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// dedupeToolResult returns the text to inject for a tool result. When deduplication is enabled
+// and the result is identical to the previously injected one, a short reference replaces it.
+func (l *ChatCompletionLogic) dedupeToolResult(toolName string, result string) (string, bool) {
+	if l.svcCtx.Config.Tools == nil || !l.svcCtx.Config.Tools.DedupeResults {
+		return result, false
+	}
+
+	sum := sha256.Sum256([]byte(toolName + "\x00" + result))
+	hash := hex.EncodeToString(sum[:])
+	if hash != l.lastToolResultHash {
+		l.lastToolResultHash = hash
+		return result, false
+	}
+
+	logger.InfoC(l.ctx, "tool result identical to the previous one, injecting reference",
+		zap.String("tool", toolName), zap.Int("result length", len(result)))
+	return fmt.Sprintf("(same result as the previous %s call above)", toolName), true
+}
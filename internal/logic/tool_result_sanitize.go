@@ -0,0 +1,27 @@
+package logic
+
+import (
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// sanitizeToolResult escapes tool tags inside a tool result when sanitizing is enabled,
+// so that a search hit quoting "<codebase_search>" is not taken for a tool call
+// when the model repeats it in the next turn
+func (l *ChatCompletionLogic) sanitizeToolResult(toolName string, result string) (string, int) {
+	if l.svcCtx.Config.Tools == nil || !l.svcCtx.Config.Tools.SanitizeResults.Enabled {
+		return result, 0
+	}
+
+	tags := slices.Concat(l.toolExecutor.GetAllTools(), l.svcCtx.Config.Tools.SanitizeResults.Tags)
+	sanitized, escaped := functions.EscapeToolTags(result, tags)
+	if escaped > 0 {
+		logger.InfoC(l.ctx, "escaped tool tags in tool result",
+			zap.String("tool", toolName), zap.Int("escaped", escaped))
+	}
+	return sanitized, escaped
+}
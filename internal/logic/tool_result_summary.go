@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/processor"
+	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+const (
+	resultReductionSummarized = "summarized"
+	resultReductionTruncated  = "truncated"
+)
+
+// toolResultSummaryPrompt asks the summary model to condense a tool result for the main model
+const toolResultSummaryPrompt = `You condense the output of the %s tool for another AI assistant working on a coding task.
+Rewrite the output in at most %d tokens. Keep file paths, symbol names, line numbers, error messages
+and code that is essential to the results; drop repetition and boilerplate. Do not add commentary.`
+
+// reduceOversizedToolResult summarizes a result above the tool's token budget with the summary
+// model, falling back to truncation at the budget. It returns the result to inject and the
+// reduction applied, empty when the result fits or summarizing is not enabled for the tool.
+func (l *ChatCompletionLogic) reduceOversizedToolResult(ctx context.Context, toolName string, result string) (string, string) {
+	configurer, ok := l.toolExecutor.(functions.ResultSummaryConfigurer)
+	if !ok {
+		return result, ""
+	}
+	cfg, ok := configurer.ResultSummaryConfig(toolName)
+	if !ok {
+		return result, ""
+	}
+	tokens := l.countTextTokens(result)
+	if tokens <= cfg.MaxTokens {
+		return result, ""
+	}
+
+	start := time.Now()
+	summary, err := l.summarizeToolResult(ctx, toolName, result, cfg.Model, cfg.MaxTokens, cfg.TimeoutMs)
+	if err == nil && strings.TrimSpace(summary) != "" {
+		logger.InfoC(ctx, "oversized tool result summarized",
+			zap.String("tool", toolName), zap.Int("tokens", tokens),
+			zap.Int("summaryTokens", l.countTextTokens(summary)),
+			zap.Duration("latency", time.Since(start)))
+		return summary, resultReductionSummarized
+	}
+
+	logger.WarnC(ctx, "failed to summarize oversized tool result, truncating",
+		zap.String("tool", toolName), zap.Int("tokens", tokens), zap.Error(err))
+	// Cut proportionally to the budget, the token count of a prefix is close enough
+	maxBytes := int(int64(len(result)) * int64(cfg.MaxTokens) / int64(tokens))
+	return l.truncateToolResult(toolName, result, maxBytes), resultReductionTruncated
+}
+
+// summarizeToolResult asks the summary model for a condensed tool result within the time limit
+func (l *ChatCompletionLogic) summarizeToolResult(ctx context.Context, toolName string, result string,
+	modelName string, maxTokens int, timeoutMs int) (string, error) {
+	if modelName == "" {
+		modelName, _ = processor.SelectSummaryModel(l.svcCtx.Config.ContextCompressConfig, l.request.Model)
+	}
+	if modelName == "" {
+		return "", fmt.Errorf("no summary model configured")
+	}
+
+	llmClient, err := client.NewLLMClient(l.svcCtx.Config.LLM, l.svcCtx.Config.LLMTimeout, modelName, l.headers)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+	return llmClient.GenerateContent(ctx, fmt.Sprintf(toolResultSummaryPrompt, toolName, maxTokens),
+		[]types.Message{{Role: types.RoleUser, Content: result}})
+}
+
+// countTextTokens counts the tokens of a text, estimating them without a token counter
+func (l *ChatCompletionLogic) countTextTokens(text string) int {
+	if l.svcCtx.TokenCounter != nil {
+		return l.svcCtx.TokenCounter.CountTokens(text)
+	}
+	return tokenizer.EstimateTokens(text)
+}
@@ -0,0 +1,19 @@
+package logic
+
+import (
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+)
+
+// toolTruncationStrategy returns the truncation strategy of the tool, keeping the head by default
+func (l *ChatCompletionLogic) toolTruncationStrategy(toolName string) string {
+	if truncator, ok := l.toolExecutor.(functions.ResultTruncator); ok {
+		return truncator.TruncationStrategy(toolName)
+	}
+	return config.ToolTruncateHead
+}
+
+// truncateToolResult cuts an oversized tool result to maxBytes with the tool's strategy
+func (l *ChatCompletionLogic) truncateToolResult(toolName string, result string, maxBytes int) string {
+	return functions.TruncateResult(result, maxBytes, l.toolTruncationStrategy(toolName))
+}
@@ -0,0 +1,127 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// toolStatusExpiration is how long tool statuses stay readable by the status endpoint
+const toolStatusExpiration = 5 * time.Minute
+
+// toolStatusBatch holds back final tool statuses so they are written together with the
+// next update of the request, or on their own once maxDelay has passed
+type toolStatusBatch struct {
+	ctx         context.Context
+	redisClient client.RedisInterface
+	key         string
+	maxDelay    time.Duration
+
+	mu      sync.Mutex
+	pending map[string]interface{}
+	timer   *time.Timer
+}
+
+func newToolStatusBatch(ctx context.Context, redisClient client.RedisInterface, key string,
+	maxDelay time.Duration) *toolStatusBatch {
+	return &toolStatusBatch{
+		ctx:         ctx,
+		redisClient: redisClient,
+		key:         key,
+		maxDelay:    maxDelay,
+		pending:     make(map[string]interface{}),
+	}
+}
+
+// Update records a status change. Running statuses are written immediately, along with any
+// held back statuses, so the status endpoint sees a tool start without delay
+func (b *toolStatusBatch) Update(toolName string, status types.ToolStatus) {
+	b.mu.Lock()
+	b.pending[toolName] = string(status)
+	if status != types.ToolStatusRunning {
+		if b.timer == nil {
+			b.timer = time.AfterFunc(b.maxDelay, b.Flush)
+		}
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Unlock()
+	b.Flush()
+}
+
+// Evict drops held back statuses of the tools
+func (b *toolStatusBatch) Evict(toolNames []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, toolName := range toolNames {
+		delete(b.pending, toolName)
+	}
+}
+
+// Flush writes all held back statuses in one round trip
+func (b *toolStatusBatch) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.pending) == 0 {
+		return
+	}
+
+	// The request context may already be done when the turn ends, the write must still happen
+	ctx := context.WithoutCancel(b.ctx)
+	if err := b.redisClient.SetHashFields(ctx, b.key, b.pending, toolStatusExpiration); errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(b.ctx, "redis unavailable, dropping tool statuses", zap.Int("count", len(b.pending)))
+	} else if err != nil {
+		logger.ErrorC(b.ctx, "failed to update tool statuses in redis",
+			zap.Any("statuses", b.pending), zap.Error(err))
+	}
+	b.pending = make(map[string]interface{})
+}
+
+// flushToolStatus writes held back tool statuses at the end of the turn
+func (l *ChatCompletionLogic) flushToolStatus() {
+	if l.toolStatusBatch != nil {
+		l.toolStatusBatch.Flush()
+	}
+}
+
+// evictToolStatuses tracks the tool in the status hash of the request and removes the oldest
+// tools once more than ToolStatus.MaxTools distinct tools were called
+func (l *ChatCompletionLogic) evictToolStatuses(chatLog *model.ChatLog, key string, toolName string) {
+	if slices.Contains(l.toolStatusTools, toolName) {
+		return
+	}
+	l.toolStatusTools = append(l.toolStatusTools, toolName)
+	maxTools := l.svcCtx.Config.ToolStatus.MaxTools
+	if maxTools <= 0 || len(l.toolStatusTools) <= maxTools {
+		return
+	}
+
+	evicted := slices.Clone(l.toolStatusTools[:len(l.toolStatusTools)-maxTools])
+	l.toolStatusTools = slices.Delete(l.toolStatusTools, 0, len(evicted))
+	chatLog.EvictedToolStatuses = append(chatLog.EvictedToolStatuses, evicted...)
+	logger.WarnC(l.ctx, "tool status limit reached, evicting oldest statuses",
+		zap.Int("maxTools", maxTools), zap.Strings("evicted", evicted))
+
+	if l.toolStatusBatch != nil {
+		l.toolStatusBatch.Evict(evicted)
+	}
+	if err := l.svcCtx.RedisClient.DeleteHashFields(l.ctx, key, evicted...); errors.Is(err, client.ErrRedisUnavailable) {
+		logger.DebugC(l.ctx, "redis unavailable, skip evicting tool statuses")
+	} else if err != nil {
+		logger.ErrorC(l.ctx, "failed to evict tool statuses in redis",
+			zap.Strings("evicted", evicted), zap.Error(err))
+	}
+}
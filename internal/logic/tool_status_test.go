@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// recordingRedis records the hash writes, other methods are not used
+type recordingRedis struct {
+	client.RedisInterface
+	mu     sync.Mutex
+	writes []map[string]interface{}
+}
+
+func (r *recordingRedis) SetHashFields(ctx context.Context, key string, fields map[string]interface{},
+	expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	r.writes = append(r.writes, copied)
+	return nil
+}
+
+func (r *recordingRedis) Writes() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.writes
+}
+
+func TestToolStatusBatch_FinalStatusWrittenWithNextRunning(t *testing.T) {
+	redis := &recordingRedis{}
+	batch := newToolStatusBatch(context.Background(), redis, "tool_status:req", time.Minute)
+
+	batch.Update("codebase_search", types.ToolStatusRunning)
+	batch.Update("codebase_search", types.ToolStatusSuccess)
+	assert.Len(t, redis.Writes(), 1, "final status is held back")
+
+	batch.Update("knowledge_base_search", types.ToolStatusRunning)
+	batch.Update("knowledge_base_search", types.ToolStatusFailed)
+	batch.Flush()
+
+	assert.Equal(t, []map[string]interface{}{
+		{"codebase_search": "running"},
+		{"codebase_search": "success", "knowledge_base_search": "running"},
+		{"knowledge_base_search": "failed"},
+	}, redis.Writes())
+}
+
+func TestToolStatusBatch_FlushedAfterMaxDelay(t *testing.T) {
+	redis := &recordingRedis{}
+	batch := newToolStatusBatch(context.Background(), redis, "tool_status:req", 20*time.Millisecond)
+
+	batch.Update("codebase_search", types.ToolStatusSuccess)
+	assert.Eventually(t, func() bool { return len(redis.Writes()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestUpdateToolStatus_EvictsOldestTools(t *testing.T) {
+	redis := &hashRedis{hashes: make(map[string]map[string]string)}
+	l := &ChatCompletionLogic{
+		ctx: context.Background(),
+		svcCtx: &bootstrap.ServiceContext{
+			Config:      config.Config{ToolStatus: config.ToolStatusConfig{MaxTools: 2}},
+			RedisClient: redis,
+		},
+		identity: &model.Identity{RequestID: "req"},
+	}
+	chatLog := &model.ChatLog{}
+
+	l.updateToolStatus(chatLog, "codebase_search", types.ToolStatusRunning)
+	l.updateToolStatus(chatLog, "codebase_search", types.ToolStatusSuccess)
+	l.updateToolStatus(chatLog, "file_search", types.ToolStatusSuccess)
+	assert.Empty(t, chatLog.EvictedToolStatuses, "repeated calls of a tool use one field")
+
+	l.updateToolStatus(chatLog, "knowledge_base_search", types.ToolStatusRunning)
+	assert.Equal(t, map[string]string{"file_search": "success", "knowledge_base_search": "running"},
+		redis.hashes["tool_status:req"])
+	assert.Equal(t, []string{"codebase_search"}, chatLog.EvictedToolStatuses)
+}
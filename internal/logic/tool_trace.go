@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+const (
+	// extraBodyToolTrace is the extra_body field requesting tool call traces
+	extraBodyToolTrace = "debug_tool_trace"
+	// toolTraceWithResults is the extra_body value also requesting the tool results
+	toolTraceWithResults = "results"
+)
+
+// toolTraceMode is the level of tool call traces returned to the caller
+type toolTraceMode int
+
+const (
+	toolTraceOff toolTraceMode = iota
+	toolTraceRedacted
+	toolTraceFull
+)
+
+// takeToolTraceMode reads the tool trace request from extra_body and removes the field, so it
+// is not forwarded to the model. Traces are only granted to users on the configured allow-list.
+func (l *ChatCompletionLogic) takeToolTraceMode() toolTraceMode {
+	value, ok := l.request.ExtraBody.Extra[extraBodyToolTrace]
+	if !ok {
+		return toolTraceOff
+	}
+	delete(l.request.ExtraBody.Extra, extraBodyToolTrace)
+
+	mode := toolTraceOff
+	switch value {
+	case true:
+		mode = toolTraceRedacted
+	case toolTraceWithResults:
+		mode = toolTraceFull
+	}
+	if mode == toolTraceOff {
+		return toolTraceOff
+	}
+
+	traceCfg := l.svcCtx.Config.Debug.ToolTrace
+	if !traceCfg.Enabled || l.identity == nil || !slices.Contains(traceCfg.AllowedUsers, l.identity.UserName) {
+		logger.WarnC(l.ctx, "tool trace requested by unauthorized caller", zap.Any("value", value))
+		return toolTraceOff
+	}
+	return mode
+}
+
+// buildToolTraces converts the logged tool calls into traces, result bodies are
+// left out unless the caller asked for them
+func (l *ChatCompletionLogic) buildToolTraces(toolCalls []model.ToolCall) []types.ToolTrace {
+	traces := make([]types.ToolTrace, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		trace := types.ToolTrace{
+			Name:         toolCall.ToolName,
+			Params:       toolCall.ToolParams,
+			LatencyMs:    toolCall.Latency,
+			Status:       toolCall.ResultStatus,
+			Error:        toolCall.Error,
+			ResultLength: len(toolCall.ToolOutput),
+		}
+		if l.toolTrace == toolTraceFull {
+			trace.Result = utils.GetContentAsStringCapped(toolCall.ToolOutput, l.svcCtx.Config.Debug.ToolTrace.MaxResultBytes)
+		}
+		traces = append(traces, trace)
+	}
+	return traces
+}
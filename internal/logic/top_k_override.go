@@ -0,0 +1,62 @@
+package logic
+
+import (
+	"math"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	// extraBodySemanticTopK is the extra_body field overriding the result count of search tools
+	extraBodySemanticTopK = "semantic_top_k"
+	// extraBodyDisableSemantic is the extra_body field leaving semantic search out of the request
+	extraBodyDisableSemantic = "disable_semantic"
+)
+
+// takeSemanticTopK reads the requested result count from extra_body and removes the field,
+// so it is not forwarded to the model. Invalid values are ignored, 0 means no override.
+func (l *ChatCompletionLogic) takeSemanticTopK() int {
+	value, ok := l.request.ExtraBody.Extra[extraBodySemanticTopK]
+	if !ok {
+		return 0
+	}
+	delete(l.request.ExtraBody.Extra, extraBodySemanticTopK)
+
+	var topK int
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && v > 0 && v <= math.MaxInt32 {
+			topK = int(v)
+		}
+	case string:
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			topK = n
+		}
+	}
+	if topK == 0 {
+		logger.WarnC(l.ctx, "ignoring invalid extra_body."+extraBodySemanticTopK, zap.Any("value", value))
+		return 0
+	}
+
+	logger.InfoC(l.ctx, "search result count requested by caller", zap.Int("topK", topK))
+	return topK
+}
+
+// takeDisableSemantic reads the semantic search opt-out from extra_body and removes the field.
+// Only true disables semantic search, any other value keeps it.
+func (l *ChatCompletionLogic) takeDisableSemantic() bool {
+	value, ok := l.request.ExtraBody.Extra[extraBodyDisableSemantic]
+	if !ok {
+		return false
+	}
+	delete(l.request.ExtraBody.Extra, extraBodyDisableSemantic)
+
+	disabled, _ := value.(bool)
+	if disabled {
+		logger.InfoC(l.ctx, "semantic search disabled by caller")
+	}
+	return disabled
+}
@@ -38,6 +38,53 @@ type ToolCall struct {
 	ResultStatus string `json:"result_status"`
 	Latency      int64  `json:"latency"`
 	Error        string `json:"error"`
+	Broadened    bool   `json:"broadened,omitempty"`
+	// Path filter removed by the broadened retry
+	BroadenedFromPath string `json:"broadened_from_path,omitempty"`
+	// Number of results returned and passing the score threshold, set when result stats are enabled
+	ResultCount         *int `json:"result_count,omitempty"`
+	AboveThresholdCount *int `json:"above_threshold_count,omitempty"`
+	// Query sent to the tool after blending in the thinking summary
+	AugmentedQuery string `json:"augmented_query,omitempty"`
+	// Parameters parsed from ToolInput, ToolParamsError notes parts that could not be parsed
+	ToolParams      map[string]interface{} `json:"tool_params,omitempty"`
+	ToolParamsError string                 `json:"tool_params_error,omitempty"`
+	// Results found under each path of a multi-path search
+	PathResultCounts map[string]int `json:"path_result_counts,omitempty"`
+	// Index age reported by the backend, IndexStale is set when it exceeds the configured maximum
+	IndexAgeSec *int64 `json:"index_age_sec,omitempty"`
+	IndexStale  bool   `json:"index_stale,omitempty"`
+	// Result matched the previous tool result and was injected as a reference only
+	DuplicateResult bool `json:"duplicate_result,omitempty"`
+	// Variant of the instruction appended after the result
+	FollowUpVariant string `json:"follow_up_variant,omitempty"`
+	// Where the instruction was placed relative to the result: "append" or "prepend"
+	FollowUpPlacement string `json:"follow_up_placement,omitempty"`
+	// Number of tool tags escaped in the injected result
+	EscapedTags int `json:"escaped_tags,omitempty"`
+	// Result count requested through extra_body, after capping
+	EffectiveTopK *int `json:"effective_top_k,omitempty"`
+	// How an oversized result was reduced: "summarized" or "truncated"
+	ResultReduction string `json:"result_reduction,omitempty"`
+	// Part of the result kept when it was truncated: "head", "tail" or "head_tail"
+	TruncationStrategy string `json:"truncation_strategy,omitempty"`
+	// Outcome of the cross-request result cache lookup: "hit" or "miss", empty when disabled
+	ResultCache string `json:"result_cache,omitempty"`
+	// Search endpoint that served the result, set for tools with failover endpoints
+	ServedEndpoint string `json:"served_endpoint,omitempty"`
+	// Result reused from an identical earlier call of the same request
+	RequestCacheHit bool `json:"request_cache_hit,omitempty"`
+	// Number of files listed in the file index injected above the result
+	IndexedFiles int `json:"indexed_files,omitempty"`
+	// Token cap from the tool's context share and the results dropped to stay within it
+	ContextShareCap int `json:"context_share_cap,omitempty"`
+	TrimmedResults  int `json:"trimmed_results,omitempty"`
+	// Reason the backend response failed validation and was replaced with a no-results message
+	InvalidResult string `json:"invalid_result,omitempty"`
+	// Timeout applied to the call for its depth in the tool call chain
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+	// The call exceeded the tool's own timeout and was replaced with a no-results message
+	TimedOut bool `json:"timed_out,omitempty"`
 }
 
 // RequestParams represents the request parameters for a chat completion
@@ -45,6 +92,26 @@ type RequestParams struct {
 	Model       string                 `json:"model"`
 	RoutedModel string                 `json:"routed_model,omitempty"`
 	LlmParams   types.LLMRequestParams `json:"llm_params"`
+	// Model from the x-original-model header, set when a gateway remapped the user's model
+	OriginalModel string `json:"original_model,omitempty"`
+	// Effective output token limit after applying configured defaults and maximums
+	EffectiveMaxTokens int `json:"effective_max_tokens,omitempty"`
+	// Effective temperature after applying configured per-model default and range
+	EffectiveTemperature *float64 `json:"effective_temperature,omitempty"`
+}
+
+// Sources of retrieved chunks
+const (
+	// Returned by a tool call of the model
+	ChunkSourceTool = "tool"
+)
+
+// RetrievedChunk records where a chunk injected into the prompt came from
+type RetrievedChunk struct {
+	FilePath string   `json:"file_path"`
+	Score    *float64 `json:"score,omitempty"`
+	Source   string   `json:"source"`
+	Tool     string   `json:"tool,omitempty"`
 }
 
 // ChatLog represents a single chat completion log entry
@@ -53,27 +120,77 @@ type ChatLog struct {
 	Timestamp time.Time `json:"timestamp"`
 	// Agent information
 	Agent string `json:"agent,omitempty"`
+	// Short checksum of the processed system prompt, set when the metrics label is enabled
+	SystemPromptChecksum string `json:"system_prompt_checksum,omitempty"`
 	// Token statistics
 	Tokens types.TokenMetrics `json:"tokens"`
 
 	// Processing flags
 	IsPromptProceed bool `json:"is_prompt_proceed"`
+	// The latest question was restated after the compression summary
+	QuestionReinjected bool `json:"question_reinjected,omitempty"`
+	// Compression was needed but skipped because the conversation has too few messages
+	CompressionSkipped bool `json:"compression_skipped,omitempty"`
+	// The summary failed and the summary fallback compressed the conversation instead
+	CompressionFallback bool `json:"compression_fallback,omitempty"`
+	// Compression was needed but dropped because the compression queue was full
+	CompressionDropped bool `json:"compression_dropped,omitempty"`
+	// Model asked to summarize the conversation, empty when no summary was requested
+	SummaryModel string `json:"summary_model,omitempty"`
+	// Compression strategy picked for the conversation size
+	CompressionStrategy string `json:"compression_strategy,omitempty"`
+	// The current date and time was appended to the system prompt
+	CurrentTimeInjected bool `json:"current_time_injected,omitempty"`
 
 	// Latency metrics
 	Latency LatencyMetrics `json:"latency"`
 
 	// Tools
-	ToolCalls []ToolCall `json:"tool_calls"`
+	ToolCalls     []ToolCall `json:"tool_calls"`
+	InjectedTools []string   `json:"injected_tools,omitempty"`
+	// Tools passed through as text because the distinct tool limit was reached
+	SkippedTools []string `json:"skipped_tools,omitempty"`
+	// Tools left out of the prompt because their backend is failing
+	UnhealthyTools []string `json:"unhealthy_tools,omitempty"`
+	// Tools whose status was evicted to keep the status hash within its limit
+	EvictedToolStatuses []string `json:"evicted_tool_statuses,omitempty"`
+	// Chunks injected into the prompt, set when retrieval provenance is enabled
+	RetrievedChunks []RetrievedChunk `json:"retrieved_chunks,omitempty"`
+	// Chunks beyond the provenance limit, injected but not recorded
+	RetrievedChunksOmitted int `json:"retrieved_chunks_omitted,omitempty"`
+	// Number of messages whose content parts were merged to respect the part limit
+	MergedContentMessages int `json:"merged_content_messages,omitempty"`
+	// Semantic search tools left out because the request sent extra_body.disable_semantic
+	SemanticSkipped []string `json:"semantic_skipped,omitempty"`
+	// Number of oversized user messages split into chunks before they were summarized
+	SplitUserMessages int `json:"split_user_messages,omitempty"`
+	// Tokens removed from the system prompt by dropping duplicated rule blocks
+	DedupedTokens int `json:"deduped_tokens,omitempty"`
+	// Tokens removed from the system prompt by stripping tool sections when no tools are available
+	NoToolsSavedTokens int `json:"no_tools_saved_tokens,omitempty"`
+	// Request messages summarized or trimmed away during prompt processing
+	DroppedMessages []types.DroppedMessage `json:"dropped_messages,omitempty"`
+	// Number of times the streaming request was repeated because the upstream dropped before the first token
+	StreamDropRetries int `json:"stream_drop_retries,omitempty"`
+	// Set when the model asked for another tool after the tool call depth was used up
+	ToolDepthLimited bool `json:"tool_depth_limited,omitempty"`
+	// Deployment environment of the instance that served the request
+	Environment string `json:"environment,omitempty"`
 
 	Params RequestParams `json:"params"`
 
-	// OriginalPrompt  []types.Message `json:"original_prompt"`
+	// Request messages before processing, only kept when prompts are stored as diffs
+	OriginalPrompt  []types.Message `json:"original_prompt,omitempty"`
 	ProcessedPrompt []types.Message `json:"processed_prompt"`
+	// ProcessedPrompt as a diff against OriginalPrompt, replaces it in stored logs
+	ProcessedPromptDiff []PromptDiffOp `json:"processed_prompt_diff,omitempty"`
 
 	// Response information
 	ResponseHeaders []map[string]string  `json:"response_headers,omitempty"`
 	ResponseContent *types.ResponseContent `json:"response_content,omitempty"`
 	Usage           types.Usage          `json:"usage,omitempty"`
+	// Names of the response filters that rewrote part of the response
+	ResponseFilters []string `json:"response_filters,omitempty"`
 
 	// Classification (will be filled by async processor)
 	Category string `json:"category,omitempty"`
@@ -106,16 +223,45 @@ func (cl *ChatLog) ToPrettyJSON() (string, error) {
 	return cl.toStringJSON("  ")
 }
 
-// FromJSON creates a ChatLog from JSON string
+// FromJSON creates a ChatLog from JSON string, rebuilding a processed prompt stored as a diff
 func FromJSON(jsonStr string) (*ChatLog, error) {
 	var log ChatLog
 	err := json.Unmarshal([]byte(jsonStr), &log)
 	if err != nil {
 		return nil, err
 	}
+	if err := log.RestoreProcessedPrompt(); err != nil {
+		return nil, err
+	}
 	return &log, nil
 }
 
+// WithPromptDiff returns a copy of the log storing ProcessedPrompt as a diff against OriginalPrompt.
+// Logs without an original prompt are returned as they are.
+func (cl *ChatLog) WithPromptDiff() *ChatLog {
+	if cl.OriginalPrompt == nil || cl.ProcessedPrompt == nil {
+		return cl
+	}
+	compact := *cl
+	compact.ProcessedPromptDiff = DiffPrompts(cl.OriginalPrompt, cl.ProcessedPrompt)
+	compact.ProcessedPrompt = nil
+	return &compact
+}
+
+// RestoreProcessedPrompt rebuilds ProcessedPrompt from ProcessedPromptDiff, if the log has one
+func (cl *ChatLog) RestoreProcessedPrompt() error {
+	if cl.ProcessedPromptDiff == nil {
+		return nil
+	}
+	processed, err := ApplyPromptDiff(cl.OriginalPrompt, cl.ProcessedPromptDiff)
+	if err != nil {
+		return err
+	}
+	cl.ProcessedPrompt = processed
+	cl.ProcessedPromptDiff = nil
+	return nil
+}
+
 // Helper functions
 
 // AddError adds an error entry with type and message to the ChatLog
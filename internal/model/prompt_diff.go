@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// PromptDiffOp is one step rebuilding the processed prompt from the original prompt.
+// It either copies Count original messages starting at From, or adds Message.
+type PromptDiffOp struct {
+	From    int            `json:"from,omitempty"`
+	Count   int            `json:"count,omitempty"`
+	Message *types.Message `json:"message,omitempty"`
+}
+
+// DiffPrompts describes processed as the original messages it kept, in runs, and the
+// messages it added or replaced. Original messages not referenced were removed.
+func DiffPrompts(original, processed []types.Message) []PromptDiffOp {
+	keys := make([]string, len(original))
+	firstIndex := make(map[string]int, len(original))
+	for i, msg := range original {
+		keys[i] = messageKey(msg)
+		if _, exists := firstIndex[keys[i]]; !exists {
+			firstIndex[keys[i]] = i
+		}
+	}
+
+	ops := make([]PromptDiffOp, 0)
+	for i := range processed {
+		key := messageKey(processed[i])
+
+		// Extend the current run while the original continues in order
+		if n := len(ops); n > 0 && ops[n-1].Message == nil {
+			next := ops[n-1].From + ops[n-1].Count
+			if next < len(keys) && keys[next] == key {
+				ops[n-1].Count++
+				continue
+			}
+		}
+		if index, ok := firstIndex[key]; ok {
+			ops = append(ops, PromptDiffOp{From: index, Count: 1})
+			continue
+		}
+		ops = append(ops, PromptDiffOp{Message: &processed[i]})
+	}
+	return ops
+}
+
+// ApplyPromptDiff rebuilds the processed prompt from the original prompt and its diff
+func ApplyPromptDiff(original []types.Message, ops []PromptDiffOp) ([]types.Message, error) {
+	processed := make([]types.Message, 0, len(ops))
+	for _, op := range ops {
+		if op.Message != nil {
+			processed = append(processed, *op.Message)
+			continue
+		}
+		if op.From < 0 || op.Count <= 0 || op.From+op.Count > len(original) {
+			return nil, fmt.Errorf("prompt diff copies messages %d-%d of an original prompt with %d messages",
+				op.From, op.From+op.Count-1, len(original))
+		}
+		processed = append(processed, original[op.From:op.From+op.Count]...)
+	}
+	return processed, nil
+}
+
+// messageKey identifies a message by its serialized form, content may be a string or parts
+func messageKey(msg types.Message) string {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Sprintf("%p", &msg)
+	}
+	return string(data)
+}
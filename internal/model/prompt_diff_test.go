@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestDiffPrompts(t *testing.T) {
+	original := []types.Message{
+		{Role: types.RoleSystem, Content: "You are a coding assistant"},
+		{Role: types.RoleUser, Content: "first question"},
+		{Role: types.RoleAssistant, Content: "first answer"},
+		{Role: types.RoleUser, Content: []Content{{Type: ContTypeText, Text: "second question"}}},
+	}
+	processed := []types.Message{
+		{Role: types.RoleSystem, Content: "You are a coding assistant\n\n# Tools ..."},
+		original[1], original[2], original[3],
+		{Role: types.RoleAssistant, Content: "<codebase_search>...</codebase_search>"},
+	}
+
+	ops := DiffPrompts(original, processed)
+	assert.Equal(t, []PromptDiffOp{
+		{Message: &processed[0]},
+		{From: 1, Count: 3},
+		{Message: &processed[4]},
+	}, ops)
+
+	rebuilt, err := ApplyPromptDiff(original, ops)
+	require.NoError(t, err)
+	assert.Equal(t, processed, rebuilt)
+
+	_, err = ApplyPromptDiff(original[:2], ops)
+	assert.Error(t, err, "copies beyond the original prompt are rejected")
+}
+
+func TestChatLog_WithPromptDiff(t *testing.T) {
+	original := []types.Message{
+		{Role: types.RoleSystem, Content: "system"},
+		{Role: types.RoleUser, Content: "old"},
+		{Role: types.RoleUser, Content: "question"},
+	}
+	log := &ChatLog{
+		OriginalPrompt:  original,
+		ProcessedPrompt: []types.Message{original[0], original[2]},
+	}
+
+	compact := log.WithPromptDiff()
+	assert.Nil(t, compact.ProcessedPrompt)
+	assert.Equal(t, []PromptDiffOp{{From: 0, Count: 1}, {From: 2, Count: 1}}, compact.ProcessedPromptDiff)
+	assert.NotNil(t, log.ProcessedPrompt, "the logged entry itself is unchanged")
+
+	data, err := compact.ToCompressedJSON()
+	require.NoError(t, err)
+	restored, err := FromJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, log.ProcessedPrompt, restored.ProcessedPrompt)
+	assert.Nil(t, restored.ProcessedPromptDiff)
+
+	full := &ChatLog{ProcessedPrompt: original}
+	assert.Same(t, full, full.WithPromptDiff(), "logs without the original prompt are stored in full")
+}
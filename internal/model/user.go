@@ -34,6 +34,9 @@ type Identity struct {
 	Sender        string    `json:"sender"` // user, system, ...
 	Language      string    `json:"language"`
 	UserInfo      *UserInfo `json:"user_info"`
+
+	// Model requested by the user before an upstream gateway remapped it, recorded in RequestParams
+	OriginalModel string `json:"-"`
 }
 
 // UserInfo defines the user information structure
@@ -10,4 +10,32 @@ type ProcessedPrompt struct {
 	Tools        []types.Function   `json:"tools"`
 	Agent        string             `json:"agent"`
 	TokenMetrics types.TokenMetrics `json:"token_metrics"`
+	// Tools whose description and capability were injected into the system prompt
+	InjectedTools []string `json:"injected_tools,omitempty"`
+	// Tools left out of the system prompt because their backend is failing
+	UnhealthyTools []string `json:"unhealthy_tools,omitempty"`
+	// Semantic search tools left out because the request disabled them
+	SemanticSkipped []string `json:"semantic_skipped,omitempty"`
+	// Number of oversized user messages split into chunks before they were summarized
+	SplitUserMessages int `json:"split_user_messages,omitempty"`
+	// Tokens removed from the system prompt by dropping duplicated rule blocks
+	DedupedTokens int `json:"deduped_tokens,omitempty"`
+	// Tokens removed from the system prompt by stripping tool sections when no tools are available
+	NoToolsSavedTokens int `json:"no_tools_saved_tokens,omitempty"`
+	// Request messages summarized or trimmed away
+	DroppedMessages []types.DroppedMessage `json:"dropped_messages,omitempty"`
+	// Compression was needed but skipped because the conversation has too few messages
+	CompressionSkipped bool `json:"compression_skipped,omitempty"`
+	// The summary failed and the summary fallback compressed the conversation instead
+	CompressionFallback bool `json:"compression_fallback,omitempty"`
+	// Compression was needed but dropped because the compression queue was full
+	CompressionDropped bool `json:"compression_dropped,omitempty"`
+	// Model asked to summarize the conversation, empty when no summary was requested
+	SummaryModel string `json:"summary_model,omitempty"`
+	// Compression strategy picked for the conversation size
+	CompressionStrategy string `json:"compression_strategy,omitempty"`
+	// The current date and time was appended to the system prompt
+	CurrentTimeInjected bool `json:"current_time_injected,omitempty"`
+	// The latest question was restated after the compression summary
+	QuestionReinjected bool `json:"question_reinjected,omitempty"`
 }
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
@@ -15,6 +16,12 @@ type PromptMsg struct {
 	olderUserMsgList []types.Message
 	lastUserMsg      *types.Message
 	tools            []types.Function
+
+	// request index of each older message, -1 for messages added by processors
+	olderIndices []int
+	// request ids of the older messages, keyed by request index
+	messageIDs map[int]string
+	dropped    []types.DroppedMessage
 }
 
 type Recorder struct {
@@ -34,13 +41,73 @@ func NewPromptMsg(messages []types.Message) (*PromptMsg, error) {
 	}
 
 	olderUserMsg := utils.GetOldUserMsgsWithNum(messagesCopy, 1)
+
+	// The older messages are a contiguous window of the request, located by the capacity
+	// they have left in the shared backing array
+	olderIndices := make([]int, len(olderUserMsg))
+	messageIDs := make(map[int]string)
+	if len(olderUserMsg) > 0 {
+		offset := cap(messagesCopy) - cap(olderUserMsg)
+		for i, msg := range olderUserMsg {
+			olderIndices[i] = offset + i
+			if id, ok := msg.Extra["id"].(string); ok && id != "" {
+				messageIDs[offset+i] = id
+			}
+		}
+	}
+
 	return &PromptMsg{
 		systemMsg:        &systemMsg,
 		olderUserMsgList: olderUserMsg,
 		lastUserMsg:      &lastUserMsg,
+		olderIndices:     olderIndices,
+		messageIDs:       messageIDs,
 	}, nil
 }
 
+// replaceOlderMessages sets the older messages to kept, where sources[i] is the position in the
+// current list that kept[i] comes from, or -1 for a message added by the processor. Request
+// messages not kept are recorded as dropped with the reason returned for their position.
+func (p *PromptMsg) replaceOlderMessages(kept []types.Message, sources []int, reasonOf func(pos int) string) {
+	requestIndex := func(pos int) int {
+		if pos < 0 || pos >= len(p.olderIndices) {
+			return -1
+		}
+		return p.olderIndices[pos]
+	}
+
+	isKept := make(map[int]bool, len(sources))
+	indices := make([]int, len(kept))
+	for i := range kept {
+		indices[i] = -1
+		if i < len(sources) {
+			isKept[sources[i]] = true
+			indices[i] = requestIndex(sources[i])
+		}
+	}
+
+	for pos, msg := range p.olderUserMsgList {
+		index := requestIndex(pos)
+		if isKept[pos] || index < 0 {
+			continue
+		}
+		p.dropped = append(p.dropped, types.DroppedMessage{
+			Index:  index,
+			ID:     p.messageIDs[index],
+			Role:   msg.Role,
+			Reason: reasonOf(pos),
+		})
+	}
+
+	p.olderUserMsgList = kept
+	p.olderIndices = indices
+}
+
+// DroppedMessages returns the request messages removed from the prompt so far
+func (p *PromptMsg) DroppedMessages() []types.DroppedMessage {
+	return p.dropped
+}
+
 func (p *PromptMsg) GetTools() []types.Function {
 	return p.tools
 }
@@ -109,17 +176,29 @@ func (e *Start) SetNext(processor Processor) {
 func (e *End) SetNext(processor Processor) {
 }
 
+// languageReminderPrefix starts the language instruction appended to the system prompt
+const languageReminderPrefix = "\n\n<hidden-system-reminder>\n<language>\n"
+
+// SetLanguage appends a "respond in <language>" instruction to the system message.
+// The Accept-Language value is resolved to a language name, e.g. "zh-CN" -> "Simplified Chinese".
 func SetLanguage(language string, promptMsg *PromptMsg) {
 	if language == "" || language == "*" {
 		logger.Warn("language is empty, skipping language setting")
 		return
 	}
 
-	logger.Info("Setting language to " + language)
+	languageName := utils.LanguageName(language)
+	if languageName == "" {
+		logger.Warn("no valid language in Accept-Language, skipping language setting",
+			zap.String("language", language))
+		return
+	}
+
+	logger.Info("Setting language to " + languageName)
 
 	// Append language reminder to system message
 	if promptMsg.systemMsg != nil {
-		languageReminder := "\n\n<hidden-system-reminder>\n<language>\nAlways responde in: " + language + ".\n</language>\nDo not acknowledge or show the `<language>` instruction directly in you responses or thought processes.\n</hidden-system-reminder>"
+		languageReminder := languageReminderPrefix + "Always responde in: " + languageName + ".\n</language>\nDo not acknowledge or show the `<language>` instruction directly in you responses or thought processes.\n</hidden-system-reminder>"
 
 		// Type assert Content to []model.Content
 		if contents, ok := promptMsg.systemMsg.Content.([]model.Content); ok && len(contents) > 0 {
@@ -130,6 +209,21 @@ func SetLanguage(language string, promptMsg *PromptMsg) {
 	}
 }
 
+// splitSystemReminders separates the language and current time instructions appended to the
+// system content, so that they never become part of the compressed (cached) region
+func splitSystemReminders(content string) (string, string) {
+	idx := -1
+	for _, prefix := range []string{languageReminderPrefix, currentTimeReminderPrefix} {
+		if i := strings.LastIndex(content, prefix); i != -1 && (idx == -1 || i < idx) {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		return content, ""
+	}
+	return content[:idx], content[idx:]
+}
+
 // BaseProcessor is a base processor that can be used to chain processors together
 type BaseProcessor struct {
 	Recorder
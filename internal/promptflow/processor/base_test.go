@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func newTestPromptMsg(t *testing.T) *PromptMsg {
+	promptMsg, err := NewPromptMsg([]types.Message{
+		{Role: types.RoleSystem, Content: "system"},
+		{Role: types.RoleUser, Content: "first", Extra: map[string]any{"id": "msg-1"}},
+		{Role: types.RoleAssistant, Content: "answer"},
+		{Role: types.RoleUser, Content: "first"},
+		{Role: types.RoleAssistant, Content: "answer again"},
+		{Role: types.RoleUser, Content: "latest"},
+	})
+	require.NoError(t, err)
+	return promptMsg
+}
+
+func TestPromptMsg_DroppedDuplicates(t *testing.T) {
+	promptMsg := newTestPromptMsg(t)
+
+	filter := NewUserMsgFilter(nil, "", "", nil)
+	filter.filterDuplicateMessages(promptMsg)
+
+	assert.Len(t, promptMsg.olderUserMsgList, 3)
+	assert.Equal(t, []types.DroppedMessage{
+		{Index: 1, ID: "msg-1", Role: types.RoleUser, Reason: types.DropReasonDuplicate},
+	}, promptMsg.DroppedMessages())
+	assert.Equal(t, []int{2, 3, 4}, promptMsg.olderIndices)
+}
+
+func TestPromptMsg_DroppedBySummary(t *testing.T) {
+	promptMsg := newTestPromptMsg(t)
+
+	u := NewUserCompressor(nil, config.Config{}, nil, nil)
+	retained := promptMsg.olderUserMsgList[2:]
+	u.updatePromptMessages(promptMsg, "summary", 0, 1, retained)
+
+	assert.Equal(t, "summary", promptMsg.olderUserMsgList[0].Content)
+	assert.Equal(t, []types.DroppedMessage{
+		{Index: 1, ID: "msg-1", Role: types.RoleUser, Reason: types.DropReasonTrimmed},
+		{Index: 2, Role: types.RoleAssistant, Reason: types.DropReasonSummarized},
+	}, promptMsg.DroppedMessages())
+
+	// A later step dropping the summary records nothing, it is not a request message
+	promptMsg.replaceOlderMessages(promptMsg.olderUserMsgList[1:], []int{1, 2}, func(int) string {
+		return types.DropReasonTrimmed
+	})
+	assert.Len(t, promptMsg.DroppedMessages(), 2)
+}
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrCompressQueueFull is returned when a compression is dropped because every worker is busy
+// and the queue is full, the request then proceeds uncompressed
+var ErrCompressQueueFull = errors.New("compression queue full")
+
+// CompressPool bounds the summary model calls of prompt compression. Compressions beyond the
+// number of workers wait in a queue, and are dropped when it is full.
+type CompressPool struct {
+	tasks chan *compressTask
+
+	mu           sync.Mutex
+	onQueueDepth func(depth int)
+}
+
+// compressTask is a queued compression, done is closed once it ran or was skipped
+type compressTask struct {
+	ctx  context.Context
+	run  func()
+	ran  bool
+	done chan struct{}
+}
+
+// NewCompressPool starts workers goroutines serving a queue of queueSize compressions
+func NewCompressPool(workers, queueSize int) *CompressPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	pool := &CompressPool{tasks: make(chan *compressTask, queueSize)}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// SetQueueDepthObserver registers a callback receiving the number of queued compressions
+func (p *CompressPool) SetQueueDepthObserver(observer func(depth int)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onQueueDepth = observer
+}
+
+// Do runs the compression on a worker and waits for it to finish. It returns ErrCompressQueueFull
+// without running it when the queue is full, and the context error when ctx ends first; run must
+// then not share state with the caller.
+func (p *CompressPool) Do(ctx context.Context, run func()) error {
+	task := &compressTask{ctx: ctx, run: run, done: make(chan struct{})}
+	select {
+	case p.tasks <- task:
+		p.reportQueueDepth()
+	default:
+		return ErrCompressQueueFull
+	}
+
+	select {
+	case <-task.done:
+		if !task.ran {
+			return ctx.Err()
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work runs queued compressions until the process exits, skipping those whose request ended
+func (p *CompressPool) work() {
+	for task := range p.tasks {
+		p.reportQueueDepth()
+		if task.ctx.Err() == nil {
+			task.run()
+			task.ran = true
+		}
+		close(task.done)
+	}
+}
+
+// reportQueueDepth reports the number of compressions waiting for a worker
+func (p *CompressPool) reportQueueDepth() {
+	p.mu.Lock()
+	observer := p.onQueueDepth
+	p.mu.Unlock()
+
+	if observer != nil {
+		observer(len(p.tasks))
+	}
+}
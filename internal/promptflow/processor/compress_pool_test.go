@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressPool_QueueFull(t *testing.T) {
+	pool := NewCompressPool(1, 1)
+	var depthMu sync.Mutex
+	maxDepth := 0
+	pool.SetQueueDepthObserver(func(depth int) {
+		depthMu.Lock()
+		defer depthMu.Unlock()
+		maxDepth = max(maxDepth, depth)
+	})
+
+	release := make(chan struct{})
+	var running, ran atomic.Int32
+	compress := func() {
+		running.Add(1)
+		<-release
+		running.Add(-1)
+		ran.Add(1)
+	}
+
+	// One compression occupies the worker, one waits in the queue
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, pool.Do(context.Background(), compress))
+		}()
+		if i == 0 {
+			assert.Eventually(t, func() bool { return running.Load() == 1 }, time.Second, time.Millisecond)
+		}
+	}
+	assert.Eventually(t, func() bool { return len(pool.tasks) == 1 }, time.Second, time.Millisecond)
+
+	// A full queue drops the compression without running it
+	assert.ErrorIs(t, pool.Do(context.Background(), func() { t.Error("dropped compression ran") }), ErrCompressQueueFull)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), ran.Load())
+	depthMu.Lock()
+	assert.Equal(t, 1, maxDepth)
+	depthMu.Unlock()
+}
+
+func TestCompressPool_CanceledRequest(t *testing.T) {
+	pool := NewCompressPool(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = pool.Do(context.Background(), func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	// A request ending while queued returns at once and its compression is skipped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, pool.Do(ctx, func() { t.Error("canceled compression ran") }), context.Canceled)
+
+	close(release)
+	assert.Eventually(t, func() bool { return len(pool.tasks) == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Do(context.Background(), func() {}))
+}
@@ -0,0 +1,25 @@
+package processor
+
+import (
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// SelectCompressStrategy returns the strategy of the first bucket the conversation size falls
+// into, and the full summary when no bucket matches or the threshold is not set
+func SelectCompressStrategy(cfg config.ContextCompressConfig, tokens int) string {
+	if cfg.TokenThreshold <= 0 {
+		return config.CompressStrategyFullSummary
+	}
+
+	overRatio := float64(tokens) / float64(cfg.TokenThreshold)
+	for _, bucket := range cfg.StrategyBuckets {
+		if bucket.MaxOverRatio > 0 && overRatio > bucket.MaxOverRatio {
+			continue
+		}
+		switch bucket.Strategy {
+		case config.CompressStrategyTrimOnly, config.CompressStrategySummarizeMiddle, config.CompressStrategyFullSummary:
+			return bucket.Strategy
+		}
+	}
+	return config.CompressStrategyFullSummary
+}
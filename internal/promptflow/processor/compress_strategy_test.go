@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestSelectCompressStrategy(t *testing.T) {
+	cfg := config.ContextCompressConfig{
+		TokenThreshold: 10000,
+		StrategyBuckets: []config.CompressStrategyBucket{
+			{MaxOverRatio: 1.3, Strategy: config.CompressStrategyTrimOnly},
+			{MaxOverRatio: 2, Strategy: config.CompressStrategySummarizeMiddle},
+			{Strategy: config.CompressStrategyFullSummary},
+		},
+	}
+
+	assert.Equal(t, config.CompressStrategyTrimOnly, SelectCompressStrategy(cfg, 11000))
+	assert.Equal(t, config.CompressStrategyTrimOnly, SelectCompressStrategy(cfg, 13000))
+	assert.Equal(t, config.CompressStrategySummarizeMiddle, SelectCompressStrategy(cfg, 15000))
+	assert.Equal(t, config.CompressStrategyFullSummary, SelectCompressStrategy(cfg, 50000))
+}
+
+func TestSelectCompressStrategy_Defaults(t *testing.T) {
+	assert.Equal(t, config.CompressStrategyFullSummary,
+		SelectCompressStrategy(config.ContextCompressConfig{TokenThreshold: 10000}, 11000))
+
+	// Unknown strategies are skipped, sizes beyond every bucket get the full summary
+	cfg := config.ContextCompressConfig{
+		TokenThreshold: 10000,
+		StrategyBuckets: []config.CompressStrategyBucket{
+			{MaxOverRatio: 1.5, Strategy: "truncate"},
+			{MaxOverRatio: 1.5, Strategy: config.CompressStrategySummarizeMiddle},
+		},
+	}
+	assert.Equal(t, config.CompressStrategySummarizeMiddle, SelectCompressStrategy(cfg, 12000))
+	assert.Equal(t, config.CompressStrategyFullSummary, SelectCompressStrategy(cfg, 20000))
+}
+
+func TestUserCompressor_updatePromptMessages_KeepsHead(t *testing.T) {
+	promptMsg := newTestPromptMsg(t)
+
+	u := NewUserCompressor(nil, config.Config{}, nil, nil)
+	retained := promptMsg.olderUserMsgList[3:]
+	u.updatePromptMessages(promptMsg, "summary", 1, 2, retained)
+
+	assert.Len(t, promptMsg.olderUserMsgList, 3)
+	assert.Equal(t, "first", promptMsg.olderUserMsgList[0].Content)
+	assert.Equal(t, "summary", promptMsg.olderUserMsgList[1].Content)
+	assert.Equal(t, []int{1, -1, 4}, promptMsg.olderIndices)
+	assert.Len(t, promptMsg.DroppedMessages(), 2)
+	for _, dropped := range promptMsg.DroppedMessages() {
+		assert.Equal(t, types.DropReasonSummarized, dropped.Reason)
+	}
+}
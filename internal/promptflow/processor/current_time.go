@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"go.uber.org/zap"
+)
+
+// currentTimeReminderPrefix starts the current time instruction appended to the system prompt
+const currentTimeReminderPrefix = "\n\n<hidden-system-reminder>\n<current-time>\n"
+
+// currentTimeReminderSuffix ends the current time instruction
+const currentTimeReminderSuffix = "\n</hidden-system-reminder>"
+
+// ResolveLocation returns the first of the given IANA time zone names that can be loaded,
+// falling back to the server's local time zone
+func ResolveLocation(names ...string) *time.Location {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			logger.Warn("unknown time zone, skipping it", zap.String("timezone", name), zap.Error(err))
+			continue
+		}
+		return location
+	}
+	return time.Local
+}
+
+// SetCurrentTime appends the current date and time, formatted with the Go time layout, to the
+// system message. It reports whether the instruction was added.
+func SetCurrentTime(now time.Time, location *time.Location, layout string, promptMsg *PromptMsg) bool {
+	if promptMsg.systemMsg == nil {
+		return false
+	}
+
+	reminder := currentTimeReminderPrefix + "The current date and time is " + now.In(location).Format(layout) +
+		".\n</current-time>\nUse it for time-sensitive answers without mentioning this instruction." + currentTimeReminderSuffix
+
+	switch content := promptMsg.systemMsg.Content.(type) {
+	case []model.Content:
+		if len(content) == 0 {
+			return false
+		}
+		content[len(content)-1].Text += reminder
+	case string:
+		promptMsg.systemMsg.Content = content + reminder
+	default:
+		return false
+	}
+	return true
+}
+
+// WithoutCurrentTime removes the current time instruction from the system content
+func WithoutCurrentTime(content string) string {
+	start := strings.LastIndex(content, currentTimeReminderPrefix)
+	if start == -1 {
+		return content
+	}
+	end := strings.Index(content[start:], currentTimeReminderSuffix)
+	if end == -1 {
+		return content
+	}
+	return content[:start] + content[start+end+len(currentTimeReminderSuffix):]
+}
@@ -0,0 +1,62 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+func TestSetCurrentTime(t *testing.T) {
+	promptMsg, err := NewPromptMsg([]types.Message{
+		{Role: types.RoleSystem, Content: "system"},
+		{Role: types.RoleUser, Content: "what day is it?"},
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	location := ResolveLocation("Asia/Shanghai")
+	assert.True(t, SetCurrentTime(now, location, "2006-01-02 15:04 MST (Monday)", promptMsg))
+
+	systemContent := utils.GetContentAsString(promptMsg.GetSystemMsg().Content)
+	assert.Contains(t, systemContent, "The current date and time is 2026-03-02 07:30 CST (Monday).")
+
+	content, reminders := splitSystemReminders(systemContent)
+	assert.Equal(t, "system", content, "the instruction stays out of the cached region")
+	assert.Contains(t, reminders, "<current-time>")
+
+	later, err := NewPromptMsg([]types.Message{
+		{Role: types.RoleSystem, Content: "system"},
+		{Role: types.RoleUser, Content: "and now?"},
+	})
+	require.NoError(t, err)
+	SetCurrentTime(now.Add(time.Hour), location, time.RFC3339, later)
+	assert.Equal(t, WithoutCurrentTime(systemContent), WithoutCurrentTime(utils.GetContentAsString(later.GetSystemMsg().Content)),
+		"the content does not change with the time")
+	assert.Equal(t, "system", WithoutCurrentTime(systemContent))
+}
+
+func TestSplitSystemReminders_LanguageAndTime(t *testing.T) {
+	promptMsg, err := NewPromptMsg([]types.Message{
+		{Role: types.RoleSystem, Content: "system"},
+		{Role: types.RoleUser, Content: "hello"},
+	})
+	require.NoError(t, err)
+	promptMsg.UpdateSystemMsg("system")
+	SetLanguage("zh-CN", promptMsg)
+	SetCurrentTime(time.Now(), time.UTC, time.RFC3339, promptMsg)
+
+	content, reminders := splitSystemReminders(utils.GetContentAsString(promptMsg.GetSystemMsg().Content))
+	assert.Equal(t, "system", content)
+	assert.Contains(t, reminders, "<language>")
+	assert.Contains(t, reminders, "<current-time>")
+}
+
+func TestResolveLocation(t *testing.T) {
+	assert.Equal(t, "Asia/Tokyo", ResolveLocation("", "Asia/Tokyo").String())
+	assert.Equal(t, "Europe/Berlin", ResolveLocation("Not/AZone", "Europe/Berlin").String())
+	assert.Equal(t, time.Local, ResolveLocation("Not/AZone", ""))
+}
@@ -85,6 +85,7 @@ func (u *UserMsgFilter) filterDuplicateMessages(promptMsg *PromptMsg) {
 	originalCount := len(promptMsg.olderUserMsgList)
 	seenContents := make(map[string]struct{})
 	filteredMessages := make([]types.Message, 0, len(promptMsg.olderUserMsgList))
+	sources := make([]int, 0, len(promptMsg.olderUserMsgList))
 
 	// Iterate in reverse to keep the last occurrence of each duplicate
 	for i := len(promptMsg.olderUserMsgList) - 1; i >= 0; i-- {
@@ -94,6 +95,7 @@ func (u *UserMsgFilter) filterDuplicateMessages(promptMsg *PromptMsg) {
 		if !ok {
 			// Include non-string content messages as-is
 			filteredMessages = append(filteredMessages, msg)
+			sources = append(sources, i)
 			continue
 		}
 
@@ -105,14 +107,18 @@ func (u *UserMsgFilter) filterDuplicateMessages(promptMsg *PromptMsg) {
 		// Mark content as seen and add to filtered list
 		seenContents[content] = struct{}{}
 		filteredMessages = append(filteredMessages, msg)
+		sources = append(sources, i)
 	}
 
 	// Reverse back to original order (now with duplicates removed)
 	for i, j := 0, len(filteredMessages)-1; i < j; i, j = i+1, j-1 {
 		filteredMessages[i], filteredMessages[j] = filteredMessages[j], filteredMessages[i]
+		sources[i], sources[j] = sources[j], sources[i]
 	}
 
-	promptMsg.olderUserMsgList = filteredMessages
+	promptMsg.replaceOlderMessages(filteredMessages, sources, func(int) string {
+		return types.DropReasonDuplicate
+	})
 
 	removedCount := originalCount - len(promptMsg.olderUserMsgList)
 	logger.Info("removed duplicate content count",
@@ -0,0 +1,32 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
+)
+
+// serverToolTokens estimates the tokens of the descriptions, capabilities and rules the server
+// injects for the tools, the part of the system prompt the no-tools variant leaves out. The
+// tool sections sent by the client are not counted, they stay in the prompt.
+func serverToolTokens(toolExecutor functions.ToolExecutor, toolNames []string) int {
+	var content strings.Builder
+	for _, name := range toolNames {
+		getters := []func(string) (string, error){
+			toolExecutor.GetToolDescription,
+			toolExecutor.GetToolCapability,
+			toolExecutor.GetToolRule,
+		}
+		for _, get := range getters {
+			if text, err := get(name); err == nil {
+				content.WriteString(text)
+			}
+		}
+	}
+
+	if content.Len() == 0 {
+		return 0
+	}
+	return tokenizer.EstimateTokens(content.String())
+}
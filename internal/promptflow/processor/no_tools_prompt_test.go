@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+// promptToolExecutor is a ready tool executor with fixed prompt texts for each tool
+type promptToolExecutor struct {
+	functions.ToolExecutor
+	tools   []string
+	healthy bool
+}
+
+func (e *promptToolExecutor) CheckToolReady(_ context.Context, _ string) (bool, error) {
+	return true, nil
+}
+
+func (e *promptToolExecutor) ToolHealthy(_ string) bool {
+	return e.healthy
+}
+
+func (e *promptToolExecutor) GetToolDescription(toolName string) (string, error) {
+	return "## " + toolName + "\nSearches the codebase for relevant code.", nil
+}
+
+func (e *promptToolExecutor) GetToolCapability(toolName string) (string, error) {
+	return "- You can use " + toolName + " to find code.\n", nil
+}
+
+func (e *promptToolExecutor) GetToolRule(toolName string) (string, error) {
+	return "- Prefer " + toolName + " over reading whole files.\n", nil
+}
+
+func (e *promptToolExecutor) GetAllTools() []string {
+	return e.tools
+}
+
+func TestXmlToolAdapter_NoToolsPrompt(t *testing.T) {
+	systemPrompt := "You are an assistant.\n\n====\n\nTOOL USE\n\nYou have access to a set of tools.\n\n# Tools\n\n" +
+		"## read_file\nRead a file.\n\n====\n\nCAPABILITIES\n\nYou can read files.\n\n====\n\nRULES\n\n- Be concise.\n"
+	toolConfig := &config.ToolConfig{NoToolsPrompt: config.NoToolsPromptConfig{Enabled: true}}
+
+	execute := func(ctx context.Context, executor *promptToolExecutor, toolConfig *config.ToolConfig) (*XmlToolAdapter, string) {
+		promptMsg, err := NewPromptMsg([]types.Message{
+			{Role: types.RoleSystem, Content: systemPrompt},
+			{Role: types.RoleUser, Content: "hello"},
+		})
+		require.NoError(t, err)
+		x := NewXmlToolAdapter(ctx, executor, toolConfig, "code", "")
+		x.Execute(promptMsg)
+		return x, utils.GetContentAsString(promptMsg.GetSystemMsg().Content)
+	}
+	executor := &promptToolExecutor{tools: []string{"codebase_search"}, healthy: true}
+
+	x, prompt := execute(context.Background(), executor, toolConfig)
+	assert.Equal(t, []string{"codebase_search"}, x.InjectedTools)
+	assert.Contains(t, prompt, "## codebase_search")
+	assert.Zero(t, x.NoToolsSavedTokens)
+
+	x, prompt = execute(functions.WithToolsDisabled(context.Background()), executor, toolConfig)
+	assert.Empty(t, x.InjectedTools)
+	assert.Equal(t, systemPrompt, prompt, "the client's tool sections are kept")
+	assert.Positive(t, x.NoToolsSavedTokens)
+
+	x, prompt = execute(functions.WithToolsDisabled(context.Background()), executor, &config.ToolConfig{})
+	assert.Contains(t, prompt, "## codebase_search", "the variant is opt-in")
+	assert.Zero(t, x.NoToolsSavedTokens)
+
+	noAgentTools := &config.ToolConfig{
+		NoToolsPrompt:     config.NoToolsPromptConfig{Enabled: true},
+		EnabledAgentTools: map[string][]string{"code": {}},
+	}
+	x, prompt = execute(context.Background(), executor, noAgentTools)
+	assert.Equal(t, systemPrompt, prompt)
+
+	unhealthy := &promptToolExecutor{tools: []string{"codebase_search"}}
+	x, prompt = execute(context.Background(), unhealthy, toolConfig)
+	assert.Empty(t, x.InjectedTools)
+	assert.Equal(t, []string{"codebase_search"}, x.UnhealthyTools)
+	assert.Contains(t, prompt, "TOOL USE")
+	assert.Zero(t, x.NoToolsSavedTokens, "tools unavailable for now do not select the variant")
+}
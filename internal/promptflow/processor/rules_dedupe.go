@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
+	"go.uber.org/zap"
+)
+
+// defaultDedupeMarkers match headings of tool-use, rule and guideline blocks
+var defaultDedupeMarkers = []string{
+	`^#{1,4} .*Tool Use`,
+	`^#{1,4} .*(Rules|RULES)\s*$`,
+	`^#{1,4} .*(Guidelines|GUIDELINES)\s*$`,
+}
+
+// compileDedupeMarkers compiles marker patterns, skipping invalid ones
+func compileDedupeMarkers(patterns []string) []*regexp.Regexp {
+	if len(patterns) == 0 {
+		patterns = defaultDedupeMarkers
+	}
+
+	markers := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			logger.Warn("invalid dedupe marker pattern, skipped",
+				zap.String("pattern", pattern), zap.Error(err))
+			continue
+		}
+		markers = append(markers, re)
+	}
+	return markers
+}
+
+// dedupeRuleBlocks removes repeated blocks from the system prompt, keeping the first occurrence.
+// A block starts at a line matching one of the markers and ends before the next heading,
+// marker line or "====" section separator. Returns the content and the estimated tokens removed.
+func dedupeRuleBlocks(content string, markers []*regexp.Regexp) (string, int) {
+	if len(markers) == 0 || content == "" {
+		return content, 0
+	}
+
+	isMarker := func(line string) bool {
+		trimmed := strings.TrimSpace(line)
+		for _, re := range markers {
+			if re.MatchString(trimmed) {
+				return true
+			}
+		}
+		return false
+	}
+	isBoundary := func(line string) bool {
+		trimmed := strings.TrimSpace(line)
+		return strings.HasPrefix(trimmed, "#") || trimmed == "====" || isMarker(line)
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	seen := make(map[string]struct{})
+	var result strings.Builder
+	var removed strings.Builder
+
+	for i := 0; i < len(lines); {
+		if !isMarker(lines[i]) {
+			result.WriteString(lines[i])
+			i++
+			continue
+		}
+
+		// Collect the block starting at the marker line
+		end := i + 1
+		for end < len(lines) && !isBoundary(lines[end]) {
+			end++
+		}
+		block := strings.Join(lines[i:end], "")
+
+		key := strings.Join(strings.Fields(block), " ")
+		if _, exists := seen[key]; exists {
+			removed.WriteString(block)
+		} else {
+			seen[key] = struct{}{}
+			result.WriteString(block)
+		}
+		i = end
+	}
+
+	if removed.Len() == 0 {
+		return content, 0
+	}
+	return result.String(), tokenizer.EstimateTokens(removed.String())
+}
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeRuleBlocks(t *testing.T) {
+	toolUse := "# Tool Use Guidelines\n\n1. Use one tool per message.\n2. Wait for the result.\n"
+	editRules := "## Editing Rules\nAlways read a file before editing it.\n"
+
+	tests := []struct {
+		name          string
+		content       string
+		markers       []string
+		expected      string
+		expectRemoved bool
+	}{
+		{
+			name:          "duplicated tool use block is removed",
+			content:       "You are an assistant.\n\n" + toolUse + "\n====\n\n" + toolUse + "\n# Other\nkeep me\n",
+			expected:      "You are an assistant.\n\n" + toolUse + "\n====\n\n" + "# Other\nkeep me\n",
+			expectRemoved: true,
+		},
+		{
+			name:          "duplicates with different whitespace are removed",
+			content:       editRules + "\n# Tools\n" + strings.ReplaceAll(editRules, " ", "  "),
+			expected:      editRules + "\n# Tools\n",
+			expectRemoved: true,
+		},
+		{
+			name:     "distinct blocks with the same heading are kept",
+			content:  editRules + "## Editing Rules\nNever edit generated files.\n",
+			expected: editRules + "## Editing Rules\nNever edit generated files.\n",
+		},
+		{
+			name:     "blocks not matching markers are kept",
+			content:  "# Notes\nsame\n# Notes\nsame\n",
+			expected: "# Notes\nsame\n# Notes\nsame\n",
+		},
+		{
+			name:          "custom markers",
+			content:       "# Notes\nsame\n# Notes\nsame\n",
+			markers:       []string{`^# Notes$`},
+			expected:      "# Notes\nsame\n",
+			expectRemoved: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, removed := dedupeRuleBlocks(tt.content, compileDedupeMarkers(tt.markers))
+			assert.Equal(t, tt.expected, result)
+			if tt.expectRemoved {
+				assert.Greater(t, removed, 0)
+			} else {
+				assert.Equal(t, 0, removed)
+			}
+		})
+	}
+}
+
+func TestCompileDedupeMarkers_SkipsInvalidPattern(t *testing.T) {
+	markers := compileDedupeMarkers([]string{`^# Valid$`, `(`})
+	assert.Len(t, markers, 1)
+}
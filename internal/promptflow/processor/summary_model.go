@@ -0,0 +1,23 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+// SelectSummaryModel returns the model used to summarize context for the given main model,
+// and the name of the tier that selected it. It falls back to SummaryModel with an empty tier.
+func SelectSummaryModel(cfg config.ContextCompressConfig, mainModel string) (string, string) {
+	for _, tier := range cfg.SummaryModelTiers {
+		if tier.SummaryModel == "" {
+			continue
+		}
+		for _, m := range tier.MainModels {
+			if strings.EqualFold(m, mainModel) {
+				return tier.SummaryModel, tier.Name
+			}
+		}
+	}
+	return cfg.SummaryModel, ""
+}
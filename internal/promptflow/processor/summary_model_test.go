@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestSelectSummaryModel(t *testing.T) {
+	cfg := config.ContextCompressConfig{
+		SummaryModel: "summary-default",
+		SummaryModelTiers: []config.SummaryModelTier{
+			{Name: "premium", MainModels: []string{"big-model", "Huge-Model"}, SummaryModel: "summary-mini"},
+			{Name: "broken", MainModels: []string{"other-model"}},
+		},
+	}
+
+	tests := []struct {
+		mainModel   string
+		expectModel string
+		expectTier  string
+	}{
+		{"big-model", "summary-mini", "premium"},
+		{"huge-model", "summary-mini", "premium"},
+		{"other-model", "summary-default", ""},
+		{"unknown", "summary-default", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mainModel, func(t *testing.T) {
+			model, tier := SelectSummaryModel(cfg, tt.mainModel)
+			assert.Equal(t, tt.expectModel, model)
+			assert.Equal(t, tt.expectTier, tier)
+		})
+	}
+}
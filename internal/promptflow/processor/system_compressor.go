@@ -81,6 +81,27 @@ func generateHash(content string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// SystemPromptCacheKey returns the SystemPromptCache key of the system content, the hash of the
+// compressed region starting at splitStr. It reports false when the content has no such region.
+func SystemPromptCacheKey(systemContent, splitStr string) (string, bool) {
+	_, region, _, ok := splitCompressedRegion(systemContent, splitStr)
+	if !ok {
+		return "", false
+	}
+	return generateHash(region), true
+}
+
+// splitCompressedRegion splits the system content into the part before splitStr, the region that
+// is compressed and cached, and the per-request instructions appended after it
+func splitCompressedRegion(systemContent, splitStr string) (string, string, string, bool) {
+	index := strings.Index(systemContent, splitStr)
+	if index == -1 {
+		return systemContent, "", "", false
+	}
+	region, reminders := splitSystemReminders(systemContent[index:])
+	return systemContent[:index], region, reminders, true
+}
+
 type SystemCompressor struct {
 	Recorder
 	systemPromptSplitStr string
@@ -152,9 +173,9 @@ func (p *SystemCompressor) processSystemMessageWithCache(msg *types.Message) *ty
 
 // processContentWithCache handles the caching logic for system content
 func (p *SystemCompressor) processContentWithCache(content []model.Content, systemContent string) *types.Message {
-	// Check if system prompt contains SystemPromptSplitStr
-	toolGuidelinesIndex := strings.Index(systemContent, p.systemPromptSplitStr)
-	if toolGuidelinesIndex == -1 {
+	// Split content, keeping the per-request instructions out of the cached region
+	contentBeforeGuidelines, contentToCompress, reminders, ok := splitCompressedRegion(systemContent, p.systemPromptSplitStr)
+	if !ok {
 		logger.Warn("No SystemPromptSplitStr found",
 			zap.String("method", "processSystemMessageWithCache"),
 		)
@@ -164,10 +185,6 @@ func (p *SystemCompressor) processContentWithCache(content []model.Content, syst
 		}
 	}
 
-	// Split content
-	contentBeforeGuidelines := systemContent[:toolGuidelinesIndex]
-	contentToCompress := systemContent[toolGuidelinesIndex:]
-
 	// Try to get from cache
 	systemHash := generateHash(contentToCompress)
 	cache := GetSystemPromptCache()
@@ -175,7 +192,7 @@ func (p *SystemCompressor) processContentWithCache(content []model.Content, syst
 		logger.Info("using cached compressed system prompt",
 			zap.String("method", "processSystemMessageWithCache"),
 		)
-		content[0].Text = contentBeforeGuidelines + compressedContent
+		content[0].Text = contentBeforeGuidelines + compressedContent + reminders
 		return &types.Message{
 			Role:    types.RoleSystem,
 			Content: content,
@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+func TestSystemPromptCacheKey(t *testing.T) {
+	const splitStr = "====\n\nRULES"
+	promptMsg, err := NewPromptMsg([]types.Message{
+		{Role: types.RoleSystem, Content: "You are an agent.\n" + splitStr + "\n- keep answers short"},
+		{Role: types.RoleUser, Content: "hello"},
+	})
+	require.NoError(t, err)
+	SetCurrentTime(time.Now(), time.UTC, time.RFC3339, promptMsg)
+	systemContent := utils.GetContentAsString(promptMsg.GetSystemMsg().Content)
+
+	key, ok := SystemPromptCacheKey(systemContent, splitStr)
+	require.True(t, ok)
+	other, _ := SystemPromptCacheKey("Another agent.\n"+splitStr+"\n- keep answers short", splitStr)
+	assert.Equal(t, key, other, "only the compressed region is hashed")
+
+	GetSystemPromptCache().Set(key, "compressed rules")
+	compressor := NewSystemCompressor(splitStr, nil)
+	msg := compressor.processContentWithCache([]model.Content{{Type: model.ContTypeText, Text: systemContent}}, systemContent)
+	assert.Contains(t, utils.GetContentAsString(msg.Content), "You are an agent.\ncompressed rules",
+		"the key is the one the compressor serves from")
+
+	_, ok = SystemPromptCacheKey("no rules here", splitStr)
+	assert.False(t, ok)
+}
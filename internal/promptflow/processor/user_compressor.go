@@ -57,13 +57,30 @@ Example summary structure:
 
 Output only the summary of the conversation so far, without any additional commentary or explanation.`
 
-// Deprecated
+// UserCompressor summarizes the older user-side messages once they exceed the token threshold
+// of ContextCompressConfig. It is only part of the prompt chain when EnableUserCompressor is set,
+// and it does nothing unless EnableCompress is set
 type UserCompressor struct {
 	Recorder
+	// Skipped is set when compression was needed but skipped due to MinMessagesForCompression
+	Skipped bool
+	// FallbackUsed is set when the summary failed and fallback compression was applied
+	FallbackUsed bool
+	// SummaryRequested is set when the summary model was asked for a summary
+	SummaryRequested bool
+	// QuestionReinjected is set when the latest question was restated after the summary
+	QuestionReinjected bool
+	// Strategy is the compression strategy picked for the conversation size
+	Strategy string
+	// Dropped is set when the summary was not requested because the compression queue was full
+	Dropped bool
+
 	ctx          context.Context
 	config       config.Config
 	llmClient    client.LLMInterface
 	tokenCounter *tokenizer.TokenCounter
+	splitter     *UserMsgSplitter
+	pool         *CompressPool
 
 	next Processor
 }
@@ -79,9 +96,20 @@ func NewUserCompressor(
 		config:       config,
 		llmClient:    llmClient,
 		tokenCounter: tokenCounter,
+		splitter:     NewUserMsgSplitter(config.ContextCompressConfig.SplitUserMessageTokens, tokenCounter),
 	}
 }
 
+// SetPool bounds the summary model calls with the pool, without one every request calls it directly
+func (u *UserCompressor) SetPool(pool *CompressPool) {
+	u.pool = pool
+}
+
+// SplitMessages returns the number of oversized messages split before they were summarized
+func (u *UserCompressor) SplitMessages() int {
+	return u.splitter.SplitMessages
+}
+
 func (u *UserCompressor) Execute(promptMsg *PromptMsg) {
 	const method = "UserCompressor.Execute"
 
@@ -113,8 +141,42 @@ func (u *UserCompressor) Execute(promptMsg *PromptMsg) {
 		return
 	}
 
+	// Short conversations are not summarized, there is too little context to lose
+	minMessages := u.config.ContextCompressConfig.MinMessagesForCompression
+	if minMessages > 0 && len(userMsgList) < minMessages {
+		logger.Info("compression skipped, conversation too short",
+			zap.Int("messages", len(userMsgList)),
+			zap.Int("minMessagesForCompression", minMessages),
+			zap.Int("tokens", userMessageTokens),
+			zap.String("method", method),
+		)
+		u.Skipped = true
+		u.passToNext(promptMsg)
+		return
+	}
+
+	// Conversations barely over the threshold are compressed more lightly
+	u.Strategy = SelectCompressStrategy(u.config.ContextCompressConfig, userMessageTokens)
+	logger.Info("compression strategy selected",
+		zap.String("strategy", u.Strategy),
+		zap.Int("tokens", userMessageTokens),
+		zap.Int("tokenThreshold", u.config.ContextCompressConfig.TokenThreshold),
+		zap.String("method", method),
+	)
+	if u.Strategy == config.CompressStrategyTrimOnly {
+		u.applyTrimOnly(promptMsg)
+		u.passToNext(promptMsg)
+		return
+	}
+
+	// The first message usually states the task, summarize_middle leaves it as it is
+	keepHead := 0
+	if u.Strategy == config.CompressStrategySummarizeMiddle && len(promptMsg.olderUserMsgList) > 0 {
+		keepHead = 1
+	}
+
 	// Split out the messages that need to be summarized from olderUserMsgList according to the threshold
-	messagesToSummarize, retainedMessages := u.trimMessagesToTokenThreshold(promptMsg.olderUserMsgList)
+	messagesToSummarize, retainedMessages := u.trimMessagesToTokenThreshold(promptMsg.olderUserMsgList[keepHead:])
 	if len(messagesToSummarize) == 0 {
 		logger.Info("no messages to summarize", zap.String("method", method))
 		u.passToNext(promptMsg)
@@ -122,6 +184,16 @@ func (u *UserCompressor) Execute(promptMsg *PromptMsg) {
 	}
 
 	summary, err := u.compressMessages(messagesToSummarize)
+	if errors.Is(err, ErrCompressQueueFull) {
+		logger.Warn("compression queue full, proceeding uncompressed",
+			zap.Int("tokens", userMessageTokens),
+			zap.String("method", method),
+		)
+		u.Dropped = true
+		u.passToNext(promptMsg)
+		return
+	}
+	u.SummaryRequested = true
 	if err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(u.ctx.Err(), context.Canceled) {
 			logger.Warn("Context canceled during message compression",
@@ -135,12 +207,16 @@ func (u *UserCompressor) Execute(promptMsg *PromptMsg) {
 			)
 		}
 		u.Err = err
+		u.applyFallbackCompression(promptMsg)
 		u.passToNext(promptMsg)
 		return
 	}
 
-	u.updatePromptMessages(promptMsg, summary, retainedMessages)
+	u.updatePromptMessages(promptMsg, summary, keepHead, len(messagesToSummarize), retainedMessages)
 	u.Handled = true
+	if u.config.ContextCompressConfig.ReinjectQuestion {
+		u.reinjectQuestion(promptMsg)
+	}
 	u.passToNext(promptMsg)
 }
 
@@ -162,30 +238,60 @@ func (u *UserCompressor) compressMessages(messages []types.Message) (string, err
 	// Add final user instruction
 	messagesToSummarize := make([]types.Message, len(messages), len(messages)+1)
 	copy(messagesToSummarize, messages)
+	u.splitter.Split(messagesToSummarize)
 	messagesToSummarize = append(messagesToSummarize, types.Message{
 		Role:    types.RoleUser,
 		Content: "Summarize the conversation so far, as described in the prompt instructions.",
 	})
 
-	summary, err := u.llmClient.GenerateContent(
-		u.ctx,
-		USER_SUMMARY_PROMPT,
-		messagesToSummarize,
-	)
+	var summary string
+	var err error
+	generate := func() {
+		summary, err = u.llmClient.GenerateContent(
+			u.ctx,
+			USER_SUMMARY_PROMPT,
+			messagesToSummarize,
+		)
+	}
+	if u.pool == nil {
+		generate()
+	} else if poolErr := u.pool.Do(u.ctx, generate); poolErr != nil {
+		return "", poolErr
+	}
 	if err != nil {
 		return "", fmt.Errorf("LLM generate content failed in UserCompressor: %w", err)
 	}
 	return summary, nil
 }
 
-func (u *UserCompressor) updatePromptMessages(promptMsg *PromptMsg, summary string, retained []types.Message) {
+// updatePromptMessages replaces the older messages between the first keepHead ones and the
+// retained ones with the summary. Of the replaced messages the last summarized ones went into
+// the summary, the earlier ones were trimmed to fit the summary model.
+func (u *UserCompressor) updatePromptMessages(promptMsg *PromptMsg, summary string, keepHead int, summarized int,
+	retained []types.Message) {
 	var compressedMessages []types.Message
+	compressedMessages = append(compressedMessages, promptMsg.olderUserMsgList[:keepHead]...)
 	compressedMessages = append(compressedMessages, types.Message{
 		Role:    types.RoleAssistant,
 		Content: summary,
 	})
 	compressedMessages = append(compressedMessages, retained...)
-	promptMsg.olderUserMsgList = compressedMessages
+
+	retainedStart := len(promptMsg.olderUserMsgList) - len(retained)
+	sources := make([]int, 0, len(compressedMessages))
+	for i := 0; i < keepHead; i++ {
+		sources = append(sources, i)
+	}
+	sources = append(sources, -1)
+	for i := range retained {
+		sources = append(sources, retainedStart+i)
+	}
+	promptMsg.replaceOlderMessages(compressedMessages, sources, func(pos int) string {
+		if pos < retainedStart-summarized {
+			return types.DropReasonTrimmed
+		}
+		return types.DropReasonSummarized
+	})
 }
 
 func (u *UserCompressor) trimMessagesToTokenThreshold(messages []types.Message) ([]types.Message, []types.Message) {
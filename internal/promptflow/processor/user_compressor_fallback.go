@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"go.uber.org/zap"
+)
+
+// Fallback compression strategies used when the summary model is unavailable
+const (
+	SummaryFallbackNone     = "none"
+	SummaryFallbackHeadTail = "head_tail"
+)
+
+// applyFallbackCompression compresses the older messages without the summary model
+func (u *UserCompressor) applyFallbackCompression(promptMsg *PromptMsg) {
+	const method = "UserCompressor.applyFallbackCompression"
+
+	switch u.config.ContextCompressConfig.SummaryFallback {
+	case "", SummaryFallbackNone:
+		return
+	case SummaryFallbackHeadTail:
+		budget := u.config.ContextCompressConfig.TokenThreshold -
+			u.tokenCounter.CountOneMessageTokens(*promptMsg.lastUserMsg)
+		kept, sources, dropped := u.keepHeadAndTail(promptMsg.olderUserMsgList, budget)
+		if dropped == 0 {
+			return
+		}
+
+		promptMsg.replaceOlderMessages(kept, sources, func(int) string {
+			return types.DropReasonTrimmed
+		})
+		u.FallbackUsed = true
+		logger.Warn("summary failed, applied fallback compression",
+			zap.String("strategy", SummaryFallbackHeadTail),
+			zap.Int("droppedMessages", dropped),
+			zap.Int("keptMessages", len(kept)),
+			zap.String("method", method),
+		)
+	default:
+		logger.Warn("unknown summary fallback strategy, messages left uncompressed",
+			zap.String("strategy", u.config.ContextCompressConfig.SummaryFallback),
+			zap.String("method", method),
+		)
+	}
+}
+
+// applyTrimOnly compresses the older messages by keeping the first and most recent ones
+// within the token threshold, for conversations not worth a summary
+func (u *UserCompressor) applyTrimOnly(promptMsg *PromptMsg) {
+	budget := u.config.ContextCompressConfig.TokenThreshold -
+		u.tokenCounter.CountOneMessageTokens(*promptMsg.lastUserMsg)
+	kept, sources, dropped := u.keepHeadAndTail(promptMsg.olderUserMsgList, budget)
+	if dropped == 0 {
+		return
+	}
+
+	promptMsg.replaceOlderMessages(kept, sources, func(int) string {
+		return types.DropReasonTrimmed
+	})
+	u.Handled = true
+	logger.Info("trimmed older messages without summary",
+		zap.Int("droppedMessages", dropped),
+		zap.Int("keptMessages", len(kept)),
+		zap.String("method", "UserCompressor.applyTrimOnly"),
+	)
+}
+
+// keepHeadAndTail keeps the first message and as many recent messages as fit in the
+// token budget, replacing the dropped middle with a short note. Returns the kept
+// messages, the position in messages each came from (-1 for the note) and the number
+// of messages dropped.
+func (u *UserCompressor) keepHeadAndTail(messages []types.Message, budget int) ([]types.Message, []int, int) {
+	if len(messages) == 0 || u.tokenCounter.CountMessagesTokens(messages) <= budget {
+		return messages, nil, 0
+	}
+
+	head := messages[:0]
+	used := 0
+	if firstTokens := u.tokenCounter.CountOneMessageTokens(messages[0]); firstTokens <= budget {
+		head = messages[:1]
+		used = firstTokens
+	}
+
+	// Walk back from the most recent message while the budget allows
+	tailStart := len(messages)
+	for tailStart > len(head) {
+		tokens := u.tokenCounter.CountOneMessageTokens(messages[tailStart-1])
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		tailStart--
+	}
+
+	dropped := tailStart - len(head)
+	kept := make([]types.Message, 0, len(head)+1+len(messages)-tailStart)
+	kept = append(kept, head...)
+	kept = append(kept, types.Message{
+		Role:    types.RoleAssistant,
+		Content: fmt.Sprintf("[%d earlier messages omitted]", dropped),
+	})
+	kept = append(kept, messages[tailStart:]...)
+
+	sources := make([]int, 0, len(kept))
+	for i := range head {
+		sources = append(sources, i)
+	}
+	sources = append(sources, -1)
+	for i := tailStart; i < len(messages); i++ {
+		sources = append(sources, i)
+	}
+	return kept, sources, dropped
+}
@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+	"go.uber.org/zap"
+)
+
+// defaultReinjectQuestionMaxBytes bounds the restated question when not configured
+const defaultReinjectQuestionMaxBytes = 2000
+
+// reinjectedQuestionPrefix introduces the restated question after the summary
+const reinjectedQuestionPrefix = "The conversation above was summarized. The current question is:\n"
+
+// reinjectQuestion restates the latest user question at the end of the compressed prompt,
+// so the model's attention lands on it rather than on the summary
+func (u *UserCompressor) reinjectQuestion(promptMsg *PromptMsg) {
+	const method = "UserCompressor.reinjectQuestion"
+
+	maxBytes := u.config.ContextCompressConfig.ReinjectQuestionMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultReinjectQuestionMaxBytes
+	}
+	question := strings.TrimSpace(utils.GetContentAsStringCapped(promptMsg.lastUserMsg.Content, maxBytes))
+	if question == "" {
+		return
+	}
+
+	promptMsg.lastUserMsg.Content = appendTextContent(promptMsg.lastUserMsg.Content,
+		reinjectedQuestionPrefix+question)
+	u.QuestionReinjected = true
+	logger.Info("re-injected the latest user question after compression",
+		zap.Int("questionBytes", len(question)),
+		zap.String("method", method),
+	)
+}
+
+// appendTextContent adds a text part to message content, keeping existing parts as they are
+func appendTextContent(content interface{}, text string) interface{} {
+	switch val := content.(type) {
+	case []model.Content:
+		return append(val, model.Content{Type: model.ContTypeText, Text: text})
+	case []any:
+		return append(val, map[string]any{"type": utils.ContentTypeText, "text": text})
+	case string:
+		return []model.Content{
+			{Type: model.ContTypeText, Text: val},
+			{Type: model.ContTypeText, Text: text},
+		}
+	}
+	return []model.Content{{Type: model.ContTypeText, Text: text}}
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+)
+
+func TestUserCompressor_reinjectQuestion(t *testing.T) {
+	cfg := config.Config{ContextCompressConfig: config.ContextCompressConfig{
+		ReinjectQuestion:         true,
+		ReinjectQuestionMaxBytes: 20,
+	}}
+	u := NewUserCompressor(nil, cfg, nil, nil)
+
+	promptMsg := &PromptMsg{lastUserMsg: &types.Message{
+		Role:    types.RoleUser,
+		Content: "How does the retry logic in llm.go decide the backoff?",
+	}}
+	u.reinjectQuestion(promptMsg)
+
+	assert.True(t, u.QuestionReinjected)
+	parts, ok := promptMsg.lastUserMsg.Content.([]model.Content)
+	assert.True(t, ok)
+	assert.Len(t, parts, 2)
+	assert.Equal(t, "How does the retry logic in llm.go decide the backoff?", parts[0].Text)
+	assert.True(t, strings.HasPrefix(parts[1].Text, reinjectedQuestionPrefix))
+	assert.True(t, strings.HasSuffix(parts[1].Text, "How does the retry l"+utils.ContentTruncatedMarker))
+}
+
+func TestUserCompressor_reinjectQuestion_KeepsContentParts(t *testing.T) {
+	u := NewUserCompressor(nil, config.Config{}, nil, nil)
+	promptMsg := &PromptMsg{lastUserMsg: &types.Message{
+		Role: types.RoleUser,
+		Content: []any{
+			map[string]any{"type": utils.ContentTypeText, "text": "what is in this image?"},
+			map[string]any{"type": utils.ContentTypeImageURL, "image_url": map[string]any{"url": "data:"}},
+		},
+	}}
+	u.reinjectQuestion(promptMsg)
+
+	parts := promptMsg.lastUserMsg.Content.([]any)
+	assert.Len(t, parts, 3)
+	assert.Equal(t, utils.ContentTypeImageURL, parts[1].(map[string]any)["type"])
+	assert.Contains(t, parts[2].(map[string]any)["text"], "what is in this image?")
+}
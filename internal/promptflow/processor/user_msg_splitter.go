@@ -0,0 +1,256 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+	"github.com/zgsm-ai/chat-rag/internal/utils"
+	"go.uber.org/zap"
+)
+
+// splitPartLabel introduces each chunk so the summary model reads them as one message
+const splitPartLabel = "[Part %d/%d of a split message]\n"
+
+// fileBoundaryPattern matches lines starting a new file in pasted content
+var fileBoundaryPattern = regexp.MustCompile(`^(diff --git |<file[ >]|(#|//)? ?[Ff]ile: )`)
+
+// UserMsgSplitter splits oversized user messages into chunks before they are summarized,
+// so a pasted file is summarized piece by piece instead of as one unit
+type UserMsgSplitter struct {
+	// SplitMessages is the number of messages split, SplitChunks the chunks they became
+	SplitMessages int
+	SplitChunks   int
+
+	maxTokens    int
+	tokenCounter *tokenizer.TokenCounter
+}
+
+func NewUserMsgSplitter(maxTokens int, tokenCounter *tokenizer.TokenCounter) *UserMsgSplitter {
+	return &UserMsgSplitter{
+		maxTokens:    maxTokens,
+		tokenCounter: tokenCounter,
+	}
+}
+
+// Split splits the oversized user messages in messages in place. Callers pass a copy of the
+// messages going to the summary model, the prompt sent to the main model is not changed.
+func (s *UserMsgSplitter) Split(messages []types.Message) {
+	if s.maxTokens <= 0 {
+		return
+	}
+
+	splitBefore := s.SplitMessages
+	for i := range messages {
+		if messages[i].Role == types.RoleUser {
+			s.splitMessage(&messages[i])
+		}
+	}
+
+	if s.SplitMessages > splitBefore {
+		logger.Info("split oversized user messages",
+			zap.Int("messages", s.SplitMessages),
+			zap.Int("chunks", s.SplitChunks),
+			zap.Int("maxTokens", s.maxTokens),
+			zap.String("method", "UserMsgSplitter.Split"),
+		)
+	}
+}
+
+// splitMessage replaces oversized text in the message with one text part per chunk,
+// other parts such as images are kept as they are
+func (s *UserMsgSplitter) splitMessage(msg *types.Message) {
+	split := false
+	expand := func(text string) []string {
+		if s.countTokens(text) <= s.maxTokens {
+			return nil
+		}
+		chunks := s.splitText(text)
+		if len(chunks) < 2 {
+			return nil
+		}
+		split = true
+		s.SplitChunks += len(chunks)
+		return labelChunks(chunks)
+	}
+
+	switch content := msg.Content.(type) {
+	case string:
+		if chunks := expand(content); chunks != nil {
+			parts := make([]model.Content, 0, len(chunks))
+			for _, chunk := range chunks {
+				parts = append(parts, model.Content{Type: model.ContTypeText, Text: chunk})
+			}
+			msg.Content = parts
+		}
+	case []model.Content:
+		parts := make([]model.Content, 0, len(content))
+		for _, part := range content {
+			if part.Type != model.ContTypeText {
+				parts = append(parts, part)
+				continue
+			}
+			chunks := expand(part.Text)
+			if chunks == nil {
+				parts = append(parts, part)
+				continue
+			}
+			for _, chunk := range chunks {
+				parts = append(parts, model.Content{Type: model.ContTypeText, Text: chunk})
+			}
+		}
+		msg.Content = parts
+	case []any:
+		parts := make([]any, 0, len(content))
+		for _, part := range content {
+			partMap, ok := part.(map[string]any)
+			text, _ := partMap["text"].(string)
+			if !ok || partMap["type"] != utils.ContentTypeText {
+				parts = append(parts, part)
+				continue
+			}
+			chunks := expand(text)
+			if chunks == nil {
+				parts = append(parts, part)
+				continue
+			}
+			for _, chunk := range chunks {
+				parts = append(parts, map[string]any{"type": utils.ContentTypeText, "text": chunk})
+			}
+		}
+		msg.Content = parts
+	}
+
+	if split {
+		s.SplitMessages++
+	}
+}
+
+// splitText cuts text into chunks of at most maxTokens, preferring file boundaries and code
+// fences, then line breaks, and only cutting inside a line when a single line is too long
+func (s *UserMsgSplitter) splitText(text string) []string {
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+	}
+
+	for _, block := range splitLogicalBlocks(text) {
+		blockTokens := s.countTokens(block)
+		if blockTokens > s.maxTokens {
+			flush()
+			chunks = append(chunks, s.splitOversizedBlock(block)...)
+			continue
+		}
+		if currentTokens+blockTokens > s.maxTokens {
+			flush()
+		}
+		current.WriteString(block)
+		currentTokens += blockTokens
+	}
+	flush()
+
+	return chunks
+}
+
+// splitOversizedBlock packs the lines of a block into chunks, cutting lines that are too long
+func (s *UserMsgSplitter) splitOversizedBlock(block string) []string {
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+
+	for _, line := range strings.SplitAfter(block, "\n") {
+		lineTokens := s.countTokens(line)
+		if currentTokens+lineTokens > s.maxTokens && current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+		if lineTokens > s.maxTokens {
+			chunks = append(chunks, cutByBytes(line, s.maxTokens*4)...)
+			continue
+		}
+		current.WriteString(line)
+		currentTokens += lineTokens
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+func (s *UserMsgSplitter) countTokens(text string) int {
+	if s.tokenCounter == nil {
+		return tokenizer.EstimateTokens(text)
+	}
+	return s.tokenCounter.CountTokens(text)
+}
+
+// splitLogicalBlocks cuts text before each file boundary and around each fenced code block
+func splitLogicalBlocks(text string) []string {
+	var blocks []string
+	var current strings.Builder
+	inFence := false
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		isFence := strings.HasPrefix(trimmed, "```")
+		startsBlock := (!inFence && isFence) || (!inFence && fileBoundaryPattern.MatchString(trimmed))
+		if startsBlock && current.Len() > 0 {
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+		if isFence {
+			inFence = !inFence
+			if !inFence {
+				blocks = append(blocks, current.String())
+				current.Reset()
+			}
+		}
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+	return blocks
+}
+
+// cutByBytes cuts text into pieces of at most maxBytes without splitting a UTF-8 character
+func cutByBytes(text string, maxBytes int) []string {
+	var pieces []string
+	for len(text) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = maxBytes
+		}
+		pieces = append(pieces, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		pieces = append(pieces, text)
+	}
+	return pieces
+}
+
+// labelChunks prefixes each chunk with its position in the split message
+func labelChunks(chunks []string) []string {
+	labeled := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		labeled[i] = fmt.Sprintf(splitPartLabel, i+1, len(chunks)) + chunk
+	}
+	return labeled
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestUserMsgSplitter_Split(t *testing.T) {
+	fileA := "File: a.go\n" + strings.Repeat("a := 1\n", 20)
+	fileB := "```go\n" + strings.Repeat("b := 2\n", 20) + "```\n"
+	small := "what changed?"
+
+	messages := []types.Message{
+		{Role: types.RoleUser, Content: small},
+		{Role: types.RoleAssistant, Content: fileA + fileB},
+		{Role: types.RoleUser, Content: fileA + fileB},
+	}
+	s := NewUserMsgSplitter(50, nil)
+	s.Split(messages)
+
+	assert.Equal(t, 1, s.SplitMessages)
+	assert.Equal(t, 2, s.SplitChunks)
+	assert.Equal(t, small, messages[0].Content)
+	assert.Equal(t, fileA+fileB, messages[1].Content, "only user messages are split")
+
+	parts, ok := messages[2].Content.([]model.Content)
+	assert.True(t, ok)
+	assert.Len(t, parts, 2)
+	assert.Equal(t, "[Part 1/2 of a split message]\n"+fileA, parts[0].Text)
+	assert.Equal(t, "[Part 2/2 of a split message]\n"+fileB, parts[1].Text)
+}
+
+func TestUserMsgSplitter_splitText_LongLine(t *testing.T) {
+	s := NewUserMsgSplitter(10, nil)
+	chunks := s.splitText(strings.Repeat("x", 100))
+
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, strings.Repeat("x", 100), strings.Join(chunks, ""))
+}
+
+func TestUserMsgSplitter_Disabled(t *testing.T) {
+	content := strings.Repeat("line\n", 1000)
+	messages := []types.Message{{Role: types.RoleUser, Content: content}}
+	s := NewUserMsgSplitter(0, nil)
+	s.Split(messages)
+
+	assert.Equal(t, 0, s.SplitMessages)
+	assert.Equal(t, content, messages[0].Content)
+}
@@ -21,6 +21,17 @@ type XmlToolAdapter struct {
 	toolConfig   *config.ToolConfig
 	agentName    string
 	promptMode   string
+
+	// DedupedTokens is the estimated number of tokens removed by rule de-duplication
+	DedupedTokens int
+	// InjectedTools lists the tools whose description and capability were injected
+	InjectedTools []string
+	// SemanticSkipped lists the semantic search tools left out because the request disabled them
+	SemanticSkipped []string
+	// UnhealthyTools lists the tools left out because their backend is failing
+	UnhealthyTools []string
+	// NoToolsSavedTokens is the estimated number of tokens removed by the no-tools prompt variant
+	NoToolsSavedTokens int
 }
 
 func NewXmlToolAdapter(ctx context.Context, toolExecutor functions.ToolExecutor, toolConfig *config.ToolConfig, agentName string, promptMode string) *XmlToolAdapter {
@@ -45,6 +56,7 @@ func (x *XmlToolAdapter) Execute(promptMsg *PromptMsg) {
 	// Check if all tools are disabled globally
 	if x.toolConfig != nil && x.toolConfig.DisableTools {
 		logger.InfoC(x.ctx, "All tools are disabled globally", zap.String("method", method))
+		x.recordNoToolsSavedTokens()
 		x.passToNext(promptMsg)
 		return
 	}
@@ -63,6 +75,16 @@ func (x *XmlToolAdapter) Execute(promptMsg *PromptMsg) {
 	if x.toolConfig != nil && x.isAgentDisabled(x.agentName, x.promptMode) {
 		logger.InfoC(x.ctx, "Agent is disabled from using tools",
 			zap.String("agent", x.agentName), zap.String("mode", x.promptMode), zap.String("method", method))
+		x.recordNoToolsSavedTokens()
+		x.passToNext(promptMsg)
+		return
+	}
+
+	// The request does not allow tool calls or the agent has no tools, leave the server tools out
+	if x.noToolsVariant() {
+		logger.InfoC(x.ctx, "No tools allowed for the request, server tools not injected",
+			zap.String("agent", x.agentName), zap.String("method", method))
+		x.recordNoToolsSavedTokens()
 		x.passToNext(promptMsg)
 		return
 	}
@@ -78,6 +100,14 @@ func (x *XmlToolAdapter) Execute(promptMsg *PromptMsg) {
 		return
 	}
 
+	// Remove rule/guideline blocks that appear more than once
+	if x.toolConfig != nil && x.toolConfig.DedupeRules.Enabled {
+		updatedContent, x.DedupedTokens = dedupeRuleBlocks(updatedContent,
+			compileDedupeMarkers(x.toolConfig.DedupeRules.Markers))
+		logger.InfoC(x.ctx, "Duplicated rule blocks removed from system prompt",
+			zap.Int("tokensRemoved", x.DedupedTokens), zap.String("method", method))
+	}
+
 	// Update the system message with the modified content
 	promptMsg.UpdateSystemMsg(updatedContent)
 
@@ -85,6 +115,32 @@ func (x *XmlToolAdapter) Execute(promptMsg *PromptMsg) {
 	x.passToNext(promptMsg)
 }
 
+// noToolsVariant reports whether the no-tools prompt variant applies: the request set tool_choice
+// to "none", or the agent's tool allow-list leaves no tools. Tools that are only unavailable for
+// now, unhealthy or not ready, do not count.
+func (x *XmlToolAdapter) noToolsVariant() bool {
+	if x.toolConfig == nil || !x.toolConfig.NoToolsPrompt.Enabled || x.toolExecutor == nil {
+		return false
+	}
+	if functions.ToolsDisabled(x.ctx) {
+		return true
+	}
+	return len(x.toolExecutor.GetAllTools()) > 0 && len(x.enabledTools(x.toolExecutor.GetAllTools())) == 0
+}
+
+// recordNoToolsSavedTokens records the tokens of the server tool descriptions, capabilities and
+// rules left out of the system message because no tools are available. The system message is kept
+// as it is, the tool sections sent by the client are not the server's to remove.
+func (x *XmlToolAdapter) recordNoToolsSavedTokens() {
+	if x.toolConfig == nil || !x.toolConfig.NoToolsPrompt.Enabled || x.toolExecutor == nil {
+		return
+	}
+
+	x.NoToolsSavedTokens = serverToolTokens(x.toolExecutor, x.enabledTools(x.toolExecutor.GetAllTools()))
+	logger.InfoC(x.ctx, "No tools available, server tool descriptions and rules left out of system prompt",
+		zap.String("agent", x.agentName), zap.Int("tokensSaved", x.NoToolsSavedTokens))
+}
+
 // insertToolsIntoSystemContent inserts tool descriptions under the "# Tools" section
 func (x *XmlToolAdapter) insertToolsIntoSystemContent(content string) (string, error) {
 	const method = "XmlToolAdapter.insertToolsIntoSystemContent"
@@ -104,7 +160,7 @@ func (x *XmlToolAdapter) insertToolsIntoSystemContent(content string) (string, e
 	var capabilitiesContent strings.Builder
 	var ruleContent strings.Builder
 
-	toolNames := x.toolExecutor.GetAllTools()
+	toolNames := x.withoutSemanticTools(x.enabledTools(x.toolExecutor.GetAllTools()))
 	if len(toolNames) == 0 {
 		logger.InfoC(x.ctx, "No tools available", zap.String("method", method))
 	}
@@ -112,6 +168,7 @@ func (x *XmlToolAdapter) insertToolsIntoSystemContent(content string) (string, e
 	// Parallel processing of tool checks and description retrieval
 	type toolResult struct {
 		name       string
+		unhealthy  bool
 		ready      bool
 		readyErr   error
 		desc       string
@@ -132,6 +189,13 @@ func (x *XmlToolAdapter) insertToolsIntoSystemContent(content string) (string, e
 
 			result := toolResult{name: name}
 
+			// Tools with a failing backend are not offered, the model would call them in vain
+			if checker, ok := x.toolExecutor.(functions.ToolHealthChecker); ok && !checker.ToolHealthy(name) {
+				result.unhealthy = true
+				results[index] = result
+				return
+			}
+
 			// Check if tool is ready
 			result.ready, result.readyErr = x.toolExecutor.CheckToolReady(x.ctx, name)
 
@@ -154,6 +218,12 @@ func (x *XmlToolAdapter) insertToolsIntoSystemContent(content string) (string, e
 
 	// Process results and build content
 	for _, result := range results {
+		if result.unhealthy {
+			logger.WarnC(x.ctx, "Tool backend is unhealthy, skip adapt", zap.String("tool", result.name),
+				zap.String("method", method))
+			x.UnhealthyTools = append(x.UnhealthyTools, result.name)
+			continue
+		}
 		if !result.ready {
 			logger.WarnC(x.ctx, "Tool is not ready, skip adapt", zap.String("tool", result.name),
 				zap.String("method", method), zap.Error(result.readyErr))
@@ -195,8 +265,11 @@ func (x *XmlToolAdapter) insertToolsIntoSystemContent(content string) (string, e
 			ruleContent.WriteString(result.rule)
 		}
 
+		x.InjectedTools = append(x.InjectedTools, result.name)
 		logger.InfoC(x.ctx, "Tool adapted in system prompt", zap.String("name", result.name))
 	}
+	logger.InfoC(x.ctx, "Tool capabilities injected", zap.Strings("tools", x.InjectedTools),
+		zap.String("agent", x.agentName), zap.String("method", method))
 
 	// Insert the tools content after the tools header
 	result, err := insertContentAfterMarker(content, "# Tools", toolsContent.String())
@@ -241,6 +314,55 @@ func insertContentAfterMarker(content, marker, newContent string) (string, error
 	return content[:insertPos] + newContent + content[insertPos:], nil
 }
 
+// enabledTools filters tools by the agent's allow-list; agents without one keep all tools
+func (x *XmlToolAdapter) enabledTools(toolNames []string) []string {
+	if x.toolConfig == nil || len(x.toolConfig.EnabledAgentTools) == 0 {
+		return toolNames
+	}
+
+	// Map keys are lower-cased by the config loader
+	allowed, exists := x.toolConfig.EnabledAgentTools[strings.ToLower(x.agentName)]
+	if !exists {
+		return toolNames
+	}
+
+	enabled := make([]string, 0, len(toolNames))
+	for _, name := range toolNames {
+		for _, allowedName := range allowed {
+			if name == allowedName {
+				enabled = append(enabled, name)
+				break
+			}
+		}
+	}
+	return enabled
+}
+
+// withoutSemanticTools drops the semantic search tools when the request disabled them
+func (x *XmlToolAdapter) withoutSemanticTools(toolNames []string) []string {
+	if !functions.SemanticDisabled(x.ctx) {
+		return toolNames
+	}
+
+	var semanticTools []string
+	if x.toolConfig != nil {
+		semanticTools = x.toolConfig.SemanticTools
+	}
+	kept := make([]string, 0, len(toolNames))
+	for _, name := range toolNames {
+		if functions.IsSemanticTool(semanticTools, name) {
+			x.SemanticSkipped = append(x.SemanticSkipped, name)
+			continue
+		}
+		kept = append(kept, name)
+	}
+	if len(x.SemanticSkipped) > 0 {
+		logger.InfoC(x.ctx, "semantic search disabled by the request, skipped tools",
+			zap.Strings("tools", x.SemanticSkipped))
+	}
+	return kept
+}
+
 // isAgentDisabled checks if the agent is disabled from using tools in the current mode
 func (x *XmlToolAdapter) isAgentDisabled(agentName, mode string) bool {
 	if x.toolConfig == nil || x.toolConfig.DisabledAgents == nil {
@@ -0,0 +1,26 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/functions"
+)
+
+func TestXmlToolAdapter_withoutSemanticTools(t *testing.T) {
+	tools := []string{"codebase_search", "search_files", "knowledge_base_search"}
+
+	x := NewXmlToolAdapter(context.Background(), nil, &config.ToolConfig{}, "", "")
+	assert.Equal(t, tools, x.withoutSemanticTools(tools), "semantic search is kept by default")
+	assert.Empty(t, x.SemanticSkipped)
+
+	ctx := functions.WithSemanticDisabled(context.Background())
+	x = NewXmlToolAdapter(ctx, nil, &config.ToolConfig{}, "", "")
+	assert.Equal(t, []string{"search_files", "knowledge_base_search"}, x.withoutSemanticTools(tools))
+	assert.Equal(t, []string{"codebase_search"}, x.SemanticSkipped)
+
+	x = NewXmlToolAdapter(ctx, nil, &config.ToolConfig{SemanticTools: []string{"codebase_search", "knowledge_base_search"}}, "", "")
+	assert.Equal(t, []string{"search_files"}, x.withoutSemanticTools(tools))
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/client"
 	"github.com/zgsm-ai/chat-rag/internal/config"
 	"github.com/zgsm-ai/chat-rag/internal/functions"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
@@ -25,24 +27,27 @@ type ProcessorChainBuilder interface {
 }
 
 type RagCompressProcessor struct {
-	// llmClient    client.LLMInterface
+	llmClient    client.LLMInterface // Summary model client, nil unless compression is enabled
+	summaryModel string              // Model of llmClient
 	// functionsManager *functions.ToolManager
 
 	ctx           context.Context
 	tokenCounter  *tokenizer.TokenCounter
+	compressPool  *processor.CompressPool // Bounds the summary model calls, nil when unlimited
 	config        config.Config
 	identity      *model.Identity
 	modelName     string
 	toolsExecutor functions.ToolExecutor
 	agentName     string // detected agent type
 	promptMode    string // current prompt mode
+	timezone      string // user's time zone from the request header
 
 	// functionAdapter *processor.FunctionAdapter
-	// userCompressor *processor.UserCompressor
 
 	userMsgFilter        *processor.UserMsgFilter
 	taskContentProcessor *processor.TaskContentProcessor
 	xmlToolAdapter       *processor.XmlToolAdapter
+	userCompressor       *processor.UserCompressor
 	start                *processor.Start
 	end                  *processor.End
 
@@ -50,17 +55,17 @@ type RagCompressProcessor struct {
 	chainBuilder ProcessorChainBuilder
 }
 
-/*
-// copyAndSetQuotaIdentity
+// copyAndSetQuotaIdentity copies the request headers, billing summary calls to the system quota
 func copyAndSetQuotaIdentity(headers *http.Header) *http.Header {
 	headersCopy := make(http.Header)
-	for k, v := range *headers {
-		headersCopy[k] = v
+	if headers != nil {
+		for k, v := range *headers {
+			headersCopy[k] = v
+		}
 	}
 	headersCopy.Set(types.HeaderQuotaIdentity, "system")
 	return &headersCopy
 }
-*/
 
 // NewRagCompressProcessor creates a new RAG compression processor
 func NewRagCompressProcessor(
@@ -71,35 +76,45 @@ func NewRagCompressProcessor(
 	modelName string,
 	promptMode string,
 ) (*RagCompressProcessor, error) {
-	/* Deprecated
-	// Use default timeout config for summary
-	timeoutCfg := config.LLMTimeoutConfig{
-		IdleTimeoutMs:      30000,
-		TotalIdleTimeoutMs: 30000,
-	}
-	llmClient, err := client.NewLLMClient(
-		svcCtx.Config.LLM,
-		timeoutCfg,
-		svcCtx.Config.ContextCompressConfig.SummaryModel,
-		copyAndSetQuotaIdentity(headers),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("create LLM client: %w", err)
+	// The summary model is only called when the conversation may be compressed
+	var llmClient client.LLMInterface
+	var summaryModel string
+	if userCompressorEnabled(svcCtx.Config.ContextCompressConfig) {
+		// Use default timeout config for summary
+		timeoutCfg := config.LLMTimeoutConfig{
+			IdleTimeoutMs:      30000,
+			TotalIdleTimeoutMs: 30000,
+		}
+		var tier string
+		summaryModel, tier = processor.SelectSummaryModel(svcCtx.Config.ContextCompressConfig, modelName)
+		logger.InfoC(ctx, "summary model selected",
+			zap.String("mainModel", modelName), zap.String("summaryModel", summaryModel), zap.String("tier", tier))
+		var err error
+		llmClient, err = client.NewLLMClient(
+			svcCtx.Config.LLM,
+			timeoutCfg,
+			summaryModel,
+			copyAndSetQuotaIdentity(headers),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create LLM client: %w", err)
+		}
 	}
-	*/
 
 	if promptMode == "" {
 		promptMode = "vibe"
 	}
 
 	processor := &RagCompressProcessor{
-		// llmClient:    llmClient,
+		llmClient:    llmClient,
+		summaryModel: summaryModel,
 		// functionsManager: svcCtx.FunctionsManager,
 
 		ctx:           ctx,
 		modelName:     modelName,
 		config:        svcCtx.Config,
 		tokenCounter:  svcCtx.TokenCounter,
+		compressPool:  svcCtx.CompressPool,
 		identity:      identity,
 		toolsExecutor: svcCtx.ToolExecutor,
 		promptMode:    promptMode,
@@ -107,6 +122,10 @@ func NewRagCompressProcessor(
 		end:           processor.NewEndpoint(),
 	}
 
+	if headers != nil && svcCtx.Config.CurrentTime.Header != "" {
+		processor.timezone = headers.Get(svcCtx.Config.CurrentTime.Header)
+	}
+
 	processor.chainBuilder = processor
 
 	return processor, nil
@@ -161,34 +180,79 @@ func (p *RagCompressProcessor) buildProcessorChain() error {
 		p.agentName,
 		p.promptMode,
 	)
-	// p.userCompressor = processor.NewUserCompressor(
-	// 	p.ctx,
-	// 	p.config,
-	// 	p.llmClient,
-	// 	p.tokenCounter,
-	// )
+	p.userCompressor = processor.NewUserCompressor(
+		p.ctx,
+		p.config,
+		p.llmClient,
+		p.tokenCounter,
+	)
+	p.userCompressor.SetPool(p.compressPool)
 
 	// execute chain
 	p.start.SetNext(p.userMsgFilter)
 	p.userMsgFilter.SetNext(p.taskContentProcessor)
 	p.taskContentProcessor.SetNext(p.xmlToolAdapter)
-	// p.xmlToolAdapter.SetNext(p.userCompressor)
-	p.xmlToolAdapter.SetNext(p.end)
+	if userCompressorEnabled(p.config.ContextCompressConfig) {
+		p.xmlToolAdapter.SetNext(p.userCompressor)
+	}
+	p.lastProcessor().SetNext(p.end)
 
 	return nil
 }
 
+// userCompressorEnabled reports whether the user compressor runs on the prompt chain
+func userCompressorEnabled(cfg config.ContextCompressConfig) bool {
+	return cfg.EnableCompress && cfg.EnableUserCompressor
+}
+
+// lastProcessor returns the processor that the end of the chain follows
+func (p *RagCompressProcessor) lastProcessor() processor.Processor {
+	if userCompressorEnabled(p.config.ContextCompressConfig) {
+		return p.userCompressor
+	}
+	return p.xmlToolAdapter
+}
+
 // createProcessedPrompt creates the final processed prompt result
 func (p *RagCompressProcessor) createProcessedPrompt(
 	promptMsg *processor.PromptMsg,
 ) *ds.ProcessedPrompt {
-	processor.SetLanguage(p.identity.Language, promptMsg)
+	if p.config.Language.InjectInstruction {
+		processor.SetLanguage(p.identity.Language, promptMsg)
+	}
+	currentTimeInjected := false
+	if p.config.CurrentTime.Enabled {
+		location := processor.ResolveLocation(p.timezone, p.config.CurrentTime.Timezone)
+		currentTimeInjected = processor.SetCurrentTime(time.Now(), location, p.config.CurrentTime.Format, promptMsg)
+	}
 	return &ds.ProcessedPrompt{
-		Messages:     promptMsg.AssemblePrompt(),
-		Tools:        promptMsg.GetTools(),
-		Agent:        p.agentName,
-		TokenMetrics: p.userMsgFilter.TokenMetrics,
+		Messages:            promptMsg.AssemblePrompt(),
+		Tools:               promptMsg.GetTools(),
+		Agent:               p.agentName,
+		TokenMetrics:        p.userMsgFilter.TokenMetrics,
+		InjectedTools:       p.xmlToolAdapter.InjectedTools,
+		UnhealthyTools:      p.xmlToolAdapter.UnhealthyTools,
+		SplitUserMessages:   p.userCompressor.SplitMessages(),
+		DedupedTokens:       p.xmlToolAdapter.DedupedTokens,
+		NoToolsSavedTokens:  p.xmlToolAdapter.NoToolsSavedTokens,
+		SemanticSkipped:     p.xmlToolAdapter.SemanticSkipped,
+		DroppedMessages:     promptMsg.DroppedMessages(),
+		CurrentTimeInjected: currentTimeInjected,
+		CompressionSkipped:  p.userCompressor.Skipped,
+		CompressionFallback: p.userCompressor.FallbackUsed,
+		CompressionDropped:  p.userCompressor.Dropped,
+		SummaryModel:        p.usedSummaryModel(),
+		QuestionReinjected:  p.userCompressor.QuestionReinjected,
+		CompressionStrategy: p.userCompressor.Strategy,
+	}
+}
+
+// usedSummaryModel returns the summary model when the compressor called it, empty otherwise
+func (p *RagCompressProcessor) usedSummaryModel() string {
+	if !p.userCompressor.SummaryRequested {
+		return ""
 	}
+	return p.summaryModel
 }
 
 // detectAgent detects the agent type based on the system message content
@@ -52,8 +52,8 @@ func (r *RagWithRuleProcessor) buildProcessorChain() error {
 	// Create rule injector
 	r.ruleInjector = processor.NewRulesInjector(r.promptMode, r.rulesConfig, r.agentName)
 
-	// Rebuild chain with rule injector inserted at the beginning
-	r.xmlToolAdapter.SetNext(r.ruleInjector)
+	// Rebuild chain with rule injector inserted before the end
+	r.lastProcessor().SetNext(r.ruleInjector)
 	r.ruleInjector.SetNext(r.end)
 	// The rest of the chain remains the same as in parent
 
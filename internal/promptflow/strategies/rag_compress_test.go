@@ -0,0 +1,311 @@
+package strategies
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zgsm-ai/chat-rag/internal/bootstrap"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/promptflow/processor"
+	"github.com/zgsm-ai/chat-rag/internal/tokenizer"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// summaryServer answers summary requests with a fixed summary and records the requested models
+// and the messages sent for summarizing
+type summaryServer struct {
+	*httptest.Server
+	models   []string
+	messages []string
+}
+
+func newSummaryServer(t *testing.T, status int) *summaryServer {
+	s := &summaryServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string          `json:"model"`
+			Messages json.RawMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.models = append(s.models, req.Model)
+		s.messages = append(s.messages, string(req.Messages))
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "conversation summary"}}]}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// arrangeCompressed returns a RAG compression processor with the user compressor enabled
+func arrangeCompressed(t *testing.T, llmEndpoint string, compress config.ContextCompressConfig,
+	modelName string) *RagCompressProcessor {
+	compress.EnableCompress = true
+	compress.EnableUserCompressor = true
+	return newRagCompressProcessor(t, llmEndpoint, compress, modelName)
+}
+
+// newRagCompressProcessor returns a RAG compression processor with the given compression config
+func newRagCompressProcessor(t *testing.T, llmEndpoint string, compress config.ContextCompressConfig,
+	modelName string) *RagCompressProcessor {
+	tokenCounter, err := tokenizer.NewTokenCounter()
+	require.NoError(t, err)
+
+	svcCtx := &bootstrap.ServiceContext{TokenCounter: tokenCounter}
+	svcCtx.Config.LLM.Endpoint = llmEndpoint
+	svcCtx.Config.PreciseContextConfig = &config.PreciseContextConfig{}
+	svcCtx.Config.ContextCompressConfig = compress
+	p, err := NewRagCompressProcessor(context.Background(), svcCtx, &http.Header{}, &model.Identity{}, modelName, "")
+	require.NoError(t, err)
+	return p
+}
+
+// conversation returns a system prompt and count user-side messages of about 100 tokens each
+func conversation(count int) []types.Message {
+	messages := []types.Message{{Role: types.RoleSystem, Content: "You are a coding assistant."}}
+	for i := 0; i < count; i++ {
+		role := types.RoleUser
+		if i%2 == 1 {
+			role = types.RoleAssistant
+		}
+		messages = append(messages, types.Message{Role: role, Content: strings.Repeat("refactor the parser ", 25)})
+	}
+	return messages
+}
+
+func TestRagCompressProcessor_Arrange_UserCompressorOptIn(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		EnableCompress:             true,
+		TokenThreshold:             200,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+	}
+
+	p := newRagCompressProcessor(t, server.URL, compress, "main-model")
+	assert.Nil(t, p.llmClient, "no summary client without the opt-in")
+	processed, err := p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.Len(t, processed.Messages, 6, "messages are left as they are")
+	assert.Empty(t, server.models, "the summary model is not called")
+	assert.Empty(t, processed.SummaryModel)
+
+	p = arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err = p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summary-model"}, server.models)
+	assert.Equal(t, "summary-model", processed.SummaryModel)
+}
+
+func TestRagCompressProcessor_Arrange_SplitUserMessages(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		EnableCompress:             true,
+		TokenThreshold:             200,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+		SplitUserMessageTokens:     60,
+	}
+	latest := conversation(7)[7].Content
+
+	p := newRagCompressProcessor(t, server.URL, compress, "main-model")
+	processed, err := p.Arrange(conversation(7))
+	require.NoError(t, err)
+	assert.Zero(t, processed.SplitUserMessages)
+	assert.Equal(t, latest, processed.Messages[7].Content, "nothing is split without compression")
+
+	p = arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err = p.Arrange(conversation(7))
+	require.NoError(t, err)
+	require.Len(t, server.messages, 1)
+	assert.Contains(t, server.messages[0], "of a split message]")
+	assert.Positive(t, processed.SplitUserMessages)
+	last := processed.Messages[len(processed.Messages)-1]
+	assert.Equal(t, latest, last.Content, "the latest message is sent as it is")
+}
+
+func TestRagCompressProcessor_Arrange_CompressQueueFull(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		TokenThreshold:             200,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+	}
+
+	// The only worker is busy and there is no queue
+	pool := processor.NewCompressPool(1, 0)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go func() {
+		// The worker may not be waiting for work yet, without a queue that drops the call
+		for errors.Is(pool.Do(context.Background(), func() {
+			close(started)
+			<-release
+		}), processor.ErrCompressQueueFull) {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	<-started
+
+	p := arrangeCompressed(t, server.URL, compress, "main-model")
+	p.compressPool = pool
+	processed, err := p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.True(t, processed.CompressionDropped)
+	assert.False(t, processed.CompressionFallback)
+	assert.Len(t, processed.Messages, 6, "the request proceeds uncompressed")
+	assert.Empty(t, server.models, "the summary model is not called")
+	assert.Empty(t, processed.SummaryModel)
+}
+
+func TestRagCompressProcessor_Arrange_MinMessagesForCompression(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		TokenThreshold:             200,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+		MinMessagesForCompression:  10,
+	}
+
+	p := arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err := p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.True(t, processed.CompressionSkipped)
+	assert.Len(t, processed.Messages, 6, "messages are left as they are")
+	assert.Empty(t, server.models, "the summary model is not called")
+
+	compress.MinMessagesForCompression = 3
+	p = arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err = p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.False(t, processed.CompressionSkipped)
+	assert.Equal(t, []string{"summary-model"}, server.models)
+	assert.Contains(t, processed.Messages[1].Content, "conversation summary")
+}
+
+func TestRagCompressProcessor_Arrange_SummaryFallback(t *testing.T) {
+	server := newSummaryServer(t, http.StatusInternalServerError)
+	compress := config.ContextCompressConfig{
+		TokenThreshold:             400,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+		SummaryFallback:            processor.SummaryFallbackHeadTail,
+	}
+
+	p := arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err := p.Arrange(conversation(9))
+	require.NoError(t, err)
+	assert.NotEmpty(t, server.models, "the summary model was called")
+	assert.True(t, processed.CompressionFallback)
+	assert.Less(t, len(processed.Messages), 10)
+	assert.NotEmpty(t, processed.DroppedMessages)
+	for _, dropped := range processed.DroppedMessages {
+		assert.Equal(t, types.DropReasonTrimmed, dropped.Reason)
+	}
+
+	compress.SummaryFallback = processor.SummaryFallbackNone
+	p = arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err = p.Arrange(conversation(9))
+	require.NoError(t, err)
+	assert.False(t, processed.CompressionFallback)
+	assert.Len(t, processed.Messages, 10, "messages are left uncompressed")
+}
+
+func TestRagCompressProcessor_Arrange_SummaryModelTier(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		TokenThreshold:             200,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+		SummaryModelTiers: []config.SummaryModelTier{
+			{Name: "premium", MainModels: []string{"main-model"}, SummaryModel: "cheap-summary-model"},
+		},
+	}
+
+	p := arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err := p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cheap-summary-model"}, server.models)
+	assert.Equal(t, "cheap-summary-model", processed.SummaryModel)
+
+	p = arrangeCompressed(t, server.URL, compress, "other-model")
+	processed, err = p.Arrange(conversation(1))
+	require.NoError(t, err)
+	assert.Empty(t, processed.SummaryModel, "no summary was requested")
+}
+
+func TestRagCompressProcessor_Arrange_ReinjectQuestion(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		TokenThreshold:             200,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+		ReinjectQuestion:           true,
+	}
+	messages := append(conversation(4), types.Message{Role: types.RoleUser, Content: "Where is the lexer?"})
+
+	p := arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err := p.Arrange(messages)
+	require.NoError(t, err)
+	assert.True(t, processed.QuestionReinjected)
+	last, err := json.Marshal(processed.Messages[len(processed.Messages)-1].Content)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(last), "Where is the lexer?"), "the question is restated")
+
+	compress.ReinjectQuestion = false
+	p = arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err = p.Arrange(messages)
+	require.NoError(t, err)
+	assert.False(t, processed.QuestionReinjected)
+}
+
+func TestRagCompressProcessor_Arrange_CompressionStrategy(t *testing.T) {
+	server := newSummaryServer(t, http.StatusOK)
+	compress := config.ContextCompressConfig{
+		TokenThreshold:             400,
+		SummaryModel:               "summary-model",
+		SummaryModelTokenThreshold: 100000,
+		RecentUserMsgUsedNums:      1,
+		StrategyBuckets: []config.CompressStrategyBucket{
+			{MaxOverRatio: 1.5, Strategy: config.CompressStrategyTrimOnly},
+			{Strategy: config.CompressStrategyFullSummary},
+		},
+	}
+
+	p := arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err := p.Arrange(conversation(5))
+	require.NoError(t, err)
+	assert.Equal(t, config.CompressStrategyTrimOnly, processed.CompressionStrategy)
+	assert.Empty(t, server.models, "trimming needs no summary")
+
+	p = arrangeCompressed(t, server.URL, compress, "main-model")
+	processed, err = p.Arrange(conversation(9))
+	require.NoError(t, err)
+	assert.Equal(t, config.CompressStrategyFullSummary, processed.CompressionStrategy)
+	assert.Equal(t, []string{"summary-model"}, server.models)
+
+	processed, err = arrangeCompressed(t, server.URL, compress, "main-model").Arrange(conversation(1))
+	require.NoError(t, err)
+	assert.Empty(t, processed.CompressionStrategy, "below the threshold")
+}
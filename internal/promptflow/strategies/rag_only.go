@@ -69,7 +69,9 @@ func (p *RagOnlyProcessor) buildProcessorChain() error {
 func (p *RagOnlyProcessor) createProcessedPrompt(
 	promptMsg *processor.PromptMsg,
 ) *ds.ProcessedPrompt {
-	processor.SetLanguage(p.identity.Language, promptMsg)
+	if p.config.Language.InjectInstruction {
+		processor.SetLanguage(p.identity.Language, promptMsg)
+	}
 	return &ds.ProcessedPrompt{
 		Messages: promptMsg.AssemblePrompt(),
 	}
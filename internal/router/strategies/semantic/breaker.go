@@ -0,0 +1,119 @@
+package semantic
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+const (
+	defaultAnalyzerFailureThreshold = 3
+	defaultAnalyzerCooldown         = 30 * time.Second
+)
+
+// analyzerBreaker stops calling a failing analyzer: after consecutive failures requests skip
+// it until the cooldown lets a single probe through, whose outcome closes or reopens it
+type analyzerBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openUntil time.Time
+	probing   bool
+}
+
+// Strategies are built per request, so breakers live here and are shared by every request
+// using the same analyzer model and endpoint
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*analyzerBreaker{}
+)
+
+// analyzerBreakerFor returns the shared breaker of the analyzer, nil when the breaker is disabled
+func analyzerBreakerFor(cfg config.AnalyzerConfig) *analyzerBreaker {
+	if !cfg.Breaker.Enabled {
+		return nil
+	}
+	threshold := cfg.Breaker.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultAnalyzerFailureThreshold
+	}
+	cooldown := time.Duration(cfg.Breaker.CooldownMs) * time.Millisecond
+	if cooldown <= 0 {
+		cooldown = defaultAnalyzerCooldown
+	}
+
+	key := cfg.Model + "|" + cfg.Endpoint
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[key]
+	if !ok {
+		b = &analyzerBreaker{now: time.Now}
+		breakers[key] = b
+	}
+	// Pick up reloaded settings without losing the current state
+	b.mu.Lock()
+	b.threshold = threshold
+	b.cooldown = cooldown
+	b.mu.Unlock()
+	return b
+}
+
+// allow reports whether the analyzer may be called. Every allowed call must be followed by record.
+func (b *analyzerBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of an analyzer call, err is nil when the analyzer
+// answered. Calls cut short because the request itself ended say nothing about the analyzer.
+func (b *analyzerBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	b.probing = false
+	if err != nil && ctx.Err() != nil {
+		b.mu.Unlock()
+		return
+	}
+
+	var opened, closed bool
+	if err == nil {
+		b.failures = 0
+		closed = b.open
+		b.open = false
+	} else {
+		b.failures++
+		if b.open || b.failures >= b.threshold {
+			opened = !b.open
+			b.open = true
+			b.openUntil = b.now().Add(b.cooldown)
+		}
+	}
+	failures := b.failures
+	cooldown := b.cooldown
+	b.mu.Unlock()
+
+	if opened {
+		logger.WarnC(ctx, "semantic router: analyzer breaker opened, classification disabled",
+			zap.Int("consecutive_failures", failures),
+			zap.Duration("cooldown", cooldown),
+			zap.Error(err))
+	} else if closed {
+		logger.InfoC(ctx, "semantic router: analyzer breaker closed, classification resumed")
+	}
+}
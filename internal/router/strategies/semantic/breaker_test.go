@@ -0,0 +1,55 @@
+package semantic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+)
+
+func TestAnalyzerBreaker(t *testing.T) {
+	cfg := config.AnalyzerConfig{
+		Model:   "breaker-test-model",
+		Breaker: config.AnalyzerBreakerConfig{Enabled: true, FailureThreshold: 2, CooldownMs: 1000},
+	}
+	b := analyzerBreakerFor(cfg)
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	errAnalyzer := errors.New("analyzer down")
+
+	assert.Same(t, b, analyzerBreakerFor(cfg), "requests share one breaker per analyzer")
+
+	assert.True(t, b.allow())
+	b.record(ctx, errAnalyzer)
+	assert.True(t, b.allow(), "below the threshold")
+	b.record(ctx, errAnalyzer)
+	assert.False(t, b.allow(), "open after consecutive failures")
+
+	now = now.Add(time.Second)
+	assert.True(t, b.allow(), "probe after the cooldown")
+	assert.False(t, b.allow(), "only one probe at a time")
+	b.record(ctx, errAnalyzer)
+	assert.False(t, b.allow(), "failed probe reopens")
+
+	now = now.Add(time.Second)
+	assert.True(t, b.allow())
+	b.record(ctx, nil)
+	assert.True(t, b.allow(), "successful probe closes")
+	b.record(ctx, nil)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for i := 0; i < 3; i++ {
+		assert.True(t, b.allow())
+		b.record(canceled, context.Canceled)
+	}
+	assert.True(t, b.allow(), "aborted requests are not analyzer failures")
+	b.record(ctx, nil)
+
+	assert.Nil(t, analyzerBreakerFor(config.AnalyzerConfig{Model: "breaker-test-model"}))
+}
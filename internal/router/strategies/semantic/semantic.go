@@ -71,6 +71,19 @@ func (s *Strategy) Run(
 		zap.String("prompt", prompt),
 	)
 
+	// Skip the analyzer while its breaker is open; an allowed call always reports its outcome
+	var analyzerErr error
+	if breaker := analyzerBreakerFor(s.cfg.Analyzer); breaker != nil {
+		if !breaker.allow() {
+			logger.WarnC(ctx, "semantic router: fallback used",
+				zap.String("reason", "analyzer_breaker_open"),
+				zap.String("selected_model", s.selectFallback(req)),
+			)
+			return s.selectFallback(req), current, s.orderCandidatesByLabel("", req.Model, cands), nil
+		}
+		defer func() { breaker.record(ctx, analyzerErr) }()
+	}
+
 	// Analyzer timeout and total timeout with retry
 	perTimeout := time.Duration(s.cfg.Analyzer.TimeoutMs) * time.Millisecond
 	if perTimeout <= 0 {
@@ -119,6 +132,7 @@ func (s *Strategy) Run(
 	for {
 		remaining := time.Until(deadline)
 		if remaining <= 0 {
+			analyzerErr = context.DeadlineExceeded
 			logger.WarnC(ctx, "semantic router: fallback used",
 				zap.String("reason", "total_timeout"),
 				zap.String("selected_model", s.selectFallback(req)),
@@ -155,6 +169,7 @@ func (s *Strategy) Run(
 				)
 				continue
 			}
+			analyzerErr = err
 			logger.WarnC(ctx, "semantic router: fallback used",
 				zap.String("reason", "analyzer_error"),
 				zap.Error(err),
@@ -286,6 +301,11 @@ func contains(arr []string, s string) bool {
 	return false
 }
 
+// classifiesFullHistory reports whether earlier user messages are sent to the analyzer as history
+func (s *Strategy) classifiesFullHistory() bool {
+	return s.cfg.InputExtraction.Scope == config.InputScopeFull
+}
+
 func (s *Strategy) extractInputs(req *types.ChatCompletionRequest) (current string, history string) {
 	// Follow plugin logic: extractHistoryAndCurrent for protocol==openai
 	if !strings.EqualFold(s.cfg.InputExtraction.Protocol, "openai") {
@@ -299,7 +319,7 @@ func (s *Strategy) extractInputs(req *types.ChatCompletionRequest) (current stri
 			}
 		}
 		if lastUserIdx >= 0 {
-			current = utils.GetContentAsString(req.Messages[lastUserIdx].Content)
+			current = utils.GetContentAsStringCapped(req.Messages[lastUserIdx].Content, s.maxContentBytes())
 		}
 		if s.cfg.InputExtraction.StripCodeFences {
 			current = stripCodeFences(current, s.cfg.InputExtraction.CodeFenceRegex)
@@ -344,7 +364,7 @@ func (s *Strategy) extractInputs(req *types.ChatCompletionRequest) (current stri
 	}
 
 	// helper to get raw content
-	getRaw := func(c any) string { return utils.GetContentAsString(c) }
+	getRaw := func(c any) string { return utils.GetContentAsStringCapped(c, s.maxContentBytes()) }
 
 	msgs := req.Messages
 	if len(msgs) == 0 {
@@ -385,7 +405,7 @@ func (s *Strategy) extractInputs(req *types.ChatCompletionRequest) (current stri
 			}
 			continue
 		}
-		if role == types.RoleUser {
+		if role == types.RoleUser && s.classifiesFullHistory() {
 			if v, ok := extractExplicit(raw); ok && strings.TrimSpace(v) != "" {
 				histParts = append(histParts, v)
 			}
@@ -479,6 +499,17 @@ func (s *Strategy) extractInputs(req *types.ChatCompletionRequest) (current stri
 	return current, history
 }
 
+// defaultMaxContentBytes caps message text read for classification when not configured
+const defaultMaxContentBytes = 65536
+
+// maxContentBytes returns the cap applied to each message's text before extraction
+func (s *Strategy) maxContentBytes() int {
+	if s.cfg.InputExtraction.MaxContentBytes > 0 {
+		return s.cfg.InputExtraction.MaxContentBytes
+	}
+	return defaultMaxContentBytes
+}
+
 func (s *Strategy) buildPrompt(current, history string) string {
 	if s.cfg.Analyzer.PromptTemplate != "" {
 		return strings.ReplaceAll(strings.ReplaceAll(s.cfg.Analyzer.PromptTemplate, "{HISTORY}", history), "{CURRENT}", current)
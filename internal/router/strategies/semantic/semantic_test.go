@@ -0,0 +1,37 @@
+package semantic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+func TestExtractInputs_Scope(t *testing.T) {
+	req := &types.ChatCompletionRequest{LLMRequestParams: types.LLMRequestParams{Messages: []types.Message{
+		{Role: types.RoleUser, Content: "<task>explain the auth flow</task>"},
+		{Role: types.RoleAssistant, Content: "The auth flow starts in middleware."},
+		{Role: types.RoleUser, Content: "<user_message>now add rate limiting</user_message>"},
+	}}}
+	extract := func(scope string) (string, string) {
+		return New(config.SemanticConfig{InputExtraction: config.InputExtractionConfig{
+			Protocol: "openai", Scope: scope, MaxHistoryMessages: 5,
+		}}).extractInputs(req)
+	}
+
+	current, history := extract(config.InputScopeLastMessage)
+	assert.Equal(t, "now add rate limiting", current)
+	assert.Empty(t, history)
+
+	current, history = extract(config.InputScopeFull)
+	assert.Equal(t, "now add rate limiting", current)
+	assert.Equal(t, "explain the auth flow", history)
+
+	// Short follow-ups keep the previous reply for disambiguation in either scope
+	req.Messages[2].Content = "<user_message>retry</user_message>"
+	current, history = extract(config.InputScopeLastMessage)
+	assert.Equal(t, "retry", current)
+	assert.Equal(t, "The auth flow starts in middleware.", history)
+}
@@ -76,6 +76,10 @@ type LoggerRecordService struct {
 	metricsService MetricsInterface
 	deptClient     client.DepartmentInterface
 	instanceID     string
+	promptStorage  string
+	pipelineDepth  int
+	concurrency    int
+	environment    string
 	// enableClassification bool
 
 	logChan         chan *model.ChatLog
@@ -120,6 +124,10 @@ func NewLogRecordService(config config.Config) LogRecordInterface {
 		stopChan:        make(chan struct{}),
 		instanceID:      instanceID,
 		deptClient:      deptClient,
+		promptStorage:   config.Log.PromptStorage,
+		pipelineDepth:   config.Log.PipelineDepth,
+		concurrency:     max(config.Log.LogProcessorConcurrency, 1),
+		environment:     config.Environment,
 		metricsReporter: metricsReporter,
 	}
 }
@@ -199,6 +207,10 @@ func (ls *LoggerRecordService) LogAsync(logs *model.ChatLog, headers *http.Heade
 		ls.llmClient = llmClient
 	*/
 
+	if ls.environment != "" {
+		logs.Environment = ls.environment
+	}
+
 	select {
 	case ls.logChan <- logs:
 	default:
@@ -225,12 +237,44 @@ func (ls *LoggerRecordService) LogAsync(logs *model.ChatLog, headers *http.Heade
 func (ls *LoggerRecordService) logWriter() {
 	defer ls.wg.Done()
 
+	handle := ls.logDirectToStorage
+	if ls.pipelineDepth > 0 {
+		enriched, wait := ls.startStoreStage()
+		defer wait()
+		handle = func(log *model.ChatLog) {
+			ls.getDepartment(log)
+			enriched <- log
+		}
+	} else if ls.concurrency > 1 {
+		// Lookups of different logs overlap, storage writes stay serialized
+		handle = func(log *model.ChatLog) {
+			ls.getDepartment(log)
+			ls.mu.Lock()
+			defer ls.mu.Unlock()
+			ls.storeLog(log)
+		}
+	}
+
+	// Each log is received by a single worker, which stores it only after its lookup
+	var workers sync.WaitGroup
+	for i := 0; i < ls.concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			ls.consumeLogs(handle)
+		}()
+	}
+	workers.Wait()
+}
+
+// consumeLogs handles queued logs until the service stops, then handles the logs left
+func (ls *LoggerRecordService) consumeLogs(handle func(*model.ChatLog)) {
 	for {
 		select {
 		case log := <-ls.logChan:
 			if log != nil {
 				// ls.logSync(log)
-				ls.logDirectToStorage(log)
+				handle(log)
 			}
 		case <-ls.stopChan:
 			// Arrange remaining logs
@@ -238,7 +282,7 @@ func (ls *LoggerRecordService) logWriter() {
 				log := <-ls.logChan
 				if log != nil {
 					// ls.logSync(log)
-					ls.logDirectToStorage(log)
+					handle(log)
 				}
 			}
 			return
@@ -246,6 +290,28 @@ func (ls *LoggerRecordService) logWriter() {
 	}
 }
 
+// startStoreStage starts the goroutine storing enriched logs in the order they are sent, with
+// room for pipelineDepth logs waiting. The returned function closes the stage and waits for
+// the logs still buffered to be stored.
+func (ls *LoggerRecordService) startStoreStage() (chan<- *model.ChatLog, func()) {
+	enriched := make(chan *model.ChatLog, ls.pipelineDepth)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for log := range enriched {
+			ls.mu.Lock()
+			ls.storeLog(log)
+			ls.mu.Unlock()
+		}
+	}()
+
+	return enriched, func() {
+		close(enriched)
+		<-done
+	}
+}
+
 // writeLogToFile writes log content to specified file path
 func (ls *LoggerRecordService) writeLogToFile(filePath string, content string, mode int) error {
 	// Create directory if needed
@@ -323,6 +389,11 @@ func (ls *LoggerRecordService) logDirectToStorage(logs *model.ChatLog) {
 	// Get department info
 	ls.getDepartment(logs)
 
+	ls.storeLog(logs)
+}
+
+// storeLog records the metrics of an enriched log and saves it to permanent storage
+func (ls *LoggerRecordService) storeLog(logs *model.ChatLog) {
 	// Record metrics if available
 	if ls.metricsService != nil {
 		ls.metricsService.RecordChatLog(logs)
@@ -586,7 +657,11 @@ func (ls *LoggerRecordService) saveLogToPermanentStorage(chatLog *model.ChatLog)
 	storageKey := filepath.Join(yearMonth, day, username, filename)
 
 	// Convert to pretty JSON
-	jsonStr, err := chatLog.ToPrettyJSON()
+	storedLog := chatLog
+	if ls.promptStorage == config.PromptStorageDiff {
+		storedLog = chatLog.WithPromptDiff()
+	}
+	jsonStr, err := storedLog.ToPrettyJSON()
 	if err != nil {
 		logger.Error("Failed to marshal log for permanent storage",
 			zap.Error(err),
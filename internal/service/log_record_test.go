@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/model"
+	"github.com/zgsm-ai/chat-rag/internal/storage"
+)
+
+// slowDepartmentClient answers department lookups after a delay that varies per employee
+type slowDepartmentClient struct {
+	delays map[string]time.Duration
+}
+
+func (c *slowDepartmentClient) GetDepartment(employeeNumber string) (*model.DepartmentInfo, error) {
+	time.Sleep(c.delays[employeeNumber])
+	return &model.DepartmentInfo{Level1Dept: "dept-" + employeeNumber}, nil
+}
+
+// recordingBackend keeps the keys and data of every write in order
+type recordingBackend struct {
+	mu   sync.Mutex
+	keys []string
+	data []string
+}
+
+func (b *recordingBackend) Write(key string, data []byte) (*storage.WriteInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.keys = append(b.keys, key)
+	b.data = append(b.data, string(data))
+	return &storage.WriteInfo{FilePath: key}, nil
+}
+
+func (b *recordingBackend) Close() error { return nil }
+
+func TestLogWriter_PipelinePreservesOrder(t *testing.T) {
+	for _, depth := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("depth=%d", depth), func(t *testing.T) {
+			cfg := config.Config{}
+			cfg.Log.PipelineDepth = depth
+			ls := NewLogRecordService(cfg).(*LoggerRecordService)
+
+			deptClient := &slowDepartmentClient{delays: map[string]time.Duration{}}
+			ls.deptClient = deptClient
+			backend := &recordingBackend{}
+			ls.SetStorageBackend(backend)
+			require.NoError(t, ls.Start())
+
+			const count = 10
+			for i := 0; i < count; i++ {
+				employee := fmt.Sprintf("e%d", i)
+				deptClient.delays[employee] = time.Duration((count-i)%3) * time.Millisecond
+			}
+			for i := 0; i < count; i++ {
+				ls.LogAsync(&model.ChatLog{
+					Timestamp: time.Now(),
+					Identity: model.Identity{
+						RequestID: fmt.Sprintf("req-%02d", i),
+						UserInfo:  &model.UserInfo{EmployeeNumber: fmt.Sprintf("e%d", i)},
+					},
+				}, nil)
+			}
+			ls.Stop()
+
+			require.Len(t, backend.keys, count)
+			for i, key := range backend.keys {
+				assert.Contains(t, key, fmt.Sprintf("_req-%02d_", i))
+				assert.True(t, strings.Contains(backend.data[i], fmt.Sprintf(`"dept_1": "dept-e%d"`, i)),
+					"log %d was stored before its department lookup finished", i)
+			}
+		})
+	}
+}
+
+func TestLogWriter_ConcurrentWorkers(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Log.LogProcessorConcurrency = 4
+	ls := NewLogRecordService(cfg).(*LoggerRecordService)
+
+	deptClient := &slowDepartmentClient{delays: map[string]time.Duration{}}
+	const count = 20
+	for i := 0; i < count; i++ {
+		deptClient.delays[fmt.Sprintf("e%d", i)] = 20 * time.Millisecond
+	}
+	ls.deptClient = deptClient
+	backend := &recordingBackend{}
+	ls.SetStorageBackend(backend)
+	require.NoError(t, ls.Start())
+
+	start := time.Now()
+	for i := 0; i < count; i++ {
+		ls.LogAsync(&model.ChatLog{
+			Timestamp: time.Now(),
+			Identity: model.Identity{
+				RequestID: fmt.Sprintf("req-%02d", i),
+				UserInfo:  &model.UserInfo{EmployeeNumber: fmt.Sprintf("e%d", i)},
+			},
+		}, nil)
+	}
+	ls.Stop()
+	elapsed := time.Since(start)
+
+	require.Len(t, backend.keys, count)
+	stored := map[string]bool{}
+	for i, key := range backend.keys {
+		for j := 0; j < count; j++ {
+			if strings.Contains(key, fmt.Sprintf("_req-%02d_", j)) {
+				assert.False(t, stored[key], "log stored twice")
+				stored[key] = true
+				assert.Contains(t, backend.data[i], fmt.Sprintf(`"dept_1": "dept-e%d"`, j))
+			}
+		}
+	}
+	assert.Len(t, stored, count)
+	assert.Less(t, elapsed, time.Duration(count)*20*time.Millisecond, "department lookups did not overlap")
+}
+
+func TestLogAsync_TagsEnvironment(t *testing.T) {
+	cfg := config.Config{Environment: "staging"}
+	ls := NewLogRecordService(cfg).(*LoggerRecordService)
+	backend := &recordingBackend{}
+	ls.SetStorageBackend(backend)
+	require.NoError(t, ls.Start())
+
+	ls.LogAsync(&model.ChatLog{
+		Timestamp: time.Now(),
+		Identity:  model.Identity{RequestID: "req-env", UserInfo: &model.UserInfo{}},
+	}, nil)
+	ls.Stop()
+
+	require.Len(t, backend.data, 1)
+	assert.Contains(t, backend.data[0], `"environment": "staging"`)
+}
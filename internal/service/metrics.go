@@ -1,7 +1,11 @@
 package service
 
 import (
+	"slices"
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zgsm-ai/chat-rag/internal/config"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
 	"github.com/zgsm-ai/chat-rag/internal/types"
@@ -23,10 +27,23 @@ const (
 	metricsBaseLabelDept4      = "dept_level4"
 	metricsBaseLabelPromptMode = "prompt_mode"
 
+	// Optional base labels
+	metricsLabelPromptChecksum = "prompt_checksum"
+
+	// Constant label holding the deployment environment
+	metricsLabelEnvironment = "environment"
+
 	// Label names
 	metricsLabelCategory   = "category"
 	metricsLabelTokenScope = "token_scope"
 	metricsLabelErrorType  = "error_type"
+	metricsLabelTool       = "tool"
+	metricsLabelStage      = "stage"
+	metricsLabelUseful     = "useful"
+	metricsLabelReason     = "reason"
+	metricsLabelStatus     = "status"
+	metricsLabelDataID     = "data_id"
+	metricsLabelResult     = "result"
 
 	// Metric names
 	metricRequestsTotal         = "chat_rag_requests_total"
@@ -39,6 +56,16 @@ const (
 	metricResponseTokens        = "chat_rag_response_tokens_total"
 	metricErrorsTotal           = "chat_rag_errors_total"
 	metricTokenRatio            = "chat_rag_token_ratio"
+	metricSearchResults         = "chat_rag_search_results"
+	metricToolQueueDepth        = "chat_rag_tool_queue_depth"
+	metricCompressQueueDepth    = "chat_rag_compress_queue_depth"
+	metricRedisAvailable        = "chat_rag_redis_available"
+	metricRetrievalFeedback     = "chat_rag_retrieval_feedback_total"
+	metricToolResultInvalid     = "chat_rag_tool_result_invalid_total"
+	metricToolLatency           = "chat_rag_tool_latency_ms"
+	metricToolCallsTotal        = "chat_rag_tool_calls_total"
+	metricNacosConfigReloads    = "chat_rag_nacos_config_reloads_total"
+	metricToolCacheTotal        = "chat_rag_tool_cache_total"
 
 	// Default values
 	defaultCategory    = "unknown"
@@ -48,6 +75,10 @@ const (
 	tokenScopeSystem = "system"
 	tokenScopeUser   = "user"
 	tokenScopeAll    = "all"
+
+	// Search result stages
+	searchStageReturned       = "returned"
+	searchStageAboveThreshold = "above_threshold"
 )
 
 // Bucket definitions
@@ -56,6 +87,11 @@ var (
 		100, 500, 1000, 2000, 5000, 10000,
 		20000, 30000, 60000, 120000, 300000,
 	}
+	searchResultsBuckets = []float64{0, 1, 2, 3, 5, 10, 20, 50}
+	toolLatencyBuckets   = []float64{
+		50, 100, 250, 500, 1000, 2000,
+		5000, 10000, 30000, 60000,
+	}
 )
 
 // Base label list
@@ -77,6 +113,11 @@ var metricsBaseLabels = []string{
 // MetricsInterface defines the interface for metrics service
 type MetricsInterface interface {
 	RecordChatLog(log *model.ChatLog)
+	SetToolQueueDepth(toolName string, depth int)
+	SetCompressQueueDepth(depth int)
+	SetRedisAvailable(available bool)
+	RecordRetrievalFeedback(tools []string, useful bool)
+	RecordConfigReload(dataID string)
 	GetRegistry() *prometheus.Registry
 }
 
@@ -92,11 +133,39 @@ type MetricsService struct {
 	responseTokens        *prometheus.CounterVec
 	errorsTotal           *prometheus.CounterVec
 	tokenRatio            *prometheus.GaugeVec
+	searchResults         *prometheus.HistogramVec
+	toolResultInvalid     *prometheus.CounterVec
+	toolLatency           *prometheus.HistogramVec
+	toolCallsTotal        *prometheus.CounterVec
+	toolCacheTotal        *prometheus.CounterVec
+	toolQueueDepth        *prometheus.GaugeVec
+	compressQueueDepth    prometheus.Gauge
+	redisAvailable        prometheus.Gauge
+	retrievalFeedback     *prometheus.CounterVec
+	configReloads         *prometheus.CounterVec
+
+	baseLabels            []string
+	promptChecksumEnabled bool
+	ratioCategoryEnabled  bool
+	originalModelEnabled  bool
+	// Deployment environment attached to every metric, empty when not labelled
+	environment string
 }
 
 // NewMetricsService creates a new metrics service
-func NewMetricsService() MetricsInterface {
-	ms := &MetricsService{}
+func NewMetricsService(cfg config.MetricsConfig, environment string) MetricsInterface {
+	ms := &MetricsService{
+		baseLabels:            metricsBaseLabels,
+		promptChecksumEnabled: cfg.SystemPromptChecksumLabel,
+		ratioCategoryEnabled:  cfg.CompressionRatioCategoryLabel,
+		originalModelEnabled:  cfg.AttributeOriginalModel,
+	}
+	if cfg.EnvironmentLabel {
+		ms.environment = environment
+	}
+	if ms.promptChecksumEnabled {
+		ms.baseLabels = slices.Concat(metricsBaseLabels, []string{metricsLabelPromptChecksum})
+	}
 
 	ms.requestsTotal = ms.createCounterVec(metricRequestsTotal, "Total number of chat completion requests", metricsLabelCategory)
 	ms.originalTokensTotal = ms.createCounterVec(metricOriginalTokensTotal, "Total number of original tokens processed", metricsLabelTokenScope)
@@ -107,7 +176,43 @@ func NewMetricsService() MetricsInterface {
 	ms.totalLatency = ms.createHistogramVec(metricTotalLatency, "Total processing latency in milliseconds", nil, modelLatencyBuckets)
 	ms.responseTokens = ms.createCounterVec(metricResponseTokens, "Total number of response tokens generated")
 	ms.errorsTotal = ms.createCounterVec(metricErrorsTotal, "Total number of errors encountered", metricsLabelErrorType)
-	ms.tokenRatio = ms.createGaugeVec(metricTokenRatio, "Token compression ratio by scope", metricsLabelTokenScope)
+	ratioLabels := []string{metricsLabelTokenScope}
+	if ms.ratioCategoryEnabled {
+		ratioLabels = append(ratioLabels, metricsLabelCategory)
+	}
+	ms.tokenRatio = ms.createGaugeVec(metricTokenRatio, "Token compression ratio by scope", ratioLabels...)
+	ms.searchResults = ms.createHistogramVec(metricSearchResults, "Number of results per tool call by stage",
+		[]string{metricsLabelTool, metricsLabelStage}, searchResultsBuckets)
+	ms.toolResultInvalid = ms.createCounterVec(metricToolResultInvalid,
+		"Number of tool results replaced because they failed validation", metricsLabelTool, metricsLabelReason)
+	ms.toolLatency = ms.createHistogramVec(metricToolLatency, "Server tool call latency in milliseconds",
+		[]string{metricsLabelTool, metricsLabelStatus}, toolLatencyBuckets)
+	ms.toolCallsTotal = ms.createCounterVec(metricToolCallsTotal, "Total number of server tool calls",
+		metricsLabelTool, metricsLabelStatus)
+	ms.toolCacheTotal = ms.createCounterVec(metricToolCacheTotal, "Number of cacheable tool calls by cache result",
+		metricsLabelTool, metricsLabelResult)
+	// Queue depth is not tied to a request, so it carries the tool label only
+	ms.toolQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metricToolQueueDepth,
+		Help: "Number of tool calls waiting for a free concurrency slot",
+	}, []string{metricsLabelTool})
+	ms.compressQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: metricCompressQueueDepth,
+		Help: "Number of prompt compressions waiting for a summary model worker",
+	})
+	ms.redisAvailable = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: metricRedisAvailable,
+		Help: "Whether Redis is available (1) or the Redis circuit breaker is open (0)",
+	})
+	// Feedback arrives after the request, its identity labels are not known any more
+	ms.retrievalFeedback = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricRetrievalFeedback,
+		Help: "Client feedback on whether the context retrieved by a tool was useful",
+	}, []string{metricsLabelTool, metricsLabelUseful})
+	ms.configReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricNacosConfigReloads,
+		Help: "Number of Nacos config updates applied",
+	}, []string{metricsLabelDataID})
 
 	ms.registerMetrics()
 	return ms
@@ -115,9 +220,9 @@ func NewMetricsService() MetricsInterface {
 
 // createCounterVec creates a CounterVec with base labels
 func (ms *MetricsService) createCounterVec(name, help string, extraLabels ...string) *prometheus.CounterVec {
-	labels := metricsBaseLabels
+	labels := ms.baseLabels
 	if len(extraLabels) > 0 {
-		labels = append(labels, extraLabels...)
+		labels = slices.Concat(labels, extraLabels)
 	}
 	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -130,9 +235,9 @@ func (ms *MetricsService) createCounterVec(name, help string, extraLabels ...str
 
 // createHistogramVec creates a HistogramVec with base labels
 func (ms *MetricsService) createHistogramVec(name, help string, extraLabels []string, buckets []float64) *prometheus.HistogramVec {
-	labels := metricsBaseLabels
+	labels := ms.baseLabels
 	if extraLabels != nil {
-		labels = append(labels, extraLabels...)
+		labels = slices.Concat(labels, extraLabels)
 	}
 	return prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -146,9 +251,9 @@ func (ms *MetricsService) createHistogramVec(name, help string, extraLabels []st
 
 // createGaugeVec creates a GaugeVec with base labels
 func (ms *MetricsService) createGaugeVec(name, help string, extraLabels ...string) *prometheus.GaugeVec {
-	labels := metricsBaseLabels
+	labels := ms.baseLabels
 	if len(extraLabels) > 0 {
-		labels = append(labels, extraLabels...)
+		labels = slices.Concat(labels, extraLabels)
 	}
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -159,9 +264,15 @@ func (ms *MetricsService) createGaugeVec(name, help string, extraLabels ...strin
 	)
 }
 
-// registerMetrics registers all metrics
+// registerMetrics registers all metrics, wrapped with the environment label when one is set.
+// The label is constant for the process, so it is not part of the per-request base labels
 func (ms *MetricsService) registerMetrics() {
-	prometheus.MustRegister(
+	registerer := prometheus.DefaultRegisterer
+	if ms.environment != "" {
+		registerer = prometheus.WrapRegistererWith(
+			prometheus.Labels{metricsLabelEnvironment: ms.environment}, registerer)
+	}
+	registerer.MustRegister(
 		ms.requestsTotal,
 		ms.originalTokensTotal,
 		ms.compressedTokensTotal,
@@ -172,9 +283,50 @@ func (ms *MetricsService) registerMetrics() {
 		ms.responseTokens,
 		ms.errorsTotal,
 		ms.tokenRatio,
+		ms.searchResults,
+		ms.toolResultInvalid,
+		ms.toolLatency,
+		ms.toolCallsTotal,
+		ms.toolCacheTotal,
+		ms.toolQueueDepth,
+		ms.compressQueueDepth,
+		ms.redisAvailable,
+		ms.retrievalFeedback,
+		ms.configReloads,
 	)
 }
 
+// SetToolQueueDepth records the number of calls waiting for a tool's concurrency slot
+func (ms *MetricsService) SetToolQueueDepth(toolName string, depth int) {
+	ms.toolQueueDepth.WithLabelValues(toolName).Set(float64(depth))
+}
+
+// SetCompressQueueDepth records the number of prompt compressions waiting for a worker
+func (ms *MetricsService) SetCompressQueueDepth(depth int) {
+	ms.compressQueueDepth.Set(float64(depth))
+}
+
+// SetRedisAvailable records the Redis availability reported by the circuit breaker
+func (ms *MetricsService) SetRedisAvailable(available bool) {
+	if available {
+		ms.redisAvailable.Set(1)
+	} else {
+		ms.redisAvailable.Set(0)
+	}
+}
+
+// RecordRetrievalFeedback counts a feedback once for every tool the request called
+func (ms *MetricsService) RecordRetrievalFeedback(tools []string, useful bool) {
+	for _, tool := range tools {
+		ms.retrievalFeedback.WithLabelValues(tool, strconv.FormatBool(useful)).Inc()
+	}
+}
+
+// RecordConfigReload counts an applied update of a Nacos config
+func (ms *MetricsService) RecordConfigReload(dataID string) {
+	ms.configReloads.WithLabelValues(dataID).Inc()
+}
+
 // RecordChatLog records metrics from a ChatLog entry
 func (ms *MetricsService) RecordChatLog(log *model.ChatLog) {
 	if log == nil {
@@ -188,6 +340,9 @@ func (ms *MetricsService) RecordChatLog(log *model.ChatLog) {
 	ms.recordResponseMetrics(log, labels)
 	ms.recordErrorMetrics(log, labels)
 	ms.recordTokenRatioMetrics(log, labels)
+	ms.recordSearchResultMetrics(log, labels)
+	ms.recordToolResultInvalidMetrics(log, labels)
+	ms.recordToolCallMetrics(log, labels)
 }
 
 // recordRequestMetrics records request related metrics
@@ -265,6 +420,49 @@ func (ms *MetricsService) recordErrorMetrics(log *model.ChatLog, labels promethe
 	}
 }
 
+// recordSearchResultMetrics records result counts of tool calls
+func (ms *MetricsService) recordSearchResultMetrics(log *model.ChatLog, labels prometheus.Labels) {
+	for _, toolCall := range log.ToolCalls {
+		if toolCall.ResultCount == nil || toolCall.AboveThresholdCount == nil {
+			continue
+		}
+		toolLabels := ms.addLabel(labels, metricsLabelTool, toolCall.ToolName)
+		ms.searchResults.With(ms.addLabel(toolLabels, metricsLabelStage, searchStageReturned)).
+			Observe(float64(*toolCall.ResultCount))
+		ms.searchResults.With(ms.addLabel(toolLabels, metricsLabelStage, searchStageAboveThreshold)).
+			Observe(float64(*toolCall.AboveThresholdCount))
+	}
+}
+
+// recordToolResultInvalidMetrics counts tool results rejected by validation
+func (ms *MetricsService) recordToolResultInvalidMetrics(log *model.ChatLog, labels prometheus.Labels) {
+	for _, toolCall := range log.ToolCalls {
+		if toolCall.InvalidResult == "" {
+			continue
+		}
+		toolLabels := ms.addLabel(labels, metricsLabelTool, toolCall.ToolName)
+		ms.toolResultInvalid.With(ms.addLabel(toolLabels, metricsLabelReason, toolCall.InvalidResult)).Inc()
+	}
+}
+
+// recordToolCallMetrics records the count and latency of tool calls by tool and result status,
+// and the result cache hits and misses of cacheable tools
+func (ms *MetricsService) recordToolCallMetrics(log *model.ChatLog, labels prometheus.Labels) {
+	for _, toolCall := range log.ToolCalls {
+		status := toolCall.ResultStatus
+		if status == "" {
+			status = defaultCategory
+		}
+		toolLabels := ms.addLabel(ms.addLabel(labels, metricsLabelTool, toolCall.ToolName), metricsLabelStatus, status)
+		ms.toolCallsTotal.With(toolLabels).Inc()
+		ms.toolLatency.With(toolLabels).Observe(float64(toolCall.Latency))
+		if toolCall.ResultCache != "" {
+			ms.toolCacheTotal.With(ms.addLabel(ms.addLabel(labels, metricsLabelTool, toolCall.ToolName),
+				metricsLabelResult, toolCall.ResultCache)).Inc()
+		}
+	}
+}
+
 // getBaseLabels creates base labels map
 func (ms *MetricsService) getBaseLabels(log *model.ChatLog) prometheus.Labels {
 	promptMode := string(log.Params.LlmParams.ExtraBody.PromptMode)
@@ -282,6 +480,12 @@ func (ms *MetricsService) getBaseLabels(log *model.ChatLog) prometheus.Labels {
 		metricsBaseLabelSender:     log.Identity.Sender,
 		metricsBaseLabelPromptMode: promptMode,
 	}
+	if ms.promptChecksumEnabled {
+		labels[metricsLabelPromptChecksum] = log.SystemPromptChecksum
+	}
+	if ms.originalModelEnabled && log.Params.OriginalModel != "" {
+		labels[metricsBaseLabelModel] = log.Params.OriginalModel
+	}
 
 	if log.Identity.UserInfo != nil &&
 		log.Identity.UserInfo.Department != nil &&
@@ -319,6 +523,14 @@ func (ms *MetricsService) GetRegistry() *prometheus.Registry {
 
 // recordTokenRatioMetrics records token ratio related metrics
 func (ms *MetricsService) recordTokenRatioMetrics(log *model.ChatLog, labels prometheus.Labels) {
+	if ms.ratioCategoryEnabled {
+		category := log.Category
+		if category == "" {
+			category = defaultCategory
+		}
+		labels = ms.addLabel(labels, metricsLabelCategory, category)
+	}
+
 	// Record system token ratio
 	if log.Tokens.Ratios.SystemRatio >= 0 {
 		ratioLabels := ms.addLabel(labels, metricsLabelTokenScope, tokenScopeSystem)
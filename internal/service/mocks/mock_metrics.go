@@ -60,3 +60,63 @@ func (mr *MockMetricsInterfaceMockRecorder) RecordChatLog(log interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordChatLog", reflect.TypeOf((*MockMetricsInterface)(nil).RecordChatLog), log)
 }
+
+// RecordRetrievalFeedback mocks base method.
+func (m *MockMetricsInterface) RecordRetrievalFeedback(tools []string, useful bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordRetrievalFeedback", tools, useful)
+}
+
+// RecordRetrievalFeedback indicates an expected call of RecordRetrievalFeedback.
+func (mr *MockMetricsInterfaceMockRecorder) RecordRetrievalFeedback(tools, useful interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRetrievalFeedback", reflect.TypeOf((*MockMetricsInterface)(nil).RecordRetrievalFeedback), tools, useful)
+}
+
+// RecordConfigReload mocks base method.
+func (m *MockMetricsInterface) RecordConfigReload(dataID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordConfigReload", dataID)
+}
+
+// RecordConfigReload indicates an expected call of RecordConfigReload.
+func (mr *MockMetricsInterfaceMockRecorder) RecordConfigReload(dataID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordConfigReload", reflect.TypeOf((*MockMetricsInterface)(nil).RecordConfigReload), dataID)
+}
+
+// SetRedisAvailable mocks base method.
+func (m *MockMetricsInterface) SetRedisAvailable(available bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRedisAvailable", available)
+}
+
+// SetRedisAvailable indicates an expected call of SetRedisAvailable.
+func (mr *MockMetricsInterfaceMockRecorder) SetRedisAvailable(available interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRedisAvailable", reflect.TypeOf((*MockMetricsInterface)(nil).SetRedisAvailable), available)
+}
+
+// SetCompressQueueDepth mocks base method.
+func (m *MockMetricsInterface) SetCompressQueueDepth(depth int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetCompressQueueDepth", depth)
+}
+
+// SetCompressQueueDepth indicates an expected call of SetCompressQueueDepth.
+func (mr *MockMetricsInterfaceMockRecorder) SetCompressQueueDepth(depth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCompressQueueDepth", reflect.TypeOf((*MockMetricsInterface)(nil).SetCompressQueueDepth), depth)
+}
+
+// SetToolQueueDepth mocks base method.
+func (m *MockMetricsInterface) SetToolQueueDepth(toolName string, depth int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetToolQueueDepth", toolName, depth)
+}
+
+// SetToolQueueDepth indicates an expected call of SetToolQueueDepth.
+func (mr *MockMetricsInterfaceMockRecorder) SetToolQueueDepth(toolName, depth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetToolQueueDepth", reflect.TypeOf((*MockMetricsInterface)(nil).SetToolQueueDepth), toolName, depth)
+}
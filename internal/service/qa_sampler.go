@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// QASampleRecord is a minimal request/response pair for evaluation datasets.
+// It deliberately carries no user identity, headers or credentials
+type QASampleRecord struct {
+	Timestamp time.Time       `json:"timestamp"`
+	RequestID string          `json:"request_id"`
+	Model     string          `json:"model"`
+	Agent     string          `json:"agent,omitempty"`
+	Messages  []types.Message `json:"messages"`
+	Response  string          `json:"response"`
+	Usage     types.Usage     `json:"usage"`
+}
+
+// QASamplerInterface decides which requests are sampled and stores their records
+type QASamplerInterface interface {
+	Sample() bool
+	Record(record QASampleRecord)
+	Close() error
+}
+
+// QASampler appends sampled records as JSON lines to one file per day
+type QASampler struct {
+	rate      float64
+	directory string
+
+	mu      sync.Mutex
+	day     string
+	file    *os.File
+	randF64 func() float64
+}
+
+// NewQASampler creates the sampler writing into the configured directory
+func NewQASampler(cfg config.QASamplingConfig) (*QASampler, error) {
+	if cfg.Rate < 0 || cfg.Rate > 1 {
+		return nil, fmt.Errorf("qa sampling rate must be between 0 and 1, got %v", cfg.Rate)
+	}
+	if cfg.Directory == "" {
+		return nil, fmt.Errorf("qa sampling directory is empty")
+	}
+	if err := os.MkdirAll(cfg.Directory, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create qa sampling directory: %w", err)
+	}
+	return &QASampler{rate: cfg.Rate, directory: cfg.Directory, randF64: rand.Float64}, nil
+}
+
+// Sample reports whether the current request should be recorded
+func (s *QASampler) Sample() bool {
+	return s.rate > 0 && s.randF64() < s.rate
+}
+
+// Record appends the record to the file of its day, failures are logged only
+func (s *QASampler) Record(record QASampleRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Warn("failed to marshal qa sample", zap.String("requestID", record.RequestID), zap.Error(err))
+		return
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.openDay(record.Timestamp.Format(time.DateOnly)); err != nil {
+		logger.Warn("failed to open qa sample file", zap.Error(err))
+		return
+	}
+	if _, err := s.file.Write(data); err != nil {
+		logger.Warn("failed to write qa sample", zap.String("requestID", record.RequestID), zap.Error(err))
+	}
+}
+
+// openDay switches to the file of the given day, the caller holds the lock
+func (s *QASampler) openDay(day string) error {
+	if s.file != nil && s.day == day {
+		return nil
+	}
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+
+	path := filepath.Join(s.directory, "qa-"+day+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.day = day
+	return nil
+}
+
+// Close closes the current file, if any
+func (s *QASampler) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zgsm-ai/chat-rag/internal/config"
+	"github.com/zgsm-ai/chat-rag/internal/logger"
+)
+
+// ToolAuditRecord is one audited tool execution. Tool results are deliberately not included
+type ToolAuditRecord struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	RequestID   string                 `json:"request_id"`
+	TaskID      string                 `json:"task_id,omitempty"`
+	UserName    string                 `json:"user_name"`
+	ClientID    string                 `json:"client_id,omitempty"`
+	ClientIDE   string                 `json:"client_ide,omitempty"`
+	ProjectPath string                 `json:"project_path,omitempty"`
+	Tool        string                 `json:"tool"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Status      string                 `json:"status"`
+	Error       string                 `json:"error,omitempty"`
+	LatencyMs   int64                  `json:"latency_ms"`
+}
+
+// ToolAuditInterface writes audit records of tool executions
+type ToolAuditInterface interface {
+	Record(record ToolAuditRecord)
+	Close() error
+}
+
+// ToolAuditLogger writes one JSON line per tool execution to its destination
+type ToolAuditLogger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	closer io.Closer
+}
+
+// NewToolAuditLogger creates the audit logger for the configured destination
+func NewToolAuditLogger(cfg config.ToolAuditConfig) (*ToolAuditLogger, error) {
+	switch cfg.Destination {
+	case "stdout":
+		return &ToolAuditLogger{out: os.Stdout}, nil
+	case "file", "":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("tool audit file path is empty")
+		}
+		if err := os.MkdirAll(filepath.Dir(cfg.FilePath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create tool audit directory: %w", err)
+		}
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tool audit file: %w", err)
+		}
+		return &ToolAuditLogger{out: file, closer: file}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tool audit destination: %s", cfg.Destination)
+	}
+}
+
+// Record writes the record as a single line, failures are logged and never reach the request
+func (a *ToolAuditLogger) Record(record ToolAuditRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Warn("failed to marshal tool audit record",
+			zap.String("tool", record.Tool), zap.Error(err))
+		return
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.out.Write(data); err != nil {
+		logger.Warn("failed to write tool audit record",
+			zap.String("tool", record.Tool), zap.Error(err))
+	}
+}
+
+// Close closes the destination file, if any
+func (a *ToolAuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closer == nil {
+		return nil
+	}
+	err := a.closer.Close()
+	a.closer = nil
+	return err
+}
@@ -99,3 +99,11 @@ func TestCountTokensCustomer(t *testing.T) {
 		}
 	})
 }
+
+func TestWarmUp(t *testing.T) {
+	tokenCounter, err := NewTokenCounter()
+	assert.NoError(t, err)
+	assert.NoError(t, tokenCounter.WarmUp())
+
+	assert.Error(t, (&TokenCounter{}).WarmUp(), "missing encoder must fail warm-up")
+}
@@ -3,9 +3,11 @@ package tokenizer
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkoukk/tiktoken-go"
 	"github.com/zgsm-ai/chat-rag/internal/logger"
@@ -74,6 +76,36 @@ func NewTokenCounter() (*TokenCounter, error) {
 	}, nil
 }
 
+// warmUpSample mixes scripts and code so every encoder path is exercised once
+const warmUpSample = "Warm up the tokenizer 预热分词器 func main() { fmt.Println(\"ok\") } 12345"
+
+// WarmUp encodes a sample text so the first request does not pay for lazy initialization,
+// and reports a broken encoder instead of silently falling back to estimation
+func (tc *TokenCounter) WarmUp() (err error) {
+	if tc == nil || tc.encoder == nil {
+		return fmt.Errorf("tokenizer encoder is not initialized")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("tokenizer warm-up panicked: %v", r)
+		}
+	}()
+
+	start := time.Now()
+	tokens := tc.encoder.Encode(warmUpSample, nil, nil)
+	if len(tokens) == 0 {
+		return fmt.Errorf("tokenizer produced no tokens for the warm-up sample")
+	}
+	if decoded := tc.encoder.Decode(tokens); decoded != warmUpSample {
+		return fmt.Errorf("tokenizer round trip mismatch for the warm-up sample")
+	}
+
+	logger.Info("tokenizer warmed up",
+		zap.Int("tokens", len(tokens)),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
 // CountTokens counts tokens in a text string
 func (tc *TokenCounter) CountTokens(text string) int {
 	if tc.encoder == nil {
@@ -34,6 +34,13 @@ const (
 
 	ErrServerModel     ErrorType = "ai_model_error"
 	ErrInvalidArgument ErrorType = "invalid_argument"
+	ErrInvalidRequest  ErrorType = "invalid_request_error"
+
+	// ErrPromptTooLarge represents requests rejected for exceeding the maximum prompt size
+	ErrPromptTooLarge ErrorType = "PromptTooLarge"
+
+	// ErrSlowClient represents streams aborted because the client stopped reading
+	ErrSlowClient ErrorType = "SlowClient"
 )
 
 const (
@@ -71,6 +78,12 @@ const (
 
 	ErrCodeEmptyMessageContent = "chat-rag.empty_message_content"
 	ErrMsgEmptyMessageContent  = "Message content cannot be empty."
+
+	ErrCodeNoUserMessage = "chat-rag.no_user_message"
+	ErrMsgNoUserMessage  = "The request must contain at least one user message."
+
+	ErrCodePromptTooLarge = "chat-rag.prompt_too_large"
+	ErrMsgPromptTooLarge  = "The request is too large to process (%d tokens, limit %d). Please remove large pasted content or attach fewer files, then try again."
 )
 
 type APIError struct {
@@ -156,6 +169,26 @@ func NewEmptyMessageContentError() *APIError {
 	}
 }
 
+func NewNoUserMessageError() *APIError {
+	return &APIError{
+		Code:       ErrCodeNoUserMessage,
+		Message:    ErrMsgNoUserMessage,
+		Success:    false,
+		StatusCode: http.StatusBadRequest,
+		Type:       string(ErrInvalidRequest),
+	}
+}
+
+func NewPromptTooLargeError(tokens, maxTokens int) *APIError {
+	return &APIError{
+		Code:       ErrCodePromptTooLarge,
+		Message:    fmt.Sprintf(ErrMsgPromptTooLarge, tokens, maxTokens),
+		Success:    false,
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Type:       string(ErrInvalidRequest),
+	}
+}
+
 func NewInvaildResponseContentError() *APIError {
 	return &APIError{
 		Code:       ErrCodeInvalidResponseContent,
@@ -170,6 +203,21 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf(`{"code":"%s","message":"%s","success":%v}`, e.Code, e.Message, e.Success)
 }
 
+// UpstreamDropError marks an APIError caused by the upstream connection failing or breaking
+// off, as opposed to the upstream answering with an error status
+type UpstreamDropError struct {
+	*APIError
+}
+
+// NewUpstreamDropError wraps apiErr to mark it as an upstream connection drop
+func NewUpstreamDropError(apiErr *APIError) *UpstreamDropError {
+	return &UpstreamDropError{APIError: apiErr}
+}
+
+func (e *UpstreamDropError) Unwrap() error {
+	return e.APIError
+}
+
 // IdleTimeoutError represents an idle timeout error
 type IdleTimeoutError struct {
 	Total      bool   // true if total idle budget exhausted
@@ -62,6 +62,15 @@ const (
 	HeaderUserInput   = "x-user-input"
 	HeaderSelectLLm   = "x-select-llm"
 	HeaderOneAPIReqId = "x-oneapi-request-id"
+	HeaderAgent       = "X-Chat-Rag-Agent"
+	// Number of request messages summarized or trimmed away
+	HeaderMessagesDropped = "X-Chat-Rag-Messages-Dropped"
+
+	// Debug Response Headers, only set for trusted debug requests
+	HeaderDebugSystemPromptCacheKey = "x-debug-system-prompt-cache-key"
+	HeaderDebugSystemPromptLength   = "x-debug-system-prompt-length"
+	HeaderDebugSystemPrompt         = "x-debug-system-prompt"
+	HeaderDebugEffectiveConfig      = "x-debug-effective-config"
 )
 
 // ResponseHeadersToForward defines the list of response headers that should be forwarded
@@ -71,6 +80,15 @@ var ResponseHeadersToForward = []string{
 	HeaderOneAPIReqId,
 }
 
+// DefaultSuppressedResponseHeaders defines internal gateway headers that are never sent to clients
+var DefaultSuppressedResponseHeaders = []string{
+	"x-oneapi-channel-id",
+	"x-oneapi-cost",
+	"x-envoy-upstream-service-time",
+	"x-envoy-upstream-host",
+	"x-higress-route",
+}
+
 // ToolStatus defines the status of the tool
 type ToolStatus string
 
@@ -83,10 +101,24 @@ const (
 // Redis key prefix for tool status
 const ToolStatusRedisKeyPrefix = "tool_status:"
 
+// Redis key prefix for per-session tool-call history
+const ToolHistoryRedisKeyPrefix = "tool_history:"
+
+// Redis key prefix for the tool calls of a request kept for replay
+const ToolCallReplayRedisKeyPrefix = "tool_calls:"
+
+// Redis key prefixes for tool results shared across requests and their index versions
+const ToolResultCacheRedisKeyPrefix = "tool_cache:"
+const ToolResultCacheVersionRedisKeyPrefix = "tool_cache_version:"
+
+// Redis key prefix for the tools of a request awaiting retrieval feedback
+const RetrievalFeedbackRedisKeyPrefix = "retrieval_feedback:"
+
 // Tool string filter
 const StrFilterToolAnalyzing = "\n#### 💡 检索已完成，分析中"
 const StrFilterToolSearchStart = "\n#### 🔍 "
 const StrFilterToolSearchEnd = "工具检索中"
+const StrFilterToolIndexStale = "\n> ⚠️ 检索索引可能已过期，结果可能与当前代码不一致\n"
 
 type ExtraBody struct {
 	PromptMode PromptMode `json:"prompt_mode,omitempty"`
@@ -266,6 +298,21 @@ type Message struct {
 	Extra map[string]any `json:"-"`
 }
 
+// Reasons a request message was removed from the prompt
+const (
+	DropReasonSummarized = "summarized" // Replaced by the compression summary
+	DropReasonTrimmed    = "trimmed"    // Removed to stay within a token budget
+	DropReasonDuplicate  = "duplicate"  // Repeated a later message
+)
+
+// DroppedMessage identifies a request message left out of the processed prompt
+type DroppedMessage struct {
+	Index  int    `json:"index"`        // Position in the request's messages
+	ID     string `json:"id,omitempty"` // Message id sent by the client, if any
+	Role   string `json:"role"`
+	Reason string `json:"reason"`
+}
+
 // UnmarshalJSON implements custom JSON unmarshaling to capture unknown fields
 func (m *Message) UnmarshalJSON(data []byte) error {
 	// First unmarshal into a map to capture all fields
@@ -319,6 +366,43 @@ type StreamOptions struct {
 	IncludeUsage bool `json:"include_usage"`
 }
 
+// ObjectStreamSummary is the object type of the summary event sent before [DONE]
+const ObjectStreamSummary = "chat.completion.summary"
+
+// StreamSummaryEvent is the final SSE event of a stream, carrying request metadata
+type StreamSummaryEvent struct {
+	ID      string        `json:"id,omitempty"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created,omitempty"`
+	Summary StreamSummary `json:"summary"`
+}
+
+// StreamSummary holds the metadata of a completed streamed request
+type StreamSummary struct {
+	Model          string `json:"model"`
+	Usage          Usage  `json:"usage"`
+	TotalLatencyMs int64  `json:"total_latency_ms"`
+	ToolCalls      int    `json:"tool_calls"`
+	// Processed to original prompt tokens, 1 when the prompt was not compressed
+	CompressionRatio float64 `json:"compression_ratio"`
+	// Tool calls of the request, only for authorized callers asking for them
+	ToolTraces []ToolTrace `json:"tool_traces,omitempty"`
+}
+
+// ToolTrace describes one tool call executed while answering a request
+type ToolTrace struct {
+	Name      string         `json:"name"`
+	Params    map[string]any `json:"params,omitempty"`
+	LatencyMs int64          `json:"latency_ms"`
+	// Outcome as reported in tool status: success or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Length in bytes of the result injected for the model
+	ResultLength int `json:"result_length"`
+	// Result injected for the model, only when explicitly requested
+	Result string `json:"result,omitempty"`
+}
+
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
@@ -384,3 +468,23 @@ func marshalJSONWithoutEscape(v any) ([]byte, error) {
 	// Remove the trailing newline added by Encode
 	return bytes.TrimRight(buf.Bytes(), "\n"), nil
 }
+
+// RetrievalFeedbackRequest is the client's verdict on the context retrieved for a request
+type RetrievalFeedbackRequest struct {
+	Useful  *bool  `json:"useful" binding:"required"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ToolCallsResponse is the response of the tool call replay endpoint, the tool calls
+// of the request in the order they were made
+type ToolCallsResponse struct {
+	Code    int               `json:"code"`
+	Data    []json.RawMessage `json:"data"`
+	Message string            `json:"message"`
+}
+
+// RetrievalFeedbackResponse is the response of the retrieval feedback endpoint
+type RetrievalFeedbackResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
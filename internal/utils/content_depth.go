@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// ErrContentTooDeep is returned when message content nests deeper than the configured limit
+var ErrContentTooDeep = errors.New("message content is nested too deeply")
+
+// CheckContentDepth reports an error wrapping ErrContentTooDeep when arrays and objects in
+// content nest deeper than maxDepth. A string has depth 0 and the usual array of parts 2.
+// The walk stops at maxDepth, so malformed input costs no more than valid input.
+// maxDepth < 0 disables the check.
+func CheckContentDepth(content any, maxDepth int) error {
+	if maxDepth < 0 || !contentDeeperThan(content, maxDepth) {
+		return nil
+	}
+	return fmt.Errorf("%w: limit is %d levels", ErrContentTooDeep, maxDepth)
+}
+
+// CheckMessagesContentDepth applies CheckContentDepth to the content of every message
+func CheckMessagesContentDepth(messages []types.Message, maxDepth int) error {
+	for i, msg := range messages {
+		if err := CheckContentDepth(msg.Content, maxDepth); err != nil {
+			return fmt.Errorf("messages[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// contentDeeperThan reports whether v nests arrays or objects more than remaining levels deep
+func contentDeeperThan(v any, remaining int) bool {
+	switch val := v.(type) {
+	case []any:
+		if remaining == 0 {
+			return true
+		}
+		for _, item := range val {
+			if contentDeeperThan(item, remaining-1) {
+				return true
+			}
+		}
+	case map[string]any:
+		if remaining == 0 {
+			return true
+		}
+		for _, item := range val {
+			if contentDeeperThan(item, remaining-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zgsm-ai/chat-rag/internal/types"
+)
+
+// nestedContent wraps a text part in depth-1 arrays
+func nestedContent(depth int) any {
+	var content any = textPart("deep")
+	for i := 1; i < depth; i++ {
+		content = []any{content}
+	}
+	return content
+}
+
+func TestCheckContentDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  any
+		maxDepth int
+		wantErr  bool
+	}{
+		{"string", "hello", 0, false},
+		{"parts array", []any{textPart("a"), imagePart("http://x/y.png")}, 3, false},
+		{"image url object counts", []any{imagePart("http://x/y.png")}, 2, true},
+		{"at limit", nestedContent(32), 32, false},
+		{"beyond limit", nestedContent(33), 32, true},
+		{"deeply nested", nestedContent(100000), 32, true},
+		{"disabled", nestedContent(100), -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckContentDepth(tt.content, tt.maxDepth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckContentDepth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrContentTooDeep) {
+				t.Errorf("error %v does not wrap ErrContentTooDeep", err)
+			}
+		})
+	}
+}
+
+func TestCheckMessagesContentDepth(t *testing.T) {
+	messages := []types.Message{
+		{Role: types.RoleUser, Content: "hi"},
+		{Role: types.RoleUser, Content: nestedContent(40)},
+	}
+	err := CheckMessagesContentDepth(messages, 32)
+	if !errors.Is(err, ErrContentTooDeep) {
+		t.Fatalf("expected ErrContentTooDeep, got %v", err)
+	}
+	if got := err.Error(); got != "messages[1]: message content is nested too deeply: limit is 32 levels" {
+		t.Errorf("unexpected error message %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+// contentPartSeparator joins the text of merged content parts
+const contentPartSeparator = "\n\n"
+
+// LimitContentParts reduces a content array to at most maxParts parts. Adjacent text parts
+// are merged first; if that is not enough, all text parts are merged into the first one.
+// Non-text parts such as images are always kept, so the result can still exceed maxParts
+// when they alone do. It returns the content and whether parts were merged.
+func LimitContentParts(content any, maxParts int) (any, bool) {
+	if maxParts <= 0 {
+		return content, false
+	}
+
+	switch parts := content.(type) {
+	case []any:
+		if len(parts) <= maxParts {
+			return content, false
+		}
+		merged := mergeAnyParts(parts, false)
+		if len(merged) > maxParts {
+			merged = mergeAnyParts(merged, true)
+		}
+		return merged, len(merged) < len(parts)
+	case []model.Content:
+		if len(parts) <= maxParts {
+			return content, false
+		}
+		// Typed content only holds text parts, they all merge into one
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			texts = append(texts, part.Text)
+		}
+		return []model.Content{{
+			Type:         model.ContTypeText,
+			Text:         strings.Join(texts, contentPartSeparator),
+			CacheControl: parts[len(parts)-1].CacheControl,
+		}}, true
+	default:
+		return content, false
+	}
+}
+
+// mergeAnyParts merges text parts of a raw content array, either only adjacent ones or all of
+// them into the position of the first. A merged part keeps the cache_control of its last part.
+func mergeAnyParts(parts []any, all bool) []any {
+	result := make([]any, 0, len(parts))
+	textIndex := -1
+	for _, part := range parts {
+		partMap, ok := part.(map[string]any)
+		text, isText := partMap["text"].(string)
+		if !ok || partMap["type"] != ContentTypeText || !isText {
+			result = append(result, part)
+			if !all {
+				textIndex = -1
+			}
+			continue
+		}
+
+		if textIndex < 0 {
+			copied := make(map[string]any, len(partMap))
+			for k, v := range partMap {
+				copied[k] = v
+			}
+			result = append(result, copied)
+			textIndex = len(result) - 1
+			continue
+		}
+
+		target := result[textIndex].(map[string]any)
+		target["text"] = target["text"].(string) + contentPartSeparator + text
+		if cacheControl, exists := partMap["cache_control"]; exists {
+			target["cache_control"] = cacheControl
+		}
+	}
+	return result
+}
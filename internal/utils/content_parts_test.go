@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/zgsm-ai/chat-rag/internal/model"
+)
+
+func textPart(text string) map[string]any {
+	return map[string]any{"type": ContentTypeText, "text": text}
+}
+
+func imagePart(url string) map[string]any {
+	return map[string]any{"type": ContentTypeImageURL, "image_url": map[string]any{"url": url}}
+}
+
+func TestLimitContentParts(t *testing.T) {
+	manyTexts := make([]any, 0, 50)
+	for i := 0; i < 50; i++ {
+		manyTexts = append(manyTexts, textPart(fmt.Sprintf("part %d", i)))
+	}
+	cached := textPart("last")
+	cached["cache_control"] = map[string]any{"type": "ephemeral"}
+
+	tests := []struct {
+		name       string
+		content    any
+		maxParts   int
+		wantMerged bool
+		wantParts  int
+		check      func(t *testing.T, got any)
+	}{
+		{
+			name:      "no limit",
+			content:   manyTexts,
+			maxParts:  0,
+			wantParts: 50,
+		},
+		{
+			name:      "within limit",
+			content:   []any{textPart("a"), imagePart("img")},
+			maxParts:  2,
+			wantParts: 2,
+		},
+		{
+			name:       "many text parts merge into one",
+			content:    manyTexts,
+			maxParts:   10,
+			wantMerged: true,
+			wantParts:  1,
+			check: func(t *testing.T, got any) {
+				text := got.([]any)[0].(map[string]any)["text"].(string)
+				if text != GetContentAsString(joinedParts(manyTexts)) {
+					t.Errorf("merged text lost content: %.80q", text)
+				}
+			},
+		},
+		{
+			name:       "adjacent merge keeps images in place",
+			content:    []any{textPart("a"), textPart("b"), imagePart("img"), textPart("c"), cached},
+			maxParts:   3,
+			wantMerged: true,
+			wantParts:  3,
+			check: func(t *testing.T, got any) {
+				want := []any{
+					textPart("a\n\nb"),
+					imagePart("img"),
+					map[string]any{"type": ContentTypeText, "text": "c\n\nlast", "cache_control": map[string]any{"type": "ephemeral"}},
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("LimitContentParts() = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:       "all text merged when adjacent merging is not enough",
+			content:    []any{textPart("a"), imagePart("1"), textPart("b"), imagePart("2"), textPart("c")},
+			maxParts:   3,
+			wantMerged: true,
+			wantParts:  3,
+			check: func(t *testing.T, got any) {
+				want := []any{textPart("a\n\nb\n\nc"), imagePart("1"), imagePart("2")}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("LimitContentParts() = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:       "typed content",
+			content:    []model.Content{{Type: model.ContTypeText, Text: "a"}, {Type: model.ContTypeText, Text: "b"}, {Type: model.ContTypeText, Text: "c"}},
+			maxParts:   2,
+			wantMerged: true,
+			wantParts:  1,
+		},
+		{
+			name:      "string content",
+			content:   "plain",
+			maxParts:  1,
+			wantParts: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, merged := LimitContentParts(tt.content, tt.maxParts)
+			if merged != tt.wantMerged {
+				t.Errorf("LimitContentParts() merged = %v, want %v", merged, tt.wantMerged)
+			}
+			if n := reflect.ValueOf(got); tt.wantParts >= 0 && n.Len() != tt.wantParts {
+				t.Errorf("LimitContentParts() parts = %d, want %d", n.Len(), tt.wantParts)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+// joinedParts returns a single text part with the texts of parts joined like merging does
+func joinedParts(parts []any) []any {
+	text := ""
+	for i, part := range parts {
+		if i > 0 {
+			text += contentPartSeparator
+		}
+		text += part.(map[string]any)["text"].(string)
+	}
+	return []any{textPart(text)}
+}
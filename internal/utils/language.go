@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// languageNames maps common language tags to display names used in prompts
+var languageNames = map[string]string{
+	"zh":      "Simplified Chinese",
+	"zh-cn":   "Simplified Chinese",
+	"zh-sg":   "Simplified Chinese",
+	"zh-hans": "Simplified Chinese",
+	"zh-tw":   "Traditional Chinese",
+	"zh-hk":   "Traditional Chinese",
+	"zh-mo":   "Traditional Chinese",
+	"zh-hant": "Traditional Chinese",
+	"en":      "English",
+	"ja":      "Japanese",
+	"ko":      "Korean",
+	"fr":      "French",
+	"de":      "German",
+	"es":      "Spanish",
+	"pt":      "Portuguese",
+	"pt-br":   "Brazilian Portuguese",
+	"it":      "Italian",
+	"ru":      "Russian",
+	"vi":      "Vietnamese",
+	"th":      "Thai",
+	"id":      "Indonesian",
+	"ar":      "Arabic",
+	"tr":      "Turkish",
+	"nl":      "Dutch",
+	"pl":      "Polish",
+}
+
+// PreferredLanguageTag returns the highest weighted tag of an Accept-Language value,
+// e.g. "zh-CN,zh;q=0.9,en;q=0.8" returns "zh-CN". Wildcards are ignored.
+func PreferredLanguageTag(acceptLanguage string) string {
+	type weightedTag struct {
+		tag     string
+		quality float64
+	}
+
+	tags := make([]weightedTag, 0)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if q, ok := strings.CutPrefix(param, "q="); ok {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		tags = append(tags, weightedTag{tag: tag, quality: quality})
+	}
+
+	if len(tags) == 0 {
+		return ""
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+	return tags[0].tag
+}
+
+// LanguageName converts an Accept-Language value to a language name,
+// falling back to the preferred tag itself for unknown languages
+func LanguageName(acceptLanguage string) string {
+	tag := PreferredLanguageTag(acceptLanguage)
+	if tag == "" {
+		return ""
+	}
+
+	normalized := strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if name, ok := languageNames[normalized]; ok {
+		return name
+	}
+	// Try the primary subtag, e.g. "en-GB" -> "en"
+	if idx := strings.Index(normalized, "-"); idx > 0 {
+		if name, ok := languageNames[normalized[:idx]]; ok {
+			return name
+		}
+	}
+	return tag
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/zgsm-ai/chat-rag/internal/logger"
 	"github.com/zgsm-ai/chat-rag/internal/model"
@@ -14,6 +15,9 @@ import (
 const (
 	ContentTypeText     = "text"
 	ContentTypeImageURL = "image_url"
+
+	// ContentTruncatedMarker is appended to content cut by GetContentAsStringCapped
+	ContentTruncatedMarker = "...[truncated]"
 )
 
 // GetContentAsString converts content to string without parsing internal structure
@@ -52,6 +56,57 @@ func GetContentAsString(content interface{}) string {
 	return ""
 }
 
+// GetContentAsStringCapped converts content to string like GetContentAsString, but stops
+// once maxBytes are collected and appends ContentTruncatedMarker. The full string is never
+// built, so oversized content arrays stay cheap. maxBytes <= 0 means no limit.
+func GetContentAsStringCapped(content interface{}, maxBytes int) string {
+	if maxBytes <= 0 {
+		return GetContentAsString(content)
+	}
+
+	var parts []string
+	switch val := content.(type) {
+	case string:
+		parts = []string{val}
+	case []any:
+		for _, contentItem := range val {
+			contentMap, ok := contentItem.(map[string]any)
+			if !ok || contentMap["type"] != ContentTypeText {
+				continue
+			}
+			if subStr, ok := contentMap["text"].(string); ok {
+				parts = append(parts, subStr)
+			}
+		}
+	case []model.Content:
+		for _, contentItem := range val {
+			parts = append(parts, contentItem.Text)
+		}
+	}
+
+	var builder strings.Builder
+	for _, part := range parts {
+		if builder.Len()+len(part) > maxBytes {
+			builder.WriteString(truncateUTF8(part, maxBytes-builder.Len()))
+			builder.WriteString(ContentTruncatedMarker)
+			return builder.String()
+		}
+		builder.WriteString(part)
+	}
+	return builder.String()
+}
+
+// truncateUTF8 cuts s to at most maxBytes without splitting a multi-byte character
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
 // GetUserMsgs filters out non-system messages
 func GetUserMsgs(messages []types.Message) []types.Message {
 	filtered := make([]types.Message, 0, len(messages))
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/zgsm-ai/chat-rag/internal/model"
 	"github.com/zgsm-ai/chat-rag/internal/types"
 )
 
@@ -46,6 +48,55 @@ func TestGetContentAsString(t *testing.T) {
 	}
 }
 
+func TestGetContentAsStringCapped(t *testing.T) {
+	oversized := make([]interface{}, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		oversized = append(oversized, map[string]interface{}{
+			"type": ContentTypeText,
+			"text": strings.Repeat("x", 1000),
+		})
+	}
+
+	tests := []struct {
+		name     string
+		content  interface{}
+		maxBytes int
+		expected string
+	}{
+		{
+			name:     "content below limit",
+			content:  "short",
+			maxBytes: 10,
+			expected: "short",
+		},
+		{
+			name:     "no limit",
+			content:  "unlimited content",
+			maxBytes: 0,
+			expected: "unlimited content",
+		},
+		{
+			name:     "oversized content list",
+			content:  oversized,
+			maxBytes: 1500,
+			expected: strings.Repeat("x", 1500) + ContentTruncatedMarker,
+		},
+		{
+			name:     "multi-byte characters are not split",
+			content:  []model.Content{{Text: "ab"}, {Text: "中文"}},
+			maxBytes: 4,
+			expected: "ab" + ContentTruncatedMarker,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetContentAsStringCapped(tt.content, tt.maxBytes); got != tt.expected {
+				t.Errorf("GetContentAsStringCapped() = %.80q, want %.80q", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetUserMsgs(t *testing.T) {
 	tests := []struct {
 		name     string